@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var aliasDelete bool
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias [name] [worktree]",
+	Short: "Name a worktree with a short alias",
+	Long: `Give a worktree a short alias to switch to it by, independent of its branch:
+
+  wt alias api feature/api-gateway-rewrite
+  wt switch api
+
+An alias works wherever a worktree name does, and is offered in completion and
+shown in the selectors. Each worktree has at most one alias; setting another
+replaces it. An alias cannot contain "/" or whitespace, and cannot be the name
+of another worktree.
+
+Without arguments, all aliases are listed. With only a name, the branch of the
+worktree it names is printed to stdout; --delete removes it instead.`,
+	Args: cobra.RangeArgs(0, 2),
+	RunE: runAlias,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return completeAliases(), cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	aliasCmd.Flags().BoolVarP(&aliasDelete, "delete", "d", false, "Remove the alias")
+	rootCmd.AddCommand(aliasCmd)
+}
+
+func runAlias(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+	store := state.New(info.StateDir())
+
+	switch {
+	case aliasDelete:
+		if len(args) != 1 {
+			return fmt.Errorf("--delete takes the alias to remove")
+		}
+		if err := store.Update(func(st *state.State) error {
+			path, ok := st.Aliased(args[0])
+			if !ok {
+				return fmt.Errorf("no alias %q", args[0])
+			}
+			st.Worktree(path).Alias = ""
+			return nil
+		}); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Removed alias %q\n", args[0])
+		return nil
+	case len(args) == 0:
+		return listAliases(info, worktrees)
+	case len(args) == 1:
+		wt, ok := aliasedWorktree(worktrees, worktreeAliases(info), args[0])
+		if !ok {
+			return fmt.Errorf("no alias %q", args[0])
+		}
+		fmt.Println(wt.Branch)
+		return nil
+	}
+
+	name := args[0]
+	if name == "" || strings.ContainsAny(name, "/ \t\n") {
+		return fmt.Errorf("invalid alias %q: it must be non-empty without \"/\" or whitespace", name)
+	}
+	aliases := worktreeAliases(info)
+	target, err := matchWorktree(worktrees, aliases, args[1])
+	if err != nil {
+		return err
+	}
+	if wt, ok := findWorktree(worktrees, aliases, name); ok && wt.Path != target.Path {
+		return fmt.Errorf("%q already names the worktree of %s", name, wt.Branch)
+	}
+	if err := store.Update(func(st *state.State) error {
+		if path, ok := st.Aliased(name); ok {
+			st.Worktree(path).Alias = "" // left by a worktree wt no longer knows
+		}
+		st.Worktree(target.Path).Alias = name
+		return nil
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Aliased %s as %q\n", target.Branch, name)
+	return nil
+}
+
+// listAliases prints a table of the aliases of existing worktrees.
+func listAliases(info *repo.Info, worktrees []git.Worktree) error {
+	aliases := worktreeAliases(info)
+	t := newTable("ALIAS", "BRANCH", "PATH")
+	var rows [][]string
+	for _, wt := range worktrees {
+		if alias := aliases[wt.Path]; alias != "" {
+			rows = append(rows, []string{alias, wt.Branch, displayPath(info, wt.Path)})
+		}
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(os.Stderr, "No aliases. Set one with: wt alias <name> <worktree>")
+		return nil
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	for _, r := range rows {
+		t.row(nil, r...)
+	}
+	return t.flush(os.Stderr)
+}
+
+// worktreeAliases returns the alias of each aliased worktree, by path. They
+// are only a convenience, so a failure to read them yields none.
+func worktreeAliases(info *repo.Info) map[string]string {
+	st, err := state.New(info.StateDir()).Load()
+	if err != nil {
+		return nil
+	}
+	aliases := make(map[string]string)
+	for path, wt := range st.Worktrees {
+		if wt.Alias != "" {
+			aliases[path] = wt.Alias
+		}
+	}
+	return aliases
+}
+
+// aliasedWorktree returns the worktree that name is the alias of, among the
+// aliases of worktreeAliases.
+func aliasedWorktree(worktrees []git.Worktree, aliases map[string]string, name string) (git.Worktree, bool) {
+	if name == "" {
+		return git.Worktree{}, false
+	}
+	for _, wt := range worktrees {
+		if aliases[wt.Path] == name {
+			return wt, true
+		}
+	}
+	return git.Worktree{}, false
+}
+
+// completeAliases returns the aliases of the repository for tab completion.
+func completeAliases() []string {
+	info, err := repo.Resolve()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, alias := range worktreeAliases(info) {
+		names = append(names, alias)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// expandArgs expands a command alias from the [alias] config table in args,
+// the command line without the program name. The configuration is only read
+// when args name a command that is not built in. Completion requests are
+// expanded too, so that aliases complete like the commands they stand for.
+func expandArgs(args []string) ([]string, error) {
+	offset := 0
+	if len(args) > 0 && args[0] == cobra.ShellCompRequestCmd {
+		offset = 1
+	}
+	i, globals := commandIndex(args[offset:])
+	if i < 0 || isCommand(args[offset+i]) {
+		return args, nil
+	}
+	// An unreadable configuration is reported once the command runs
+	aliases, unapproved := aliasConfig(globals)
+	if slices.Contains(unapproved, args[offset+i]) && offset == 0 {
+		return nil, fmt.Errorf("alias %q of %s has not been approved to run; review it with 'wt hooks list' and approve it with 'wt hooks trust'", args[offset+i], config.RepoFileName)
+	}
+	if len(aliases) == 0 {
+		return args, nil
+	}
+	expanded, err := expandAlias(args[offset:], i, aliases)
+	if err != nil {
+		return nil, err
+	}
+	return append(args[:offset:offset], expanded...), nil
+}
+
+// expandAlias replaces the command name at args[i] with its definition from
+// aliases, keeping the arguments around it. An alias may stand for another
+// alias, but not for a built-in command's name being redefined.
+func expandAlias(args []string, i int, aliases map[string]string) ([]string, error) {
+	seen := make(map[string]bool)
+	for {
+		name := args[i]
+		def, ok := aliases[name]
+		if !ok || isCommand(name) {
+			return args, nil
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("alias %q refers to itself", name)
+		}
+		seen[name] = true
+		fields := strings.Fields(def)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("alias %q is empty", name)
+		}
+		args = slices.Concat(args[:i:i], fields, args[i+1:])
+	}
+}
+
+// aliasConfig returns the [alias] table of the user config and, inside a
+// repository, its .wt.toml. The repository is the one the command will run
+// in, given the values of the global -C and --repo flags in globals. Aliases
+// of the .wt.toml run commands as the user like hooks do, so unless the user
+// approved them (see hooksAllowed) they are left out and named in
+// unapproved instead.
+func aliasConfig(globals map[string]string) (aliases map[string]string, unapproved []string) {
+	dir := cmp.Or(globals["directory"], ".")
+	if name := globals["repo"]; name != "" {
+		if reg, err := openRegistry(); err == nil {
+			if r, ok, _ := reg.Lookup(name); ok {
+				dir = r.Path
+			}
+		}
+	}
+	mainWorktree := ""
+	info, err := repo.ResolveDir(context.Background(), dir)
+	if err == nil {
+		mainWorktree = info.MainWorktree
+	}
+	loaded, err := config.Load(mainWorktree)
+	if err != nil {
+		return nil, nil
+	}
+	if info == nil || repoCommandsTrusted(info, loaded) {
+		return loaded.Alias, nil
+	}
+	aliases = make(map[string]string, len(loaded.Alias))
+	for name, def := range loaded.Alias {
+		if loaded.SetByRepo("alias." + name) {
+			unapproved = append(unapproved, name)
+			continue
+		}
+		aliases[name] = def
+	}
+	return aliases, unapproved
+}
+
+// isCommand reports whether name is a built-in command or one of its aliases.
+func isCommand(name string) bool {
+	if name == "help" || name == cobra.ShellCompRequestCmd || name == cobra.ShellCompNoDescRequestCmd {
+		return true
+	}
+	return slices.ContainsFunc(rootCmd.Commands(), func(c *cobra.Command) bool {
+		return c.Name() == name || c.HasAlias(name)
+	})
+}
+
+// commandIndex returns the index in args of the command name, past the
+// global flags before it, or -1 if args name no command. The values of those
+// flags are returned by flag name.
+func commandIndex(args []string) (int, map[string]string) {
+	values := make(map[string]string)
+	// value records the value of flag f, attached to args[i] if ok, or else
+	// the argument after it
+	value := func(f *pflag.Flag, i *int, attached string, ok bool) {
+		if !ok && *i+1 < len(args) {
+			*i++
+			attached = args[*i]
+		}
+		values[f.Name] = attached
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return -1, values
+		case strings.HasPrefix(arg, "--"):
+			name, v, ok := strings.Cut(arg[2:], "=")
+			if f := rootFlag(name, ""); takesValue(f) {
+				value(f, &i, v, ok)
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Shorthands may be combined, as in -yC dir; one taking a value
+			// ends the group, with the value attached or in the next argument
+			for j := 1; j < len(arg); j++ {
+				if f := rootFlag("", arg[j:j+1]); takesValue(f) {
+					value(f, &i, arg[j+1:], j+1 < len(arg))
+					break
+				}
+			}
+		default:
+			return i, values
+		}
+	}
+	return -1, values
+}
+
+// rootFlag looks up a flag of the root command by name or shorthand.
+func rootFlag(name, shorthand string) *pflag.Flag {
+	for _, flags := range []*pflag.FlagSet{rootCmd.PersistentFlags(), rootCmd.LocalNonPersistentFlags()} {
+		if name != "" {
+			if f := flags.Lookup(name); f != nil {
+				return f
+			}
+		} else if f := flags.ShorthandLookup(shorthand); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// takesValue reports whether f reads the following argument as its value.
+func takesValue(f *pflag.Flag) bool {
+	return f != nil && f.NoOptDefVal == ""
+}
@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/provenimpact/wt/internal/auth"
+	"github.com/provenimpact/wt/internal/forge"
+	"github.com/spf13/cobra"
+)
+
+var (
+	authHost   string
+	authAPIURL string
+)
+
+var forgeProviders = []string{"github", "gitlab", "gitea"}
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage forge tokens in the system keychain",
+	Long: `Store the API tokens of forges (see 'wt pr') in the operating system's
+credential store: the Keychain on macOS, the Secret Service through
+secret-tool on Linux, or the Credential Manager on Windows.
+
+A token in the provider's environment variable ($GITHUB_TOKEN, $GITLAB_TOKEN,
+$GITEA_TOKEN) takes precedence over a stored one.`,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login <provider>",
+	Short: "Store a forge token in the system keychain",
+	Long: `Ask for an API token of the provider (github, gitlab, or gitea), check it
+with the forge, and store it in the system keychain. The token is read from
+stdin when it is not a terminal:
+
+  echo "$TOKEN" | wt auth login gitlab
+
+The host defaults to the one of the current repository's forge, if it is the
+same provider, and otherwise to the provider's public service (github.com,
+gitlab.com, or codeberg.org).`,
+	Args:              cobra.ExactArgs(1),
+	RunE:              runAuthLogin,
+	ValidArgsFunction: completeProviders,
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:               "logout <provider>",
+	Short:             "Remove a stored forge token",
+	Args:              cobra.ExactArgs(1),
+	RunE:              runAuthLogout,
+	ValidArgsFunction: completeProviders,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{authLoginCmd, authLogoutCmd} {
+		c.Flags().StringVar(&authHost, "host", "", "Host of the forge, e.g. gitlab.example.com")
+	}
+	authLoginCmd.Flags().StringVar(&authAPIURL, "api-url", "", "API endpoint to check the token against (default: derived from the host)")
+	authCmd.AddCommand(authLoginCmd, authLogoutCmd)
+	rootCmd.AddCommand(authCmd)
+}
+
+func completeProviders(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return forgeProviders, cobra.ShellCompDirectiveNoFileComp
+}
+
+// authForge returns the forge that a token for provider is for, with token
+// set. Without --host, the repository's forge is used when it has the same
+// provider.
+func authForge(cmd *cobra.Command, provider, token string) (forge.Forge, error) {
+	host, apiURL := authHost, authAPIURL
+	if host == "" {
+		if repoForge, _, err := openForge(cmd.Context()); err == nil && sameProvider(repoForge.Name(), provider) {
+			host = repoForge.Host()
+			// As in openForge, which warned about an api-url it ignores
+			if apiURL == "" && (!cfg.SetByRepo("forge.api-url") || forge.ServesHost(cfg.Forge.APIURL, host)) {
+				apiURL = cfg.Forge.APIURL
+			}
+		}
+	}
+	return forge.ForHost(provider, host, forge.Options{Token: token, APIURL: apiURL})
+}
+
+// sameProvider reports whether the provider names a and b are the same
+// software, treating Forgejo as Gitea.
+func sameProvider(a, b string) bool {
+	canonical := func(p string) string {
+		if p == "forgejo" {
+			return "gitea"
+		}
+		return p
+	}
+	return canonical(a) == canonical(b)
+}
+
+func runAuthLogin(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	// Resolve the forge before asking, so a bad provider fails first
+	f, err := authForge(cmd, args[0], "")
+	if err != nil {
+		return err
+	}
+	token, err := readToken(fmt.Sprintf("Paste a %s token for %s (input is hidden): ", f.Name(), f.Host()))
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return errors.New("no token given")
+	}
+
+	if f, err = authForge(cmd, args[0], token); err != nil {
+		return err
+	}
+	login, err := f.Login(ctx)
+	if err != nil {
+		return fmt.Errorf("checking the token: %w", err)
+	}
+	if err := auth.Set(f.Name(), f.Host(), token); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Logged in to %s as %s; the token is stored in the system keychain.\n", f.Host(), login)
+	return nil
+}
+
+// readToken reads a token without echoing it from a terminal, or as the
+// whole of stdin otherwise.
+func readToken(prompt string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("reading token: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if globalNoInteractive {
+		return "", errors.New("pipe the token to stdin, as prompts are disabled with --no-interactive")
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("reading token: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func runAuthLogout(cmd *cobra.Command, args []string) error {
+	f, err := authForge(cmd, args[0], "")
+	if err != nil {
+		return err
+	}
+	err = auth.Delete(f.Name(), f.Host())
+	if errors.Is(err, auth.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "No %s token is stored for %s.\n", f.Name(), f.Host())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Removed the %s token for %s.\n", f.Name(), f.Host())
+	return nil
+}
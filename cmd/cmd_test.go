@@ -39,23 +39,47 @@ package cmd
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/provenimpact/wt/internal/cache"
+	"github.com/provenimpact/wt/internal/names"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
 )
 
 // runWt builds and runs the wt binary with the given args in the given dir.
 // Returns stdout, stderr, and error.
 func runWt(t *testing.T, dir string, args ...string) (string, string, error) {
 	t.Helper()
+	return runWtEnv(t, dir, nil, args...)
+}
+
+// runWtEnv is like runWt but adds extra environment variables.
+// User-level config is isolated in a per-run directory unless env overrides it.
+func runWtEnv(t *testing.T, dir string, env []string, args ...string) (string, string, error) {
+	t.Helper()
 
 	// Build the binary once per test run
 	binary := wtBinary(t)
 
 	cmd := exec.Command(binary, args...)
 	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "WT_CONFIG_DIR="+testConfigDir(t))
+	cmd.Env = append(cmd.Env, env...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -66,6 +90,22 @@ func runWt(t *testing.T, dir string, args ...string) (string, string, error) {
 
 var cachedBinary string
 
+var cachedConfigDir string
+
+// testConfigDir returns a config directory shared by all runs in this test
+// process, keeping tests away from the user's real wt configuration.
+func testConfigDir(t *testing.T) string {
+	t.Helper()
+	if cachedConfigDir == "" {
+		dir, err := os.MkdirTemp("", "wt-test-config")
+		if err != nil {
+			t.Fatalf("creating test config dir: %v", err)
+		}
+		cachedConfigDir = dir
+	}
+	return cachedConfigDir
+}
+
 func wtBinary(t *testing.T) string {
 	t.Helper()
 	if cachedBinary != "" {
@@ -131,6 +171,14 @@ func gitRun(t *testing.T, dir string, args ...string) {
 	}
 }
 
+// trustHooks approves the hooks of the .wt.toml of the repository at dir.
+func trustHooks(t *testing.T, dir string) {
+	t.Helper()
+	if _, stderr, err := runWt(t, dir, "hooks", "trust"); err != nil {
+		t.Fatalf("wt hooks trust failed: %v\nstderr: %s", err, stderr)
+	}
+}
+
 // --- Create tests ---
 
 // WT-006, WT-007, WT-008, WT-011, WT-025: Create worktree with new branch,
@@ -230,6 +278,35 @@ func TestList_WithWorktrees(t *testing.T) {
 }
 
 // WT-019: No additional worktrees message.
+func TestList_PathStyle(t *testing.T) {
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "feat"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feat")
+
+	for style, want := range map[string]string{
+		"parent":   filepath.Join("testrepo-worktrees", "feat"),
+		"absolute": wtDir,
+		"cwd":      filepath.Join("..", "testrepo-worktrees", "feat"),
+	} {
+		_, stderr, err := runWt(t, dir, "list", "--path-style", style)
+		if err != nil || !strings.Contains(stderr, " "+want+" ") {
+			t.Errorf("--path-style %s: want %q, err=%v stderr=%s", style, want, err, stderr)
+		}
+	}
+
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`path-style = "home"`), 0o644)
+	_, stderr, err := runWtEnv(t, dir, []string{"HOME=" + filepath.Dir(dir)}, "status")
+	if want := filepath.Join("~", "testrepo-worktrees", "feat"); err != nil || !strings.Contains(stderr, want) {
+		t.Errorf("configured home style: want %q, err=%v stderr=%s", want, err, stderr)
+	}
+
+	if _, stderr, err := runWt(t, dir, "list", "--path-style", "short"); err == nil || !strings.Contains(stderr, "unknown path style") {
+		t.Errorf("expected an unknown path style error, err=%v stderr=%s", err, stderr)
+	}
+}
+
 func TestList_NoWorktrees(t *testing.T) {
 	dir := setupTestRepo(t)
 
@@ -263,6 +340,60 @@ func TestSwitch_ExistingWorktree(t *testing.T) {
 	}
 }
 
+// Under the shell wrapper, directives go to the file it names instead of
+// stdout.
+func TestSwitch_DirectiveFile(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "switch-target")
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[hooks]\npost-switch = [\"echo switched\"]\n"), 0o644)
+	trustHooks(t, dir)
+	file := filepath.Join(t.TempDir(), "directives")
+	os.WriteFile(file, nil, 0o600)
+
+	stdout, stderr, err := runWtEnv(t, dir, []string{"WT_DIRECTIVE_FILE=" + file}, "switch", "switch-target")
+	if err != nil {
+		t.Fatalf("wt switch failed: %v\nstderr: %s", err, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("stdout should be left empty, got: %q", stdout)
+	}
+	data, _ := os.ReadFile(file)
+	wtPath := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "switch-target")
+	if want := "__wt_cd:" + wtPath + "\n__wt_run:echo switched\n"; string(data) != want {
+		t.Errorf("directive file = %q, want %q", data, want)
+	}
+	if strings.Contains(stderr, "shell function") {
+		t.Errorf("a current wrapper should not be warned about, got: %s", stderr)
+	}
+
+	// Without a wrapper, e.g. with stdout piped, there is nothing to warn about
+	if _, stderr, _ = runWt(t, dir, "switch", "switch-target"); strings.Contains(stderr, "shell function") {
+		t.Errorf("wt without a wrapper should not warn about one, got: %s", stderr)
+	}
+
+	_, stderr, _ = runWtEnv(t, dir, []string{"WT_SHELL_WRAPPER_VERSION=1", "SHELL=/bin/zsh"}, "switch", "switch-target")
+	if !strings.Contains(stderr, "out of date") || !strings.Contains(stderr, `eval "$(wt init zsh)"`) {
+		t.Errorf("a stale wrapper should be warned about with reload instructions, got: %s", stderr)
+	}
+}
+
+func TestSwitch_AlreadyThere(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "feature-x")
+	wtPath := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feature-x")
+	os.MkdirAll(filepath.Join(wtPath, "sub"), 0o755)
+
+	for _, from := range []string{wtPath, filepath.Join(wtPath, "sub")} {
+		stdout, stderr, err := runWt(t, from, "switch", "feature-x")
+		if err != nil {
+			t.Fatalf("wt switch failed: %v\nstderr: %s", err, stderr)
+		}
+		if stdout != "" || !strings.Contains(stderr, "Already in feature-x") {
+			t.Errorf("switching from %s: stdout=%q stderr=%q, want no sentinel and a note", from, stdout, stderr)
+		}
+	}
+}
+
 // WT-021: Switch error with available worktrees.
 func TestSwitch_NotFound(t *testing.T) {
 	dir := setupTestRepo(t)
@@ -313,6 +444,150 @@ func TestRemove_ByName(t *testing.T) {
 	}
 }
 
+func TestRemove_PathAndCurrent(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "here")
+	runWt(t, dir, "create", "there")
+	worktrees := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	sub := filepath.Join(worktrees, "here", "sub")
+	os.Mkdir(sub, 0o755)
+
+	stdout, stderr, err := runWt(t, sub, "remove", ".")
+	if err != nil {
+		t.Fatalf("wt remove . failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(worktrees, "here")); !os.IsNotExist(err) {
+		t.Error("wt remove . should remove the worktree containing the current directory")
+	}
+	if stdout != "__wt_cd:"+dir {
+		t.Errorf("wt remove . should cd back to the main worktree, got stdout %q", stdout)
+	}
+
+	if _, _, err := runWt(t, dir, "remove", "--current"); err == nil {
+		t.Error("wt remove --current in the main worktree should fail")
+	}
+	if _, _, err := runWt(t, dir, "remove", "--current", "there"); err == nil {
+		t.Error("wt remove --current with a name should fail")
+	}
+
+	stdout, stderr, err = runWt(t, dir, "remove", "../testrepo-worktrees/there")
+	if err != nil {
+		t.Fatalf("wt remove <path> failed: %v\nstderr: %s", err, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("removing another worktree should not cd, got stdout %q", stdout)
+	}
+}
+
+func TestRemove_AllMatching(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "tmp/one")
+	runWt(t, dir, "create", "tmp/two")
+	runWt(t, dir, "create", "keep")
+	worktrees := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	os.WriteFile(filepath.Join(worktrees, "tmp-two", "dirty.txt"), []byte("dirty"), 0o644)
+
+	if _, _, err := runWt(t, dir, "remove", "tmp/*", "--all-matching"); err == nil {
+		t.Fatal("wt remove --all-matching without --yes should ask for confirmation and fail non-interactively")
+	}
+
+	_, stderr, err := runWt(t, dir, "remove", "tmp/*", "--all-matching", "--yes")
+	if err != nil {
+		t.Fatalf("wt remove --all-matching failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "tmp/one") || !strings.Contains(stderr, "tmp/two") || strings.Contains(stderr, "(keep)") {
+		t.Errorf("the confirmation listing should show only the matching worktrees, got:\n%s", stderr)
+	}
+	if _, err := os.Stat(filepath.Join(worktrees, "tmp-one")); !os.IsNotExist(err) {
+		t.Error("tmp/one should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(worktrees, "tmp-two")); err != nil {
+		t.Error("the dirty tmp/two should be kept without --force")
+	}
+	if _, err := os.Stat(filepath.Join(worktrees, "keep")); err != nil {
+		t.Error("keep does not match and should be kept")
+	}
+
+	if _, stderr, err := runWt(t, dir, "remove", "tmp/*", "--all-matching", "--force", "--yes"); err != nil {
+		t.Fatalf("wt remove --all-matching --force failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(worktrees, "tmp-two")); !os.IsNotExist(err) {
+		t.Error("--force should remove the dirty tmp/two")
+	}
+
+	// A worktree git refuses to remove does not stop the others' removal
+	runWt(t, dir, "create", "tmp/locked")
+	runWt(t, dir, "create", "tmp/three")
+	gitRun(t, dir, "worktree", "lock", filepath.Join(worktrees, "tmp-locked"))
+	_, stderr, err = runWt(t, dir, "remove", "tmp/*", "--all-matching", "--yes")
+	if err == nil || !strings.Contains(stderr, "Failed to remove testrepo-worktrees/tmp-locked") {
+		t.Errorf("the locked worktree should be reported, err=%v stderr=%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(worktrees, "tmp-three")); !os.IsNotExist(err) {
+		t.Error("tmp/three should be removed despite the locked tmp/locked")
+	}
+
+	if _, _, err := runWt(t, dir, "remove", "[", "--all-matching"); err == nil {
+		t.Error("a malformed glob should fail")
+	}
+}
+
+func TestCommandAliases(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "feature")
+	runWt(t, dir, "create", "other")
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`[alias]
+co = "switch"
+rm = "remove --delete-branch"
+bye = "rm"
+list = "status"
+loop = "loop"
+`), 0o644)
+
+	// The repository's aliases run commands, so they need approval first
+	if _, stderr, err := runWt(t, dir, "co", "feature"); err == nil || !strings.Contains(stderr, "wt hooks trust") {
+		t.Errorf("an unapproved alias should be refused, err=%v stderr=%s", err, stderr)
+	}
+	if _, stderr, _ := runWt(t, dir, "hooks", "list"); !strings.Contains(stderr, "rm = remove --delete-branch") {
+		t.Errorf("wt hooks list should show the aliases to approve, got:\n%s", stderr)
+	}
+	trustHooks(t, dir)
+
+	stdout, stderr, err := runWt(t, dir, "co", "feature")
+	if err != nil || !strings.HasPrefix(stdout, "__wt_cd:") {
+		t.Errorf("wt co should switch: stdout=%q err=%v\nstderr: %s", stdout, err, stderr)
+	}
+	// The aliases of the repository named with -C apply
+	for _, args := range [][]string{{"-C", dir, "co", "feature"}, {"--no-color", "--directory=" + dir, "co", "feature"}} {
+		stdout, stderr, err = runWt(t, filepath.Dir(dir), args...)
+		if err != nil || !strings.HasPrefix(stdout, "__wt_cd:") {
+			t.Errorf("wt %s should switch: stdout=%q err=%v\nstderr: %s", strings.Join(args, " "), stdout, err, stderr)
+		}
+	}
+
+	_, stderr, err = runWt(t, dir, "--yes", "bye", "other")
+	if err != nil {
+		t.Fatalf("wt bye failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, `Deleted branch "other"`) {
+		t.Errorf("wt bye should expand through rm to remove --delete-branch, got:\n%s", stderr)
+	}
+
+	_, stderr, _ = runWt(t, dir, "list")
+	if strings.Contains(stderr, "STATUS") {
+		t.Errorf("an alias should not override the built-in list, got:\n%s", stderr)
+	}
+
+	if _, stderr, err := runWt(t, dir, "loop"); err == nil || !strings.Contains(stderr, "refers to itself") {
+		t.Errorf("a looping alias should fail, got err=%v\nstderr: %s", err, stderr)
+	}
+
+	stdout, _, _ = runWt(t, dir, "__complete", "co", "")
+	if !strings.Contains(stdout, "feature") {
+		t.Errorf("an alias should complete like its command, got: %s", stdout)
+	}
+}
+
 // WT-016: Error on nonexistent worktree remove.
 func TestRemove_NotFound(t *testing.T) {
 	dir := setupTestRepo(t)
@@ -437,6 +712,21 @@ func TestInit_Bash(t *testing.T) {
 	}
 }
 
+func TestInit_ZshWithCompletion(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	stdout, stderr, err := runWt(t, dir, "init", "zsh", "--completion")
+	if err != nil {
+		t.Fatalf("wt init zsh --completion failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "wt()") || !strings.Contains(stdout, "_wt()") {
+		t.Error("init zsh --completion should output the wrapper and the completion function")
+	}
+	if !strings.Contains(stdout, "$+functions[compdef]") {
+		t.Error("init zsh --completion should guard compdef for use before compinit")
+	}
+}
+
 func TestInit_Fish(t *testing.T) {
 	dir := setupTestRepo(t)
 
@@ -449,6 +739,22 @@ func TestInit_Fish(t *testing.T) {
 	}
 }
 
+func TestInit_Bind(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	stdout, stderr, err := runWt(t, dir, "init", "zsh", "--bind", "ctrl-g")
+	if err != nil {
+		t.Fatalf("wt init zsh --bind ctrl-g failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "wt()") || !strings.Contains(stdout, "bindkey '^G' _wt_widget") {
+		t.Errorf("init zsh --bind should output the wrapper and the key binding, got:\n%s", stdout)
+	}
+
+	if _, _, err := runWt(t, dir, "init", "zsh", "--bind", "shift-x"); err == nil {
+		t.Error("init --bind with an unsupported key should fail")
+	}
+}
+
 // WT-028: Unsupported shell errors.
 func TestInit_UnsupportedShell(t *testing.T) {
 	dir := setupTestRepo(t)
@@ -492,6 +798,24 @@ func TestCreate_SlashBranch_FlatDirectory(t *testing.T) {
 	}
 }
 
+func TestCreate_NonASCIIBranch_KeepsLetters(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	for branch, dirName := range map[string]string{"feature/żółw": "feature-żółw", "功能/登录": "功能-登录"} {
+		stdout, stderr, err := runWt(t, dir, "create", branch)
+		if err != nil {
+			t.Fatalf("wt create %s failed: %v\nstderr: %s", branch, err, stderr)
+		}
+		expectedDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", dirName)
+		if !strings.Contains(stdout, "__wt_cd:"+expectedDir) {
+			t.Errorf("stdout = %q, want __wt_cd:%s", stdout, expectedDir)
+		}
+		if _, stderr, err := runWt(t, dir, "switch", dirName); err != nil {
+			t.Errorf("wt switch %s failed: %v\nstderr: %s", dirName, err, stderr)
+		}
+	}
+}
+
 // WT-033: The system shall preserve the original branch name for all git operations.
 func TestCreate_SlashBranch_PreservesBranchName(t *testing.T) {
 	dir := setupTestRepo(t)
@@ -712,6 +1036,78 @@ func TestCompletion_SwitchSuggestsWorktrees(t *testing.T) {
 	}
 }
 
+// Switch completion describes each worktree's state along with its path.
+func TestCompletion_SwitchDescribesState(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "ahead")
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	runWt(t, clone, "create", "ahead")
+	runWt(t, clone, "create", "quiet")
+	wtsDir := filepath.Join(filepath.Dir(upstream), "clonerepo-worktrees")
+	gitRun(t, filepath.Join(wtsDir, "ahead"), "commit", "--allow-empty", "-m", "one")
+	gitRun(t, filepath.Join(wtsDir, "ahead"), "commit", "--allow-empty", "-m", "two")
+	os.WriteFile(filepath.Join(wtsDir, "ahead", "file.txt"), []byte("x"), 0o644)
+	gitRun(t, filepath.Join(wtsDir, "ahead"), "add", "file.txt")
+
+	stdout, _, _ := runWt(t, clone, "__complete", "switch", "")
+	if !strings.Contains(stdout, "ahead\tclonerepo-worktrees/ahead (dirty, ↑2)\n") {
+		t.Errorf("completion should describe ahead as dirty and 2 ahead, got: %s", stdout)
+	}
+	if !strings.Contains(stdout, "quiet\tclonerepo-worktrees/quiet\n") {
+		t.Errorf("completion should describe a clean worktree by its path alone, got: %s", stdout)
+	}
+}
+
+// switch --pull fast-forwards the worktree first, unless it is dirty.
+func TestSwitch_Pull(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "feature")
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	runWt(t, clone, "create", "feature")
+	wtDir := filepath.Join(filepath.Dir(upstream), "clonerepo-worktrees", "feature")
+
+	commitUpstream := func(msg string) string {
+		gitRun(t, upstream, "checkout", "-q", "feature")
+		gitRun(t, upstream, "commit", "-q", "--allow-empty", "-m", msg)
+		gitRun(t, upstream, "checkout", "-q", "main")
+		out, _ := exec.Command("git", "-C", upstream, "rev-parse", "feature").Output()
+		return string(out)
+	}
+	head := func() string {
+		out, _ := exec.Command("git", "-C", wtDir, "rev-parse", "HEAD").Output()
+		return string(out)
+	}
+
+	want := commitUpstream("one")
+	stdout, stderr, err := runWt(t, clone, "switch", "feature", "--pull")
+	if err != nil {
+		t.Fatalf("wt switch --pull failed: %v\nstderr: %s", err, stderr)
+	}
+	if head() != want {
+		t.Errorf("switch --pull should fast-forward the worktree, stderr: %s", stderr)
+	}
+	if !strings.Contains(stdout, "__wt_cd:"+wtDir) {
+		t.Errorf("switch --pull should still cd to %s, got: %s", wtDir, stdout)
+	}
+
+	before := head()
+	commitUpstream("two")
+	os.WriteFile(filepath.Join(wtDir, "file.txt"), []byte("x"), 0o644)
+	gitRun(t, wtDir, "add", "file.txt")
+	stdout, stderr, err = runWt(t, clone, "switch", "feature", "--pull")
+	if err != nil {
+		t.Fatalf("wt switch --pull of a dirty worktree failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "uncommitted changes") || head() != before {
+		t.Errorf("a dirty worktree should be skipped with a warning, stderr: %s", stderr)
+	}
+	if !strings.Contains(stdout, "__wt_cd:"+wtDir) {
+		t.Errorf("switch --pull should cd even when skipping the pull, got: %s", stdout)
+	}
+}
+
 // WT-045: Tab completion for remove suggests existing linked worktree branch names.
 func TestCompletion_RemoveSuggestsLinkedWorktrees(t *testing.T) {
 	dir := setupTestRepo(t)
@@ -733,6 +1129,26 @@ func TestCompletion_RemoveSuggestsLinkedWorktrees(t *testing.T) {
 	}
 }
 
+// Remove completion offers sanitized directory names alongside branch names,
+// each with a description.
+func TestCompletion_RemoveSuggestsDirectoryNames(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "fix/bug-123")
+
+	stdout, _, _ := runWt(t, dir, "__complete", "remove", "")
+
+	if !strings.Contains(stdout, "fix/bug-123\ttestrepo-worktrees/fix-bug-123") {
+		t.Errorf("completion should offer the branch described by its path, got: %s", stdout)
+	}
+	if !strings.Contains(stdout, "fix-bug-123\tworktree of fix/bug-123") {
+		t.Errorf("completion should offer the directory name described by its branch, got: %s", stdout)
+	}
+
+	if _, stderr, err := runWt(t, dir, "remove", "fix-bug-123"); err != nil {
+		t.Errorf("remove by directory name failed: %v\nstderr: %s", err, stderr)
+	}
+}
+
 // --- Switch with sanitized name ---
 
 // Test that switch works with sanitized directory name for slash branches.
@@ -751,3 +1167,3098 @@ func TestSwitch_SanitizedName(t *testing.T) {
 		t.Errorf("stdout should contain __wt_cd:, got: %q", stdout)
 	}
 }
+
+// --- Diff tests ---
+
+// Diff summarizes changes between a worktree and the main worktree.
+func TestDiff_AgainstMain(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "diff-wt")
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "diff-wt")
+	os.WriteFile(filepath.Join(wtDir, "added.txt"), []byte("one\ntwo\n"), 0o644)
+	gitRun(t, wtDir, "add", "added.txt")
+	gitRun(t, wtDir, "commit", "-m", "add file")
+
+	_, stderr, err := runWt(t, dir, "diff", "diff-wt")
+	if err != nil {
+		t.Fatalf("wt diff failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "1 file changed") {
+		t.Errorf("diff should summarize changed files, got: %s", stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "diff", "diff-wt", "--name-only")
+	if err != nil {
+		t.Fatalf("wt diff --name-only failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "added.txt") {
+		t.Errorf("diff --name-only should list added.txt, got: %s", stderr)
+	}
+}
+
+func TestDiff_NoDifferences(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "same-wt")
+
+	_, stderr, err := runWt(t, dir, "diff", "same-wt")
+	if err != nil {
+		t.Fatalf("wt diff failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "No differences") {
+		t.Errorf("stderr should say 'No differences', got: %s", stderr)
+	}
+}
+
+// --- Path tests ---
+
+// Path prints the bare worktree path without the cd sentinel.
+func TestPath_PrintsAbsolutePath(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "fix/path-test")
+
+	stdout, stderr, err := runWt(t, dir, "path", "fix/path-test")
+	if err != nil {
+		t.Fatalf("wt path failed: %v\nstderr: %s", err, stderr)
+	}
+
+	expected := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "fix-path-test")
+	if strings.TrimSpace(stdout) != expected {
+		t.Errorf("stdout = %q, want %q", stdout, expected)
+	}
+	if strings.Contains(stdout, "__wt_cd:") {
+		t.Error("wt path should not emit the cd sentinel")
+	}
+}
+
+func TestPath_DefaultsToMainWorktree(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	stdout, _, err := runWt(t, dir, "path")
+	if err != nil {
+		t.Fatalf("wt path failed: %v", err)
+	}
+	if strings.TrimSpace(stdout) != dir {
+		t.Errorf("stdout = %q, want %q", stdout, dir)
+	}
+}
+
+// Status compares each worktree against the default branch.
+// --files lists the pending files of dirty worktrees under their branch.
+// Untracked files only count with --untracked.
+func TestStatus_Files(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "clean-wt")
+	runWt(t, dir, "create", "dirty-wt")
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "dirty-wt")
+	os.WriteFile(filepath.Join(wtDir, "new.txt"), []byte("new"), 0o644)
+
+	_, stderr, err := runWt(t, dir, "status", "--files")
+	if err != nil {
+		t.Fatalf("wt status --files failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Contains(stderr, "new.txt") || strings.Contains(stderr, " dirty ") {
+		t.Errorf("status should skip untracked files without --untracked, got:\n%s", stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "status", "--files", "--untracked")
+	if err != nil {
+		t.Fatalf("wt status --files failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "dirty-wt (testrepo-worktrees/dirty-wt):\n  ?? new.txt\n") {
+		t.Errorf("status --files should list new.txt under dirty-wt, got:\n%s", stderr)
+	}
+	if strings.Contains(stderr, "clean-wt (") {
+		t.Errorf("status --files should not list clean worktrees, got:\n%s", stderr)
+	}
+}
+
+// list and status filters narrow the output to matching worktrees.
+func TestListStatus_Filters(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "release/1.0")
+	runWt(t, dir, "create", "release/2.0")
+	runWt(t, dir, "create", "feature")
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "release-2.0")
+	os.WriteFile(filepath.Join(wtDir, "dirty.txt"), []byte("dirty"), 0o644)
+	gitRun(t, wtDir, "add", "dirty.txt")
+
+	_, stderr, err := runWt(t, dir, "list", "--branch", "release/*")
+	if err != nil {
+		t.Fatalf("wt list --branch failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "release/1.0") || !strings.Contains(stderr, "release/2.0") || strings.Contains(stderr, "feature") {
+		t.Errorf("list --branch 'release/*' should show only release branches, got:\n%s", stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "status", "--branch", "release/*", "--dirty")
+	if err != nil {
+		t.Fatalf("wt status --dirty failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "release/2.0") || strings.Contains(stderr, "release/1.0") {
+		t.Errorf("status --dirty should show only release/2.0, got:\n%s", stderr)
+	}
+
+	// Untracked files do not make a worktree dirty, in list as in status
+	os.WriteFile(filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feature", "new.txt"), []byte("x"), 0o644)
+	for _, command := range []string{"list", "status"} {
+		_, stderr, err = runWt(t, dir, command, "--dirty")
+		if err != nil || !strings.Contains(stderr, "release/2.0") || strings.Contains(stderr, "feature") {
+			t.Errorf("%s --dirty should show only release/2.0, err=%v stderr:\n%s", command, err, stderr)
+		}
+	}
+
+	_, stderr, _ = runWt(t, dir, "list", "--behind")
+	if !strings.Contains(stderr, "No worktrees match") {
+		t.Errorf("list --behind without upstreams should match nothing, got:\n%s", stderr)
+	}
+
+	if _, _, err := runWt(t, dir, "list", "--branch", "["); err == nil {
+		t.Error("a malformed --branch glob should fail")
+	}
+	if _, _, err := runWt(t, dir, "status", "--dirty", "--clean"); err == nil {
+		t.Error("--dirty and --clean should be mutually exclusive")
+	}
+}
+
+func TestListStatus_SortAndColumns(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "zeta")
+	runWt(t, dir, "create", "alpha")
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "alpha")
+	os.WriteFile(filepath.Join(wtDir, "dirty.txt"), []byte("dirty"), 0o644)
+	gitRun(t, wtDir, "add", "dirty.txt")
+
+	_, stderr, err := runWt(t, dir, "list", "--sort", "branch")
+	if err != nil {
+		t.Fatalf("wt list --sort branch failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Index(stderr, "alpha") > strings.Index(stderr, "zeta") {
+		t.Errorf("list --sort branch should show alpha before zeta, got:\n%s", stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "list", "--sort", "age")
+	if err != nil {
+		t.Fatalf("wt list --sort age failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Index(stderr, "zeta") > strings.Index(stderr, "alpha") {
+		t.Errorf("list --sort age should show the older zeta first, got:\n%s", stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "list", "--columns", "branch,ahead,status")
+	if err != nil {
+		t.Fatalf("wt list --columns failed: %v\nstderr: %s", err, stderr)
+	}
+	header, _, _ := strings.Cut(stderr, "\n")
+	if fields := strings.Fields(header); !slices.Equal(fields, []string{"BRANCH", "AHEAD", "STATUS"}) {
+		t.Errorf("list --columns should show the chosen columns in order, got header %q", header)
+	}
+	if !strings.Contains(stderr, "dirty") {
+		t.Errorf("the status column should show alpha dirty, got:\n%s", stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "status", "--sort", "status", "--columns", "branch,status")
+	if err != nil {
+		t.Fatalf("wt status --sort status failed: %v\nstderr: %s", err, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stderr), "\n")
+	if len(lines) < 2 || !strings.Contains(lines[1], "alpha") {
+		t.Errorf("status --sort status should show the dirty worktree first, got:\n%s", stderr)
+	}
+
+	if _, _, err := runWt(t, dir, "list", "--columns", "branch,bogus"); err == nil {
+		t.Error("an unknown column should fail")
+	}
+	if _, _, err := runWt(t, dir, "status", "--sort", "bogus"); err == nil {
+		t.Error("an unknown sort key should fail")
+	}
+}
+
+func TestStatus_LockedAndMissing(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "usb")
+	runWt(t, dir, "create", "here")
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "usb")
+	gitRun(t, dir, "worktree", "lock", "--reason", "on a USB drive", wtDir)
+	if err := os.Rename(wtDir, wtDir+"-unmounted"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, stderr, err := runWt(t, dir, "status")
+	if err != nil {
+		t.Fatalf("wt status failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "locked") || !strings.Contains(stderr, "on a USB drive") {
+		t.Errorf("status should show usb as locked with its reason, got:\n%s", stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "status", "--skip-missing")
+	if err != nil {
+		t.Fatalf("wt status --skip-missing failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Contains(stderr, "usb") || !strings.Contains(stderr, "here") {
+		t.Errorf("status --skip-missing should leave out only usb, got:\n%s", stderr)
+	}
+
+	if _, _, err := runWt(t, dir, "status", "--check", "--check-on", "error"); err == nil {
+		t.Error("status --check-on error should fail on a worktree that could not be checked")
+	}
+	if _, stderr, err := runWt(t, dir, "status", "--check", "--check-on", "error", "--skip-missing"); err != nil {
+		t.Errorf("status --check --skip-missing should pass: %v\nstderr: %s", err, stderr)
+	}
+}
+
+func TestStatus_AgainstDefaultBranch(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "ahead-wt")
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "ahead-wt")
+	gitRun(t, wtDir, "commit", "--allow-empty", "-m", "one")
+	gitRun(t, wtDir, "commit", "--allow-empty", "-m", "two")
+
+	_, stderr, err := runWt(t, dir, "status")
+	if err != nil {
+		t.Fatalf("wt status failed: %v", err)
+	}
+	if !strings.Contains(stderr, "VS main") {
+		t.Errorf("status should have a 'VS main' column, got: %s", stderr)
+	}
+	if !strings.Contains(stderr, "↑2 ↓0") {
+		t.Errorf("status should show ahead-wt 2 commits ahead of main, got: %s", stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "status", "--against", "ahead-wt")
+	if err != nil {
+		t.Fatalf("wt status --against failed: %v", err)
+	}
+	if !strings.Contains(stderr, "VS ahead-wt") || !strings.Contains(stderr, "↑0 ↓2") {
+		t.Errorf("status --against should compare main against ahead-wt, got: %s", stderr)
+	}
+}
+
+// Status --check fails when a worktree is dirty and passes once it is clean.
+func TestStatus_Check(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "check-wt")
+
+	if _, stderr, err := runWt(t, dir, "status", "--check"); err != nil {
+		t.Fatalf("wt status --check should pass on clean worktrees: %v\nstderr: %s", err, stderr)
+	}
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "check-wt")
+	os.WriteFile(filepath.Join(wtDir, "dirty.txt"), []byte("dirty"), 0o644)
+	gitRun(t, wtDir, "add", "dirty.txt")
+
+	_, stderr, err := runWt(t, dir, "status", "--check")
+	if err == nil {
+		t.Fatal("wt status --check should fail with a dirty worktree")
+	}
+	if !strings.Contains(stderr, "check-wt (dirty)") {
+		t.Errorf("stderr should name the failing worktree, got: %s", stderr)
+	}
+
+	if _, stderr, err := runWt(t, dir, "status", "--check", "--check-on", "behind"); err != nil {
+		t.Errorf("--check-on behind should ignore dirty worktrees: %v\nstderr: %s", err, stderr)
+	}
+
+	_, stderr, err = runWt(t, dir, "status", "--check", "--check-on", "bogus")
+	if err == nil || !strings.Contains(stderr, "unknown check condition") {
+		t.Errorf("unknown condition should be rejected, got err=%v stderr: %s", err, stderr)
+	}
+}
+
+// Manually deleted worktrees are flagged in list/status and cleaned by prune --broken,
+// which also deletes orphan directories in the worktrees directory.
+// Worktrees older than the [expire] policy are marked by status and removed by
+// prune --expired, except excluded branches and dirty worktrees.
+func TestPrune_Expired(t *testing.T) {
+	dir := setupTestRepo(t)
+	for _, branch := range []string{"old", "dirty-old", "release/1", "fresh"} {
+		if _, stderr, err := runWt(t, dir, "create", branch); err != nil {
+			t.Fatalf("wt create %s failed: %v\nstderr: %s", branch, err, stderr)
+		}
+	}
+	wtsDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	longAgo := time.Now().Add(-40 * 24 * time.Hour)
+	state.New(filepath.Join(dir, ".git", "wt")).Update(func(st *state.State) error {
+		for _, name := range []string{"old", "dirty-old", "release-1"} {
+			st.Worktree(filepath.Join(wtsDir, name)).Created = longAgo
+		}
+		return nil
+	})
+	os.WriteFile(filepath.Join(wtsDir, "dirty-old", "wip.txt"), []byte("wip"), 0o644)
+	// A worktree wt did not create is dated by its .git file
+	adopted := filepath.Join(wtsDir, "adopted")
+	gitRun(t, dir, "worktree", "add", "-q", "-b", "adopted", adopted)
+	os.Chtimes(filepath.Join(adopted, ".git"), longAgo, longAgo)
+
+	if _, stderr, err := runWt(t, dir, "prune", "--expired"); err == nil || !strings.Contains(stderr, "[expire]") {
+		t.Errorf("prune --expired without a policy should fail, err=%v stderr=%s", err, stderr)
+	}
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[expire]\nafter = \"30d\"\nexclude = [\"release/*\"]\n"), 0o644)
+
+	_, stderr, err := runWt(t, dir, "status")
+	if err != nil {
+		t.Fatalf("wt status failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, line := range strings.Split(stderr, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		wantExpired := fields[0] == "old" || fields[0] == "dirty-old" || fields[0] == "adopted"
+		if strings.Contains(line, "expired") != wantExpired && !strings.Contains(line, "wt prune --expired") {
+			t.Errorf("status line %q: expired mark should be %v", line, wantExpired)
+		}
+	}
+	if !strings.Contains(stderr, "3 worktree(s) are older than 30d") {
+		t.Errorf("status should hint at prune --expired, got: %s", stderr)
+	}
+	if _, stderr, err := runWt(t, dir, "status", "--check", "--check-on", "expired"); err == nil || !strings.Contains(stderr, "old (expired)") {
+		t.Errorf("status --check-on expired should fail, err=%v stderr=%s", err, stderr)
+	}
+
+	if _, _, err := runWt(t, dir, "prune", "--expired"); err == nil {
+		t.Error("removing expired worktrees without --yes should fail when non-interactive")
+	}
+	// A worktree git refuses to remove does not stop the others' removal
+	runWt(t, dir, "create", "locked-old")
+	state.New(filepath.Join(dir, ".git", "wt")).Update(func(st *state.State) error {
+		st.Worktree(filepath.Join(wtsDir, "locked-old")).Created = longAgo
+		return nil
+	})
+	gitRun(t, dir, "worktree", "lock", filepath.Join(wtsDir, "locked-old"))
+	_, stderr, err = runWt(t, dir, "prune", "--expired", "--yes")
+	if err == nil || !strings.Contains(stderr, "Failed to remove testrepo-worktrees/locked-old") {
+		t.Errorf("prune --expired should report the locked worktree, err=%v stderr=%s", err, stderr)
+	}
+	for name, kept := range map[string]bool{"old": false, "adopted": false, "dirty-old": true, "release-1": true, "fresh": true, "locked-old": true} {
+		if _, err := os.Stat(filepath.Join(wtsDir, name)); (err == nil) != kept {
+			t.Errorf("%s: kept = %v, want %v", name, err == nil, kept)
+		}
+	}
+	if !strings.Contains(stderr, "Kept testrepo-worktrees/dirty-old") {
+		t.Errorf("stderr should report the dirty worktree as kept, got: %s", stderr)
+	}
+}
+
+func TestPrune_Broken(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "gone-wt")
+	runWt(t, dir, "create", "kept-wt")
+
+	wtsDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	os.RemoveAll(filepath.Join(wtsDir, "gone-wt"))
+	orphan := filepath.Join(wtsDir, "leftover")
+	os.MkdirAll(orphan, 0o755)
+
+	_, stderr, _ := runWt(t, dir, "list")
+	if !strings.Contains(stderr, "(prunable)") || !strings.Contains(stderr, "wt prune --broken") {
+		t.Errorf("list should mark the missing worktree as prunable, got: %s", stderr)
+	}
+	_, stderr, _ = runWt(t, dir, "status")
+	if !strings.Contains(stderr, "prunable") {
+		t.Errorf("status should mark the missing worktree as prunable, got: %s", stderr)
+	}
+
+	_, stderr, err := runWt(t, dir, "prune", "--broken", "--dry-run")
+	if err != nil {
+		t.Fatalf("wt prune --dry-run failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(orphan); err != nil {
+		t.Error("dry run should not delete orphan directories")
+	}
+
+	if _, _, err := runWt(t, dir, "prune", "--broken"); err == nil {
+		t.Error("deleting orphan directories without --yes should fail when non-interactive")
+	}
+	_, stderr, err = runWt(t, dir, "prune", "--broken", "--yes")
+	if err != nil {
+		t.Fatalf("wt prune --broken failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Error("orphan directory should be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "kept-wt")); err != nil {
+		t.Error("live worktree must not be deleted")
+	}
+
+	_, stderr, _ = runWt(t, dir, "list")
+	if strings.Contains(stderr, "gone-wt") {
+		t.Errorf("pruned worktree still listed: %s", stderr)
+	}
+}
+
+// Orphans are told apart from live worktrees by resolved paths, so running
+// from a symlinked path deletes no worktree, and a directory holding a .git
+// file is never deleted.
+func TestPrune_BrokenThroughSymlink(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "live")
+	wtsDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	os.WriteFile(filepath.Join(wtsDir, "live", "work.txt"), []byte("unsaved\n"), 0o644)
+	gitDirLike := filepath.Join(wtsDir, "checkout")
+	os.MkdirAll(gitDirLike, 0o755)
+	os.WriteFile(filepath.Join(gitDirLike, ".git"), []byte("gitdir: /elsewhere\n"), 0o644)
+
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(filepath.Dir(dir), link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	_, stderr, err := runWt(t, filepath.Join(link, "testrepo"), "prune", "--broken", "--yes")
+	if err != nil {
+		t.Fatalf("wt prune --broken failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Contains(stderr, "orphan") {
+		t.Errorf("nothing should be an orphan, got: %s", stderr)
+	}
+	for _, keep := range []string{filepath.Join(wtsDir, "live", "work.txt"), gitDirLike} {
+		if _, err := os.Stat(keep); err != nil {
+			t.Errorf("%s must not be deleted: %v", keep, err)
+		}
+	}
+}
+
+// A --base that names no commit fails before anything is created, suggesting
+// the refs that were probably meant.
+func TestCreate_UnknownBaseSuggests(t *testing.T) {
+	dir := setupTestRepo(t)
+	gitRun(t, dir, "branch", "develop")
+
+	_, stderr, err := runWt(t, dir, "create", "feature", "--base", "devlop")
+	if err == nil {
+		t.Fatal("wt create with a mistyped --base should fail")
+	}
+	if !strings.Contains(stderr, `base "devlop" is not a branch, tag, or commit; did you mean develop?`) {
+		t.Errorf("stderr should suggest develop, got: %s", stderr)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "testrepo-worktrees")); !os.IsNotExist(err) {
+		t.Error("the worktrees directory should not be created")
+	}
+	if exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", "refs/heads/feature").Run() == nil {
+		t.Error("the branch should not be created")
+	}
+}
+
+// Creating a worktree for a branch that only exists on the remote sets up tracking.
+func TestCreate_RemoteOnlyBranchTracksUpstream(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "remote-only")
+
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+
+	_, stderr, err := runWt(t, clone, "create", "remote-only")
+	if err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "tracking origin/remote-only") {
+		t.Errorf("stderr should mention the tracked ref, got: %s", stderr)
+	}
+
+	wtDir := filepath.Join(filepath.Dir(upstream), "clonerepo-worktrees", "remote-only")
+	out, err := exec.Command("git", "-C", wtDir, "rev-parse", "--abbrev-ref", "@{upstream}").Output()
+	if err != nil {
+		t.Fatalf("worktree branch has no upstream: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "origin/remote-only" {
+		t.Errorf("upstream = %q, want origin/remote-only", got)
+	}
+}
+
+// Create refuses layouts that nest worktrees inside each other.
+func TestCreate_RefusesNesting(t *testing.T) {
+	dir := setupTestRepo(t)
+	parent := filepath.Dir(dir)
+	wtsDir := filepath.Join(parent, "testrepo-worktrees")
+
+	// A worktree occupying the worktrees directory itself
+	gitRun(t, dir, "worktree", "add", "-q", "-b", "outer", wtsDir)
+	_, stderr, err := runWt(t, dir, "create", "inner")
+	if err == nil || !strings.Contains(stderr, "nested inside the worktree") {
+		t.Errorf("create inside an existing worktree should fail, err=%v stderr=%s", err, stderr)
+	}
+	gitRun(t, dir, "worktree", "remove", wtsDir)
+
+	// A worktrees directory linked into an existing worktree
+	outer := filepath.Join(parent, "outer")
+	gitRun(t, dir, "worktree", "add", "-q", "-b", "linked-outer", outer)
+	os.MkdirAll(filepath.Join(outer, "wts"), 0o755)
+	if err := os.Symlink(filepath.Join(outer, "wts"), wtsDir); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err = runWt(t, dir, "create", "linked")
+	if err == nil || !strings.Contains(stderr, "nested inside the worktree") {
+		t.Errorf("create through a link into an existing worktree should fail, err=%v stderr=%s", err, stderr)
+	}
+	os.Remove(wtsDir)
+	gitRun(t, dir, "worktree", "remove", "--force", outer)
+
+	// Running from the worktrees directory when an enclosing repository exists
+	gitRun(t, parent, "init", "-q")
+	os.MkdirAll(filepath.Join(wtsDir, "stray"), 0o755)
+	_, stderr, err = runWt(t, filepath.Join(wtsDir, "stray"), "create", "other")
+	if err == nil || !strings.Contains(stderr, "inside the worktrees directory of "+dir) {
+		t.Errorf("create from the worktrees directory should fail, err=%v stderr=%s", err, stderr)
+	}
+}
+
+// --local and --remote restrict which existing branches direct creation uses.
+func TestCreate_LocalRemoteOnDirectPath(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "remote-only")
+	gitRun(t, upstream, "branch", "both")
+
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	gitRun(t, clone, "branch", "both")
+	wtsDir := filepath.Join(filepath.Dir(upstream), "clonerepo-worktrees")
+
+	// --local ignores the remote branch and creates an untracked local one
+	if _, stderr, err := runWt(t, clone, "create", "remote-only", "--local"); err != nil {
+		t.Fatalf("wt create --local failed: %v\nstderr: %s", err, stderr)
+	}
+	if err := exec.Command("git", "-C", filepath.Join(wtsDir, "remote-only"), "rev-parse", "@{upstream}").Run(); err == nil {
+		t.Error("--local should not set up tracking")
+	}
+
+	// --remote refuses to shadow a same-named local branch
+	_, stderr, err := runWt(t, clone, "create", "both", "--remote")
+	if err == nil || !strings.Contains(stderr, "also exists locally") {
+		t.Errorf("--remote with a local branch should fail, err=%v stderr=%s", err, stderr)
+	}
+	_, stderr, err = runWt(t, clone, "create", "nowhere", "--remote")
+	if err == nil || !strings.Contains(stderr, "no remote branch") {
+		t.Errorf("--remote without a remote branch should fail, err=%v stderr=%s", err, stderr)
+	}
+}
+
+// --fetch-base refreshes a stale remote-tracking base before branching.
+func TestCreate_FetchBase(t *testing.T) {
+	upstream := setupTestRepo(t)
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+
+	// Advance upstream main after the clone, so origin/main in the clone is stale
+	gitRun(t, upstream, "commit", "--allow-empty", "-m", "upstream tip")
+	tip, _ := exec.Command("git", "-C", upstream, "rev-parse", "HEAD").Output()
+
+	_, stderr, err := runWt(t, clone, "create", "stale-base", "--base", "origin/main")
+	if err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	_, stderr, err = runWt(t, clone, "create", "fresh-base", "--base", "origin/main", "--fetch-base")
+	if err != nil {
+		t.Fatalf("wt create --fetch-base failed: %v\nstderr: %s", err, stderr)
+	}
+
+	wtsDir := filepath.Join(filepath.Dir(upstream), "clonerepo-worktrees")
+	stale, _ := exec.Command("git", "-C", filepath.Join(wtsDir, "stale-base"), "rev-parse", "HEAD").Output()
+	fresh, _ := exec.Command("git", "-C", filepath.Join(wtsDir, "fresh-base"), "rev-parse", "HEAD").Output()
+	if string(stale) == string(tip) {
+		t.Error("without --fetch-base the branch should start from the stale local origin/main")
+	}
+	if string(fresh) != string(tip) {
+		t.Errorf("with --fetch-base the branch should start at the upstream tip %s, got %s", tip, fresh)
+	}
+}
+
+// --base-remote branches from the fetched remote branch of a local base, and
+// prefer-remote-base does the same by default.
+func TestCreate_BaseRemote(t *testing.T) {
+	upstream := setupTestRepo(t)
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	gitRun(t, clone, "branch", "local-only")
+
+	// Advance upstream main after the clone, so main in the clone is stale
+	gitRun(t, upstream, "commit", "--allow-empty", "-m", "upstream tip")
+	tip, _ := exec.Command("git", "-C", upstream, "rev-parse", "HEAD").Output()
+	wtsDir := filepath.Join(filepath.Dir(upstream), "clonerepo-worktrees")
+	head := func(name string) string {
+		out, _ := exec.Command("git", "-C", filepath.Join(wtsDir, name), "rev-parse", "HEAD").Output()
+		return string(out)
+	}
+
+	if _, stderr, err := runWt(t, clone, "create", "stale", "--base", "main"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, stderr, err := runWt(t, clone, "create", "fresh", "--base", "main", "--base-remote"); err != nil {
+		t.Fatalf("wt create --base-remote failed: %v\nstderr: %s", err, stderr)
+	}
+	if head("stale") == string(tip) {
+		t.Error("without --base-remote the branch should start from the stale local main")
+	}
+	if head("fresh") != string(tip) {
+		t.Errorf("with --base-remote the branch should start at the upstream tip %s, got %s", tip, head("fresh"))
+	}
+	if out, err := exec.Command("git", "-C", clone, "config", "--get", "branch.fresh.merge").Output(); err == nil {
+		t.Errorf("a branch started at the remote base should not track it, got upstream %s", out)
+	}
+
+	// Without --base, the remote branch of the current branch is used
+	if _, stderr, err := runWt(t, clone, "create", "implicit", "--base-remote"); err != nil {
+		t.Fatalf("wt create --base-remote without --base failed: %v\nstderr: %s", err, stderr)
+	}
+	if head("implicit") != string(tip) {
+		t.Errorf("--base-remote without --base should start at the upstream tip, got %s", head("implicit"))
+	}
+
+	_, stderr, err := runWt(t, clone, "create", "nope", "--base", "local-only", "--base-remote")
+	if err == nil || !strings.Contains(stderr, `"local-only" has no remote branch`) {
+		t.Errorf("--base-remote with a local-only base should fail, err=%v stderr=%s", err, stderr)
+	}
+
+	os.WriteFile(filepath.Join(clone, ".wt.toml"), []byte("[create]\nprefer-remote-base = true\n"), 0o644)
+	if _, stderr, err := runWt(t, clone, "create", "configured", "--base", "main"); err != nil {
+		t.Fatalf("wt create with prefer-remote-base failed: %v\nstderr: %s", err, stderr)
+	}
+	if head("configured") != string(tip) {
+		t.Errorf("prefer-remote-base should start at the upstream tip, got %s", head("configured"))
+	}
+	_, stderr, err = runWt(t, clone, "create", "kept", "--base", "local-only")
+	if err != nil || !strings.Contains(stderr, "branching from the local one") {
+		t.Errorf("prefer-remote-base with a local-only base should warn and go on, err=%v stderr=%s", err, stderr)
+	}
+}
+
+// --no-track leaves new branches without an upstream, and --reset recreates a
+// stale local branch from its remote branch.
+func TestCreate_NoTrackAndReset(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "feat")
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	wtsDir := filepath.Join(filepath.Dir(upstream), "clonerepo-worktrees")
+	hasUpstream := func(name string) bool {
+		return exec.Command("git", "-C", filepath.Join(wtsDir, name), "rev-parse", "@{upstream}").Run() == nil
+	}
+
+	if _, stderr, err := runWt(t, clone, "create", "untracked", "--base", "origin/main", "--no-track"); err != nil {
+		t.Fatalf("wt create --no-track failed: %v\nstderr: %s", err, stderr)
+	}
+	if hasUpstream("untracked") {
+		t.Error("--no-track should not set an upstream for a branch started at origin/main")
+	}
+	gitRun(t, clone, "branch", "existing")
+	_, stderr, err := runWt(t, clone, "create", "existing", "--no-track")
+	if err == nil || !strings.Contains(stderr, "only applies to new branches") {
+		t.Errorf("--no-track on an existing branch should fail, err=%v stderr=%s", err, stderr)
+	}
+
+	// A stale local feat, behind and diverged from origin/feat
+	gitRun(t, upstream, "checkout", "-q", "feat")
+	gitRun(t, upstream, "commit", "--allow-empty", "-m", "upstream feat")
+	gitRun(t, clone, "fetch", "-q")
+	gitRun(t, clone, "branch", "feat", "main")
+	gitRun(t, clone, "commit", "--allow-empty", "-m", "stale")
+	gitRun(t, clone, "branch", "-f", "feat", "HEAD")
+
+	_, stderr, err = runWt(t, clone, "create", "feat", "--reset")
+	if err != nil {
+		t.Fatalf("wt create --reset failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "Reset branch \"feat\" to origin/feat") {
+		t.Errorf("stderr should report the reset, got: %s", stderr)
+	}
+	head, _ := exec.Command("git", "-C", filepath.Join(wtsDir, "feat"), "log", "-1", "--format=%s").Output()
+	if got := strings.TrimSpace(string(head)); got != "upstream feat" {
+		t.Errorf("feat head = %q, want origin/feat's commit", got)
+	}
+	if !hasUpstream("feat") {
+		t.Error("a branch reset to its remote branch should track it")
+	}
+
+	_, stderr, err = runWt(t, clone, "create", "feat", "--reset")
+	if err == nil || !strings.Contains(stderr, "cannot be reset") {
+		t.Errorf("--reset of a checked-out branch should fail, err=%v stderr=%s", err, stderr)
+	}
+
+	// --reset --no-track also drops the upstream a branch had
+	gitRun(t, clone, "branch", "-q", "--track", "tracked", "origin/feat")
+	if _, stderr, err := runWt(t, clone, "create", "tracked", "--reset", "--base", "main", "--no-track"); err != nil {
+		t.Fatalf("wt create --reset --no-track failed: %v\nstderr: %s", err, stderr)
+	}
+	if hasUpstream("tracked") {
+		t.Error("--reset --no-track should drop the branch's upstream")
+	}
+	_, stderr, err = runWt(t, clone, "create", "local-only", "--reset", "--local")
+	if err == nil || !strings.Contains(stderr, "--base") {
+		t.Errorf("--reset without anything to reset to should fail, err=%v stderr=%s", err, stderr)
+	}
+}
+
+// Post-create hooks run in the new worktree with the WT_* environment and
+// templated arguments.
+func TestCreate_RunsPostCreateHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use POSIX sh")
+	}
+	dir := setupTestRepo(t)
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`[hooks]
+post-create = ['echo "$WT_BRANCH|$WT_BASE|$WT_REPO_NAME" > hook.txt', 'echo {{dir_name}} >> hook.txt']
+`), 0o644)
+	trustHooks(t, dir)
+
+	_, stderr, err := runWt(t, dir, "create", "fix/hooked", "--base", "main")
+	if err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "fix-hooked")
+	data, err := os.ReadFile(filepath.Join(wtDir, "hook.txt"))
+	if err != nil {
+		t.Fatalf("post-create hook did not run in the worktree: %v", err)
+	}
+	if got := string(data); got != "fix/hooked|main|testrepo\nfix-hooked\n" {
+		t.Errorf("hook output = %q", got)
+	}
+
+	if _, stderr, err := runWt(t, dir, "create", "fix/unhooked", "--no-hooks"); err != nil {
+		t.Fatalf("wt create --no-hooks failed: %v\nstderr: %s", err, stderr)
+	}
+	skipped := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "fix-unhooked", "hook.txt")
+	if _, err := os.Stat(skipped); err == nil {
+		t.Error("--no-hooks should skip the post-create hooks")
+	}
+}
+
+// Hooks from the repository's .wt.toml only run once approved, and need
+// approval again when they change; hooks from the user config always run.
+func TestCreate_SkipsUnapprovedRepoHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use POSIX sh")
+	}
+	dir := setupTestRepo(t)
+	wtsDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[hooks]\npost-create = ['touch hook.txt']\n"), 0o644)
+
+	_, stderr, err := runWt(t, dir, "create", "one")
+	if err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "one", "hook.txt")); err == nil || !strings.Contains(stderr, "wt hooks trust") {
+		t.Errorf("an unapproved hook should be skipped with a warning, stderr=%s", stderr)
+	}
+	if _, _, err := runWt(t, dir, "hooks", "run", "post-create", "one"); err == nil {
+		t.Error("wt hooks run should refuse an unapproved hook")
+	}
+
+	trustHooks(t, dir)
+	if _, stderr, err := runWt(t, dir, "create", "two"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "two", "hook.txt")); err != nil {
+		t.Error("an approved hook should run")
+	}
+
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[hooks]\npost-create = ['touch hook.txt changed.txt']\n"), 0o644)
+	if _, stderr, err := runWt(t, dir, "create", "three"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "three", "hook.txt")); err == nil {
+		t.Error("a changed hook should need approval again")
+	}
+
+	configDir := t.TempDir()
+	os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("[hooks]\npost-create = ['touch user.txt']\n"), 0o644)
+	os.Remove(filepath.Join(dir, ".wt.toml"))
+	if _, stderr, err := runWtEnv(t, dir, []string{"WT_CONFIG_DIR=" + configDir}, "create", "four"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "four", "user.txt")); err != nil {
+		t.Error("a hook from the user config should run without approval")
+	}
+}
+
+// A worktree add that fails after git created the worktree, here in git's
+// post-checkout hook, is rolled back so that creating it can be retried.
+func TestCreate_FailedAddRollsBack(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the git hook is a POSIX sh script")
+	}
+	dir := setupTestRepo(t)
+	hook := filepath.Join(dir, ".git", "hooks", "post-checkout")
+	os.MkdirAll(filepath.Dir(hook), 0o755)
+	os.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0o755)
+
+	if _, _, err := runWt(t, dir, "create", "feat"); err == nil {
+		t.Fatal("wt create should fail when git worktree add fails")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "testrepo-worktrees")); !os.IsNotExist(err) {
+		t.Error("the worktrees directory created for the worktree should be removed")
+	}
+	out, _ := exec.Command("git", "-C", dir, "worktree", "list").Output()
+	if strings.Contains(string(out), "feat") {
+		t.Errorf("the worktree should be unregistered, got:\n%s", out)
+	}
+	if exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", "refs/heads/feat").Run() == nil {
+		t.Error("the new branch should be deleted")
+	}
+
+	os.Remove(hook)
+	if _, stderr, err := runWt(t, dir, "create", "feat"); err != nil {
+		t.Fatalf("retrying wt create failed: %v\nstderr: %s", err, stderr)
+	}
+}
+
+// With --atomic, a failing post-create hook removes the new worktree again;
+// without it the worktree is kept with a warning.
+func TestCreate_AtomicRollsBackOnHookFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use POSIX sh")
+	}
+	dir := setupTestRepo(t)
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[hooks]\npost-create = ['exit 3']\n"), 0o644)
+	trustHooks(t, dir)
+	wtsDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+
+	_, stderr, err := runWt(t, dir, "create", "kept")
+	if err != nil || !strings.Contains(stderr, "Warning:") {
+		t.Fatalf("a failing hook should only warn without --atomic, err=%v stderr=%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "kept")); err != nil {
+		t.Error("the worktree should be kept without --atomic")
+	}
+
+	stdout, stderr, err := runWt(t, dir, "create", "dropped", "--atomic")
+	if err == nil {
+		t.Fatal("wt create --atomic should fail when a hook fails")
+	}
+	if !strings.Contains(stderr, "removed the worktree") || stdout != "" {
+		t.Errorf("stderr should report the rollback and stdout stay empty, stdout=%q stderr=%s", stdout, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "dropped")); !os.IsNotExist(err) {
+		t.Error("the worktree directory should be removed")
+	}
+	if exec.Command("git", "-C", dir, "rev-parse", "--verify", "--quiet", "refs/heads/dropped").Run() == nil {
+		t.Error("the new branch should be deleted")
+	}
+	st, _ := state.New(filepath.Join(dir, ".git", "wt")).Load()
+	for path := range st.Worktrees {
+		if strings.HasSuffix(path, "dropped") {
+			t.Errorf("state should forget the removed worktree, got %s", path)
+		}
+	}
+}
+
+func TestHooks_ListAndRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands use POSIX sh")
+	}
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "hooks", "list"); err != nil || !strings.Contains(stderr, "No hooks configured") {
+		t.Errorf("expected no hooks, err=%v stderr=%s", err, stderr)
+	}
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`[hooks]
+post-create = ['echo "$WT_BRANCH|$WT_BASE" > hook.txt']
+post-switch = ['echo {{branch}}']
+`), 0o644)
+
+	_, stderr, err := runWt(t, dir, "hooks", "list")
+	if err != nil || !strings.Contains(stderr, "post-create") || !strings.Contains(stderr, "echo {{branch}}") {
+		t.Errorf("hooks list should show both hooks, err=%v stderr=%s", err, stderr)
+	}
+	if !strings.Contains(stderr, "wt hooks trust") {
+		t.Errorf("hooks list should say the hooks are not approved, stderr=%s", stderr)
+	}
+	trustHooks(t, dir)
+
+	if _, stderr, err := runWt(t, dir, "create", "feat", "--base", "main", "--no-hooks"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feat")
+
+	// Inside the worktree, it is the default target
+	if _, stderr, err := runWt(t, wtDir, "hooks", "run", "post-create"); err != nil {
+		t.Fatalf("wt hooks run failed: %v\nstderr: %s", err, stderr)
+	}
+	if data, _ := os.ReadFile(filepath.Join(wtDir, "hook.txt")); string(data) != "feat|main\n" {
+		t.Errorf("hook output = %q, want the branch and its recorded base", data)
+	}
+
+	stdout, stderr, err := runWt(t, dir, "hooks", "run", "post-switch", "feat")
+	if err != nil {
+		t.Fatalf("wt hooks run post-switch failed: %v\nstderr: %s", err, stderr)
+	}
+	if want := "__wt_cd:" + wtDir + "\n__wt_run:echo 'feat'"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+
+	if _, stderr, err := runWt(t, dir, "hooks", "run", "pre-commit", "feat"); err == nil || !strings.Contains(stderr, "unknown hook") {
+		t.Errorf("expected an unknown hook error, err=%v stderr=%s", err, stderr)
+	}
+}
+
+func TestParseGitArgs(t *testing.T) {
+	targets, rest, err := parseGitArgs([]string{"log", "--branch=feat*", "--oneline", "-3"})
+	if err != nil || targets.branch != "feat*" || targets.all || strings.Join(rest, " ") != "log --oneline -3" {
+		t.Errorf("got %+v %q %v", targets, rest, err)
+	}
+	targets, rest, err = parseGitArgs([]string{"fetch", "--all", "--all-worktrees"})
+	if err != nil || !targets.all || strings.Join(rest, " ") != "fetch --all" {
+		t.Errorf("got %+v %q %v", targets, rest, err)
+	}
+	if _, _, err := parseGitArgs([]string{"status", "--all-worktrees", "--branch", "x"}); err == nil {
+		t.Error("expected an error for --all-worktrees with --branch")
+	}
+	if _, _, err := parseGitArgs([]string{"status", "--branch"}); err == nil {
+		t.Error("expected an error for --branch without a glob")
+	}
+	if n := leadingGlobalFlags([]string{"--no-color", "--repo", "app", "-v", "log", "-v"}); n != 4 {
+		t.Errorf("leadingGlobalFlags = %d, want 4", n)
+	}
+	if n := leadingGlobalFlags([]string{"-C", "../app", "status"}); n != 2 {
+		t.Errorf("leadingGlobalFlags = %d, want 2", n)
+	}
+}
+
+func TestGit_Passthrough(t *testing.T) {
+	dir := setupTestRepo(t)
+	for _, branch := range []string{"feat-a", "feat-b"} {
+		if _, stderr, err := runWt(t, dir, "create", branch); err != nil {
+			t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+		}
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feat-a")
+
+	stdout, stderr, err := runWt(t, dir, "git", "rev-parse", "--abbrev-ref", "HEAD", "--all-worktrees")
+	if err != nil {
+		t.Fatalf("wt git failed: %v\nstderr: %s", err, stderr)
+	}
+	if stdout != "" {
+		t.Errorf("stdout = %q, want git's output on stderr", stdout)
+	}
+	for _, want := range []string{"==> main", "==> feat-a", "==> feat-b", "\nfeat-b\n"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("stderr missing %q:\n%s", want, stderr)
+		}
+	}
+
+	_, stderr, err = runWt(t, dir, "git", "rev-parse", "--abbrev-ref", "HEAD", "--branch", "feat-*")
+	if err != nil || strings.Contains(stderr, "==> main") || !strings.Contains(stderr, "==> feat-b") {
+		t.Errorf("--branch should select only feat-*, err=%v stderr=%s", err, stderr)
+	}
+
+	// Without a selector, only the current worktree, without a header
+	_, stderr, err = runWt(t, wtDir, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || stderr != "feat-a\n" {
+		t.Errorf("stderr = %q, err=%v, want the current worktree's branch only", stderr, err)
+	}
+
+	_, stderr, err = runWt(t, dir, "git", "rev-parse", "--verify", "nope", "--all-worktrees")
+	if err == nil || !strings.Contains(stderr, "failed in 3 of 3 worktrees") {
+		t.Errorf("expected the failures to be counted, err=%v stderr=%s", err, stderr)
+	}
+
+	// git reads the terminal's input, for commands that prompt or read stdin
+	cmd := exec.Command(wtBinary(t), "git", "hash-object", "--stdin")
+	cmd.Dir = wtDir
+	cmd.Env = append(os.Environ(), "WT_CONFIG_DIR="+testConfigDir(t))
+	cmd.Stdin = strings.NewReader("hello\n")
+	out, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "ce013625030ba8dba906f756967f9e9ca394464a") {
+		t.Errorf("wt git hash-object --stdin = %q, err=%v; want the hash of its input", out, err)
+	}
+}
+
+// Post-switch hooks are emitted after the cd sentinel for the shell wrapper,
+// with placeholders expanded.
+func TestSwitch_EmitsPostSwitchHooks(t *testing.T) {
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "feat"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`[hooks]
+post-switch = ['direnv reload', 'echo {{branch}}']
+`), 0o644)
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feat")
+
+	// Unapproved commands of the repository's .wt.toml are not handed over
+	stdout, stderr, err := runWt(t, dir, "switch", "feat")
+	if err != nil {
+		t.Fatalf("wt switch failed: %v\nstderr: %s", err, stderr)
+	}
+	if stdout != "__wt_cd:"+wtDir || !strings.Contains(stderr, "wt hooks trust") {
+		t.Errorf("unapproved post-switch hooks should be skipped with a warning, stdout=%q stderr=%s", stdout, stderr)
+	}
+
+	trustHooks(t, dir)
+	stdout, stderr, err = runWt(t, dir, "switch", "feat")
+	if err != nil {
+		t.Fatalf("wt switch failed: %v\nstderr: %s", err, stderr)
+	}
+	want := "__wt_cd:" + wtDir + "\n__wt_run:direnv reload\n__wt_run:echo 'feat'"
+	if stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+}
+
+// --exec prints the expanded command in place of the cd sentinel, even for
+// the worktree the user is in.
+func TestSwitch_Exec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("placeholders are quoted for POSIX sh")
+	}
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "feat"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feat")
+
+	stdout, stderr, err := runWt(t, dir, "switch", "feat", "--exec", "tmux new-window -c {{worktree_path}} -n {{branch}}")
+	if err != nil {
+		t.Fatalf("wt switch --exec failed: %v\nstderr: %s", err, stderr)
+	}
+	if want := "__wt_run:tmux new-window -c '" + wtDir + "' -n 'feat'"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+	stdout, _, err = runWt(t, wtDir, "switch", "feat", "--exec", "code")
+	if want := "__wt_run:code '" + wtDir + "'"; err != nil || stdout != want {
+		t.Errorf("from inside the worktree: err=%v stdout=%q, want %q", err, stdout, want)
+	}
+}
+
+// Switch and remove accept names case-insensitively and fall back to a
+// unique substring; ambiguous substrings list the candidates.
+func TestResolve_CaseInsensitiveAndSubstring(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "fix/bug-123")
+	runWt(t, dir, "create", "fix/bug-456")
+	wtsDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+
+	stdout, stderr, err := runWt(t, dir, "switch", "Fix/Bug-123")
+	if err != nil {
+		t.Fatalf("case-insensitive switch failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, filepath.Join(wtsDir, "fix-bug-123")) {
+		t.Errorf("switch should cd to fix-bug-123, got: %s", stdout)
+	}
+
+	stdout, _, err = runWt(t, dir, "switch", "456")
+	if err != nil || !strings.Contains(stdout, filepath.Join(wtsDir, "fix-bug-456")) {
+		t.Errorf("unique substring should resolve, got err=%v stdout=%s", err, stdout)
+	}
+
+	_, stderr, err = runWt(t, dir, "remove", "bug")
+	if err == nil || !strings.Contains(stderr, "ambiguous") || !strings.Contains(stderr, "fix/bug-123, fix/bug-456") {
+		t.Errorf("ambiguous substring should list candidates, got err=%v stderr=%s", err, stderr)
+	}
+
+	if _, stderr, err := runWt(t, dir, "remove", "FIX/BUG-123"); err != nil {
+		t.Fatalf("case-insensitive remove failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "fix-bug-123")); !os.IsNotExist(err) {
+		t.Error("fix/bug-123 should have been removed")
+	}
+}
+
+// gc reports per-worktree disk usage including ignored build artifacts.
+func TestGC_ReportsUsage(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "heavy")
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "heavy")
+	os.WriteFile(filepath.Join(wtDir, ".gitignore"), []byte("build/\n"), 0o644)
+	os.MkdirAll(filepath.Join(wtDir, "build"), 0o755)
+	os.WriteFile(filepath.Join(wtDir, "build", "out.bin"), make([]byte, 3*1024), 0o644)
+
+	_, stderr, err := runWt(t, dir, "gc")
+	if err != nil {
+		t.Fatalf("wt gc failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "IGNORED") || !strings.Contains(stderr, "3.0 KiB") {
+		t.Errorf("gc should report 3 KiB of ignored files, got: %s", stderr)
+	}
+	if !strings.Contains(stderr, "Total in") {
+		t.Errorf("gc should report the worktrees directory total, got: %s", stderr)
+	}
+
+	// --clean needs a terminal to confirm, or --yes
+	if _, _, err := runWt(t, dir, "gc", "--clean"); err == nil {
+		t.Error("gc --clean should refuse to run without a terminal or --yes")
+	}
+	if _, stderr, err := runWt(t, dir, "gc", "--clean", "--yes"); err != nil {
+		t.Fatalf("wt gc --clean --yes failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtDir, "build")); !os.IsNotExist(err) {
+		t.Error("ignored build directory should have been deleted")
+	}
+	if _, err := os.Stat(filepath.Join(wtDir, ".gitignore")); err != nil {
+		t.Error("untracked, non-ignored files must be kept")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1536: "1.5 KiB", 5 << 30: "5.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+// --verbose and WT_DEBUG log every git invocation.
+func TestVerbose_LogsGitInvocations(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	_, stderr, err := runWt(t, dir, "list", "--verbose")
+	if err != nil {
+		t.Fatalf("wt list --verbose failed: %v", err)
+	}
+	if !strings.Contains(stderr, `cmd="git worktree list --porcelain"`) || !strings.Contains(stderr, "exit=0") {
+		t.Errorf("verbose output should log git invocations, got: %s", stderr)
+	}
+
+	logFile := filepath.Join(t.TempDir(), "wt.log")
+	_, stderr, _ = runWtEnv(t, dir, []string{"WT_DEBUG=" + logFile}, "list")
+	if strings.Contains(stderr, "cmd=") {
+		t.Errorf("WT_DEBUG=<file> should not log to stderr, got: %s", stderr)
+	}
+	data, _ := os.ReadFile(logFile)
+	if !strings.Contains(string(data), "git worktree list") {
+		t.Errorf("log file should contain git invocations, got: %s", data)
+	}
+}
+
+// create --edit launches the configured editor on the new worktree after the
+// cd sentinel; wt open does the same for an existing worktree.
+func TestCreate_EditOpensEditor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake editor is a POSIX shell script")
+	}
+	dir := setupTestRepo(t)
+
+	logFile := filepath.Join(t.TempDir(), "opened.txt")
+	fakeEditor := filepath.Join(t.TempDir(), "fake-editor")
+	os.WriteFile(fakeEditor, []byte("#!/bin/sh\necho \"$@\" >> "+logFile+"\n"), 0o755)
+	env := []string{"EDITOR=" + fakeEditor + " --wait", "VISUAL="}
+
+	stdout, stderr, err := runWtEnv(t, dir, env, "create", "-e", "edit-me")
+	if err != nil {
+		t.Fatalf("wt create -e failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "edit-me")
+	if stdout != "__wt_cd:"+wtDir {
+		t.Errorf("stdout = %q, want only the cd sentinel", stdout)
+	}
+
+	if _, stderr, err := runWtEnv(t, dir, env, "open", "edit-me"); err != nil {
+		t.Fatalf("wt open failed: %v\nstderr: %s", err, stderr)
+	}
+
+	data, _ := os.ReadFile(logFile)
+	want := "--wait " + wtDir + "\n--wait " + wtDir + "\n"
+	if string(data) != want {
+		t.Errorf("editor invocations = %q, want %q", data, want)
+	}
+
+	// Without any editor, --edit fails before creating the worktree
+	_, stderr, err = runWtEnv(t, dir, []string{"EDITOR=", "VISUAL="}, "create", "-e", "no-editor")
+	if err == nil || !strings.Contains(stderr, "no editor configured") {
+		t.Errorf("create -e without an editor should fail, got err=%v stderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "no-editor")); !os.IsNotExist(err) {
+		t.Error("worktree should not be created when no editor is configured")
+	}
+}
+
+// An editor set by the repository's .wt.toml is only run once approved.
+func TestOpen_RepoEditorNeedsApproval(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake editor is a POSIX shell script")
+	}
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "feature")
+	logFile := filepath.Join(t.TempDir(), "opened.txt")
+	fakeEditor := filepath.Join(t.TempDir(), "fake-editor")
+	os.WriteFile(fakeEditor, []byte("#!/bin/sh\necho \"$@\" >> "+logFile+"\n"), 0o755)
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("editor = \""+fakeEditor+" --repo\"\n"), 0o644)
+	env := []string{"EDITOR=" + fakeEditor + " --user", "VISUAL="}
+
+	_, stderr, err := runWtEnv(t, dir, env, "open", "feature")
+	if err != nil {
+		t.Fatalf("wt open failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "ignoring editor") {
+		t.Errorf("an unapproved editor should be ignored with a warning, got: %s", stderr)
+	}
+	trustHooks(t, dir)
+	if _, stderr, err := runWtEnv(t, dir, env, "open", "feature"); err != nil {
+		t.Fatalf("wt open failed: %v\nstderr: %s", err, stderr)
+	}
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feature")
+	data, _ := os.ReadFile(logFile)
+	if want := "--user " + wtDir + "\n--repo " + wtDir + "\n"; string(data) != want {
+		t.Errorf("editor invocations = %q, want %q", data, want)
+	}
+}
+
+// wt workspace lists all worktrees in a .code-workspace file, which
+// [workspace] sync keeps up to date on create and remove.
+func TestWorkspace_GenerateAndSync(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "ws-one")
+
+	wsFile := filepath.Join(filepath.Dir(dir), "testrepo.code-workspace")
+	if _, stderr, err := runWt(t, dir, "workspace"); err != nil {
+		t.Fatalf("wt workspace failed: %v\nstderr: %s", err, stderr)
+	}
+	data, _ := os.ReadFile(wsFile)
+	if !strings.Contains(string(data), `"path": "testrepo"`) || !strings.Contains(string(data), `"path": "testrepo-worktrees/ws-one"`) {
+		t.Errorf("workspace should list main and ws-one, got: %s", data)
+	}
+
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[workspace]\nsync = true\n"), 0o644)
+	runWt(t, dir, "create", "ws-two")
+	data, _ = os.ReadFile(wsFile)
+	if !strings.Contains(string(data), "ws-two") {
+		t.Errorf("sync should add ws-two after create, got: %s", data)
+	}
+
+	runWt(t, dir, "remove", "ws-one")
+	data, _ = os.ReadFile(wsFile)
+	if strings.Contains(string(data), "ws-one") {
+		t.Errorf("sync should drop ws-one after remove, got: %s", data)
+	}
+}
+
+// Branch lists for the create selector are cached and only refreshed once
+// the refs change.
+func TestBranchLists_Cache(t *testing.T) {
+	dir := setupTestRepo(t)
+	t.Chdir(dir)
+	info, err := repo.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lists, refresh, err := branchLists(t.Context(), info)
+	if err != nil || refresh != nil {
+		t.Fatalf("first listing should be synchronous, got refresh=%v err=%v", refresh != nil, err)
+	}
+	if len(lists.Local) != 1 || lists.Local[0] != "main" {
+		t.Errorf("Local = %v, want [main]", lists.Local)
+	}
+
+	if _, refresh, _ = branchLists(t.Context(), info); refresh != nil {
+		t.Error("an up-to-date cache should not need a refresh")
+	}
+
+	gitRun(t, dir, "branch", "cached-new")
+	lists, refresh, _ = branchLists(t.Context(), info)
+	if refresh == nil {
+		t.Fatal("a stale cache should come with a refresh function")
+	}
+	if len(lists.Local) != 1 {
+		t.Errorf("stale cache should still return the cached lists, got %v", lists.Local)
+	}
+	fresh, err := refresh()
+	if err != nil || len(fresh.Local) != 2 {
+		t.Errorf("refresh() = %v, %v; want both branches", fresh, err)
+	}
+}
+
+// Completion candidates are reused for completionCacheTTL while refs and
+// worktrees are unchanged.
+func TestCachedCompletion(t *testing.T) {
+	dir := setupTestRepo(t)
+	t.Chdir(dir)
+	calls := 0
+	list := func(*repo.Info) []string {
+		calls++
+		return []string{fmt.Sprint(calls)}
+	}
+
+	if got := cachedCompletion("test", list); !slices.Equal(got, []string{"1"}) {
+		t.Fatalf("first completion = %v, want [1]", got)
+	}
+	if got := cachedCompletion("test", list); !slices.Equal(got, []string{"1"}) || calls != 1 {
+		t.Errorf("completion within the TTL = %v after %d call(s), want the cached [1]", got, calls)
+	}
+
+	gitRun(t, dir, "branch", "completion-new")
+	if got := cachedCompletion("test", list); !slices.Equal(got, []string{"2"}) {
+		t.Errorf("completion after a ref change = %v, want a fresh [2]", got)
+	}
+
+	time.Sleep(completionCacheTTL)
+	if got := cachedCompletion("test", list); !slices.Equal(got, []string{"3"}) {
+		t.Errorf("completion after the TTL = %v, want a fresh [3]", got)
+	}
+}
+
+// Branches of recently removed worktrees are offered first in the selector.
+func TestBranchEntries_Recent(t *testing.T) {
+	dir := setupTestRepo(t)
+	gitRun(t, dir, "branch", "alpha")
+	runWt(t, dir, "create", "zeta")
+	runWt(t, dir, "create", "busy")
+	if _, stderr, err := runWt(t, dir, "remove", "zeta"); err != nil {
+		t.Fatalf("wt remove failed: %v\nstderr: %s", err, stderr)
+	}
+	t.Chdir(dir)
+	info, err := repo.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lists, _, _ := branchLists(t.Context(), info)
+	entries := branchEntries(lists, map[string]bool{"main": true, "busy": true}, nil, recentBranches(info), branchOrder{sort: branchSortName})
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Source+":"+e.Name)
+	}
+	want := []string{"recent:zeta", "local:alpha", "local:busy", "local:main"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("branchEntries() = %v, want %v", got, want)
+	}
+}
+
+// The selector lists local and then remote branches by last commit, newest
+// first, or by name or author, without those older than --since.
+func TestBranchEntries_Order(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	lists := &cache.Branches{
+		Local:  []string{"alpha", "beta", "gamma"},
+		Remote: []string{"delta", "stale"},
+		LocalCommits: map[string]cache.Commit{
+			"alpha": {Date: now.Add(-10 * day), Author: "Zoe"},
+			"beta":  {Date: now.Add(-1 * day), Author: "Zoe"},
+			"gamma": {Date: now.Add(-5 * day), Author: "Ada"},
+		},
+		RemoteCommits: map[string]cache.Commit{
+			"delta": {Date: now.Add(-2 * day), Author: "Bob"},
+			"stale": {Date: now.Add(-400 * day), Author: "Bob"},
+		},
+	}
+	names := func(order branchOrder) string {
+		var got []string
+		for _, e := range branchEntries(lists, nil, nil, nil, order) {
+			got = append(got, e.Name)
+		}
+		return strings.Join(got, " ")
+	}
+	for _, tc := range []struct {
+		order branchOrder
+		want  string
+	}{
+		{branchOrder{sort: branchSortDate}, "beta gamma alpha delta stale"},
+		{branchOrder{sort: branchSortName}, "alpha beta gamma delta stale"},
+		{branchOrder{sort: branchSortAuthor}, "gamma beta alpha delta stale"},
+		{branchOrder{sort: branchSortDate, since: now.Add(-90 * day)}, "beta gamma alpha delta"},
+	} {
+		if got := names(tc.order); got != tc.want {
+			t.Errorf("branchEntries(%+v) = %s, want %s", tc.order, got, tc.want)
+		}
+	}
+
+	createSort = "size"
+	defer func() { createSort = "" }()
+	if _, err := resolveBranchOrder(now); err == nil {
+		t.Error("an unknown --sort should be rejected")
+	}
+}
+
+// Create copies worktree template files with placeholders expanded.
+func TestCreate_CopiesTemplate(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	tmplDir := filepath.Join(dir, ".git", "wt", "worktree-template", ".vscode")
+	os.MkdirAll(tmplDir, 0o755)
+	os.WriteFile(filepath.Join(tmplDir, "settings.json"), []byte(`{"window.title": "{{branch}}"}`), 0o644)
+
+	_, stderr, err := runWt(t, dir, "create", "fix/tmpl")
+	if err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "fix-tmpl")
+	data, err := os.ReadFile(filepath.Join(wtDir, ".vscode", "settings.json"))
+	if err != nil {
+		t.Fatalf("template file not copied: %v", err)
+	}
+	if string(data) != `{"window.title": "fix/tmpl"}` {
+		t.Errorf("template placeholders not expanded: %s", data)
+	}
+
+	_, _, err = runWt(t, dir, "create", "no-tmpl", "--no-template")
+	if err != nil {
+		t.Fatalf("wt create --no-template failed: %v", err)
+	}
+	skipped := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "no-tmpl", ".vscode")
+	if _, err := os.Stat(skipped); err == nil {
+		t.Error("--no-template should skip template files")
+	}
+}
+
+// Tab completion for --base suggests branches and tags.
+func TestCompletion_BaseFlagSuggestsRefs(t *testing.T) {
+	dir := setupTestRepo(t)
+	gitRun(t, dir, "branch", "develop")
+	gitRun(t, dir, "tag", "v1.0.0")
+
+	stdout, _, _ := runWt(t, dir, "__complete", "create", "foo", "--base", "")
+
+	for _, want := range []string{"main", "develop", "v1.0.0"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("--base completion should suggest %q, got: %s", want, stdout)
+		}
+	}
+}
+
+// Creating a branch that is already checked out names the worktree holding it.
+func TestCreate_BranchCheckedOutElsewhere(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	stdout, stderr, err := runWt(t, dir, "create", "main")
+	if err == nil {
+		t.Fatal("wt create main should fail when main is checked out")
+	}
+	if !strings.Contains(stderr, "main worktree") {
+		t.Errorf("stderr should say the branch is in the main worktree, got: %s", stderr)
+	}
+	if !strings.Contains(stderr, "--detach") || strings.Contains(stderr, "already checked out at") {
+		t.Errorf("stderr should offer --detach instead of git's error, got: %s", stderr)
+	}
+	if strings.Contains(stdout, "__wt_cd:") {
+		t.Error("stdout should not contain __wt_cd: on error")
+	}
+}
+
+// --detach checks out the commit of a branch that the main worktree holds.
+func TestCreate_Detach(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	stdout, stderr, err := runWt(t, dir, "create", "main", "--detach")
+	if err != nil {
+		t.Fatalf("wt create main --detach failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "main")
+	if !strings.Contains(stdout, "__wt_cd:"+wtDir) {
+		t.Errorf("stdout should cd to %s, got: %s", wtDir, stdout)
+	}
+	head, _ := exec.Command("git", "-C", wtDir, "rev-parse", "HEAD").Output()
+	want, _ := exec.Command("git", "-C", dir, "rev-parse", "main").Output()
+	if len(head) == 0 || string(head) != string(want) {
+		t.Errorf("detached worktree is at %s, want %s", head, want)
+	}
+	if _, err := exec.Command("git", "-C", wtDir, "symbolic-ref", "-q", "HEAD").Output(); err == nil {
+		t.Error("the new worktree's HEAD should be detached")
+	}
+
+	// A branch with a linked worktree gets a second, detached copy next to it
+	runWt(t, dir, "create", "feat")
+	for _, name := range []string{"feat-2", "feat-3"} {
+		stdout, stderr, err := runWt(t, dir, "create", "feat", "--detach")
+		if err != nil {
+			t.Fatalf("wt create feat --detach failed: %v\nstderr: %s", err, stderr)
+		}
+		copyDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", name)
+		if !strings.Contains(stdout, "__wt_cd:"+copyDir) {
+			t.Errorf("the detached copy should be at %s, got: %s", copyDir, stdout)
+		}
+	}
+
+	if _, stderr, err := runWt(t, dir, "create", "no-such-branch", "--detach"); err == nil || !strings.Contains(stderr, "not a branch, tag, or commit") {
+		t.Errorf("--detach of an unknown ref should fail, got err %v, stderr: %s", err, stderr)
+	}
+}
+
+// The [names] table changes the directory names of new worktrees, and
+// migrate-layout moves existing ones to them.
+func TestCreate_NamesConfig(t *testing.T) {
+	dir := setupTestRepo(t)
+	worktreesDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	runWt(t, dir, "create", "Fix/Old")
+
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[names]\nnested = true\nlowercase = true\n"), 0o644)
+	if _, stderr, err := runWt(t, dir, "create", "Feature/Login"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	nested := filepath.Join(worktreesDir, "feature", "login")
+	if _, err := os.Stat(nested); err != nil {
+		t.Fatalf("worktree should be nested at %s: %v", nested, err)
+	}
+	if stdout, _, err := runWt(t, dir, "switch", "feature/login"); err != nil || !strings.Contains(stdout, "__wt_cd:"+nested) {
+		t.Errorf("switch by the sanitized nested name should find the worktree, got %q (%v)", stdout, err)
+	}
+
+	if _, stderr, err := runWt(t, dir, "migrate-layout"); err != nil {
+		t.Fatalf("wt migrate-layout failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(worktreesDir, "fix", "old")); err != nil {
+		t.Errorf("migrate-layout should move Fix-Old to fix/old: %v", err)
+	}
+
+	if _, stderr, err := runWt(t, dir, "remove", "feature/login"); err != nil {
+		t.Fatalf("wt remove failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(worktreesDir, "feature")); !os.IsNotExist(err) {
+		t.Errorf("removing the nested worktree should remove its empty parent: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[names]\nreplacement = \"/\"\n"), 0o644)
+	if _, stderr, err := runWt(t, dir, "list"); err == nil || !strings.Contains(stderr, "replacement") {
+		t.Errorf("an invalid replacement should be reported, err=%v stderr=%s", err, stderr)
+	}
+}
+
+// A worktrees directory wt did not create is marked when it holds only
+// worktrees, and foreign content in it is reported.
+func TestCreate_ForeignWorktreesDir(t *testing.T) {
+	dir := setupTestRepo(t)
+	worktreesDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	marker := filepath.Join(worktreesDir, ".wt-root")
+
+	runWt(t, dir, "create", "first")
+	if data, err := os.ReadFile(marker); err != nil || strings.TrimSpace(string(data)) != dir {
+		t.Errorf("a new worktrees directory should be marked with the repository, got %q (%v)", data, err)
+	}
+
+	os.Remove(marker)
+	runWt(t, dir, "create", "second")
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("a directory of only worktrees should be marked: %v", err)
+	}
+
+	os.Remove(marker)
+	os.WriteFile(filepath.Join(worktreesDir, "notes.txt"), []byte("mine\n"), 0o644)
+	os.MkdirAll(filepath.Join(worktreesDir, "photos"), 0o755)
+	_, stderr, err := runWt(t, dir, "create", "third")
+	if err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "not worktrees of this repository: notes.txt, photos") || !strings.Contains(stderr, ".wt-root") {
+		t.Errorf("foreign content should be reported with a hint about the marker, got: %s", stderr)
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Errorf("a directory with foreign content should not be marked: %v", err)
+	}
+	if _, stderr, err := runWt(t, dir, "prune", "--broken", "--yes"); err != nil || !strings.Contains(stderr, "not marked") {
+		t.Errorf("prune should refuse to delete from an unmarked directory, err=%v stderr=%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(worktreesDir, "photos")); err != nil {
+		t.Errorf("prune must not delete from an unmarked directory: %v", err)
+	}
+
+	os.WriteFile(marker, nil, 0o644)
+	if _, stderr, _ := runWt(t, dir, "create", "fourth"); strings.Contains(stderr, "not worktrees") {
+		t.Errorf("an empty marker should claim the directory for wt, got: %s", stderr)
+	}
+	if data, _ := os.ReadFile(marker); strings.TrimSpace(string(data)) != dir {
+		t.Errorf("an empty marker should be claimed for the repository, got %q", data)
+	}
+
+	other := t.TempDir()
+	os.Mkdir(filepath.Join(other, ".git"), 0o755)
+	os.WriteFile(marker, []byte(other+"\n"), 0o644)
+	if _, stderr, _ := runWt(t, dir, "create", "fifth"); !strings.Contains(stderr, "marked as the worktrees directory of") {
+		t.Errorf("a directory marked for another repository should be reported, got: %s", stderr)
+	}
+	runWt(t, dir, "prune", "--broken", "--yes")
+	if _, err := os.Stat(filepath.Join(worktreesDir, "photos")); err != nil {
+		t.Errorf("prune must not delete from another repository's directory: %v", err)
+	}
+
+	os.WriteFile(marker, []byte(dir+"\n"), 0o644)
+	if _, stderr, err := runWt(t, dir, "prune", "--broken", "--yes"); err != nil {
+		t.Fatalf("wt prune --broken failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(worktreesDir, "photos")); !os.IsNotExist(err) {
+		t.Errorf("prune should delete orphans from this repository's directory: %v", err)
+	}
+}
+
+// wt config export writes the merged settings as a profile, and wt config
+// import checks one and merges it into a config.
+func TestConfig_ExportImport(t *testing.T) {
+	dir := setupTestRepo(t)
+	configDir := t.TempDir()
+	env := []string{"WT_CONFIG_DIR=" + configDir}
+	os.WriteFile(filepath.Join(configDir, "config.toml"), []byte("editor = \"vim\"\n[hooks]\npost-switch = [\"ls\"]\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[names]\nnested = true\n[hooks]\npost-create = [\"make\"]\n"), 0o644)
+
+	stdout, stderr, err := runWtEnv(t, dir, env, "config", "export", "--scope", "all")
+	if err != nil {
+		t.Fatalf("wt config export --scope all failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{`editor = "vim"`, "nested = true", `post-create = ["make"]`, `post-switch = ["ls"]`} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("export should contain %s, got:\n%s", want, stdout)
+		}
+	}
+
+	profile := filepath.Join(t.TempDir(), "profile.toml")
+	if _, stderr, err := runWtEnv(t, dir, env, "config", "export", profile); err != nil {
+		t.Fatalf("wt config export failed: %v\nstderr: %s", err, stderr)
+	}
+	if data, _ := os.ReadFile(profile); strings.Contains(string(data), "editor") || !strings.Contains(string(data), "nested = true") {
+		t.Errorf("export should write only the repository config by default, got:\n%s", data)
+	}
+
+	other := filepath.Join(t.TempDir(), "other")
+	gitRun(t, filepath.Dir(other), "init", "-q", "-b", "main", "other")
+	gitRun(t, other, "commit", "-q", "--allow-empty", "-m", "init")
+	_, stderr, err = runWtEnv(t, other, env, "config", "import", profile)
+	if err == nil || !strings.Contains(stderr, "--yes") || !strings.Contains(stderr, `post-create = ["make"]`) {
+		t.Errorf("a profile with hooks should be shown and confirmed, even into a new config, err=%v stderr=%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(other, ".wt.toml")); !os.IsNotExist(err) {
+		t.Error("an unconfirmed import should not write the config")
+	}
+	if _, stderr, err := runWtEnv(t, other, env, "config", "import", "--yes", profile); err != nil {
+		t.Fatalf("wt config import failed: %v\nstderr: %s", err, stderr)
+	}
+	if data, _ := os.ReadFile(filepath.Join(other, ".wt.toml")); !strings.Contains(string(data), "nested = true") {
+		t.Errorf("import should write the profile into .wt.toml, got:\n%s", data)
+	}
+
+	os.WriteFile(profile, []byte("[hooks]\npost-switch = [\"pwd\"]\n"), 0o644)
+	if _, stderr, err := runWtEnv(t, other, env, "config", "import", profile); err == nil || !strings.Contains(stderr, "--yes") {
+		t.Errorf("merging into an existing config should ask, err=%v stderr=%s", err, stderr)
+	}
+	if _, stderr, err := runWtEnv(t, other, env, "config", "import", "--yes", profile); err != nil {
+		t.Fatalf("wt config import --yes failed: %v\nstderr: %s", err, stderr)
+	}
+	data, _ := os.ReadFile(filepath.Join(other, ".wt.toml"))
+	if !strings.Contains(string(data), "nested = true") || !strings.Contains(string(data), `post-switch = ["pwd"]`) || !strings.Contains(string(data), "post-create") {
+		t.Errorf("import should merge into the existing config, got:\n%s", data)
+	}
+
+	// Settings that run commands or receive the forge token are confirmed
+	// into the user config too, even a new one
+	os.WriteFile(profile, []byte("editor = \"sh -c true\"\n[forge]\napi-url = \"https://evil.example/api\"\n"), 0o644)
+	userConfig := filepath.Join(configDir, "config.toml")
+	os.Remove(userConfig)
+	_, stderr, err = runWtEnv(t, other, env, "config", "import", "--scope", "user", profile)
+	if err == nil || !strings.Contains(stderr, "--yes") || !strings.Contains(stderr, "evil.example") || !strings.Contains(stderr, "sh -c true") {
+		t.Errorf("editor and forge settings should be shown and confirmed, err=%v stderr=%s", err, stderr)
+	}
+	if _, err := os.Stat(userConfig); !os.IsNotExist(err) {
+		t.Error("an unconfirmed import should not write the user config")
+	}
+	if _, stderr, err := runWtEnv(t, other, env, "config", "import", "--scope", "user", "--yes", profile); err != nil {
+		t.Fatalf("wt config import --scope user failed: %v\nstderr: %s", err, stderr)
+	}
+	if data, _ := os.ReadFile(userConfig); !strings.Contains(string(data), "evil.example") {
+		t.Errorf("import --scope user should write the user config, got:\n%s", data)
+	}
+
+	os.WriteFile(profile, []byte("[names]\nnested = true\nflat = true\n"), 0o644)
+	if _, stderr, err := runWtEnv(t, other, env, "config", "import", "--yes", profile); err == nil || !strings.Contains(stderr, "names.flat") {
+		t.Errorf("an unknown setting should be rejected, err=%v stderr=%s", err, stderr)
+	}
+	os.WriteFile(profile, []byte("path-style = \"sideways\"\n"), 0o644)
+	if _, stderr, err := runWtEnv(t, other, env, "config", "import", "--yes", profile); err == nil || !strings.Contains(stderr, "path style") {
+		t.Errorf("an invalid path style should be rejected, err=%v stderr=%s", err, stderr)
+	}
+}
+
+// --apply cherry-picks commits and applies patch files in the new worktree.
+func TestCreate_Apply(t *testing.T) {
+	dir := setupTestRepo(t)
+	gitRun(t, dir, "checkout", "-q", "-b", "fix")
+	os.WriteFile(filepath.Join(dir, "fix.txt"), []byte("fixed\n"), 0o644)
+	gitRun(t, dir, "add", "fix.txt")
+	gitRun(t, dir, "commit", "-q", "-m", "fix")
+	gitRun(t, dir, "checkout", "-q", "main")
+
+	patch := filepath.Join(t.TempDir(), "extra.diff")
+	os.WriteFile(patch, []byte("diff --git a/extra.txt b/extra.txt\nnew file mode 100644\n--- /dev/null\n+++ b/extra.txt\n@@ -0,0 +1 @@\n+extra\n"), 0o644)
+
+	env := []string{"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@test.com"}
+	_, stderr, err := runWtEnv(t, dir, env, "create", "release", "--base", "main", "--apply", "fix", "--apply", patch)
+	if err != nil {
+		t.Fatalf("wt create --apply failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "release")
+	for _, f := range []string{"fix.txt", "extra.txt"} {
+		if _, err := os.Stat(filepath.Join(wtDir, f)); err != nil {
+			t.Errorf("%s should exist in the new worktree: %v", f, err)
+		}
+	}
+
+	_, stderr, err = runWt(t, dir, "create", "other", "--apply", "no-such-thing")
+	if err == nil || !strings.Contains(stderr, "neither a patch file nor a commit") {
+		t.Errorf("invalid --apply should fail before creating, err=%v stderr=%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "other")); err == nil {
+		t.Error("no worktree should be created for an invalid --apply")
+	}
+}
+
+func TestCreate_SwitchIfExists(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "existing-wt")
+
+	stdout, stderr, err := runWt(t, dir, "create", "existing-wt", "--switch-if-exists")
+	if err != nil {
+		t.Fatalf("wt create --switch-if-exists failed: %v\nstderr: %s", err, stderr)
+	}
+
+	expected := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "existing-wt")
+	if stdout != "__wt_cd:"+expected {
+		t.Errorf("stdout = %q, want __wt_cd:%s", stdout, expected)
+	}
+}
+
+// --- Import tests ---
+
+// Import adopts an externally created worktree and can move it into the convention layout.
+func TestImport_MovesIntoWorktreesDir(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	external := filepath.Join(filepath.Dir(dir), "elsewhere", "ext")
+	gitRun(t, dir, "worktree", "add", "-b", "feature/ext", external)
+
+	stdout, stderr, err := runWt(t, dir, "import", external, "--move")
+	if err != nil {
+		t.Fatalf("wt import failed: %v\nstderr: %s", err, stderr)
+	}
+
+	expected := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feature-ext")
+	if stdout != "__wt_cd:"+expected {
+		t.Errorf("stdout = %q, want __wt_cd:%s", stdout, expected)
+	}
+	if _, err := os.Stat(expected); err != nil {
+		t.Errorf("worktree not moved to %s: %v", expected, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, ".git", "wt", "state.json"))
+	if err != nil || !strings.Contains(string(data), "imported") {
+		t.Errorf("import should record metadata in state, got: %s (%v)", data, err)
+	}
+}
+
+func TestImport_RejectsUnknownPath(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	_, stderr, err := runWt(t, dir, "import", t.TempDir())
+	if err == nil {
+		t.Fatal("wt import of a non-worktree should fail")
+	}
+	if !strings.Contains(stderr, "not a worktree") {
+		t.Errorf("stderr should say 'not a worktree', got: %s", stderr)
+	}
+}
+
+// --- Multi-repo tests ---
+
+// Repositories are registered by create and switch and can be targeted from
+// anywhere.
+func TestRepos_CrossRepoSwitch(t *testing.T) {
+	dir := setupTestRepo(t)
+	env := []string{"WT_CONFIG_DIR=" + t.TempDir()}
+
+	if _, stderr, err := runWtEnv(t, dir, env, "create", "fix/remote-target"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	// Other commands leave the registry alone
+	quiet := filepath.Join(filepath.Dir(dir), "quietrepo")
+	gitRun(t, filepath.Dir(dir), "init", "-q", quiet)
+	runWtEnv(t, quiet, env, "list")
+
+	_, stderr, err := runWtEnv(t, t.TempDir(), env, "repos")
+	if err != nil {
+		t.Fatalf("wt repos failed: %v", err)
+	}
+	if !strings.Contains(stderr, "testrepo") {
+		t.Errorf("wt repos should list auto-registered testrepo, got: %s", stderr)
+	}
+	if strings.Contains(stderr, "quietrepo") {
+		t.Errorf("wt list should not register its repository, got: %s", stderr)
+	}
+
+	stdout, stderr, err := runWtEnv(t, t.TempDir(), env, "switch", "testrepo/fix/remote-target")
+	if err != nil {
+		t.Fatalf("cross-repo switch failed: %v\nstderr: %s", err, stderr)
+	}
+	expected := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "fix-remote-target")
+	if stdout != "__wt_cd:"+expected {
+		t.Errorf("stdout = %q, want __wt_cd:%s", stdout, expected)
+	}
+
+	_, stderr, err = runWtEnv(t, t.TempDir(), env, "--repo", "testrepo", "list")
+	if err != nil {
+		t.Fatalf("wt --repo list failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "fix/remote-target") {
+		t.Errorf("--repo should list worktrees of testrepo, got: %s", stderr)
+	}
+}
+
+// -C runs wt as if started in another directory, including for wt git.
+func TestGlobalDirectory(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "elsewhere")
+	outside := t.TempDir()
+
+	_, stderr, err := runWt(t, outside, "-C", dir, "list")
+	if err != nil {
+		t.Fatalf("wt -C list failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "elsewhere") {
+		t.Errorf("-C should list the worktrees of the repository there, got: %s", stderr)
+	}
+
+	rel, _ := filepath.Rel(outside, dir)
+	_, stderr, err = runWt(t, outside, "-C", rel, "git", "rev-parse", "--abbrev-ref", "HEAD", "--branch", "elsewhere")
+	if err != nil {
+		t.Fatalf("wt -C git failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.TrimSpace(stderr) != "elsewhere" {
+		t.Errorf("a relative -C should apply to wt git, got: %s", stderr)
+	}
+
+	if _, _, err := runWt(t, outside, "-C", filepath.Join(outside, "missing"), "list"); err == nil {
+		t.Error("-C with a missing directory should fail")
+	}
+}
+
+// --delete-branch and --delete-remote finish off a branch along with its
+// worktree; deleting on the remote needs confirmation before anything is
+// removed, and an unmerged branch is kept without it.
+func TestRemove_DeleteBranchAndRemote(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "done")
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	runWt(t, clone, "create", "done")
+	runWt(t, clone, "create", "wip")
+	wtsDir := filepath.Join(filepath.Dir(upstream), "clonerepo-worktrees")
+	gitRun(t, filepath.Join(wtsDir, "wip"), "commit", "--allow-empty", "-m", "unmerged")
+
+	_, stderr, err := runWt(t, clone, "remove", "done", "--delete-branch", "--delete-remote")
+	if err == nil || !strings.Contains(stderr, "--yes") {
+		t.Fatalf("deleting on the remote should need --yes without a terminal, err=%v stderr=%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "done")); err != nil {
+		t.Fatal("the worktree should be kept when deleting on the remote is not confirmed")
+	}
+
+	_, stderr, err = runWt(t, clone, "remove", "done", "--delete-branch", "--delete-remote", "--yes")
+	if err != nil {
+		t.Fatalf("wt remove --delete-remote failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, `Deleted branch "done" on origin`) {
+		t.Errorf("stderr should report the remote deletion, got: %s", stderr)
+	}
+	if exec.Command("git", "-C", clone, "rev-parse", "--verify", "--quiet", "refs/heads/done").Run() == nil {
+		t.Error("the local branch should be deleted")
+	}
+	if exec.Command("git", "-C", upstream, "rev-parse", "--verify", "--quiet", "refs/heads/done").Run() == nil {
+		t.Error("the branch should be deleted on the remote")
+	}
+
+	_, stderr, err = runWt(t, clone, "remove", "wip", "--delete-branch")
+	if err != nil {
+		t.Fatalf("wt remove --delete-branch failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, `Kept branch "wip"`) {
+		t.Errorf("an unmerged branch should be kept without --yes, got: %s", stderr)
+	}
+	if exec.Command("git", "-C", clone, "rev-parse", "--verify", "--quiet", "refs/heads/wip").Run() != nil {
+		t.Error("the unmerged branch should still exist")
+	}
+}
+
+// --delete-remote finds the branch on a remote whose name has a slash.
+func TestRemove_DeleteRemoteWithSlash(t *testing.T) {
+	upstream := setupTestRepo(t)
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	gitRun(t, upstream, "branch", "done")
+	gitRun(t, clone, "remote", "add", "team/fork", upstream)
+	gitRun(t, clone, "fetch", "-q", "team/fork")
+	gitRun(t, clone, "branch", "--no-track", "done", "team/fork/done")
+	if _, stderr, err := runWt(t, clone, "create", "done"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+
+	_, stderr, err := runWt(t, clone, "remove", "done", "--delete-branch", "--delete-remote", "--yes")
+	if err != nil {
+		t.Fatalf("wt remove --delete-remote failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, `Deleted branch "done" on team/fork`) {
+		t.Errorf("the branch should be deleted on team/fork, got: %s", stderr)
+	}
+	if exec.Command("git", "-C", upstream, "rev-parse", "--verify", "--quiet", "refs/heads/done").Run() == nil {
+		t.Error("the branch should be deleted on the remote")
+	}
+}
+
+// --delete-remote deletes only a remote branch of the same name, never the
+// upstream a branch was started from, and never the remote's default branch.
+func TestRemove_DeleteRemoteKeepsOtherUpstream(t *testing.T) {
+	upstream := setupTestRepo(t)
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	gitRun(t, clone, "branch", "--track", "feat", "origin/main")
+	if _, stderr, err := runWt(t, clone, "create", "feat"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+
+	_, stderr, err := runWt(t, clone, "remove", "feat", "--delete-remote", "--yes")
+	if err != nil {
+		t.Fatalf("wt remove --delete-remote failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "not on any remote") {
+		t.Errorf("a branch tracking origin/main has no remote branch of its own, got: %s", stderr)
+	}
+	if exec.Command("git", "-C", upstream, "rev-parse", "--verify", "--quiet", "refs/heads/main").Run() != nil {
+		t.Fatal("main should not be deleted on the remote")
+	}
+
+	gitRun(t, clone, "checkout", "-q", "-b", "other")
+	if _, stderr, err := runWt(t, clone, "create", "main"); err != nil {
+		t.Fatalf("wt create main failed: %v\nstderr: %s", err, stderr)
+	}
+	_, stderr, err = runWt(t, clone, "remove", "main", "--delete-remote", "--yes")
+	if err == nil || !strings.Contains(stderr, "default branch") {
+		t.Errorf("deleting the remote's default branch should be refused, err=%v stderr=%s", err, stderr)
+	}
+	if exec.Command("git", "-C", upstream, "rev-parse", "--verify", "--quiet", "refs/heads/main").Run() != nil {
+		t.Error("main should not be deleted on the remote")
+	}
+}
+
+// Refusing to remove a dirty worktree lists the changed files.
+func TestRemove_DirtyPreviewListsFiles(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "preview-wt")
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "preview-wt")
+	os.WriteFile(filepath.Join(wtDir, "pending.txt"), []byte("wip"), 0o644)
+
+	_, stderr, err := runWt(t, dir, "remove", "preview-wt")
+	if err == nil {
+		t.Fatal("wt remove of dirty worktree should fail when not interactive")
+	}
+	if !strings.Contains(stderr, "?? pending.txt") {
+		t.Errorf("stderr should preview changed files, got: %s", stderr)
+	}
+}
+
+// Remove --stash saves uncommitted changes before removing the worktree.
+func TestRemove_StashSavesChanges(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "stash-wt")
+	gitRun(t, dir, "config", "user.name", "test")
+	gitRun(t, dir, "config", "user.email", "test@test.com")
+
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "stash-wt")
+	os.WriteFile(filepath.Join(wtDir, "wip.txt"), []byte("wip"), 0o644)
+
+	_, stderr, err := runWt(t, dir, "remove", "--stash", "stash-wt")
+	if err != nil {
+		t.Fatalf("wt remove --stash failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(wtDir); err == nil {
+		t.Error("worktree directory should be removed")
+	}
+
+	cmd := exec.Command("git", "stash", "list")
+	cmd.Dir = dir
+	out, _ := cmd.Output()
+	if !strings.Contains(string(out), "stash-wt") {
+		t.Errorf("stash list should contain an entry for stash-wt, got: %s", out)
+	}
+}
+
+// Completion install writes shell files and is idempotent.
+func TestCompletion_Install(t *testing.T) {
+	dir := setupTestRepo(t)
+	home := t.TempDir()
+	env := []string{"HOME=" + home, "SHELL=/bin/bash", "XDG_DATA_HOME=", "XDG_CONFIG_HOME="}
+
+	_, stderr, err := runWtEnv(t, dir, env, "completion", "install")
+	if err != nil {
+		t.Fatalf("wt completion install failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".local", "share", "bash-completion", "completions", "wt")); err != nil {
+		t.Errorf("bash completion script not installed: %v", err)
+	}
+
+	_, stderr, err = runWtEnv(t, dir, env, "completion", "install")
+	if err != nil {
+		t.Fatalf("second install failed: %v", err)
+	}
+	if !strings.Contains(stderr, "already up to date") {
+		t.Errorf("second install should report up to date, got: %s", stderr)
+	}
+}
+
+// --- Config tests ---
+
+// An unknown theme in .wt.toml is reported instead of silently ignored.
+func TestConfig_UnknownThemeErrors(t *testing.T) {
+	dir := setupTestRepo(t)
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("theme = \"neon\"\n"), 0o644)
+
+	_, stderr, err := runWt(t, dir, "list")
+	if err == nil {
+		t.Fatal("wt list with unknown theme should fail")
+	}
+	if !strings.Contains(stderr, "unknown theme") {
+		t.Errorf("stderr should mention 'unknown theme', got: %s", stderr)
+	}
+
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("theme = \"solarized\"\n"), 0o644)
+	if _, stderr, err := runWt(t, dir, "--no-color", "list"); err != nil {
+		t.Fatalf("wt --no-color list failed: %v\nstderr: %s", err, stderr)
+	}
+}
+
+func TestNonInteractive_SelectorsSuggestDirectForm(t *testing.T) {
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "feat"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--no-interactive"}, "wt switch <name>"},
+		{[]string{"create"}, "wt create <branch>"},
+		{[]string{"remove"}, "wt remove <name>"},
+		{[]string{"open"}, "wt open <name>"},
+		{[]string{"status", "--watch"}, "without --watch"},
+	}
+	for _, tt := range tests {
+		_, stderr, err := runWtEnv(t, dir, []string{"EDITOR=true"}, tt.args...)
+		if err == nil {
+			t.Errorf("wt %v without a terminal should fail", tt.args)
+			continue
+		}
+		if !strings.Contains(stderr, tt.want) {
+			t.Errorf("wt %v stderr should suggest %q, got: %s", tt.args, tt.want, stderr)
+		}
+	}
+
+	_, stderr, err := runWt(t, dir, "list")
+	if err != nil {
+		t.Fatalf("wt list failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Contains(stderr, "\x1b[") {
+		t.Errorf("wt list without a terminal should not emit ANSI escapes, got: %q", stderr)
+	}
+}
+
+// Branch descriptions are set with wt describe and shown by wt list --long.
+func TestDescribe_ShownInLongList(t *testing.T) {
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "feat"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+
+	if _, stderr, err := runWt(t, dir, "describe", "feat", "Rework the parser"); err != nil {
+		t.Fatalf("wt describe failed: %v\nstderr: %s", err, stderr)
+	}
+	stdout, _, err := runWt(t, dir, "describe", "feat")
+	if err != nil || stdout != "Rework the parser\n" {
+		t.Errorf("wt describe feat = %q, %v; want the description", stdout, err)
+	}
+
+	_, stderr, _ := runWt(t, dir, "list", "--long")
+	if !strings.Contains(stderr, "DESCRIPTION") || !strings.Contains(stderr, "Rework the parser") {
+		t.Errorf("wt list --long should show the description, got: %s", stderr)
+	}
+	_, stderr, _ = runWt(t, dir, "list")
+	if strings.Contains(stderr, "Rework the parser") {
+		t.Errorf("wt list without --long should not show descriptions, got: %s", stderr)
+	}
+
+	if _, _, err := runWt(t, dir, "describe", "nope", "x"); err == nil {
+		t.Error("describing a missing branch should fail")
+	}
+}
+
+// Create, switch, and remove are recorded and shown by wt history.
+func TestHistory_RecordsOperations(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "feat")
+	runWt(t, dir, "switch", "feat")
+	runWt(t, dir, "--yes", "remove", "feat")
+	runWt(t, dir, "remove", "missing")
+
+	_, stderr, err := runWt(t, dir, "history")
+	if err != nil {
+		t.Fatalf("wt history failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, op := range []string{"create", "switch", "remove"} {
+		if !strings.Contains(stderr, op) {
+			t.Errorf("history should list the %s, got:\n%s", op, stderr)
+		}
+	}
+	if !strings.Contains(stderr, "testrepo-worktrees/feat") {
+		t.Errorf("history should show the worktree path, got:\n%s", stderr)
+	}
+
+	_, stderr, _ = runWt(t, dir, "history", "--op", "remove", "--branch", "f*")
+	if strings.Contains(stderr, "create") || !strings.Contains(stderr, "remove") {
+		t.Errorf("history --op remove should show only removals, got:\n%s", stderr)
+	}
+	_, stderr, _ = runWt(t, dir, "history", "--failed")
+	if !strings.Contains(stderr, "No matching history") {
+		t.Errorf("history --failed should find nothing, got:\n%s", stderr)
+	}
+	if _, _, err := runWt(t, dir, "history", "--since", "yesterday"); err == nil {
+		t.Error("an invalid --since should fail")
+	}
+}
+
+// migrate-layout moves legacy nested worktree directories to sanitized names.
+func TestMigrateLayout_MovesLegacyDirectories(t *testing.T) {
+	dir := setupTestRepo(t)
+	wtsDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	legacy := filepath.Join(wtsDir, "fix", "bug-123")
+	gitRun(t, dir, "worktree", "add", "-q", "-b", "fix/bug-123", legacy)
+	runWt(t, dir, "create", "feature")
+
+	_, stderr, err := runWt(t, dir, "migrate-layout", "--dry-run")
+	if err != nil {
+		t.Fatalf("wt migrate-layout --dry-run failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "Would move testrepo-worktrees/fix/bug-123 to testrepo-worktrees/fix-bug-123") {
+		t.Errorf("dry run should describe the move, got: %s", stderr)
+	}
+	if strings.Contains(stderr, "feature") {
+		t.Errorf("conventional worktrees should not be moved, got: %s", stderr)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Fatal("dry run should not move anything")
+	}
+
+	stdout, stderr, err := runWt(t, legacy, "migrate-layout")
+	if err != nil {
+		t.Fatalf("wt migrate-layout failed: %v\nstderr: %s", err, stderr)
+	}
+	moved := filepath.Join(wtsDir, "fix-bug-123")
+	if _, err := os.Stat(filepath.Join(moved, ".git")); err != nil {
+		t.Errorf("worktree should now be at %s: %v", moved, err)
+	}
+	if _, err := os.Stat(filepath.Join(wtsDir, "fix")); err == nil {
+		t.Error("the emptied legacy parent directory should be removed")
+	}
+	if stdout != "__wt_cd:"+moved {
+		t.Errorf("running from the moved worktree should cd to its new path, got stdout %q", stdout)
+	}
+
+	_, stderr, _ = runWt(t, dir, "migrate-layout")
+	if !strings.Contains(stderr, "already follow the layout") {
+		t.Errorf("a second run should find nothing to move, got: %s", stderr)
+	}
+}
+
+func TestErrorHint_OutsideRepository(t *testing.T) {
+	_, stderr, err := runWt(t, t.TempDir(), "list")
+	if err == nil {
+		t.Fatal("expected wt list to fail outside a repository")
+	}
+	if !strings.Contains(stderr, "Error: not a git repository") || !strings.Contains(stderr, "Hint: run wt inside a git repository") {
+		t.Errorf("stderr = %q, want the error and a hint", stderr)
+	}
+}
+
+// Ctrl-C during a slow worktree add interrupts git and leaves neither the
+// worktree directory nor the new branch behind.
+func TestCreate_InterruptDiscardsPartialWorktree(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs POSIX signals")
+	}
+	dir := setupTestRepo(t)
+	started := filepath.Join(t.TempDir(), "started")
+	hook := filepath.Join(dir, ".git", "hooks", "post-checkout")
+	os.WriteFile(hook, []byte("#!/bin/sh\ntouch '"+started+"'\nsleep 2\n"), 0o755)
+
+	cmd := exec.Command(wtBinary(t), "create", "slow")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "WT_CONFIG_DIR="+testConfigDir(t))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		if i == 500 {
+			cmd.Process.Kill()
+			t.Fatal("post-checkout hook never ran")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cmd.Process.Signal(os.Interrupt)
+	if err := cmd.Wait(); err == nil {
+		t.Fatal("expected an interrupted wt create to fail")
+	}
+	if !strings.Contains(stderr.String(), "interrupted") {
+		t.Errorf("stderr = %q, want it to report the interrupt", stderr.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "slow")); !os.IsNotExist(err) {
+		t.Error("expected the partial worktree directory to be removed")
+	}
+	out, _ := exec.Command("git", "-C", dir, "branch", "--list", "slow").Output()
+	if len(out) != 0 {
+		t.Errorf("expected the new branch to be deleted, got %q", out)
+	}
+	out, _ = exec.Command("git", "-C", dir, "worktree", "list").Output()
+	if strings.Contains(string(out), "slow") {
+		t.Errorf("expected no worktree entry for slow, got:\n%s", out)
+	}
+}
+
+// wt status shows each worktree's upstream, and wt set-upstream points a
+// branch at another remote.
+func TestSetUpstream_AndStatusColumn(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "feat")
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+	gitRun(t, clone, "remote", "add", "fork", upstream)
+
+	if _, stderr, err := runWt(t, clone, "create", "feat"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, stderr, err := runWt(t, clone, "create", "local-only"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	_, stderr, _ := runWt(t, clone, "status")
+	if !strings.Contains(stderr, "UPSTREAM") || !strings.Contains(stderr, "origin/feat") {
+		t.Errorf("status should show origin/feat as upstream:\n%s", stderr)
+	}
+
+	if _, stderr, err := runWt(t, clone, "set-upstream", "feat", "fork"); err != nil {
+		t.Fatalf("wt set-upstream failed: %v\nstderr: %s", err, stderr)
+	}
+	out, _ := exec.Command("git", "-C", clone, "rev-parse", "--abbrev-ref", "feat@{upstream}").Output()
+	if got := strings.TrimSpace(string(out)); got != "fork/feat" {
+		t.Errorf("upstream = %q, want fork/feat", got)
+	}
+	_, stderr, _ = runWt(t, clone, "status")
+	if !strings.Contains(stderr, "fork/feat") {
+		t.Errorf("status should show fork/feat as upstream:\n%s", stderr)
+	}
+
+	// A branch the remote does not have cannot track it
+	if _, stderr, err := runWt(t, clone, "set-upstream", "local-only", "fork"); err == nil || !strings.Contains(stderr, "git push -u fork local-only") {
+		t.Errorf("expected a push suggestion, err=%v stderr=%s", err, stderr)
+	}
+	if _, stderr, err := runWt(t, clone, "set-upstream", "feat", "nope"); err == nil || !strings.Contains(stderr, `no remote "nope"`) {
+		t.Errorf("expected an unknown remote error, err=%v stderr=%s", err, stderr)
+	}
+}
+
+func TestPRSync_Mine(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "feat")
+	gitRun(t, upstream, "checkout", "-q", "-b", "fix")
+	gitRun(t, upstream, "commit", "-q", "--allow-empty", "-m", "fork fix")
+	out, _ := exec.Command("git", "-C", upstream, "rev-parse", "HEAD").Output()
+	gitRun(t, upstream, "update-ref", "refs/pull/2/head", strings.TrimSpace(string(out)))
+	gitRun(t, upstream, "checkout", "-q", "main")
+	gitRun(t, upstream, "branch", "-D", "fix")
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", upstream, clone)
+
+	var mu sync.Mutex
+	featOpen := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"me"}`)
+	})
+	mux.HandleFunc("/repos/acme/widget/pulls", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		pulls := []string{`{"number":2,"title":"Fix","state":"open","user":{"login":"ext"},"assignees":[{"login":"me"}],
+			"head":{"ref":"fix","user":{"login":"ext"},"repo":{"full_name":"ext/widget"}}}`}
+		if featOpen {
+			pulls = append(pulls, `{"number":1,"title":"Feat","state":"open","user":{"login":"me"},
+			"head":{"ref":"feat","user":{"login":"me"},"repo":{"full_name":"acme/widget"}}}`)
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(pulls, ","))
+	})
+	mux.HandleFunc("/repos/acme/widget/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":1,"title":"Feat","state":"closed","merged_at":"2026-01-02T03:04:05Z",
+			"head":{"ref":"feat","user":{"login":"me"},"repo":{"full_name":"acme/widget"}}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// An api-url off the remote's host is only taken from the user config
+	configDir := t.TempDir()
+	os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(fmt.Sprintf("[forge]\napi-url = %q\n", srv.URL)), 0o644)
+	os.WriteFile(filepath.Join(clone, ".wt.toml"), []byte("[forge]\nprovider = \"github\"\nrepo = \"acme/widget\"\n"), 0o644)
+	env := []string{"GITHUB_TOKEN=test", "WT_CONFIG_DIR=" + configDir}
+
+	if _, stderr, err := runWtEnv(t, clone, env, "pr", "sync", "--mine"); err != nil {
+		t.Fatalf("wt pr sync failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(clone), "clonerepo-worktrees")
+	featDir := filepath.Join(wtDir, "feat")
+	out, _ = exec.Command("git", "-C", featDir, "rev-parse", "--abbrev-ref", "HEAD@{upstream}").Output()
+	if got := strings.TrimSpace(string(out)); got != "origin/feat" {
+		t.Errorf("feat worktree upstream = %q, want origin/feat", got)
+	}
+	out, _ = exec.Command("git", "-C", filepath.Join(wtDir, "ext-fix"), "log", "-1", "--format=%s", "ext/fix").Output()
+	if got := strings.TrimSpace(string(out)); got != "fork fix" {
+		t.Errorf("ext/fix worktree head = %q, want the fork's commit", got)
+	}
+
+	// Running again updates in place; a merged pull request's worktree goes
+	mu.Lock()
+	featOpen = false
+	mu.Unlock()
+	if _, stderr, err := runWtEnv(t, clone, env, "pr", "sync", "--mine"); err == nil || !strings.Contains(stderr, "--yes") {
+		t.Errorf("expected removal to require confirmation, err=%v stderr=%s", err, stderr)
+	}
+	_, stderr, err := runWtEnv(t, clone, env, "--yes", "pr", "sync", "--mine")
+	if err != nil {
+		t.Fatalf("wt pr sync --yes failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "#1, merged") {
+		t.Errorf("expected the merged pull request to be listed:\n%s", stderr)
+	}
+	if _, err := os.Stat(featDir); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed", featDir)
+	}
+	if _, err := os.Stat(filepath.Join(wtDir, "ext-fix")); err != nil {
+		t.Errorf("expected the open pull request's worktree to stay: %v", err)
+	}
+}
+
+// A pull request's branch that already has a worktree, here the main one, is
+// updated by sync but not removed when the pull request is merged.
+func TestPRSync_KeepsExistingWorktrees(t *testing.T) {
+	upstream := setupTestRepo(t)
+	gitRun(t, upstream, "branch", "feat")
+	clone := filepath.Join(filepath.Dir(upstream), "clonerepo")
+	gitRun(t, filepath.Dir(upstream), "clone", "-q", "-b", "feat", upstream, clone)
+
+	var mu sync.Mutex
+	prState := "open"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login":"me"}`)
+	})
+	mux.HandleFunc("/repos/acme/widget/pulls", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if prState != "open" {
+			fmt.Fprint(w, "[]")
+			return
+		}
+		fmt.Fprint(w, `[{"number":1,"title":"Feat","state":"open","user":{"login":"me"},
+			"head":{"ref":"feat","user":{"login":"me"},"repo":{"full_name":"acme/widget"}}}]`)
+	})
+	mux.HandleFunc("/repos/acme/widget/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":1,"title":"Feat","state":"closed","merged_at":"2026-01-02T03:04:05Z",
+			"head":{"ref":"feat","user":{"login":"me"},"repo":{"full_name":"acme/widget"}}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	configDir := t.TempDir()
+	os.WriteFile(filepath.Join(configDir, "config.toml"), []byte(fmt.Sprintf("[forge]\napi-url = %q\n", srv.URL)), 0o644)
+	os.WriteFile(filepath.Join(clone, ".wt.toml"), []byte("[forge]\nprovider = \"github\"\nrepo = \"acme/widget\"\n"), 0o644)
+	env := []string{"GITHUB_TOKEN=test", "WT_CONFIG_DIR=" + configDir}
+
+	if _, stderr, err := runWtEnv(t, clone, env, "pr", "sync", "--mine"); err != nil {
+		t.Fatalf("wt pr sync failed: %v\nstderr: %s", err, stderr)
+	}
+	mu.Lock()
+	prState = "merged"
+	mu.Unlock()
+	_, stderr, err := runWtEnv(t, clone, env, "--yes", "pr", "sync", "--mine")
+	if err != nil {
+		t.Fatalf("wt pr sync --yes failed: %v\nstderr: %s", err, stderr)
+	}
+	if strings.Contains(stderr, "no longer open") {
+		t.Errorf("a worktree sync did not create should not be offered for removal:\n%s", stderr)
+	}
+	if _, err := os.Stat(clone); err != nil {
+		t.Errorf("the main worktree should stay: %v", err)
+	}
+}
+
+func TestCreate_Project(t *testing.T) {
+	dir := setupTestRepo(t)
+	for _, f := range []string{"Makefile", "services/api/main.go", "apps/web/index.html", "tools/lint.sh"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, f)), 0o755)
+		os.WriteFile(filepath.Join(dir, f), []byte(f), 0o644)
+	}
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`[monorepo]
+shared = ["tools"]
+
+[monorepo.projects]
+api = ["services/api"]
+web = ["apps/web"]
+`), 0o644)
+	gitRun(t, dir, "add", "-A")
+	gitRun(t, dir, "commit", "-m", "projects")
+
+	if _, stderr, err := runWt(t, dir, "create", "api-fix", "--project", "docs"); err == nil || !strings.Contains(stderr, "api, web") {
+		t.Errorf("expected an unknown project error, err=%v stderr=%s", err, stderr)
+	}
+	if _, stderr, err := runWt(t, dir, "create", "api-fix", "--project", "api"); err != nil {
+		t.Fatalf("wt create --project failed: %v\nstderr: %s", err, stderr)
+	}
+	wtPath := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "api-fix")
+	for _, f := range []string{"Makefile", "services/api/main.go", "tools/lint.sh"} {
+		if _, err := os.Stat(filepath.Join(wtPath, f)); err != nil {
+			t.Errorf("%s should be checked out: %v", f, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "apps")); !os.IsNotExist(err) {
+		t.Error("apps/ should not be checked out")
+	}
+
+	_, stderr, _ := runWt(t, dir, "list")
+	if !strings.Contains(stderr, "PROJECT") || !strings.Contains(stderr, "api") {
+		t.Errorf("list should show the project column:\n%s", stderr)
+	}
+}
+
+func TestCreate_BaseShorthands(t *testing.T) {
+	dir := setupTestRepo(t)
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+
+	if _, stderr, err := runWt(t, dir, "create", "nothing", "--base", "-"); err == nil || !strings.Contains(stderr, "no previous base") {
+		t.Errorf("expected --base - to fail without a previous base, err=%v stderr=%s", err, stderr)
+	}
+	if _, stderr, err := runWt(t, dir, "create", "feature", "--base", "main"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	gitRun(t, filepath.Join(wtDir, "feature"), "commit", "--allow-empty", "-m", "feature work")
+
+	// @ is the branch of the worktree wt runs in
+	if _, stderr, err := runWt(t, filepath.Join(wtDir, "feature"), "create", "feature-part", "--base", "@"); err != nil {
+		t.Fatalf("wt create --base @ failed: %v\nstderr: %s", err, stderr)
+	}
+	out, _ := exec.Command("git", "-C", dir, "log", "-1", "--format=%s", "feature-part").Output()
+	if got := strings.TrimSpace(string(out)); got != "feature work" {
+		t.Errorf("feature-part starts at %q, want the feature branch's commit", got)
+	}
+
+	// - repeats the previous base, now "feature"
+	if _, stderr, err := runWt(t, dir, "create", "feature-other", "--base", "-"); err != nil {
+		t.Fatalf("wt create --base - failed: %v\nstderr: %s", err, stderr)
+	}
+	out, _ = exec.Command("git", "-C", dir, "log", "-1", "--format=%s", "feature-other").Output()
+	if got := strings.TrimSpace(string(out)); got != "feature work" {
+		t.Errorf("feature-other starts at %q, want the feature branch's commit", got)
+	}
+
+	gitRun(t, filepath.Join(wtDir, "feature"), "commit", "--allow-empty", "-m", "more feature work")
+	_, stderr, err := runWt(t, dir, "status", "--against", "base")
+	if err != nil {
+		t.Fatalf("wt status --against base failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "VS base") {
+		t.Errorf("status should compare against each worktree's base:\n%s", stderr)
+	}
+	for _, line := range strings.Split(stderr, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == "feature-part" && !strings.Contains(line, "↑0 ↓1") {
+			t.Errorf("feature-part should be one commit behind its base feature: %q", line)
+		}
+		if len(fields) > 0 && fields[0] == "feature" && !strings.Contains(line, "↑2 ↓0") {
+			t.Errorf("feature should be two commits ahead of its base main: %q", line)
+		}
+	}
+}
+
+func TestCreate_WorktreeConfig(t *testing.T) {
+	dir := setupTestRepo(t)
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`[worktree-config]
+user.email = "{{branch}}@example.com"
+`), 0o644)
+
+	_, stderr, err := runWt(t, dir, "create", "feat")
+	if err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "Enabled extensions.worktreeConfig") {
+		t.Errorf("expected a note about enabling per-worktree config:\n%s", stderr)
+	}
+	wtPath := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feat")
+	out, _ := exec.Command("git", "-C", wtPath, "config", "--worktree", "user.email").Output()
+	if got := strings.TrimSpace(string(out)); got != "feat@example.com" {
+		t.Errorf("worktree user.email = %q, want feat@example.com", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`[worktree-config]
+core.hooksPath = ["a", "b"]
+`), 0o644)
+	if _, stderr, err := runWt(t, dir, "create", "other"); err == nil || !strings.Contains(stderr, "core.hooksPath") {
+		t.Errorf("expected an invalid worktree-config error, err=%v stderr=%s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "other")); err == nil {
+		t.Error("no worktree should be created with an invalid worktree-config")
+	}
+
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte(`[worktree-config]
+core.fsmonitor = "touch pwned"
+`), 0o644)
+	if _, stderr, err := runWt(t, dir, "create", "other"); err == nil || !strings.Contains(stderr, "user config") {
+		t.Errorf("a repository should not set core.fsmonitor, err=%v stderr=%s", err, stderr)
+	}
+}
+
+func TestSetup_AcceptDefaults(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil || runtime.GOOS == "windows" {
+		t.Skip("needs bash")
+	}
+	dir := setupTestRepo(t)
+	home := t.TempDir()
+	configDir := t.TempDir()
+	// The test shell runs 'wt init bash', so wt must be on $PATH
+	bin := t.TempDir()
+	if err := os.Symlink(wtBinary(t), filepath.Join(bin, "wt")); err != nil {
+		t.Fatal(err)
+	}
+	env := []string{
+		"HOME=" + home, "SHELL=/bin/bash", "WT_CONFIG_DIR=" + configDir,
+		"XDG_DATA_HOME=", "XDG_CONFIG_HOME=", "ZDOTDIR=",
+		"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"),
+	}
+
+	if _, stderr, err := runWtEnv(t, dir, env, "setup"); err == nil || !strings.Contains(stderr, "--yes") {
+		t.Errorf("without a terminal, setup should ask for --yes, err=%v stderr=%s", err, stderr)
+	}
+
+	_, stderr, err := runWtEnv(t, dir, env, "setup", "--yes")
+	if err != nil {
+		t.Fatalf("wt setup failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "wt is set up") {
+		t.Errorf("setup should report the verified installation, stderr=%s", stderr)
+	}
+	if rc, _ := os.ReadFile(filepath.Join(home, ".bashrc")); !strings.Contains(string(rc), `eval "$(wt init bash)"`) {
+		t.Errorf(".bashrc should gain the init line, got:\n%s", rc)
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "config.toml"))
+	if err != nil || !strings.Contains(string(data), `theme = "default"`) || !strings.Contains(string(data), "nested = false") {
+		t.Errorf("starter config not written: %q (%v)", data, err)
+	}
+
+	// The starter config must load
+	if _, stderr, err := runWtEnv(t, dir, env, "list"); err != nil {
+		t.Errorf("wt list with the starter config failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, stderr, _ := runWtEnv(t, dir, env, "setup", "--yes"); !strings.Contains(stderr, "Keeping your existing config") {
+		t.Errorf("a second run should keep the config, stderr=%s", stderr)
+	}
+}
+
+func TestStatus_CurrentPorcelain(t *testing.T) {
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "feat"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feat")
+	os.WriteFile(filepath.Join(wtDir, "new.txt"), []byte("x"), 0o644)
+
+	stdout, stderr, err := runWt(t, wtDir, "status", "--current", "--porcelain")
+	if err != nil {
+		t.Fatalf("wt status failed: %v\nstderr: %s", err, stderr)
+	}
+	if stdout != "feat 1 0 0 1\n" {
+		t.Errorf("stdout = %q, want %q", stdout, "feat 1 0 0 1\n")
+	}
+	if stdout, _, _ := runWt(t, dir, "status", "--current", "--porcelain"); stdout != "main 0 0 0 0\n" {
+		t.Errorf("stdout in the main worktree = %q", stdout)
+	}
+
+	_, stderr, err = runWt(t, wtDir, "status", "--current")
+	if err != nil || !strings.Contains(stderr, "feat") || strings.Contains(stderr, "\nmain ") {
+		t.Errorf("--current should show only feat, err=%v stderr=%s", err, stderr)
+	}
+	if _, stderr, err := runWt(t, wtDir, "status", "--porcelain"); err == nil || !strings.Contains(stderr, "--current") {
+		t.Errorf("--porcelain alone should fail, err=%v stderr=%s", err, stderr)
+	}
+}
+
+// Branches whose names sanitize to the same directory each get a worktree,
+// and each can be switched to by its branch name.
+func TestCreate_SanitizedNameCollision(t *testing.T) {
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "fix-bug"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	_, stderr, err := runWt(t, dir, "create", "fix/bug")
+	if err != nil {
+		t.Fatalf("wt create of a colliding branch failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "already used by the worktree of fix-bug") {
+		t.Errorf("expected a note about the collision, stderr=%s", stderr)
+	}
+	worktreesDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+	unique := filepath.Join(worktreesDir, names.Disambiguate("fix/bug", names.Options{}))
+	if _, err := os.Stat(unique); err != nil {
+		t.Fatalf("fix/bug should be at %s: %v", unique, err)
+	}
+
+	for branch, want := range map[string]string{"fix/bug": unique, "fix-bug": filepath.Join(worktreesDir, "fix-bug")} {
+		stdout, stderr, err := runWt(t, dir, "switch", branch)
+		if err != nil || stdout != "__wt_cd:"+want {
+			t.Errorf("switch %s: stdout=%q, want %s, err=%v stderr=%s", branch, stdout, want, err, stderr)
+		}
+	}
+
+	if _, stderr, err := runWt(t, dir, "migrate-layout", "--dry-run"); err != nil || !strings.Contains(stderr, "already follow the layout") {
+		t.Errorf("migrate-layout should accept the disambiguated name, err=%v stderr=%s", err, stderr)
+	}
+}
+
+func TestCreate_DirName(t *testing.T) {
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "feature/JIRA-1234-long-description", "--dir-name", "jira-1234"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "jira-1234")
+	if _, err := os.Stat(wtDir); err != nil {
+		t.Fatalf("worktree should be at %s: %v", wtDir, err)
+	}
+
+	if stdout, stderr, err := runWt(t, dir, "switch", "jira-1234"); err != nil || stdout != "__wt_cd:"+wtDir {
+		t.Errorf("switch by directory name: stdout=%q err=%v stderr=%s", stdout, err, stderr)
+	}
+	if _, stderr, err := runWt(t, dir, "migrate-layout", "--dry-run"); err != nil || !strings.Contains(stderr, "already follow the layout") {
+		t.Errorf("migrate-layout should keep a chosen name, err=%v stderr=%s", err, stderr)
+	}
+
+	if _, stderr, err := runWt(t, dir, "create", "other", "--dir-name", "jira-1234"); err == nil || !strings.Contains(stderr, "already exists") {
+		t.Errorf("expected an error for a taken --dir-name, err=%v stderr=%s", err, stderr)
+	}
+	if _, stderr, err := runWt(t, dir, "create", "other", "--dir-name", "../escape"); err == nil || !strings.Contains(stderr, "invalid --dir-name") {
+		t.Errorf("expected an error for a path in --dir-name, err=%v stderr=%s", err, stderr)
+	}
+
+	if _, stderr, err := runWt(t, dir, "remove", "jira-1234"); err != nil {
+		t.Fatalf("remove by directory name failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(wtDir); !os.IsNotExist(err) {
+		t.Error("worktree directory should be removed")
+	}
+}
+
+func TestAuth_LoginLogout(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("uses the secret-tool backend")
+	}
+	bin, store := t.TempDir(), t.TempDir()
+	script := `#!/bin/sh
+store=` + store + `
+cmd=$1; shift
+while [ $# -gt 0 ]; do
+  case $1 in
+    account) account=$2; shift 2 ;;
+    *) shift 2 ;;
+  esac
+done
+case $cmd in
+  store) cat > "$store/$account" ;;
+  lookup) [ -f "$store/$account" ] || exit 1; cat "$store/$account" ;;
+  clear) rm -f "$store/$account" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" || r.Header.Get("Authorization") != "Bearer good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"Bad credentials"}`)
+			return
+		}
+		fmt.Fprint(w, `{"login":"me"}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	env := []string{"PATH=" + bin + string(os.PathListSeparator) + os.Getenv("PATH"), "GITHUB_TOKEN=", "GH_TOKEN="}
+	login := func(token string) (string, error) {
+		cmd := exec.Command(wtBinary(t), "auth", "login", "github", "--host", "ghe.example.com", "--api-url", srv.URL)
+		cmd.Dir = dir
+		cmd.Env = append(append(os.Environ(), "WT_CONFIG_DIR="+testConfigDir(t)), env...)
+		cmd.Stdin = strings.NewReader(token + "\n")
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+
+	if out, err := login("bad"); err == nil {
+		t.Fatalf("expected a rejected token to fail, got: %s", out)
+	}
+	if entries, _ := os.ReadDir(store); len(entries) != 0 {
+		t.Errorf("a rejected token was stored")
+	}
+	out, err := login("good")
+	if err != nil {
+		t.Fatalf("wt auth login failed: %v\n%s", err, out)
+	}
+	if !strings.Contains(out, "as me") {
+		t.Errorf("expected the login in the output, got: %s", out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(store, "github:ghe.example.com")); string(data) != "good" {
+		t.Errorf("stored token = %q, want good", data)
+	}
+
+	_, stderr, err := runWtEnv(t, dir, env, "auth", "logout", "github", "--host", "ghe.example.com")
+	if err != nil || !strings.Contains(stderr, "Removed") {
+		t.Fatalf("wt auth logout: err=%v stderr=%s", err, stderr)
+	}
+	if _, stderr, _ := runWtEnv(t, dir, env, "auth", "logout", "github", "--host", "ghe.example.com"); !strings.Contains(stderr, "No github token") {
+		t.Errorf("expected a second logout to find nothing, got: %s", stderr)
+	}
+}
+
+// doctor --maintenance reports what git maintenance keeps up to date, and
+// maintenance enable registers the repository for it.
+func TestDoctor_Maintenance(t *testing.T) {
+	dir := setupTestRepo(t)
+	env := []string{"GIT_CONFIG_GLOBAL=" + filepath.Join(t.TempDir(), "gitconfig")}
+
+	_, stderr, err := runWtEnv(t, dir, env, "doctor", "--maintenance")
+	if err != nil {
+		t.Fatalf("wt doctor failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{"git maintenance   not registered", "commit-graph      missing", "wt maintenance enable", "git commit-graph write"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("doctor output missing %q, got:\n%s", want, stderr)
+		}
+	}
+
+	if _, stderr, err := runWtEnv(t, dir, env, "maintenance", "enable", "--no-schedule"); err != nil {
+		t.Fatalf("wt maintenance enable failed: %v\nstderr: %s", err, stderr)
+	}
+	gitRun(t, dir, "commit-graph", "write", "--reachable")
+	_, stderr, _ = runWtEnv(t, dir, env, "doctor")
+	if !strings.Contains(stderr, "registered (incremental)") || !strings.Contains(stderr, "No maintenance problems found.") {
+		t.Errorf("doctor should find nothing to do once maintained, got:\n%s", stderr)
+	}
+
+	if _, stderr, err := runWtEnv(t, dir, env, "maintenance", "disable"); err != nil {
+		t.Fatalf("wt maintenance disable failed: %v\nstderr: %s", err, stderr)
+	}
+	_, stderr, _ = runWtEnv(t, dir, env, "maintenance", "disable")
+	if !strings.Contains(stderr, "not enabled") {
+		t.Errorf("disabling twice should say maintenance is not enabled, got:\n%s", stderr)
+	}
+
+	// From a linked worktree, the main worktree is registered and unregistered
+	runWtEnv(t, dir, env, "create", "linked")
+	linked := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "linked")
+	if _, stderr, err := runWtEnv(t, linked, env, "maintenance", "enable", "--no-schedule"); err != nil {
+		t.Fatalf("wt maintenance enable failed: %v\nstderr: %s", err, stderr)
+	}
+	out, _ := exec.Command("git", "config", "--file", strings.TrimPrefix(env[0], "GIT_CONFIG_GLOBAL="), "--get-all", "maintenance.repo").Output()
+	if got := strings.TrimSpace(string(out)); got != dir {
+		t.Errorf("maintenance.repo = %q, want the main worktree %s", got, dir)
+	}
+	if _, stderr, err := runWtEnv(t, linked, env, "maintenance", "disable"); err != nil || !strings.Contains(stderr, "Disabled") {
+		t.Fatalf("wt maintenance disable from a linked worktree failed: %v\nstderr: %s", err, stderr)
+	}
+	out, _ = exec.Command("git", "config", "--file", strings.TrimPrefix(env[0], "GIT_CONFIG_GLOBAL="), "--get-all", "maintenance.repo").Output()
+	if got := strings.TrimSpace(string(out)); got != "" {
+		t.Errorf("maintenance.repo should be empty after disabling, got %q", got)
+	}
+}
+
+// An alias names a worktree for switching and completion, independently of
+// its branch.
+func TestAlias(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "feature/api-gateway")
+	runWt(t, dir, "create", "web")
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "feature-api-gateway")
+
+	if _, stderr, err := runWt(t, dir, "alias", "api", "feature/api-gateway"); err != nil {
+		t.Fatalf("wt alias failed: %v\nstderr: %s", err, stderr)
+	}
+	stdout, stderr, err := runWt(t, dir, "switch", "api")
+	if err != nil {
+		t.Fatalf("wt switch api failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.HasPrefix(stdout, "__wt_cd:"+wtDir) {
+		t.Errorf("switching to the alias should cd to %s, got %q", wtDir, stdout)
+	}
+
+	stdout, _, _ = runWt(t, dir, "alias", "api")
+	if strings.TrimSpace(stdout) != "feature/api-gateway" {
+		t.Errorf("wt alias api = %q, want the branch", stdout)
+	}
+	_, stderr, _ = runWt(t, dir, "alias")
+	if !strings.Contains(stderr, "api    feature/api-gateway") {
+		t.Errorf("wt alias should list the alias, got:\n%s", stderr)
+	}
+	stdout, _, _ = runWt(t, dir, "__complete", "switch", "")
+	if !strings.Contains(stdout, "api\talias of feature/api-gateway") {
+		t.Errorf("completion should offer the alias, got: %s", stdout)
+	}
+
+	if _, _, err := runWt(t, dir, "alias", "web", "feature/api-gateway"); err == nil {
+		t.Error("an alias naming another worktree should be refused")
+	}
+	if _, _, err := runWt(t, dir, "alias", "a/b", "web"); err == nil {
+		t.Error("an alias with a slash should be refused")
+	}
+
+	if _, stderr, err := runWt(t, dir, "alias", "--delete", "api"); err != nil {
+		t.Fatalf("wt alias --delete failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, _, err := runWt(t, dir, "path", "api"); err == nil {
+		t.Error("a deleted alias should no longer name the worktree")
+	}
+}
+
+// A styled cell keeps the columns aligned and the line's style around it.
+func TestTable_StyledCell(t *testing.T) {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.ANSI256)
+	lineStyle := r.NewStyle().Foreground(lipgloss.Color("39"))
+	chip := r.NewStyle().Foreground(lipgloss.Color("1")).Render("[x]")
+
+	tbl := newTable("A", "TAGS", "B")
+	tbl.row(&lineStyle, "main", "[x]", "here")
+	tbl.styleCell(1, chip)
+	tbl.row(nil, "feature-long", "", "there")
+	var out bytes.Buffer
+	if err := tbl.flush(&out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	want := lineStyle.Render("main          ") + chip + lineStyle.Render("   here")
+	if lines[1] != want {
+		t.Errorf("styled line = %q, want %q", lines[1], want)
+	}
+	if lines[2] != "feature-long        there" {
+		t.Errorf("plain line = %q, want it aligned as before", lines[2])
+	}
+}
+
+// Tags are shown by list and status, and --tag filters list, status, and prune.
+func TestTag(t *testing.T) {
+	dir := setupTestRepo(t)
+	runWt(t, dir, "create", "fix/login")
+	runWt(t, dir, "create", "web")
+	runWt(t, dir, "create", "spike")
+
+	if _, stderr, err := runWt(t, dir, "tag", "fix/login", "hotfix", "review"); err != nil {
+		t.Fatalf("wt tag failed: %v\nstderr: %s", err, stderr)
+	}
+	runWt(t, dir, "tag", "web", "review")
+	runWt(t, dir, "tag", "spike", "experiment")
+
+	stdout, _, _ := runWt(t, dir, "tag", "fix/login")
+	if stdout != "hotfix\nreview\n" {
+		t.Errorf("wt tag fix/login = %q, want its tags one per line", stdout)
+	}
+	_, stderr, _ := runWt(t, dir, "list")
+	if !strings.Contains(stderr, "TAGS") || !strings.Contains(stderr, "[hotfix] [review]") {
+		t.Errorf("wt list should show the tags, got:\n%s", stderr)
+	}
+
+	_, stderr, _ = runWt(t, dir, "list", "--tag", "review")
+	if !strings.Contains(stderr, "fix/login") || !strings.Contains(stderr, "web") || strings.Contains(stderr, "spike") {
+		t.Errorf("wt list --tag review should show the review worktrees only, got:\n%s", stderr)
+	}
+	_, stderr, _ = runWt(t, dir, "status", "--tag", "review", "--tag", "hotfix")
+	if !strings.Contains(stderr, "fix/login") || strings.Contains(stderr, "web") {
+		t.Errorf("wt status with two --tag should show worktrees with both, got:\n%s", stderr)
+	}
+
+	// Only the missing worktree with the tag is pruned
+	os.RemoveAll(filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "spike"))
+	os.RemoveAll(filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "web"))
+	_, stderr, err := runWt(t, dir, "prune", "--broken", "--tag", "experiment")
+	if err != nil {
+		t.Fatalf("wt prune --broken --tag failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "Pruned worktree spike") || strings.Contains(stderr, "web") {
+		t.Errorf("prune --tag experiment should prune only spike, got:\n%s", stderr)
+	}
+	out, _ := exec.Command("git", "-C", dir, "worktree", "list").Output()
+	if !strings.Contains(string(out), "testrepo-worktrees/web") || strings.Contains(string(out), "testrepo-worktrees/spike") {
+		t.Errorf("git should still know the untagged missing worktree only, got:\n%s", out)
+	}
+
+	if _, _, err := runWt(t, dir, "tag", "fix/login", "a,b"); err == nil {
+		t.Error("a tag with a comma should be refused")
+	}
+	runWt(t, dir, "tag", "--delete", "fix/login", "hotfix")
+	if stdout, _, _ := runWt(t, dir, "tag", "fix/login"); stdout != "review\n" {
+		t.Errorf("after deleting hotfix, tags = %q, want review", stdout)
+	}
+}
+
+// status --all-repos shows a table per repository, registered or found under
+// --root, from outside any repository.
+func TestStatus_AllRepos(t *testing.T) {
+	dir := setupTestRepo(t)
+	root := filepath.Dir(dir)
+	other := filepath.Join(root, "group", "other")
+	os.MkdirAll(other, 0o755)
+	gitRun(t, other, "init", "-b", "main")
+	gitRun(t, other, "commit", "--allow-empty", "-m", "initial")
+	env := []string{"WT_CONFIG_DIR=" + t.TempDir()}
+	runWtEnv(t, dir, env, "create", "feature")
+	os.WriteFile(filepath.Join(root, "testrepo-worktrees", "feature", "new.txt"), []byte("x"), 0o644)
+	gitRun(t, filepath.Join(root, "testrepo-worktrees", "feature"), "add", "new.txt")
+
+	_, stderr, err := runWtEnv(t, t.TempDir(), env, "status", "--all-repos", "--root", root)
+	if err != nil {
+		t.Fatalf("wt status --all-repos --root failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{"==> group/other (" + other + ")", "==> testrepo (" + dir + ")", "feature"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("output missing %q, got:\n%s", want, stderr)
+		}
+	}
+	if strings.Index(stderr, "group/other") > strings.Index(stderr, "==> testrepo") {
+		t.Errorf("repositories should be sorted by path, got:\n%s", stderr)
+	}
+	if !strings.Contains(stderr, "testrepo-worktrees/feature  dirty") {
+		t.Errorf("the feature worktree should be dirty, got:\n%s", stderr)
+	}
+
+	// Only testrepo was registered, by running wt in it
+	_, stderr, err = runWtEnv(t, t.TempDir(), env, "status", "--all-repos")
+	if err != nil {
+		t.Fatalf("wt status --all-repos failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "==> testrepo") || strings.Contains(stderr, "other") {
+		t.Errorf("--all-repos should show the registered repositories, got:\n%s", stderr)
+	}
+
+	if _, _, err := runWtEnv(t, dir, env, "status", "--root", root); err == nil {
+		t.Error("--root without --all-repos should fail")
+	}
+}
+
+func TestEnv(t *testing.T) {
+	dir := setupTestRepo(t)
+	if _, stderr, err := runWt(t, dir, "create", "api"); err != nil {
+		t.Fatalf("wt create failed: %v\nstderr: %s", err, stderr)
+	}
+	wtDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees", "api")
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[env]\nfile = true\n[hooks]\npost-create = ['echo \"port $PORT\"']\n"), 0o644)
+	trustHooks(t, dir)
+
+	if _, stderr, err := runWt(t, dir, "env", "set", "PORT=3001", "API_URL=http://localhost:3001/v1", "--worktree", "api"); err != nil {
+		t.Fatalf("wt env set failed: %v\nstderr: %s", err, stderr)
+	}
+	stdout, stderr, err := runWt(t, wtDir, "env")
+	if err != nil {
+		t.Fatalf("wt env failed: %v\nstderr: %s", err, stderr)
+	}
+	if stdout != "API_URL=http://localhost:3001/v1\nPORT=3001\n" {
+		t.Errorf("wt env stdout = %q, want the variables in name order", stdout)
+	}
+	data, err := os.ReadFile(filepath.Join(wtDir, ".env.wt"))
+	if err != nil || !strings.Contains(string(data), "\nAPI_URL=http://localhost:3001/v1\nPORT=3001\n") {
+		t.Errorf(".env.wt = %q, %v; want the variables", data, err)
+	}
+
+	// wt exec and hooks export the variables; the main worktree has none
+	stdout, stderr, err = runWt(t, dir, "exec", "api", "--", "sh", "-c", `echo "$PORT $WT_BRANCH $(pwd)"`)
+	if err != nil {
+		t.Fatalf("wt exec failed: %v\nstderr: %s", err, stderr)
+	}
+	if resolved, _ := filepath.EvalSymlinks(wtDir); strings.TrimSpace(stdout) != "3001 api "+resolved && strings.TrimSpace(stdout) != "3001 api "+wtDir {
+		t.Errorf("wt exec stdout = %q, want the port, branch, and worktree", stdout)
+	}
+	if stdout, _, _ := runWt(t, dir, "exec", "--", "sh", "-c", `echo "[$PORT]"`); strings.TrimSpace(stdout) != "[]" {
+		t.Errorf("wt exec in the main worktree saw %q, want no PORT", stdout)
+	}
+	// A "--" in the command is the command's, with or without a worktree
+	for _, args := range [][]string{{"exec", "--", "echo", "--", "x"}, {"exec", "api", "--", "echo", "--", "x"}} {
+		if stdout, stderr, err := runWt(t, dir, args...); err != nil || strings.TrimSpace(stdout) != "-- x" {
+			t.Errorf("wt %s: stdout=%q err=%v stderr=%s, want \"-- x\"", strings.Join(args, " "), stdout, err, stderr)
+		}
+	}
+	if _, stderr, err := runWt(t, dir, "hooks", "run", "post-create", "api"); err != nil || !strings.Contains(stderr, "port 3001") {
+		t.Errorf("post-create hook should see PORT, err=%v stderr=%s", err, stderr)
+	}
+	if _, _, err := runWt(t, dir, "exec", "api", "--", "false"); err == nil {
+		t.Error("wt exec should fail when the command does")
+	}
+	fakeShell := filepath.Join(t.TempDir(), "fakeshell")
+	os.WriteFile(fakeShell, []byte("#!/bin/sh\necho \"shell port $PORT\"\n"), 0o755)
+	stdout, stderr, err = runWtEnv(t, dir, []string{"SHELL=" + fakeShell}, "shell", "api")
+	if err != nil || stdout != "" || !strings.Contains(stderr, "shell port 3001") {
+		t.Errorf("wt shell should run $SHELL with PORT, writing to stderr; err=%v stdout=%q stderr=%q", err, stdout, stderr)
+	}
+
+	for _, bad := range [][]string{{"set", "PORT"}, {"set", "1X=a"}, {"set", "WT_BRANCH=x"}} {
+		if _, _, err := runWt(t, wtDir, append([]string{"env"}, bad...)...); err == nil {
+			t.Errorf("wt env %v should fail", bad)
+		}
+	}
+
+	if _, stderr, err := runWt(t, wtDir, "env", "unset", "PORT", "API_URL"); err != nil {
+		t.Fatalf("wt env unset failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(wtDir, ".env.wt")); !os.IsNotExist(err) {
+		t.Error("unsetting the last variable should remove .env.wt")
+	}
+	if stdout, stderr, _ := runWt(t, wtDir, "env"); stdout != "" || !strings.Contains(stderr, "No environment variables") {
+		t.Errorf("wt env after unset: stdout=%q stderr=%q", stdout, stderr)
+	}
+}
+
+func TestCreate_AssignsSlots(t *testing.T) {
+	dir := setupTestRepo(t)
+	os.MkdirAll(filepath.Join(dir, ".git", "wt", "worktree-template"), 0o755)
+	os.WriteFile(filepath.Join(dir, ".git", "wt", "worktree-template", "slot.txt"), []byte("{{slot}}"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[hooks]\npost-create = ['echo \"slot $WT_SLOT port $((3000 + WT_SLOT))\"']\n"), 0o644)
+	trustHooks(t, dir)
+	wtsDir := filepath.Join(filepath.Dir(dir), "testrepo-worktrees")
+
+	for i, branch := range []string{"one", "two"} {
+		_, stderr, err := runWt(t, dir, "create", branch)
+		if err != nil {
+			t.Fatalf("wt create %s failed: %v\nstderr: %s", branch, err, stderr)
+		}
+		want := fmt.Sprintf("slot %d port %d", i+1, 3001+i)
+		if !strings.Contains(stderr, want) {
+			t.Errorf("post-create hook of %s should print %q, got: %s", branch, want, stderr)
+		}
+		if data, _ := os.ReadFile(filepath.Join(wtsDir, branch, "slot.txt")); string(data) != fmt.Sprint(i+1) {
+			t.Errorf("template of %s expanded {{slot}} to %q, want %d", branch, data, i+1)
+		}
+	}
+
+	// The slot of a removed worktree is reused; the others keep theirs
+	if _, stderr, err := runWt(t, dir, "remove", "one", "--force", "--yes"); err != nil {
+		t.Fatalf("wt remove failed: %v\nstderr: %s", err, stderr)
+	}
+	if _, stderr, _ := runWt(t, dir, "create", "three"); !strings.Contains(stderr, "slot 1 port 3001") {
+		t.Errorf("a new worktree should get the freed slot 1, got: %s", stderr)
+	}
+	if stdout, _, _ := runWt(t, dir, "exec", "two", "--", "sh", "-c", "echo $WT_SLOT"); strings.TrimSpace(stdout) != "2" {
+		t.Errorf("worktree two should keep slot 2, got %q", stdout)
+	}
+	if stdout, _, _ := runWt(t, dir, "exec", "--", "sh", "-c", "echo $WT_SLOT"); strings.TrimSpace(stdout) != "0" {
+		t.Errorf("the main worktree's slot should be 0, got %q", stdout)
+	}
+
+	// A worktree made without wt gets a slot of its own when first looked
+	// up, never the main worktree's 0, or else with the next wt create
+	gitRun(t, dir, "worktree", "add", "-q", "-b", "manual", filepath.Join(wtsDir, "manual"))
+	if stdout, _, _ := runWt(t, dir, "exec", "manual", "--", "sh", "-c", "echo $WT_SLOT"); strings.TrimSpace(stdout) != "3" {
+		t.Errorf("a worktree made with git worktree add should get slot 3, got %q", stdout)
+	}
+	gitRun(t, dir, "worktree", "add", "-q", "-b", "manual2", filepath.Join(wtsDir, "manual2"))
+	if _, stderr, _ := runWt(t, dir, "create", "four"); !strings.Contains(stderr, "slot 5 port 3005") {
+		t.Errorf("a new worktree should get slot 5 after the unslotted one, got: %s", stderr)
+	}
+	if stdout, _, _ := runWt(t, dir, "exec", "manual2", "--", "sh", "-c", "echo $WT_SLOT"); strings.TrimSpace(stdout) != "4" {
+		t.Errorf("wt create should give the unslotted worktree slot 4, got %q", stdout)
+	}
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/provenimpact/wt/internal/theme"
+	"github.com/provenimpact/wt/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// tableColumn is a column wt list and wt status can show.
+type tableColumn struct {
+	name   string
+	header func(against string) string
+	cell   func(r statusRow) string
+	// styled, if set, renders a non-empty cell in color.
+	styled func(r statusRow) string
+	// status is set for columns that need the collected status of each
+	// worktree.
+	status bool
+}
+
+// fixedHeader returns a header function for a column whose header does not
+// depend on the ref worktrees are compared against.
+func fixedHeader(h string) func(string) string {
+	return func(string) string { return h }
+}
+
+// tableColumns lists the columns in the order --columns offers them.
+var tableColumns = []tableColumn{
+	{name: "branch", header: fixedHeader("BRANCH"), cell: func(r statusRow) string { return r.wt.Branch }},
+	{name: "path", header: fixedHeader("PATH"), cell: func(r statusRow) string { return r.rel }},
+	{name: "status", header: fixedHeader("STATUS"), status: true, cell: func(r statusRow) string {
+		status := r.status
+		if r.wt.Locked != "" && status != "locked" {
+			status += ", locked"
+		}
+		if r.expired {
+			status += ", expired"
+		}
+		return status
+	}},
+	{name: "upstream", header: fixedHeader("UPSTREAM"), status: true, cell: func(r statusRow) string {
+		switch {
+		case r.upstream == "":
+			return "-"
+		case r.upstreamGone:
+			return r.upstream + " (gone)"
+		}
+		return r.upstream
+	}},
+	{name: "ahead", header: fixedHeader("AHEAD"), status: true, cell: func(r statusRow) string {
+		if r.upstreamErr != nil {
+			return "-"
+		}
+		return fmt.Sprint(r.ahead)
+	}},
+	{name: "behind", header: fixedHeader("BEHIND"), status: true, cell: func(r statusRow) string {
+		if r.upstreamErr != nil {
+			return "-"
+		}
+		return fmt.Sprint(r.behind)
+	}},
+	{name: "vs", header: func(against string) string { return "VS " + displayRef(against) }, status: true, cell: func(r statusRow) string { return r.vs }},
+	{name: "main", header: fixedHeader("MAIN"), cell: func(r statusRow) string {
+		if r.isMain {
+			return "*"
+		}
+		return ""
+	}},
+	{name: "age", header: fixedHeader("AGE"), cell: func(r statusRow) string {
+		if r.created.IsZero() {
+			return "-"
+		}
+		return formatAge(time.Since(r.created))
+	}},
+	{name: "note", header: fixedHeader("NOTE"), cell: func(r statusRow) string {
+		note, _, _ := strings.Cut(r.note, "\n")
+		return note
+	}},
+	{name: "project", header: fixedHeader("PROJECT"), cell: func(r statusRow) string { return r.project }},
+	{name: "tags", header: fixedHeader("TAGS"), cell: func(r statusRow) string { return tui.TagChips(r.tags, false) }, styled: func(r statusRow) string { return tui.TagChips(r.tags, true) }},
+	{name: "description", header: fixedHeader("DESCRIPTION"), cell: func(r statusRow) string {
+		desc, _, _ := strings.Cut(r.description, "\n")
+		return desc
+	}},
+}
+
+// Keys accepted by --sort.
+var sortKeys = []string{"branch", "path", "age", "status"}
+
+// statusOrder ranks statuses for --sort status, those needing attention first.
+var statusOrder = []string{"error", "inaccessible", "missing", "locked", "dirty", "prunable", "clean"}
+
+// tableLayout holds the --sort and --columns flags shared by wt list and
+// wt status.
+type tableLayout struct {
+	sort    string
+	columns []string
+}
+
+func (l *tableLayout) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&l.sort, "sort", "", "Order worktrees by branch, path, age (oldest first), or status (needing attention first)")
+	cmd.Flags().StringSliceVar(&l.columns, "columns", nil, "Columns to show, in order: "+strings.Join(columnNames(), ", "))
+	cmd.RegisterFlagCompletionFunc("sort", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return sortKeys, cobra.ShellCompDirectiveNoFileComp
+	})
+	cmd.RegisterFlagCompletionFunc("columns", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return columnNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// validate reports an unknown sort key or column before any work is done.
+func (l tableLayout) validate() error {
+	if l.sort != "" && !slices.Contains(sortKeys, l.sort) {
+		return fmt.Errorf("unknown sort key %q (valid: %s)", l.sort, strings.Join(sortKeys, ", "))
+	}
+	for _, name := range l.columns {
+		if _, ok := findColumn(name); !ok {
+			return fmt.Errorf("unknown column %q (valid: %s)", name, strings.Join(columnNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// columnsOr returns the --columns, or defaults without them.
+func (l tableLayout) columnsOr(defaults []string) []string {
+	if len(l.columns) > 0 {
+		return l.columns
+	}
+	return defaults
+}
+
+// needsStatus reports whether the chosen columns or sort order need the
+// collected status of each worktree.
+func (l tableLayout) needsStatus() bool {
+	if l.sort == "status" {
+		return true
+	}
+	for _, name := range l.columns {
+		if c, _ := findColumn(name); c.status {
+			return true
+		}
+	}
+	return false
+}
+
+// sortRows orders rows by the --sort key; without one, git's order is kept.
+// Ties keep their order.
+func (l tableLayout) sortRows(rows []statusRow) {
+	switch l.sort {
+	case "branch":
+		slices.SortStableFunc(rows, func(a, b statusRow) int { return strings.Compare(a.wt.Branch, b.wt.Branch) })
+	case "path":
+		slices.SortStableFunc(rows, func(a, b statusRow) int { return strings.Compare(a.wt.Path, b.wt.Path) })
+	case "age":
+		// Worktrees of unknown age, such as the main one, come last
+		slices.SortStableFunc(rows, func(a, b statusRow) int {
+			if a.created.IsZero() != b.created.IsZero() {
+				return boolDigit(a.created.IsZero()) - boolDigit(b.created.IsZero())
+			}
+			return a.created.Compare(b.created)
+		})
+	case "status":
+		slices.SortStableFunc(rows, func(a, b statusRow) int {
+			return slices.Index(statusOrder, a.status) - slices.Index(statusOrder, b.status)
+		})
+	}
+}
+
+func columnNames() []string {
+	names := make([]string, len(tableColumns))
+	for i, c := range tableColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+func findColumn(name string) (tableColumn, bool) {
+	for _, c := range tableColumns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return tableColumn{}, false
+}
+
+// annotateRows adds what the state and branch descriptions record about each
+// worktree to rows. Descriptions are only read when columns shows them.
+func annotateRows(ctx context.Context, info *repo.Info, rows []statusRow, columns []string) {
+	if st, err := state.New(info.StateDir()).Load(); err == nil {
+		for i := range rows {
+			if rec, ok := st.Lookup(rows[i].wt.Path); ok {
+				rows[i].created = rec.Created
+				rows[i].note = rec.Note
+				rows[i].project = rec.Project
+				rows[i].tags = rec.Tags
+			}
+		}
+	}
+	if slices.Contains(columns, "description") {
+		descs := branchDescriptions(ctx)
+		for i := range rows {
+			rows[i].description = descs[rows[i].wt.Branch]
+		}
+	}
+}
+
+// renderTable writes rows as a table of the named columns, the main worktree
+// and prunable ones styled. against names the ref of the vs column.
+func renderTable(out io.Writer, rows []statusRow, columns []string, against string) error {
+	cols := make([]tableColumn, len(columns))
+	headers := make([]string, len(columns))
+	for i, name := range columns {
+		cols[i], _ = findColumn(name)
+		headers[i] = cols[i].header(against)
+	}
+	t := newTable(headers...)
+	mainStyle := theme.Current().Main
+	prunableStyle := theme.Current().Disabled
+	for _, row := range rows {
+		var style *lipgloss.Style
+		if row.isMain {
+			style = &mainStyle
+		} else if row.wt.Prunable != "" {
+			style = &prunableStyle
+		}
+		cells := make([]string, len(cols))
+		for i, c := range cols {
+			cells[i] = c.cell(row)
+		}
+		t.row(style, cells...)
+		for i, c := range cols {
+			if c.styled != nil && cells[i] != "" {
+				t.styleCell(i, c.styled(row))
+			}
+		}
+	}
+	return t.flush(out)
+}
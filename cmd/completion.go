@@ -1,33 +1,97 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 
+	"github.com/provenimpact/wt/internal/shell"
 	"github.com/spf13/cobra"
 )
 
 var completionCmd = &cobra.Command{
 	Use:   "completion <shell>",
 	Short: "Output shell completion script",
-	Long:  "Output a shell completion script for the specified shell.\n\nSupported shells: bash, zsh, fish\n\nUsage:\n  eval \"$(wt completion bash)\"   # for .bashrc\n  eval \"$(wt completion zsh)\"    # for .zshrc\n  wt completion fish | source    # for config.fish",
+	Long:  "Output a shell completion script for the specified shell.\n\nSupported shells: bash, zsh, fish\n\nUsage:\n  eval \"$(wt completion bash)\"   # for .bashrc\n  eval \"$(wt completion zsh)\"    # for .zshrc\n  wt completion fish | source    # for config.fish\n\nOr let wt set everything up:\n  wt completion install",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runCompletion,
 }
 
+var completionInstallCmd = &cobra.Command{
+	Use:   "install [shell]",
+	Short: "Install completion and shell integration",
+	Long:  "Write the completion script and the 'wt init' line to the standard locations for the shell.\nThe shell is detected from $SHELL if not given. Running it again only updates what changed.\n\n  bash: ~/.local/share/bash-completion/completions/wt and ~/.bashrc\n  zsh:  ~/.local/share/zsh/site-functions/_wt and ~/.zshrc (ahead of compinit)\n  fish: ~/.config/fish/completions/wt.fish and ~/.config/fish/conf.d/wt.fish",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runCompletionInstall,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return []string{"bash", "zsh", "fish"}, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
 func init() {
+	completionCmd.AddCommand(completionInstallCmd)
 	rootCmd.AddCommand(completionCmd)
 }
 
 func runCompletion(cmd *cobra.Command, args []string) error {
-	switch args[0] {
+	return generateCompletion(os.Stdout, args[0])
+}
+
+func generateCompletion(w io.Writer, shellName string) error {
+	switch shellName {
 	case "bash":
-		return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		return rootCmd.GenBashCompletionV2(w, true)
 	case "zsh":
-		return rootCmd.GenZshCompletion(os.Stdout)
+		return rootCmd.GenZshCompletion(w)
 	case "fish":
-		return rootCmd.GenFishCompletion(os.Stdout, true)
+		return rootCmd.GenFishCompletion(w, true)
 	default:
-		return fmt.Errorf("unsupported shell %q; supported: bash, zsh, fish", args[0])
+		return fmt.Errorf("unsupported shell %q; supported: bash, zsh, fish", shellName)
+	}
+}
+
+func runCompletionInstall(cmd *cobra.Command, args []string) error {
+	var shellName string
+	if len(args) == 1 {
+		shellName = args[0]
+	} else {
+		detected, err := shell.DetectShell()
+		if err != nil {
+			return err
+		}
+		shellName = detected
+	}
+	return installShellIntegration(shellName)
+}
+
+// installShellIntegration writes the completion script and the 'wt init'
+// line for shellName and reports the files it changed.
+func installShellIntegration(shellName string) error {
+	var script bytes.Buffer
+	if err := generateCompletion(&script, shellName); err != nil {
+		return err
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	changed, err := shell.Install(shellName, home, script.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if len(changed) == 0 {
+		fmt.Fprintf(os.Stderr, "wt %s integration is already up to date.\n", shellName)
+		return nil
+	}
+	for _, p := range changed {
+		fmt.Fprintf(os.Stderr, "Updated %s\n", p)
 	}
+	fmt.Fprintln(os.Stderr, "Restart your shell to apply the changes.")
+	return nil
 }
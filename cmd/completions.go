@@ -1,31 +1,178 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/provenimpact/wt/internal/cache"
 	"github.com/provenimpact/wt/internal/git"
 	"github.com/provenimpact/wt/internal/repo"
 )
 
-// completeWorktreeBranches returns existing worktree branch names for tab completion.
-func completeWorktreeBranches() []string {
-	info, err := repo.Resolve()
+// completeWorktreeBranches returns linked worktree names for tab completion:
+// each branch name described by its path and state, such as
+// "testrepo-worktrees/feature (dirty, ↑2)", and, where it differs, the
+// worktree's directory name described by its branch, as is its alias. All
+// of these are accepted by findWorktree.
+func completeWorktreeBranches(ctx context.Context) []string {
+	return cachedCompletion("worktrees", func(info *repo.Info) []string {
+		return worktreeCandidates(ctx, info)
+	})
+}
+
+// worktreeCandidates computes the candidates of completeWorktreeBranches.
+func worktreeCandidates(ctx context.Context, info *repo.Info) []string {
+	worktrees, err := git.ListWorktrees(ctx)
 	if err != nil {
 		return nil
 	}
-	worktrees, err := git.ListWorktrees()
+	states := completionStates(ctx, worktrees)
+	aliases := worktreeAliases(info)
+	var names []string
+	for _, wt := range worktrees {
+		if wt.Path == info.MainWorktree {
+			continue
+		}
+		desc := displayPath(info, wt.Path)
+		if state := states[wt.Path]; state != "" {
+			desc += " (" + state + ")"
+		}
+		names = append(names, wt.Branch+"\t"+desc)
+		if dir := filepath.Base(wt.Path); dir != wt.Branch {
+			names = append(names, dir+"\tworktree of "+wt.Branch)
+		}
+		if alias := aliases[wt.Path]; alias != "" {
+			names = append(names, alias+"\talias of "+wt.Branch)
+		}
+	}
+	return names
+}
+
+// completionCacheTTL bounds how long cached completion candidates are
+// reused. Shells ask again on every keystroke; the cache key covers refs and
+// worktrees, and the short TTL what has no cheap fingerprint, such as whether
+// a worktree is dirty.
+const completionCacheTTL = 50 * time.Millisecond
+
+// cachedCompletion returns the candidates of the named completion, as
+// computed by list, reusing those cached within completionCacheTTL under
+// the same refs and worktrees. The cache is only rewritten when the
+// candidates change.
+func cachedCompletion(name string, list func(info *repo.Info) []string) []string {
+	info, err := repo.Resolve()
 	if err != nil {
 		return nil
 	}
-	var names []string
+	key, err := cache.CompletionKey(info.GitCommonDir)
+	if err != nil {
+		return list(info)
+	}
+	store := cache.New(info.StateDir())
+	if c := store.Completion(name); c != nil && c.Key == key && time.Since(c.Time) < completionCacheTTL {
+		return c.Items
+	}
+	items := list(info)
+	// Best effort: completion works the same without the cache
+	store.SaveCompletion(name, &cache.Completion{Key: key, Items: items, Time: time.Now()})
+	return items
+}
+
+// completionStatusTimeout bounds how long completion waits for the state of
+// worktrees. Those not read by then are described without it, so completion
+// stays quick in large repositories.
+const completionStatusTimeout = 300 * time.Millisecond
+
+// completionStates returns a short state per worktree path, such as
+// "dirty, ↑2 ↓1", for the worktrees that are dirty or have diverged from
+// their upstream. Untracked files are not counted, as in 'wt status'.
+func completionStates(ctx context.Context, worktrees []git.Worktree) map[string]string {
+	ctx, cancel := context.WithTimeout(ctx, completionStatusTimeout)
+	defer cancel()
+
+	type result struct {
+		path  string
+		dirty bool
+	}
+	// Buffered so that checks finishing after the timeout do not block. At
+	// most statusWorkers checks run at a time, as in wt status; those still
+	// waiting at the timeout are given up.
+	results := make(chan result, len(worktrees))
+	sem := make(chan struct{}, statusWorkers)
 	for _, wt := range worktrees {
-		if wt.Path != info.MainWorktree {
-			names = append(names, wt.Branch)
+		go func() {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results <- result{wt.Path, false}
+				return
+			}
+			defer func() { <-sem }()
+			dirty, err := git.IsDirty(ctx, wt.Path, false)
+			results <- result{wt.Path, dirty && err == nil}
+		}()
+	}
+	tracking, _ := git.BranchTracking(ctx)
+
+	dirty := make(map[string]bool)
+collect:
+	for range worktrees {
+		select {
+		case r := <-results:
+			dirty[r.path] = r.dirty
+		case <-ctx.Done():
+			break collect
 		}
 	}
-	return names
+
+	states := make(map[string]string)
+	for _, wt := range worktrees {
+		var parts []string
+		if dirty[wt.Path] {
+			parts = append(parts, "dirty")
+		}
+		if t := tracking[wt.Branch]; t.Ahead > 0 || t.Behind > 0 {
+			var counts []string
+			if t.Ahead > 0 {
+				counts = append(counts, fmt.Sprintf("↑%d", t.Ahead))
+			}
+			if t.Behind > 0 {
+				counts = append(counts, fmt.Sprintf("↓%d", t.Behind))
+			}
+			parts = append(parts, strings.Join(counts, " "))
+		}
+		states[wt.Path] = strings.Join(parts, ", ")
+	}
+	return states
+}
+
+// completeBaseRefs returns refs usable as a --base value for tab completion:
+// local branches, remote-tracking branches with their remote prefix, and tags.
+func completeBaseRefs(ctx context.Context) []string {
+	return cachedCompletion("base-refs", func(*repo.Info) []string {
+		return baseRefCandidates(ctx)
+	})
+}
+
+// baseRefCandidates computes the candidates of completeBaseRefs.
+func baseRefCandidates(ctx context.Context) []string {
+	var refs []string
+	if local, err := git.ListLocalBranches(ctx); err == nil {
+		refs = append(refs, local...)
+	}
+	if remote, err := git.ListRemoteRefs(ctx); err == nil {
+		refs = append(refs, remote...)
+	}
+	if tags, err := git.ListTags(ctx); err == nil {
+		refs = append(refs, tags...)
+	}
+	return refs
 }
 
 // completeLinkedWorktreeBranches returns linked (non-main) worktree branch names for tab completion.
-func completeLinkedWorktreeBranches() []string {
+func completeLinkedWorktreeBranches(ctx context.Context) []string {
 	// Same as completeWorktreeBranches — both exclude the main worktree.
-	return completeWorktreeBranches()
+	return completeWorktreeBranches(ctx)
 }
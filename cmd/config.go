@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+// Config scopes of wt config export and import.
+const (
+	configScopeAll  = "all"
+	configScopeUser = "user"
+	configScopeRepo = "repo"
+)
+
+// reviewedSettings are the settings and tables of a profile that make wt run
+// commands, change git settings, or send the forge token somewhere, which
+// import shows before installing them.
+var reviewedSettings = []string{"alias", "editor", "forge", "hooks", "worktree-config"}
+
+var (
+	configExportScope string
+	configImportScope string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Share wt settings as a profile",
+	Long: `Share a wt setup, such as the directory layout, hooks, and command aliases, as a
+single profile file:
+
+  wt config export wt-profile.toml
+  wt config import wt-profile.toml
+
+A profile is a config file like config.toml and .wt.toml. Commit one to a
+repository so that everyone working on it can set up wt the same way.`,
+}
+
+var configExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Write the settings to a profile",
+	Long: `Write the settings in effect to a profile, or to stdout without a file.
+
+By default the repository's .wt.toml is exported, which leaves personal
+settings such as the editor out of a profile for a team. --scope user exports
+the user-level config (config.toml) instead, and --scope all both of them
+merged, the repository's settings winning, as wt does when it reads them.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigExport,
+}
+
+var configImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Install the settings of a profile",
+	Long: `Install the settings of a profile ("-" reads it from stdin) into the
+repository's .wt.toml, or with --scope user into the user-level config.
+
+The profile is checked first: unknown settings and invalid values are errors,
+and nothing is written. Its settings are merged into the existing file: they
+replace the settings of the same name, tables such as [hooks] are merged key
+by key, and other settings are kept. Comments in the existing file are not
+kept, so wt asks before changing it.
+
+Hooks, aliases, the editor, [worktree-config], and [forge] make wt run
+commands, change git settings, or send your forge token to a server, so they
+are shown in full and wt asks before installing them, even into a new file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigImport,
+}
+
+func init() {
+	configExportCmd.Flags().StringVar(&configExportScope, "scope", configScopeRepo, "Settings to export: repo, user, or all")
+	configImportCmd.Flags().StringVar(&configImportScope, "scope", configScopeRepo, "Config to install into: repo or user")
+	for _, c := range []*cobra.Command{configExportCmd, configImportCmd} {
+		c.RegisterFlagCompletionFunc("scope", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if cmd == configImportCmd {
+				return []string{configScopeRepo, configScopeUser}, cobra.ShellCompDirectiveNoFileComp
+			}
+			return []string{configScopeRepo, configScopeUser, configScopeAll}, cobra.ShellCompDirectiveNoFileComp
+		})
+		configCmd.AddCommand(c)
+	}
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigExport(cmd *cobra.Command, args []string) error {
+	var scopes []string
+	switch configExportScope {
+	case configScopeAll:
+		scopes = []string{configScopeUser}
+		if _, err := repo.Resolve(); err == nil {
+			scopes = append(scopes, configScopeRepo)
+		}
+	case configScopeUser, configScopeRepo:
+		scopes = []string{configExportScope}
+	default:
+		return fmt.Errorf("invalid --scope %q: use all, user, or repo", configExportScope)
+	}
+
+	profile := config.Profile{}
+	for _, scope := range scopes {
+		path, err := configFile(scope)
+		if err != nil {
+			return err
+		}
+		settings, err := config.ReadProfile(path)
+		if err != nil {
+			return err
+		}
+		profile.Merge(settings)
+	}
+	if len(profile) == 0 {
+		return fmt.Errorf("no settings to export: the %s config is empty", strings.Join(scopes, " and "))
+	}
+	data, err := profile.Encode("wt profile, written by wt config export.\nInstall it with: wt config import <file>")
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	path := args[0]
+	if _, err := os.Stat(path); err == nil {
+		ok, err := confirmDestructive(fmt.Sprintf("Overwrite %s?", path))
+		if err != nil || !ok {
+			return err
+		}
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing profile: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %s to %s\n", strings.Join(profile.Keys(), ", "), path)
+	return nil
+}
+
+func runConfigImport(cmd *cobra.Command, args []string) error {
+	if configImportScope != configScopeRepo && configImportScope != configScopeUser {
+		return fmt.Errorf("invalid --scope %q: use repo or user", configImportScope)
+	}
+	source := args[0]
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return fmt.Errorf("reading profile: %w", err)
+	}
+	profile, err := config.ParseProfile(data)
+	if err != nil {
+		return fmt.Errorf("invalid profile %s: %w", source, err)
+	}
+	if style, ok := profile["path-style"].(string); ok {
+		if err := checkPathStyle(style); err != nil {
+			return fmt.Errorf("invalid profile %s: %w", source, err)
+		}
+	}
+	if len(profile) == 0 {
+		return fmt.Errorf("profile %s has no settings", source)
+	}
+
+	path, err := configFile(configImportScope)
+	if err != nil {
+		return err
+	}
+	settings, err := config.ReadProfile(path)
+	if err != nil {
+		return err
+	}
+	reviewed := config.Profile{}
+	for _, key := range reviewedSettings {
+		if value, ok := profile[key]; ok {
+			reviewed[key] = value
+		}
+	}
+	if len(reviewed) > 0 {
+		out, err := reviewed.Encode("")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s sets commands for wt to run, git config, or where the forge token goes:\n\n%s\n", source, out)
+	}
+	if len(settings) > 0 || len(reviewed) > 0 {
+		question := fmt.Sprintf("Install %s into %s?", strings.Join(profile.Keys(), ", "), path)
+		if len(settings) > 0 {
+			question = fmt.Sprintf("Merge %s into %s (comments in it are not kept)?", strings.Join(profile.Keys(), ", "), path)
+		}
+		ok, err := confirmDestructive(question)
+		if err != nil || !ok {
+			return err
+		}
+	}
+	settings.Merge(profile)
+	out, err := settings.Encode("wt configuration. Settings were imported with wt config import.")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Imported %s into %s\n", strings.Join(profile.Keys(), ", "), path)
+	return nil
+}
+
+// configFile returns the path of the config file of scope.
+func configFile(scope string) (string, error) {
+	if scope == configScopeRepo {
+		info, err := repo.Resolve()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(info.MainWorktree, config.RepoFileName), nil
+	}
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, config.FileName), nil
+}
@@ -1,55 +1,152 @@
 package cmd
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/provenimpact/wt/internal/cache"
+	"github.com/provenimpact/wt/internal/editor"
+	"github.com/provenimpact/wt/internal/fuzzy"
 	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/history"
+	"github.com/provenimpact/wt/internal/hooks"
 	"github.com/provenimpact/wt/internal/names"
 	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/scaffold"
+	"github.com/provenimpact/wt/internal/state"
 	"github.com/provenimpact/wt/internal/tui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	createBase   string
-	createLocal  bool
-	createRemote bool
+	createBase       string
+	createLocal      bool
+	createRemote     bool
+	createNoTemplate bool
+	createSwitch     bool
+	createEdit       bool
+	createFetchBase  bool
+	createBaseRemote bool
+	createApply      []string
+	createNoHooks    bool
+	createProject    string
+	createDirName    string
+	createNoTrack    bool
+	createReset      bool
+	createDetach     bool
+	createAtomic     bool
+	createSort       string
+	createSince      string
 )
 
+// Orders of the branch selector of wt create (--sort).
+const (
+	branchSortDate   = "date"
+	branchSortName   = "name"
+	branchSortAuthor = "author"
+)
+
+var branchSorts = []string{branchSortDate, branchSortName, branchSortAuthor}
+
 var createCmd = &cobra.Command{
 	Use:   "create [branch]",
 	Short: "Create a new worktree",
-	Long:  "Create a new git worktree for the specified branch in the worktrees directory.\nIf no branch is given, an interactive branch selector is shown.",
+	Long:  "Create a new git worktree for the specified branch in the worktrees directory.\nIf no branch is given, an interactive branch selector is shown.\n\nFiles in .git/wt/worktree-template/ are copied into the new worktree, with\n{{branch}}, {{worktree_path}}, {{dir_name}}, {{repo_name}}, {{main_worktree}},\nand {{slot}} (see wt hooks) placeholders expanded. Existing files are never\noverwritten. Settings in the [worktree-config] table of the config are written\nto the new worktree's own git config (enabling extensions.worktreeConfig), with\nthe same placeholders.\nA repository's .wt.toml may only set keys there that cannot make git run\ncommands, such as user.email; others, such as core.hooksPath, belong in the\nuser config.\n\nWith --apply, each patch file or commit is applied to the new worktree in order:\nformat-patch files are committed with git am, plain diffs are staged with\ngit apply, and commits or ranges (a..b) are cherry-picked. Repeat --apply to\nbackport the same fix onto several branches, one worktree each.\n\nWith --project, the worktree is a sparse checkout of one project of a monorepo:\nonly the project's directories, the [monorepo] shared directories, and the\nfiles at the top level of the repository are checked out.\n\nWith --dir-name, the worktree's directory in the worktrees directory gets the\ngiven name instead of the sanitized branch name. The worktree can be switched\nto or removed by that name, and wt migrate-layout leaves it in place.\n\nWith --no-track, a new branch gets no upstream, even when it starts at a remote\nbranch. With --reset, an existing branch is reset to --base, or else to its\nremote branch, before it is checked out, like git checkout -B; use it to\nrecreate a stale local branch from origin.\n\nWith --detach, the worktree checks out the commit of the branch (or any other\nref) on a detached HEAD instead of the branch itself. Use it for a second copy\nof a branch that is checked out elsewhere, such as the main worktree's branch,\nwhich git allows in only one worktree. When the branch's directory is taken,\nthe copy gets the first free one of <dir>-2, <dir>-3, and so on.\n\nWith --base-remote (or prefer-remote-base = true in the [create] config table),\na new branch starts from the remote branch of a local base, fetched first:\n--base main branches from the current origin/main. Without --base, the remote\nbranch of the current branch is used.\n\nWhen git fails to add the worktree, whatever it left behind is removed: the\ndirectory, the new branch, and the worktrees directory if it was created for\nit. A branch moved by --reset is moved back. With --atomic (or atomic = true in\nthe [create] config table), the same happens when --apply or a post-create\nhook fails, so the worktree is created completely or not at all.\n\nThe branch selector lists each branch's last commit, newest first. --sort name\nor --sort author orders the branches differently, and --since hides those whose\nlast commit is older than an age (e.g. 90d) or a date; the sort and since keys\nof the [create] config table set defaults for both.\n\nFor a new branch, the base selector offers origin's default branch first,\nfetched just before it opens, so a branch does not start from a stale local\ncopy by mistake.",
 	Args:  cobra.MaximumNArgs(1),
 	RunE:  runCreate,
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
-		return completeBranchesForCreate(), cobra.ShellCompDirectiveNoFileComp
+		return completeBranchesForCreate(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
 	},
 }
 
 func init() {
-	createCmd.Flags().StringVar(&createBase, "base", "", "Base branch/ref for new branch creation")
-	createCmd.Flags().BoolVar(&createLocal, "local", false, "Show only local branches in interactive selector")
-	createCmd.Flags().BoolVar(&createRemote, "remote", false, "Show only remote branches in interactive selector")
+	createCmd.Flags().StringVar(&createBase, "base", "", "Base branch/ref for new branch creation (@: the current branch, -: the previous base)")
+	createCmd.Flags().BoolVar(&createLocal, "local", false, "Only consider local branches (new branches are never set to track a remote)")
+	createCmd.Flags().BoolVar(&createRemote, "remote", false, "Only consider remote branches and track the one chosen")
+	createCmd.Flags().BoolVar(&createSwitch, "switch-if-exists", false, "Switch to the existing worktree if the branch is already checked out")
+	createCmd.Flags().BoolVarP(&createEdit, "edit", "e", false, "Open the worktree in your editor afterwards (see 'wt open')")
+	createCmd.Flags().BoolVar(&createFetchBase, "fetch-base", false, "Fetch a remote-tracking --base (e.g. origin/main) before branching from it")
+	createCmd.Flags().BoolVar(&createBaseRemote, "base-remote", false, "Branch from the freshly fetched remote branch of the base instead of the local one")
+	createCmd.Flags().BoolVar(&createAtomic, "atomic", false, "Remove the new worktree again if --apply or a post-create hook fails")
+	createCmd.Flags().StringArrayVar(&createApply, "apply", nil, "Patch file, commit, or commit range to apply to the new worktree (repeatable)")
+	createCmd.Flags().BoolVar(&createNoHooks, "no-hooks", false, "Skip the post-create hooks")
+	createCmd.Flags().BoolVar(&createNoTemplate, "no-template", false, "Skip copying worktree template files")
+	createCmd.Flags().StringVar(&createProject, "project", "", "Check out only this monorepo project (see [monorepo] in the config)")
+	createCmd.Flags().BoolVar(&createNoTrack, "no-track", false, "Do not set an upstream for the new branch, even when it starts at a remote branch")
+	createCmd.Flags().BoolVar(&createReset, "reset", false, "Reset an existing branch to --base (default: its remote branch), like git checkout -B")
+	createCmd.Flags().BoolVar(&createDetach, "detach", false, "Check out the branch's commit on a detached HEAD, e.g. when the branch is checked out in another worktree")
+	createCmd.Flags().StringVar(&createSort, "sort", "", "Order of the branch selector: date (of the last commit, newest first), name, or author")
+	createCmd.Flags().StringVar(&createSince, "since", "", "Hide branches whose last commit is older than this age (e.g. 90d) or date from the branch selector")
+	createCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions(branchSorts, cobra.ShellCompDirectiveNoFileComp))
+	createCmd.Flags().StringVar(&createDirName, "dir-name", "", "Name of the worktree's directory (default: the sanitized branch name)")
+	createCmd.MarkFlagsMutuallyExclusive("local", "remote")
+	createCmd.MarkFlagsMutuallyExclusive("remote", "base")
+	for _, flag := range []string{"base", "base-remote", "local", "remote", "reset", "no-track", "project", "switch-if-exists"} {
+		createCmd.MarkFlagsMutuallyExclusive("detach", flag)
+	}
+	createCmd.RegisterFlagCompletionFunc("base", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeBaseRefs(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+	})
+	createCmd.RegisterFlagCompletionFunc("project", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if loadConfig() != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return cfg.Monorepo.ProjectNames(), cobra.ShellCompDirectiveNoFileComp
+	})
 	rootCmd.AddCommand(createCmd)
 }
 
 func runCreate(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
 	info, err := repo.Resolve()
 	if err != nil {
 		return err
 	}
+	autoRegisterRepo(info)
 
-	worktrees, err := git.ListWorktrees()
+	worktrees, err := git.ListWorktrees(ctx)
 	if err != nil {
 		return err
 	}
 
+	// Catch a malformed [worktree-config] before anything is created
+	if _, err := cfg.GitConfig(); err != nil {
+		return err
+	}
+	if createDirName != "" {
+		if err := validateDirName(createDirName); err != nil {
+			return err
+		}
+	}
+
+	var sparseDirs []string
+	if createProject != "" {
+		if sparseDirs, err = cfg.Monorepo.ProjectDirs(createProject); err != nil {
+			return err
+		}
+	}
+
+	// Resolve the editor up front so a missing one fails before creating anything
+	var editorSpec string
+	if createEdit {
+		if editorSpec, err = editor.Resolve(configuredEditor(info)); err != nil {
+			return err
+		}
+	}
+
 	var branch string
 	var base string
 
@@ -59,7 +156,10 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		base = createBase
 	} else {
 		// Interactive branch selection
-		branch, base, err = interactiveBranchSelect(worktrees)
+		if err := requireInteractive("'wt create <branch>'"); err != nil {
+			return err
+		}
+		branch, base, err = interactiveBranchSelect(ctx, info, worktrees)
 		if err != nil {
 			return err
 		}
@@ -67,98 +167,754 @@ func runCreate(cmd *cobra.Command, args []string) error {
 			return nil // User cancelled
 		}
 	}
+	if base, err = resolveBase(ctx, info, base); err != nil {
+		return err
+	}
+	baseRemote := !createDetach && (createBaseRemote || cfg.Create.PreferRemoteBase)
+	if baseRemote {
+		if base, err = remoteBase(ctx, base, branch); err != nil {
+			return err
+		}
+	}
 
-	// Check if worktree already exists for this branch
+	// Check if worktree already exists for this branch. With --detach the
+	// branch stays where it is and only its commit is checked out.
 	for _, wt := range worktrees {
-		if wt.Branch == branch {
-			return fmt.Errorf("worktree for branch %q already exists at %s", branch, wt.Path)
+		if wt.Branch == branch && !createDetach {
+			if len(createApply) > 0 {
+				return fmt.Errorf("branch %q already has a worktree at %s; --apply only applies to new worktrees", branch, wt.Path)
+			}
+			if createProject != "" {
+				return fmt.Errorf("branch %q already has a worktree at %s; --project only applies to new worktrees", branch, wt.Path)
+			}
+			if createDirName != "" {
+				return fmt.Errorf("branch %q already has a worktree at %s; --dir-name only applies to new worktrees", branch, wt.Path)
+			}
+			if createReset {
+				return fmt.Errorf("branch %q is checked out at %s and cannot be reset", branch, wt.Path)
+			}
+			detach, err := switchToExisting(info, wt, editorSpec)
+			if !detach {
+				return err
+			}
+			createDetach = true
+			break
 		}
 	}
 
-	// Ensure worktrees directory exists
-	if err := info.EnsureWorktreesDir(); err != nil {
-		return fmt.Errorf("creating worktrees directory: %w", err)
+	applies, err := resolveApplies(ctx, createApply)
+	if err != nil {
+		return err
 	}
 
-	// Sanitize branch name for directory path
-	dirName := names.Sanitize(branch)
-	wtPath := filepath.Join(info.WorktreesDir, dirName)
+	// The base is fetched and checked before anything is created, so a
+	// mistyped one leaves no trace
+	if base != "" && (createFetchBase || cfg.Create.FetchBase || baseRemote) {
+		if err := fetchBase(ctx, base); err != nil {
+			return err
+		}
+	}
+	if base != "" {
+		if err := checkBase(ctx, base); err != nil {
+			return err
+		}
+	}
 
-	// Check if branch exists
-	exists, err := git.BranchExists(branch)
-	if err != nil {
+	// Ensure worktrees directory exists
+	_, statErr := os.Stat(info.WorktreesDir)
+	newWorktreesDir := statErr != nil
+	if err := prepareWorktreesDir(info, worktrees); err != nil {
 		return err
 	}
 
-	createBranch := !exists
-	if base != "" {
+	wtPath := worktreeDir(info, worktrees, branch)
+	if createDetach {
+		wtPath = detachedWorktreeDir(info, worktrees, branch)
+	}
+	if createDirName != "" {
+		wtPath = filepath.Join(info.WorktreesDir, createDirName)
+		if _, err := os.Stat(wtPath); err == nil {
+			return fmt.Errorf("%s already exists; choose another --dir-name", displayPath(info, wtPath))
+		}
+	}
+	if err := checkNesting(info, worktrees, wtPath); err != nil {
+		return err
+	}
+
+	// Decide between a new branch, an existing local one, and a remote one to
+	// track. --local and --remote restrict which existing branches count, so a
+	// remote branch never silently resolves to a same-named local one or vice
+	// versa. A branch that only exists on a remote gets a local branch tracking
+	// it, rather than relying on git's DWIM checkout to set the upstream.
+	// --reset recreates the branch at the base, or else at its remote branch.
+	createBranch := base != ""
+	var upstream string
+	switch {
+	case createDetach:
+		if err := checkBase(ctx, branch); err != nil {
+			return err
+		}
+	case createReset:
 		createBranch = true
+		if base == "" && !createLocal {
+			if upstream, err = git.RemoteTrackingRef(ctx, branch); err != nil {
+				return err
+			}
+		}
+		if base == "" && upstream == "" {
+			return fmt.Errorf("branch %q has no remote branch to reset to; give one with --base", branch)
+		}
+	case createBranch:
+	case createLocal:
+		createBranch = !git.LocalBranchExists(ctx, branch)
+	case createRemote:
+		if git.LocalBranchExists(ctx, branch) {
+			return fmt.Errorf("branch %q also exists locally; drop --remote to use the local branch", branch)
+		}
+		if upstream, err = git.RemoteTrackingRef(ctx, branch); err != nil {
+			return err
+		}
+		if upstream == "" {
+			return fmt.Errorf("no remote branch %q", branch)
+		}
+	default:
+		exists, err := git.BranchExists(ctx, branch)
+		if err != nil {
+			return err
+		}
+		createBranch = !exists
+		if exists && !git.LocalBranchExists(ctx, branch) {
+			if upstream, err = git.RemoteTrackingRef(ctx, branch); err != nil {
+				return err
+			}
+		}
+	}
+	if createNoTrack && !createBranch && upstream == "" {
+		return fmt.Errorf("branch %q already exists; --no-track only applies to new branches (add --reset to recreate it)", branch)
 	}
 
-	if err := git.AddWorktree(wtPath, branch, createBranch, base); err != nil {
+	// The branch starts at the remote branch it tracks, at the base, or, when
+	// it already exists, nowhere new
+	opts := git.BranchOptions{NoTrack: createNoTrack, Reset: createReset}
+	var start string
+	switch {
+	case upstream != "":
+		start = upstream
+		opts.Track = !createNoTrack
+	case createBranch:
+		start = cmp.Or(base, "HEAD")
+		// A new branch started at the remote branch of its base is new work:
+		// it does not track the base, as branch.autoSetupMerge would have it
+		if baseRemote && !git.LocalBranchExists(ctx, branch) {
+			opts.NoTrack = true
+		}
+	}
+	var resetFrom string
+	if createReset && git.LocalBranchExists(ctx, branch) {
+		if resetFrom, err = git.Commit(ctx, branch); err != nil {
+			return err
+		}
+	}
+
+	_, statErr = os.Stat(wtPath)
+	partial := partialWorktree{
+		path:            wtPath,
+		branch:          branch,
+		newDir:          statErr != nil,
+		newBranch:       !git.LocalBranchExists(ctx, branch),
+		newWorktreesDir: newWorktreesDir,
+		resetFrom:       resetFrom,
+	}
+	switch {
+	case createDetach:
+		err = git.AddDetachedWorktree(ctx, wtPath, branch)
+	case sparseDirs != nil:
+		err = git.AddSparseWorktree(ctx, wtPath, branch, start, opts, sparseDirs)
+	case start == "":
+		err = git.AddWorktree(ctx, wtPath, branch, false, "")
+	default:
+		err = git.AddBranchWorktree(ctx, wtPath, branch, start, opts)
+	}
+	recordEvent(info, history.Create, branch, wtPath, err)
+	if err != nil {
+		partial.discard(ctx, info)
 		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "Created worktree for branch %q at %s\n", branch, wtPath)
+	recordCreated(info, worktrees, wtPath, branch)
+	recordUse(info, wtPath, branch)
+	if createBranch && upstream == "" {
+		recordBase(ctx, info, wtPath, base)
+	}
+	if createProject != "" {
+		recordProject(info, wtPath, createProject)
+	}
+	if createDirName != "" {
+		recordDirName(info, wtPath, createDirName)
+	}
+	applyWorktreeConfig(ctx, info, wtPath, branch)
+
+	// A failed patch leaves the worktree in place, mid-apply, for the user to
+	// resolve; it is reported once the worktree is otherwise set up. With
+	// --atomic the worktree is removed instead.
+	atomic := createAtomic || cfg.Create.Atomic
+	applyErr := applyChanges(ctx, wtPath, applies)
+	if applyErr != nil && atomic {
+		return partial.rollBack(ctx, info, applyErr)
+	}
+
+	if !createNoTemplate {
+		copyTemplate(info, wtPath, branch)
+	}
+
+	hookBase := base
+	if upstream != "" {
+		hookBase = upstream
+	}
+	if !createNoHooks {
+		if err := runPostCreateHooks(ctx, info, wtPath, branch, hookBase); err != nil && atomic {
+			return partial.rollBack(ctx, info, err)
+		}
+	}
+	if interrupted(ctx) {
+		// The worktree itself is complete, so it is kept
+		emitSwitch(wtPath, branch)
+		return fmt.Errorf("%w after creating the worktree at %s", context.Cause(ctx), wtPath)
+	}
+	syncWorkspace(ctx, info)
+
+	if resetFrom != "" {
+		was := resetFrom
+		if short, err := git.ShortCommit(ctx, resetFrom); err == nil {
+			was = short
+		}
+		fmt.Fprintf(os.Stderr, "Reset branch %q to %s (it was at %s)\n", branch, start, was)
+	}
+	switch {
+	case createDetach:
+		fmt.Fprintf(os.Stderr, "Created worktree at %s, detached at the commit of %s\n", wtPath, branch)
+	case opts.Track:
+		fmt.Fprintf(os.Stderr, "Created worktree for branch %q tracking %s at %s\n", branch, upstream, wtPath)
+	default:
+		fmt.Fprintf(os.Stderr, "Created worktree for branch %q at %s\n", branch, wtPath)
+	}
 
 	// Output cd sentinel to stdout for shell wrapper
-	fmt.Printf("__wt_cd:%s", wtPath)
+	emitSwitch(wtPath, branch)
+	launchEditor(editorSpec, wtPath)
+	return applyErr
+}
+
+// resolveBase expands the --base shorthands: "@" and "HEAD" stand for the
+// branch checked out in the current worktree (its commit when detached), and
+// "-" for the base of the previous wt create --base.
+func resolveBase(ctx context.Context, info *repo.Info, base string) (string, error) {
+	switch base {
+	case "@", "HEAD":
+		branch, commit, err := git.CurrentBranch(ctx)
+		if err != nil {
+			return "", err
+		}
+		if branch != "" {
+			return branch, nil
+		}
+		return commit, nil
+	case "-":
+		st, err := state.New(info.StateDir()).Load()
+		if err != nil {
+			return "", err
+		}
+		if st.LastBase == "" {
+			return "", errors.New("no previous base: --base - reuses the base of the last 'wt create --base'")
+		}
+		return st.LastBase, nil
+	}
+	return base, nil
+}
+
+// remoteBase returns the remote branch of base for --base-remote, e.g.
+// "origin/main" for "main", so that a new branch does not start from a stale
+// local copy. Without a base, a new branch starts from the current branch, so
+// its remote branch is used if it has one. Other refs, such as tags, commits,
+// and remote branches, are returned as they are. A given local branch without
+// a remote branch is an error with --base-remote and kept, with a warning,
+// with prefer-remote-base.
+func remoteBase(ctx context.Context, base, branch string) (string, error) {
+	given := base
+	if base == "" {
+		exists, err := git.BranchExists(ctx, branch)
+		if err != nil || exists {
+			return "", err // An existing branch is checked out, not created
+		}
+		if base, _, err = git.CurrentBranch(ctx); err != nil || base == "" {
+			return "", err // A detached HEAD has no remote branch
+		}
+	}
+	if !git.LocalBranchExists(ctx, base) {
+		return base, nil
+	}
+
+	remote, remoteBranch, err := git.UpstreamBranch(ctx, base)
+	if err != nil {
+		return "", err
+	}
+	if remote != "" {
+		return remote + "/" + remoteBranch, nil
+	}
+	ref, err := git.RemoteTrackingRef(ctx, base)
+	if err != nil || ref != "" || given == "" {
+		return ref, err
+	}
+	if createBaseRemote {
+		return "", fmt.Errorf("--base-remote: branch %q has no remote branch", base)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: branch %q has no remote branch; branching from the local one\n", base)
+	return base, nil
+}
+
+// checkBase reports an error, suggesting the refs that were probably meant,
+// when base does not name a commit.
+func checkBase(ctx context.Context, base string) error {
+	if git.RefExists(ctx, base) {
+		return nil
+	}
+	err := fmt.Errorf("base %q is not a branch, tag, or commit", base)
+	if similar := fuzzy.Closest(completeBaseRefs(ctx), base, 3); len(similar) > 0 {
+		err = fmt.Errorf("%w; did you mean %s?", err, strings.Join(similar, ", "))
+	}
+	return err
+}
+
+// partialWorktree is what a failed wt create may leave behind.
+type partialWorktree struct {
+	path   string
+	branch string
+	// newDir, newBranch, and newWorktreesDir record that the directory, the
+	// branch, and the worktrees directory did not exist before, so they are
+	// wt's to delete.
+	newDir          bool
+	newBranch       bool
+	newWorktreesDir bool
+	// resetFrom is the full ID of the commit the branch was at before --reset
+	// moved it, which an abbreviation may stop naming uniquely.
+	resetFrom string
+}
+
+// discard removes the leftovers of a failed worktree add, such as an
+// interrupted one or a sparse one whose checkout failed, and unregisters the
+// worktree, so that creating it can simply be retried. git cleans up after
+// itself in some cases, but not all, and not if it had to be killed.
+func (p partialWorktree) discard(ctx context.Context, info *repo.Info) {
+	ctx = context.WithoutCancel(ctx)
+	if p.newDir {
+		os.RemoveAll(p.path)
+		info.CleanEmptyParents(p.path)
+	}
+	if p.newWorktreesDir {
+		info.RemoveWorktreesDir() // only if empty
+	}
+	git.PruneWorktrees(ctx)
+	switch {
+	case p.newBranch && git.LocalBranchExists(ctx, p.branch):
+		git.DeleteBranch(ctx, p.branch, true)
+	case p.resetFrom != "":
+		git.MoveBranch(ctx, p.branch, p.resetFrom)
+	}
+}
+
+// rollBack removes a worktree whose setup failed with err, along with what
+// wt recorded about it, and returns err with a note that it is gone.
+func (p partialWorktree) rollBack(ctx context.Context, info *repo.Info, err error) error {
+	git.RemoveWorktree(context.WithoutCancel(ctx), p.path, true)
+	forgetWorktree(info, p.path)
+	p.discard(ctx, info)
+	return fmt.Errorf("%w; removed the worktree at %s again (--atomic)", err, displayPath(info, p.path))
+}
+
+// worktreeDir returns the path for a new worktree of branch: its sanitized
+// name (see [names] in the config) in the worktrees directory. When that is
+// taken, e.g. by "fix-bug" for the branch "fix/bug", or by a directory git
+// does not know, a short hash of the branch is appended instead of failing.
+func worktreeDir(info *repo.Info, worktrees []git.Worktree, branch string) string {
+	path := filepath.Join(info.WorktreesDir, names.Sanitize(branch, nameOptions))
+	taken := ""
+	if other, ok := worktreeAt(worktrees, path); ok {
+		if other.Branch == branch {
+			return path
+		}
+		taken = "the worktree of " + worktreeName(other)
+	} else if entries, err := os.ReadDir(path); err == nil && len(entries) > 0 {
+		taken = "another directory"
+	}
+	if taken == "" {
+		return path
+	}
+	unique := filepath.Join(info.WorktreesDir, names.Disambiguate(branch, nameOptions))
+	fmt.Fprintf(os.Stderr, "%s is already used by %s; putting %s in %s\n", displayPath(info, path), taken, branch, displayPath(info, unique))
+	return unique
+}
+
+// detachedWorktreeDir returns the path for a detached worktree of ref, which
+// may be a second copy of a branch that has a worktree already: the
+// sanitized name of ref, or when that is taken, the name with the first free
+// suffix of -2, -3, and so on.
+func detachedWorktreeDir(info *repo.Info, worktrees []git.Worktree, ref string) string {
+	name := names.Sanitize(ref, nameOptions)
+	path := filepath.Join(info.WorktreesDir, name)
+	for n := 2; ; n++ {
+		_, isWorktree := worktreeAt(worktrees, path)
+		entries, _ := os.ReadDir(path)
+		if !isWorktree && len(entries) == 0 {
+			return path
+		}
+		path = filepath.Join(info.WorktreesDir, fmt.Sprintf("%s-%d", name, n))
+	}
+}
+
+// prepareWorktreesDir creates the worktrees directory if needed, and warns
+// when an existing one may not be wt's: when its marker (repo.RootMarker)
+// names another repository, or, without a marker, when it holds files that
+// are not worktrees of this repository, such as an unrelated directory that
+// happens to be named <repo>-worktrees. An unmarked directory holding nothing
+// but worktrees is marked, and so is one with an empty marker.
+func prepareWorktreesDir(info *repo.Info, worktrees []git.Worktree) error {
+	if err := info.EnsureWorktreesDir(); err != nil {
+		return fmt.Errorf("creating worktrees directory: %w", err)
+	}
+	owned, err := info.OwnsWorktreesDir()
+	if err != nil || owned {
+		return err
+	}
+	owner, marked, err := info.WorktreesDirOwner()
+	if err != nil {
+		return err
+	}
+	dir := displayPath(info, info.WorktreesDir)
+	switch {
+	case marked && owner == "":
+		return info.MarkWorktreesDir()
+	case marked:
+		if _, err := os.Stat(filepath.Join(owner, ".git")); err == nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s is marked as the worktrees directory of %s (see %s in it)\n", dir, owner, repo.RootMarker)
+			return nil
+		}
+		// The repository was moved, and the directory with it
+		return info.MarkWorktreesDir()
+	}
+
+	foreign, err := foreignEntries(info, worktrees)
+	if err != nil {
+		return err
+	}
+	if len(foreign) == 0 {
+		return info.MarkWorktreesDir()
+	}
+	shown := foreign[:min(len(foreign), 3)]
+	more := ""
+	if len(foreign) > len(shown) {
+		more = fmt.Sprintf(" and %d more", len(foreign)-len(shown))
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s holds files that are not worktrees of this repository: %s%s\n", dir, strings.Join(shown, ", "), more)
+	fmt.Fprintf(os.Stderr, "If the directory is meant for wt's worktrees, mark it with: touch %s\n", filepath.Join(info.WorktreesDir, repo.RootMarker))
 	return nil
 }
 
-// interactiveBranchSelect launches the interactive branch selector.
-// Returns the selected branch name and base ref (empty if existing branch).
-func interactiveBranchSelect(worktrees []git.Worktree) (branch string, base string, err error) {
-	// Build the set of branches that already have worktrees
-	wtBranches := make(map[string]bool)
+// foreignEntries returns the names, relative to the worktrees directory, of
+// what it holds besides worktrees, the directories leading to nested ones,
+// and the marker.
+func foreignEntries(info *repo.Info, worktrees []git.Worktree) ([]string, error) {
+	entries, err := os.ReadDir(info.WorktreesDir)
+	if err != nil {
+		return nil, fmt.Errorf("reading worktrees directory: %w", err)
+	}
+	var foreign []string
+	for _, e := range entries {
+		if !e.IsDir() && e.Name() != repo.RootMarker {
+			foreign = append(foreign, e.Name())
+		}
+	}
+	var live []string
 	for _, wt := range worktrees {
-		wtBranches[wt.Branch] = true
+		live = append(live, wt.Path)
+	}
+	orphans, err := findOrphans(info.WorktreesDir, live)
+	if err != nil {
+		return nil, err
 	}
+	for _, dir := range orphans {
+		if rel, err := filepath.Rel(info.WorktreesDir, dir); err == nil {
+			foreign = append(foreign, rel)
+		}
+	}
+	slices.Sort(foreign)
+	return foreign, nil
+}
 
-	// Gather branches based on flags
-	var entries []tui.BranchEntry
+// validateDirName checks a --dir-name: a single path element, so that the
+// worktree stays directly inside the worktrees directory.
+func validateDirName(name string) error {
+	if name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid --dir-name %q: it must be a plain directory name, without slashes", name)
+	}
+	return nil
+}
 
-	if !createRemote {
-		local, err := git.ListLocalBranches()
-		if err != nil {
-			return "", "", err
+// worktreeAt returns the worktree at path, if any.
+func worktreeAt(worktrees []git.Worktree, path string) (git.Worktree, bool) {
+	for _, wt := range worktrees {
+		if wt.Path == path {
+			return wt, true
 		}
-		for _, b := range local {
-			entries = append(entries, tui.BranchEntry{
-				Name:        b,
-				Source:      "local",
-				HasWorktree: wtBranches[b],
-			})
+	}
+	return git.Worktree{}, false
+}
+
+// checkNesting refuses layouts where worktrees end up inside each other: a
+// new worktree inside an existing one or containing one, and creation from a
+// stray directory of another repository's worktrees directory, where git
+// resolves whatever repository encloses it instead. Paths are compared with
+// symlinks resolved, as a worktrees directory may be reached through a link.
+func checkNesting(info *repo.Info, worktrees []git.Worktree, wtPath string) error {
+	target := resolveNewPath(wtPath)
+	for _, wt := range worktrees {
+		existing := resolvePath(wt.Path)
+		if _, inside := pathWithin(target, existing); inside && target != existing {
+			return fmt.Errorf("refusing to create worktree at %s: it would be nested inside the worktree at %s", wtPath, wt.Path)
+		}
+		if _, inside := pathWithin(existing, target); inside && target != existing {
+			return fmt.Errorf("refusing to create worktree at %s: the worktree at %s would be nested inside it", wtPath, wt.Path)
 		}
 	}
 
-	if !createLocal {
-		remote, err := git.ListRemoteBranches()
-		if err != nil {
-			return "", "", err
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	mainWorktree := resolvePath(info.MainWorktree)
+	for dir := resolvePath(cwd); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		name, ok := strings.CutSuffix(filepath.Base(dir), "-worktrees")
+		if !ok || name == "" {
+			continue
 		}
-		// Add remote branches not already in list from local
-		seen := make(map[string]bool)
-		for _, e := range entries {
-			seen[e.Name] = true
+		owner := filepath.Join(filepath.Dir(dir), name)
+		if resolvePath(owner) == mainWorktree {
+			break
 		}
-		for _, b := range remote {
-			if !seen[b] {
-				entries = append(entries, tui.BranchEntry{
-					Name:        b,
-					Source:      "remote",
-					HasWorktree: wtBranches[b],
-				})
+		if _, err := os.Stat(filepath.Join(owner, ".git")); err == nil {
+			return fmt.Errorf("the current directory is inside the worktrees directory of %s, not in one of its worktrees; run wt from %s or a worktree instead", owner, owner)
+		}
+	}
+	return nil
+}
+
+// resolveNewPath resolves the symlinks of path, which need not exist yet: the
+// longest existing part of it is resolved and the rest appended.
+func resolveNewPath(path string) string {
+	rest := ""
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		if dir == filepath.Dir(dir) {
+			return path
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// applySource is one --apply value: a patch file or commits to cherry-pick.
+type applySource struct {
+	patch   string // absolute path of a patch file
+	commits string // commit or range, when not a patch
+}
+
+// resolveApplies checks the --apply values before anything is created. A
+// value naming an existing file is a patch; anything else must resolve to a
+// commit, or to two commits for a range.
+func resolveApplies(ctx context.Context, values []string) ([]applySource, error) {
+	var sources []applySource
+	for _, v := range values {
+		if fi, err := os.Stat(v); err == nil && !fi.IsDir() {
+			abs, err := filepath.Abs(v)
+			if err != nil {
+				return nil, err
 			}
+			sources = append(sources, applySource{patch: abs})
+			continue
+		}
+		ends := []string{v}
+		if from, to, ok := strings.Cut(v, ".."); ok {
+			ends = []string{from, strings.TrimPrefix(to, ".")}
+		}
+		for _, ref := range ends {
+			if ref == "" || !git.RefExists(ctx, ref) {
+				return nil, fmt.Errorf("--apply %q is neither a patch file nor a commit", v)
+			}
+		}
+		sources = append(sources, applySource{commits: v})
+	}
+	return sources, nil
+}
+
+// applyChanges applies sources to the worktree at wtPath in order, stopping at
+// the first one that fails.
+func applyChanges(ctx context.Context, wtPath string, sources []applySource) error {
+	for _, s := range sources {
+		var err error
+		applied := s.commits
+		if s.patch != "" {
+			err = git.ApplyPatch(ctx, wtPath, s.patch)
+			applied = filepath.Base(s.patch)
+		} else {
+			err = git.CherryPick(ctx, wtPath, s.commits)
+		}
+		if err != nil {
+			return fmt.Errorf("%w\nthe worktree was created at %s; resolve the conflict there", err, wtPath)
+		}
+		fmt.Fprintf(os.Stderr, "Applied %s\n", applied)
+	}
+	return nil
+}
+
+// switchToExisting handles a create request for a branch that is already
+// checked out in another worktree: it switches there when --switch-if-exists
+// is set or the user agrees, and otherwise explains where the branch lives.
+// A non-empty editorSpec also opens the worktree in the editor.
+//
+// A branch checked out in the main worktree, typically the default branch, is
+// often wanted a second time, e.g. to build a release next to ongoing work. The
+// user is then also offered a worktree detached at the branch's commit, and
+// detach reports that they took it.
+func switchToExisting(info *repo.Info, wt git.Worktree, editorSpec string) (detach bool, err error) {
+	inMain := wt.Path == info.MainWorktree
+	where := wt.Path
+	if inMain {
+		where = "the main worktree (" + wt.Path + ")"
+	}
+
+	answer := "n"
+	switch {
+	case createSwitch:
+		answer = "s"
+	case !isInteractive():
+	case inMain:
+		answer = choose(fmt.Sprintf("Branch %q is checked out in %s. Switch there, or create a worktree detached at its commit?", wt.Branch, where), []choice{
+			{"s", "switch"},
+			{"d", "detach"},
+			{"n", "cancel"},
+		}, "n")
+	case confirm(fmt.Sprintf("Branch %q is already checked out in %s. Switch there instead?", wt.Branch, where)):
+		answer = "s"
+	}
+
+	switch answer {
+	case "s":
+		recordUse(info, wt.Path, wt.Branch)
+		recordEvent(info, history.Switch, wt.Branch, wt.Path, nil)
+		fmt.Fprintf(os.Stderr, "Switching to existing worktree for branch %q\n", wt.Branch)
+		emitSwitch(wt.Path, wt.Branch)
+		launchEditor(editorSpec, wt.Path)
+		return false, nil
+	case "d":
+		return true, nil
+	}
+
+	if inMain {
+		return false, fmt.Errorf("branch %q is checked out in %s, and a branch can only be checked out in one worktree; use 'wt switch %s' to go there, or 'wt create %s --detach' for a worktree at its commit", wt.Branch, where, wt.Branch, wt.Branch)
+	}
+	return false, fmt.Errorf("worktree for branch %q already exists at %s; use 'wt switch %s' or --switch-if-exists to go there", wt.Branch, where, wt.Branch)
+}
+
+// copyTemplate copies the repository's worktree template files into a new
+// worktree. Failures are reported as warnings since the worktree itself exists.
+func copyTemplate(info *repo.Info, wtPath, branch string) {
+	written, err := scaffold.Copy(info.TemplateDir(), wtPath, templateVars(info, wtPath, branch))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+	if len(written) > 0 {
+		fmt.Fprintf(os.Stderr, "Copied %d template file(s) from %s\n", len(written), info.TemplateDir())
+	}
+}
+
+// templateVars returns the placeholder values for a new worktree's template
+// files and [worktree-config] settings.
+func templateVars(info *repo.Info, wtPath, branch string) scaffold.Vars {
+	return scaffold.Vars{
+		"branch":        branch,
+		"worktree_path": wtPath,
+		"dir_name":      filepath.Base(wtPath),
+		"repo_name":     info.RepoName,
+		"main_worktree": info.MainWorktree,
+		"slot":          strconv.Itoa(worktreeSlot(info, wtPath)),
+	}
+}
+
+// applyWorktreeConfig writes the [worktree-config] settings into the own git
+// config of a new worktree, enabling extensions.worktreeConfig first if
+// needed. Failures are reported as warnings since the worktree itself exists.
+func applyWorktreeConfig(ctx context.Context, info *repo.Info, wtPath, branch string) {
+	settings, err := cfg.GitConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		return
+	}
+	if len(settings) == 0 {
+		return
+	}
+	changed, err := git.EnableWorktreeConfig(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		return
+	}
+	if changed {
+		fmt.Fprintln(os.Stderr, "Enabled extensions.worktreeConfig so that worktrees can have git settings of their own")
+	}
+	vars := templateVars(info, wtPath, branch)
+	for _, key := range slices.Sorted(maps.Keys(settings)) {
+		if err := git.SetWorktreeConfig(ctx, wtPath, key, scaffold.Expand(settings[key], vars)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
 		}
 	}
+}
 
-	if len(entries) == 0 {
+// interactiveBranchSelect launches the interactive branch selector.
+// Returns the selected branch name and base ref (empty if existing branch).
+func interactiveBranchSelect(ctx context.Context, info *repo.Info, worktrees []git.Worktree) (branch string, base string, err error) {
+	// Build the set of branches that already have worktrees
+	wtBranches := make(map[string]bool)
+	for _, wt := range worktrees {
+		wtBranches[wt.Branch] = true
+	}
+
+	order, err := resolveBranchOrder(time.Now())
+	if err != nil {
+		return "", "", err
+	}
+	lists, refresh, err := branchLists(ctx, info)
+	if err != nil {
+		return "", "", err
+	}
+	descs := branchDescriptions(ctx)
+	recent := recentBranches(info)
+	entries := branchEntries(lists, wtBranches, descs, recent, order)
+	if len(entries) == 0 && refresh == nil {
 		return "", "", fmt.Errorf("no branches available")
 	}
 
-	// Launch branch selector
-	selected, err := tui.SelectBranch(entries, "Branches")
+	// Launch branch selector, refreshing a stale cached list in the background
+	var selected string
+	if refresh != nil {
+		selected, err = tui.SelectBranchRefreshing(entries, "Branches", func() ([]tui.BranchEntry, error) {
+			fresh, err := refresh()
+			if err != nil {
+				return nil, err
+			}
+			return branchEntries(fresh, wtBranches, descs, recent, order), nil
+		})
+	} else {
+		selected, err = tui.SelectBranch(entries, "Branches")
+	}
 	if err != nil {
 		return "", "", err
 	}
@@ -167,24 +923,39 @@ func interactiveBranchSelect(worktrees []git.Worktree) (branch string, base stri
 	}
 
 	// Check if the selected branch exists
-	exists, err := git.BranchExists(selected)
+	exists, err := git.BranchExists(ctx, selected)
 	if err != nil {
 		return "", "", err
 	}
 
 	if !exists {
-		// New branch — need a base branch selector
+		// New branch — need a base selector: the freshly fetched default
+		// branch, then branches, then tags, plus whatever ref the user types
 		var baseEntries []tui.BranchEntry
+		def, hasDefault := defaultBaseEntry(ctx, info)
+		if hasDefault {
+			baseEntries = append(baseEntries, def)
+		}
 		for _, e := range entries {
-			if !e.HasWorktree {
+			if !e.HasWorktree && !(hasDefault && e.Name == def.Name) {
 				baseEntries = append(baseEntries, tui.BranchEntry{
-					Name:   e.Name,
-					Source: e.Source,
+					Name:        e.Name,
+					Source:      e.Source,
+					Description: e.Description,
+					Date:        e.Date,
+					Author:      e.Author,
 				})
 			}
 		}
+		tags, err := git.ListTags(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		for _, tag := range tags {
+			baseEntries = append(baseEntries, tui.BranchEntry{Name: tag, Source: "tag"})
+		}
 
-		baseSelected, err := tui.SelectBranch(baseEntries, "Base branch")
+		baseSelected, err := tui.SelectRef(baseEntries, "Base (branch, tag, or type any ref)")
 		if err != nil {
 			return "", "", err
 		}
@@ -199,8 +970,15 @@ func interactiveBranchSelect(worktrees []git.Worktree) (branch string, base stri
 
 // completeBranchesForCreate returns branch names for tab completion,
 // excluding branches that already have worktrees.
-func completeBranchesForCreate() []string {
-	worktrees, err := git.ListWorktrees()
+func completeBranchesForCreate(ctx context.Context) []string {
+	return cachedCompletion("create-branches", func(*repo.Info) []string {
+		return createBranchCandidates(ctx)
+	})
+}
+
+// createBranchCandidates computes the candidates of completeBranchesForCreate.
+func createBranchCandidates(ctx context.Context) []string {
+	worktrees, err := git.ListWorktrees(ctx)
 	if err != nil {
 		return nil
 	}
@@ -211,7 +989,7 @@ func completeBranchesForCreate() []string {
 
 	var suggestions []string
 
-	local, err := git.ListLocalBranches()
+	local, err := git.ListLocalBranches(ctx)
 	if err == nil {
 		for _, b := range local {
 			if !wtBranches[b] {
@@ -220,7 +998,7 @@ func completeBranchesForCreate() []string {
 		}
 	}
 
-	remote, err := git.ListRemoteBranches()
+	remote, err := git.ListRemoteBranches(ctx)
 	if err == nil {
 		seen := make(map[string]bool)
 		for _, s := range suggestions {
@@ -235,3 +1013,289 @@ func completeBranchesForCreate() []string {
 
 	return suggestions
 }
+
+// runPostCreateHooks runs the configured post-create hooks in the new worktree.
+// On a terminal they run in a progress view, one step per command; otherwise
+// their output is streamed as is. A failing or interrupted hook is reported
+// as a warning; the error of a failing one is also returned, for wt create
+// --atomic to remove the worktree.
+func runPostCreateHooks(ctx context.Context, info *repo.Info, wtPath, branch, base string) error {
+	if len(cfg.Hooks.PostCreate) == 0 || !hooksAllowed(info, hooks.PostCreate) {
+		return nil
+	}
+	hc := hooks.Context{
+		Branch:       branch,
+		Path:         wtPath,
+		Base:         base,
+		MainWorktree: info.MainWorktree,
+		RepoName:     info.RepoName,
+		Slot:         worktreeSlot(info, wtPath),
+	}
+	var err error
+	if isInteractive() {
+		steps := []tui.Step{{Name: "Create worktree"}}
+		for _, command := range cfg.Hooks.PostCreate {
+			steps = append(steps, tui.Step{
+				Name: command,
+				Run: func(stepCtx context.Context, out io.Writer) error {
+					return hooks.RunContext(stepCtx, hooks.PostCreate, []string{command}, hc, wtPath, out)
+				},
+			})
+		}
+		err = tui.Progress(ctx, fmt.Sprintf("Setting up %s", branch), steps)
+	} else {
+		err = hooks.RunContext(ctx, hooks.PostCreate, cfg.Hooks.PostCreate, hc, wtPath, os.Stderr)
+	}
+	switch {
+	case interrupted(ctx):
+		// Reported by the caller
+		return nil
+	case errors.Is(err, tui.ErrInterrupted):
+		fmt.Fprintln(os.Stderr, "Warning: post-create hooks interrupted")
+		return nil
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+	return err
+}
+
+// launchEditor opens path for create --edit. The worktree is already in place,
+// so a failing editor is only reported.
+func launchEditor(spec, path string) {
+	if spec == "" {
+		return
+	}
+	if err := openInEditor(spec, path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+}
+
+// branchLists returns the local and remote branch names for the interactive
+// selector. An up-to-date cached listing is returned as is. A stale one is
+// returned together with a refresh function that reloads it; without any
+// cache the branches are listed (and cached) right away.
+func branchLists(ctx context.Context, info *repo.Info) (*cache.Branches, func() (*cache.Branches, error), error) {
+	store := cache.New(info.StateDir())
+	cached := store.Branches()
+	if cached == nil {
+		lists, err := listBranches(ctx, info, store)
+		return lists, nil, err
+	}
+	// Listings cached before commits were recorded are refreshed as well
+	if key, err := cache.RefKey(info.GitCommonDir); err == nil && key == cached.Key && cached.LocalCommits != nil {
+		return cached, nil, nil
+	}
+	return cached, func() (*cache.Branches, error) { return listBranches(ctx, info, store) }, nil
+}
+
+// listBranches lists branches and their last commits through git and caches
+// the result. Remote branches are listed without their remote, once, with the
+// commit of the first remote that has them.
+func listBranches(ctx context.Context, info *repo.Info, store *cache.Store) (*cache.Branches, error) {
+	// Take the key first so that refs changing mid-listing make it stale
+	key, keyErr := cache.RefKey(info.GitCommonDir)
+
+	refs, err := git.ListBranchRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lists := &cache.Branches{
+		Key:           key,
+		LocalCommits:  make(map[string]cache.Commit),
+		RemoteCommits: make(map[string]cache.Commit),
+		Time:          time.Now(),
+	}
+	for _, ref := range refs {
+		commit := cache.Commit{Date: ref.Date, Author: ref.Author}
+		if !ref.Remote {
+			lists.Local = append(lists.Local, ref.Name)
+			lists.LocalCommits[ref.Name] = commit
+			continue
+		}
+		_, name, _ := strings.Cut(ref.Name, "/")
+		if _, seen := lists.RemoteCommits[name]; !seen {
+			lists.Remote = append(lists.Remote, name)
+			lists.RemoteCommits[name] = commit
+		}
+	}
+	slices.Sort(lists.Remote)
+	if keyErr == nil {
+		// Best effort: a cache that cannot be written just isn't used next time
+		store.SaveBranches(lists)
+	}
+	return lists, nil
+}
+
+// recentBranchLimit caps the Recent section of the branch selector.
+const recentBranchLimit = 5
+
+// recentBranches returns the branches of the most recent successful wt
+// operations, newest first, according to the history log.
+func recentBranches(info *repo.Info) []string {
+	events, _ := history.New(info.StateDir()).Read()
+	var recent []string
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if e.Outcome == history.OK && e.Branch != "" && !slices.Contains(recent, e.Branch) {
+			recent = append(recent, e.Branch)
+		}
+	}
+	return recent
+}
+
+// branchOrder is how the branch selector orders and filters branches.
+type branchOrder struct {
+	sort string
+	// since hides branches whose last commit is older; zero keeps all.
+	since time.Time
+}
+
+// resolveBranchOrder reads --sort and --since, defaulting to the [create]
+// config.
+func resolveBranchOrder(now time.Time) (branchOrder, error) {
+	order := branchOrder{sort: cmp.Or(createSort, cfg.Create.Sort, branchSortDate)}
+	if !slices.Contains(branchSorts, order.sort) {
+		return branchOrder{}, fmt.Errorf("unknown branch sort %q (valid: %s)", order.sort, strings.Join(branchSorts, ", "))
+	}
+	var err error
+	order.since, err = parseSince(cmp.Or(createSince, cfg.Create.Since), now)
+	return order, err
+}
+
+// apply drops the entries older than o.since and sorts the rest.
+func (o branchOrder) apply(entries []tui.BranchEntry) []tui.BranchEntry {
+	if !o.since.IsZero() {
+		entries = slices.DeleteFunc(entries, func(e tui.BranchEntry) bool {
+			return !e.Date.IsZero() && e.Date.Before(o.since)
+		})
+	}
+	slices.SortStableFunc(entries, func(a, b tui.BranchEntry) int {
+		switch o.sort {
+		case branchSortName:
+			return strings.Compare(a.Name, b.Name)
+		case branchSortAuthor:
+			return cmp.Or(strings.Compare(a.Author, b.Author), b.Date.Compare(a.Date))
+		default:
+			return b.Date.Compare(a.Date)
+		}
+	})
+	return entries
+}
+
+// branchEntries turns branch lists into selector entries, honoring --local
+// and --remote. Local branches among recent that have no worktree come first,
+// in a section of their own; the local and then the remote branches follow
+// in order, and remote branches that also exist locally are listed once.
+func branchEntries(lists *cache.Branches, wtBranches map[string]bool, descs map[string]string, recent []string, order branchOrder) []tui.BranchEntry {
+	var entries, local, remote []tui.BranchEntry
+	seen := make(map[string]bool)
+	if !createRemote {
+		for _, b := range recent {
+			if len(entries) == recentBranchLimit {
+				break
+			}
+			if !wtBranches[b] && slices.Contains(lists.Local, b) {
+				seen[b] = true
+				entries = append(entries, branchEntry(b, "recent", false, descs[b], lists.LocalCommits[b]))
+			}
+		}
+		for _, b := range lists.Local {
+			if seen[b] {
+				continue
+			}
+			seen[b] = true
+			local = append(local, branchEntry(b, "local", wtBranches[b], descs[b], lists.LocalCommits[b]))
+		}
+	}
+	if !createLocal {
+		for _, b := range lists.Remote {
+			if !seen[b] {
+				remote = append(remote, branchEntry(b, "remote", wtBranches[b], "", lists.RemoteCommits[b]))
+			}
+		}
+	}
+	return slices.Concat(entries, order.apply(local), order.apply(remote))
+}
+
+// branchEntry is the selector entry for a branch whose last commit is commit.
+func branchEntry(name, source string, hasWorktree bool, desc string, commit cache.Commit) tui.BranchEntry {
+	return tui.BranchEntry{
+		Name:        name,
+		Source:      source,
+		HasWorktree: hasWorktree,
+		Description: desc,
+		Date:        commit.Date,
+		Author:      commit.Author,
+	}
+}
+
+// defaultBaseEntry fetches the default branch from origin and returns it as
+// the first base offered for a new branch, so it is preselected instead of a
+// possibly stale local copy. If the fetch fails, a local copy of the ref is
+// still offered, described by the age of the last fetch.
+func defaultBaseEntry(ctx context.Context, info *repo.Info) (tui.BranchEntry, bool) {
+	remotes, err := git.ListRemotes(ctx)
+	if err != nil || !slices.Contains(remotes, "origin") {
+		return tui.BranchEntry{}, false
+	}
+	branch, err := git.DefaultBranch(ctx)
+	if err != nil {
+		return tui.BranchEntry{}, false
+	}
+	ref := "origin/" + branch
+
+	fmt.Fprintf(os.Stderr, "Fetching %s...\n", ref)
+	start := time.Now()
+	desc := "up to date as of %s ago"
+	if err := git.FetchBranch(ctx, "origin", branch); err != nil {
+		if interrupted(ctx) || !git.RefExists(ctx, ref) {
+			return tui.BranchEntry{}, false
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s; offering the local copy of %s\n", err, ref)
+		fi, err := os.Stat(filepath.Join(info.GitCommonDir, "FETCH_HEAD"))
+		if err != nil {
+			return tui.BranchEntry{Name: ref, Source: "default", Description: "fetch failed"}, true
+		}
+		start, desc = fi.ModTime(), "fetch failed; as of %s ago"
+	}
+	return tui.BranchEntry{
+		Name:        ref,
+		Source:      "default",
+		Description: fmt.Sprintf(desc, fetchAge(time.Since(start))),
+	}, true
+}
+
+// fetchAge renders how long ago a fetch was, e.g. "4s" or "3h".
+func fetchAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return formatAge(d)
+}
+
+// fetchBase updates base from its remote when it names a remote-tracking
+// branch such as origin/main, so the new branch starts from the current
+// upstream tip. A failed fetch only warns if a local copy of the ref exists.
+func fetchBase(ctx context.Context, base string) error {
+	remotes, err := git.ListRemotes(ctx)
+	if err != nil {
+		return err
+	}
+	remote, branch, ok := strings.Cut(base, "/")
+	if !ok || !slices.Contains(remotes, remote) {
+		return nil // Not a remote-tracking ref; nothing to fetch
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching %s...\n", base)
+	if err := git.FetchBranch(ctx, remote, branch); err != nil {
+		if !interrupted(ctx) && git.RefExists(ctx, base) {
+			fmt.Fprintf(os.Stderr, "Warning: %s; using the local copy of %s\n", err, base)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
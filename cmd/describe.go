@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe <branch> [text]",
+	Short: "Show or set a branch description",
+	Long: `Show or set the description of a local branch (branch.<name>.description,
+the same setting as git branch --edit-description).
+
+Descriptions are shown next to branches in the selectors and in wt list --long.
+Without text, the current description is printed to stdout; an empty text
+("") removes it.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDescribe,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		branches, _ := git.ListLocalBranches(cmd.Context())
+		return branches, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(describeCmd)
+}
+
+func runDescribe(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	branch := args[0]
+	if !git.LocalBranchExists(ctx, branch) {
+		return fmt.Errorf("no local branch %q", branch)
+	}
+
+	if len(args) == 1 {
+		descs, err := git.BranchDescriptions(ctx)
+		if err != nil {
+			return err
+		}
+		if desc := descs[branch]; desc != "" {
+			fmt.Println(desc)
+		}
+		return nil
+	}
+
+	if err := git.SetBranchDescription(ctx, branch, args[1]); err != nil {
+		return err
+	}
+	if args[1] == "" {
+		fmt.Fprintf(os.Stderr, "Removed description of %s\n", branch)
+	} else {
+		fmt.Fprintf(os.Stderr, "Described %s\n", branch)
+	}
+	return nil
+}
+
+// branchDescriptions returns branch descriptions for display. They are only
+// decoration, so a failure to read them yields none.
+func branchDescriptions(ctx context.Context) map[string]string {
+	descs, err := git.BranchDescriptions(ctx)
+	if err != nil {
+		return nil
+	}
+	return descs
+}
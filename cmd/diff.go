@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffStat     bool
+	diffNameOnly bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <branch-a> [branch-b]",
+	Short: "Summarize differences between two worktrees",
+	Long:  "Show a summarized diff between the HEADs of two worktrees.\nIf branch-b is omitted, the main worktree is used.",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE:  runDiff,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= 2 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffStat, "stat", false, "Show per-file change counts")
+	diffCmd.Flags().BoolVar(&diffNameOnly, "name-only", false, "Show only the names of changed files")
+	diffCmd.MarkFlagsMutuallyExclusive("stat", "name-only")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	aliases := worktreeAliases(info)
+	a, ok := findWorktree(worktrees, aliases, args[0])
+	if !ok {
+		return fmt.Errorf("worktree %q not found", args[0])
+	}
+
+	var b git.Worktree
+	if len(args) == 2 {
+		b, ok = findWorktree(worktrees, aliases, args[1])
+		if !ok {
+			return fmt.Errorf("worktree %q not found", args[1])
+		}
+	} else {
+		for _, wt := range worktrees {
+			if wt.Path == info.MainWorktree {
+				b = wt
+				break
+			}
+		}
+	}
+
+	mode := git.DiffShortStat
+	switch {
+	case diffStat:
+		mode = git.DiffStat
+	case diffNameOnly:
+		mode = git.DiffNameOnly
+	}
+
+	// Compare b -> a so insertions describe what a adds on top of b
+	out, err := git.Diff(ctx, b.HEAD, a.HEAD, mode)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		fmt.Fprintf(os.Stderr, "No differences between %q and %q\n", a.Branch, b.Branch)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "%s..%s\n", b.Branch, a.Branch)
+	fmt.Fprintln(os.Stderr, out)
+	return nil
+}
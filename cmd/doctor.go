@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var doctorMaintenance bool
+
+// looseObjectsAdvice is the number of loose objects above which doctor
+// suggests packing them. git's own gc.auto threshold is 6700.
+const looseObjectsAdvice = 1000
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the repository for problems that slow worktrees down",
+	Long: `Check the repository and report what to do about the problems found. The
+checks are selected with flags; without any, all of them run.
+
+With --maintenance, report the state of what git maintenance keeps up to
+date, which matters most in large repositories where every worktree shares
+one object store:
+
+  - whether the repository is registered for git maintenance
+  - the commit-graph, which speeds up history walks such as the divergence
+    shown by 'wt status'
+  - the multi-pack-index, which speeds up object lookups across many packs
+  - prefetched refs, which make fetches for new worktrees quick
+  - the number of pack files and loose objects
+
+'wt maintenance enable' turns on scheduled maintenance for the repository.`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorMaintenance, "maintenance", false, "Report the state of git maintenance")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+
+	// The maintenance check is the only one so far, so it also runs when no
+	// check is selected.
+	m, err := git.MaintenanceState(ctx, info.MainWorktree, info.GitCommonDir)
+	if err != nil {
+		return err
+	}
+	remotes, err := git.ListRemotes(ctx)
+	if err != nil {
+		return err
+	}
+	return printMaintenance(os.Stderr, info, m, len(remotes) > 0)
+}
+
+// printMaintenance writes the maintenance state of the repository followed
+// by advice on each problem found.
+func printMaintenance(out io.Writer, info *repo.Info, m git.Maintenance, hasRemotes bool) error {
+	registered := "not registered"
+	if m.Registered {
+		registered = "registered"
+		if m.Strategy != "" {
+			registered += " (" + m.Strategy + ")"
+		}
+	}
+	present := func(ok bool) string {
+		if ok {
+			return "present"
+		}
+		return "missing"
+	}
+	prefetch := "none"
+	if m.Prefetch {
+		prefetch = "present"
+	}
+
+	fmt.Fprintf(out, "Maintenance of %s:\n", info.MainWorktree)
+	t := newTable("CHECK", "STATE")
+	t.row(nil, "git maintenance", registered)
+	t.row(nil, "commit-graph", present(m.CommitGraph))
+	t.row(nil, "multi-pack-index", present(m.MultiPackIndex))
+	t.row(nil, "prefetched refs", prefetch)
+	t.row(nil, "pack files", fmt.Sprint(m.Packs))
+	t.row(nil, "loose objects", fmt.Sprint(m.LooseObjects))
+	if err := t.flush(out); err != nil {
+		return err
+	}
+
+	var advice []string
+	if !m.Registered {
+		advice = append(advice, "The repository is not registered for git maintenance; run 'wt maintenance enable' to keep the commit-graph, multi-pack-index, and prefetched refs up to date in the background.")
+	}
+	if !m.CommitGraph {
+		advice = append(advice, "There is no commit-graph, so history walks read every commit; 'git commit-graph write --reachable' writes one now.")
+	}
+	if !m.MultiPackIndex && m.Packs > 1 {
+		advice = append(advice, fmt.Sprintf("Objects are spread over %d pack files without a multi-pack-index; 'git multi-pack-index write' writes one now.", m.Packs))
+	}
+	if !m.Prefetch && hasRemotes && !m.Registered {
+		advice = append(advice, "Nothing has been prefetched, so fetches for new worktrees download everything since the last fetch; scheduled maintenance prefetches hourly.")
+	}
+	if m.LooseObjects > looseObjectsAdvice {
+		advice = append(advice, fmt.Sprintf("There are %d loose objects; 'git maintenance run --task=loose-objects' packs them.", m.LooseObjects))
+	}
+
+	fmt.Fprintln(out)
+	if len(advice) == 0 {
+		fmt.Fprintln(out, "No maintenance problems found.")
+		return nil
+	}
+	for _, a := range advice {
+		fmt.Fprintf(out, "- %s\n", a)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// envFileName is the dotenv file written into a worktree with [env] file.
+const envFileName = ".env.wt"
+
+var envWorktree string
+
+// envName matches the environment variable names wt env accepts.
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Show or set environment variables of a worktree",
+	Long: `Give a worktree environment variables of its own, e.g. a port for each of
+several dev servers running side by side:
+
+  wt env set PORT=3001 --worktree api
+  wt exec api -- npm run dev
+
+wt exec, wt shell, and hooks run for the worktree export its variables (the
+post-switch hook excepted, since it runs in your own shell). Without a
+subcommand, the variables of the worktree are printed as NAME=value lines.
+
+With file = true in the [env] config table, the variables are also written to
+a .env.wt file in the worktree whenever they change, for tools that read
+dotenv files; add it to .gitignore.
+
+The current worktree is used unless --worktree names another.`,
+	Args: cobra.NoArgs,
+	RunE: runEnv,
+}
+
+var envSetCmd = &cobra.Command{
+	Use:   "set <name>=<value>...",
+	Short: "Set environment variables of a worktree",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runEnvSet,
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:   "unset <name>...",
+	Short: "Remove environment variables of a worktree",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runEnvUnset,
+}
+
+func init() {
+	envCmd.PersistentFlags().StringVarP(&envWorktree, "worktree", "w", "", "Worktree to use instead of the current one")
+	envCmd.RegisterFlagCompletionFunc("worktree", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+	})
+	envCmd.AddCommand(envSetCmd, envUnsetCmd)
+	rootCmd.AddCommand(envCmd)
+}
+
+func runEnv(cmd *cobra.Command, args []string) error {
+	info, wt, err := envTarget(cmd)
+	if err != nil {
+		return err
+	}
+	env := hookContext(info, wt).WorktreeEnv
+	if len(env) == 0 {
+		fmt.Fprintf(os.Stderr, "No environment variables for %s. Set one with: wt env set NAME=value\n", wt.Branch)
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		fmt.Printf("%s=%s\n", name, env[name])
+	}
+	return nil
+}
+
+func runEnvSet(cmd *cobra.Command, args []string) error {
+	vars := make(map[string]string, len(args))
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("%q is not of the form NAME=value", arg)
+		}
+		if err := checkEnvName(name); err != nil {
+			return err
+		}
+		vars[name] = value
+	}
+	return updateEnv(cmd, func(env map[string]string) {
+		maps.Copy(env, vars)
+	})
+}
+
+func runEnvUnset(cmd *cobra.Command, args []string) error {
+	for _, name := range args {
+		if err := checkEnvName(name); err != nil {
+			return err
+		}
+	}
+	return updateEnv(cmd, func(env map[string]string) {
+		for _, name := range args {
+			delete(env, name)
+		}
+	})
+}
+
+// checkEnvName rejects names that are not shell variable names, and the WT_*
+// names wt sets itself.
+func checkEnvName(name string) error {
+	if !envName.MatchString(name) {
+		return fmt.Errorf("invalid variable name %q: use letters, digits, and _", name)
+	}
+	if strings.HasPrefix(name, "WT_") {
+		return fmt.Errorf("invalid variable name %q: WT_* variables are set by wt", name)
+	}
+	return nil
+}
+
+// updateEnv applies change to the variables of the target worktree and
+// rewrites its .env.wt file if one is configured.
+func updateEnv(cmd *cobra.Command, change func(env map[string]string)) error {
+	info, wt, err := envTarget(cmd)
+	if err != nil {
+		return err
+	}
+	var env map[string]string
+	if err := state.New(info.StateDir()).Update(func(st *state.State) error {
+		rec := st.Worktree(wt.Path)
+		if rec.Env == nil {
+			rec.Env = make(map[string]string)
+		}
+		change(rec.Env)
+		if len(rec.Env) == 0 {
+			rec.Env = nil
+		}
+		env = rec.Env
+		return nil
+	}); err != nil {
+		return err
+	}
+	if cfg.Env.File {
+		if err := writeEnvFile(wt.Path, env); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Updated the environment of %s (%d variable(s))\n", wt.Branch, len(env))
+	return nil
+}
+
+// envTarget returns the worktree named by --worktree, or else the current one.
+func envTarget(cmd *cobra.Command) (*repo.Info, git.Worktree, error) {
+	return resolveTarget(cmd, envWorktree)
+}
+
+// targetWorktree returns the worktree matching name, or the current
+// worktree when name is empty.
+func targetWorktree(worktrees []git.Worktree, aliases map[string]string, name string) (git.Worktree, error) {
+	if name != "" {
+		return matchWorktree(worktrees, aliases, name)
+	}
+	wt, ok := currentWorktree(worktrees)
+	if !ok {
+		return git.Worktree{}, errors.New("not inside a worktree; name the worktree to use")
+	}
+	return wt, nil
+}
+
+// writeEnvFile writes env to the .env.wt file of the worktree at dir, one
+// NAME=value line per variable, or removes the file when env is empty.
+func writeEnvFile(dir string, env map[string]string) error {
+	path := filepath.Join(dir, envFileName)
+	if len(env) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("removing %s: %w", envFileName, err)
+		}
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("# Written by wt env; changes here are overwritten.\n")
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		fmt.Fprintf(&b, "%s=%s\n", name, dotenvValue(env[name]))
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", envFileName, err)
+	}
+	return nil
+}
+
+// dotenvValue double-quotes value when a dotenv parser would otherwise
+// misread it.
+func dotenvValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n#\"'\\$`") {
+		return strconv.Quote(value)
+	}
+	return value
+}
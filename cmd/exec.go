@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/shell"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec [worktree] -- <command> [args...]",
+	Short: "Run a command in a worktree with its environment",
+	Long: `Run a command in a worktree, with the worktree's environment variables
+(see wt env) and the WT_* variables hooks get. The worktree is named before
+"--"; without it, the command runs in the current worktree:
+
+  wt exec api -- npm run dev
+  wt exec -- make test
+
+Everything after the worktree is the command, so its flags need no quoting.`,
+	RunE: runExec,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveDefault
+	},
+}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell [worktree]",
+	Short: "Start a shell in a worktree with its environment",
+	Long: `Start your shell ($SHELL) in a worktree, with the worktree's environment
+variables (see wt env) and the WT_* variables hooks get. Exit the shell to come
+back. Without a worktree, the shell starts in the current one.
+
+The shell writes to the terminal directly, past the shell integration.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runShell,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	// Flags after the command belong to it
+	execCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(execCmd, shellCmd)
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	// Arguments are not parsed past the first, so a "--" after the worktree
+	// is still among them. A "--" before any argument ends the flags of exec
+	// instead and is gone, and a "--" after it belongs to the command
+	var name string
+	if cmd.ArgsLenAtDash() != 0 && len(args) > 1 && args[1] == "--" {
+		name, args = args[0], args[2:]
+	}
+	if len(args) == 0 {
+		return errors.New("no command given, e.g. wt exec api -- npm run dev")
+	}
+
+	info, wt, err := resolveTarget(cmd, name)
+	if err != nil {
+		return err
+	}
+	c := exec.CommandContext(cmd.Context(), args[0], args[1:]...)
+	c.Stdout = os.Stdout
+	if err := runInWorktree(info, wt, c); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
+
+func runShell(cmd *cobra.Command, args []string) error {
+	var name string
+	if len(args) == 1 {
+		name = args[0]
+	}
+	info, wt, err := resolveTarget(cmd, name)
+	if err != nil {
+		return err
+	}
+
+	shell := os.Getenv("SHELL")
+	if runtime.GOOS == "windows" {
+		shell = cmp.Or(os.Getenv("COMSPEC"), "cmd.exe")
+	}
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	fmt.Fprintf(os.Stderr, "Starting %s in %s; exit it to return\n", shell, displayPath(info, wt.Path))
+	c := exec.Command(shell)
+	// Shell integrations from older wt versions capture stdout, which would
+	// hide the prompt and everything run in the shell
+	c.Stdout = os.Stderr
+	if err := runInWorktree(info, wt, c); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil // The exit status of the last command run in the shell
+		}
+		return fmt.Errorf("starting %s: %w", shell, err)
+	}
+	return nil
+}
+
+// resolveTarget returns the worktree matching name, or the current one when
+// name is empty.
+func resolveTarget(cmd *cobra.Command, name string) (*repo.Info, git.Worktree, error) {
+	info, err := repo.Resolve()
+	if err != nil {
+		return nil, git.Worktree{}, err
+	}
+	worktrees, err := git.ListWorktrees(cmd.Context())
+	if err != nil {
+		return nil, git.Worktree{}, err
+	}
+	wt, err := targetWorktree(worktrees, worktreeAliases(info), name)
+	return info, wt, err
+}
+
+// runInWorktree runs c in wt, with the environment hooks get there.
+func runInWorktree(info *repo.Info, wt git.Worktree, c *exec.Cmd) error {
+	c.Dir = wt.Path
+	c.Env = append(shell.Environ(), hookContext(info, wt).Env()...)
+	c.Stdin = os.Stdin
+	c.Stderr = os.Stderr
+	return c.Run()
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+)
+
+// expiry applies the [expire] policy of the config to worktrees.
+type expiry struct {
+	after   time.Duration
+	created map[string]time.Time // recorded creation times, keyed by path
+	now     time.Time
+}
+
+// loadExpiry returns the configured expiry policy, or nil when none is set.
+func loadExpiry(info *repo.Info) (*expiry, error) {
+	after, err := cfg.Expire.Duration()
+	if err != nil || after == 0 {
+		return nil, err
+	}
+	e := &expiry{after: after, created: make(map[string]time.Time), now: time.Now()}
+	if st, err := state.New(info.StateDir()).Load(); err == nil {
+		for path, wt := range st.Worktrees {
+			if !wt.Created.IsZero() {
+				e.created[path] = wt.Created
+			}
+		}
+	}
+	return e, nil
+}
+
+// age returns how long ago the worktree at path was created. Worktrees that
+// wt did not create, or created before it recorded creation times, are dated
+// by their .git file, which git writes when adding a worktree.
+func (e *expiry) age(path string) (time.Duration, bool) {
+	created, ok := e.created[path]
+	if !ok {
+		fi, err := os.Lstat(filepath.Join(path, ".git"))
+		if err != nil {
+			return 0, false
+		}
+		created = fi.ModTime()
+	}
+	return e.now.Sub(created), true
+}
+
+// expired reports whether wt has outlived the policy, and its age. The main
+// worktree, missing worktrees, and excluded branches never expire.
+func (e *expiry) expired(info *repo.Info, wt git.Worktree) (time.Duration, bool) {
+	if e == nil || wt.Path == info.MainWorktree || wt.Prunable != "" || cfg.Expire.Excluded(wt.Branch) {
+		return 0, false
+	}
+	age, ok := e.age(wt.Path)
+	return age, ok && age >= e.after
+}
+
+// formatAge renders an age in whole days, or hours below a day.
+func formatAge(d time.Duration) string {
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
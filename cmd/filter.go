@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+// worktreeFilter holds the --branch, --tag, --dirty, --clean, --ahead, and
+// --behind flags shared by wt list and wt status. A worktree must match all of
+// them. Dirty means changes to tracked files in both commands, unless wt
+// status is given --untracked.
+type worktreeFilter struct {
+	branch string
+	tags   []string
+	dirty  bool
+	clean  bool
+	ahead  bool
+	behind bool
+}
+
+func (f *worktreeFilter) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.branch, "branch", "", "Only show worktrees whose branch matches the glob (e.g. 'release/*')")
+	registerTagFilter(cmd, &f.tags)
+	cmd.Flags().BoolVar(&f.dirty, "dirty", false, "Only show worktrees with uncommitted changes to tracked files")
+	cmd.Flags().BoolVar(&f.clean, "clean", false, "Only show worktrees without uncommitted changes to tracked files")
+	cmd.Flags().BoolVar(&f.ahead, "ahead", false, "Only show worktrees ahead of their upstream")
+	cmd.Flags().BoolVar(&f.behind, "behind", false, "Only show worktrees behind their upstream")
+	cmd.MarkFlagsMutuallyExclusive("dirty", "clean")
+}
+
+// registerTagFilter adds the --tag flag, also used by wt prune, to cmd.
+func registerTagFilter(cmd *cobra.Command, tags *[]string) {
+	cmd.Flags().StringSliceVar(tags, "tag", nil, "Only include worktrees with this tag (repeatable; all must match)")
+	cmd.RegisterFlagCompletionFunc("tag", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeTags(), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// validate reports a malformed --branch glob before any work is done.
+func (f worktreeFilter) validate() error {
+	if _, err := path.Match(f.branch, ""); err != nil {
+		return fmt.Errorf("invalid --branch pattern %q: %w", f.branch, err)
+	}
+	return nil
+}
+
+// active reports whether any filter is set.
+func (f worktreeFilter) active() bool {
+	return f.branch != "" || len(f.tags) > 0 || f.needsStatus()
+}
+
+// needsStatus reports whether matching requires the collected status of each
+// worktree rather than just its branch.
+func (f worktreeFilter) needsStatus() bool {
+	return f.dirty || f.clean || f.ahead || f.behind
+}
+
+// matchBranch applies the --branch glob.
+func (f worktreeFilter) matchBranch(branch string) bool {
+	return f.branch == "" || globMatch(f.branch, branch)
+}
+
+// match applies all filters to a collected status row.
+func (f worktreeFilter) match(r statusRow) bool {
+	switch {
+	case !f.matchBranch(r.wt.Branch), !hasTags(r.tags, f.tags):
+		return false
+	case f.dirty && r.status != "dirty":
+		return false
+	case f.clean && r.status != "clean":
+		return false
+	case f.ahead && (r.upstreamErr != nil || r.ahead == 0):
+		return false
+	case f.behind && (r.upstreamErr != nil || r.behind == 0):
+		return false
+	}
+	return true
+}
+
+// filterRows returns the rows that match f.
+func (f worktreeFilter) filterRows(rows []statusRow) []statusRow {
+	if !f.active() {
+		return rows
+	}
+	var matched []statusRow
+	for _, r := range rows {
+		if f.match(r) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// filterWorktrees returns the worktrees that match f. Their status is only
+// collected when a filter needs it, and untracked files are left out of it as
+// in wt status.
+func (f worktreeFilter) filterWorktrees(ctx context.Context, info *repo.Info, worktrees []git.Worktree) ([]git.Worktree, error) {
+	var matched []git.Worktree
+	if !f.needsStatus() {
+		var tags map[string][]string
+		if len(f.tags) > 0 {
+			tags = worktreeTags(info)
+		}
+		for _, wt := range worktrees {
+			if f.matchBranch(wt.Branch) && hasTags(tags[wt.Path], f.tags) {
+				matched = append(matched, wt)
+			}
+		}
+		return matched, nil
+	}
+
+	rows, _, err := collectStatus(ctx, info, "", false, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range f.filterRows(rows) {
+		matched = append(matched, r.wt)
+	}
+	return matched, nil
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var gcClean bool
+
+var gcCmd = &cobra.Command{
+	Use:     "gc",
+	Aliases: []string{"du"},
+	Short:   "Show worktree disk usage and clean build artifacts",
+	Long: `Show the disk usage of each linked worktree, how much of it is taken by
+ignored files (build output, node_modules, caches), and the total size of the
+worktrees directory.
+
+With --clean, offer to delete the ignored files of each worktree
+(git clean -Xdf); --yes deletes them without asking. Untracked files that are
+not ignored are never touched.`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+func init() {
+	gcCmd.Flags().BoolVar(&gcClean, "clean", false, "Offer to delete ignored files in each worktree")
+	rootCmd.AddCommand(gcCmd)
+}
+
+// worktreeUsage is the disk usage of one worktree.
+type worktreeUsage struct {
+	wt      git.Worktree
+	size    int64
+	ignored int64
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	var usages []worktreeUsage
+	for _, wt := range worktrees {
+		if wt.Path == info.MainWorktree || wt.Prunable != "" {
+			continue
+		}
+		u := worktreeUsage{wt: wt, size: diskUsage(wt.Path)}
+		ignored, err := git.IgnoredPaths(ctx, wt.Path)
+		if err != nil {
+			return err
+		}
+		for _, p := range ignored {
+			u.ignored += diskUsage(filepath.Join(wt.Path, p))
+		}
+		usages = append(usages, u)
+	}
+
+	if len(usages) == 0 {
+		fmt.Fprintln(os.Stderr, "No additional worktrees.")
+		return nil
+	}
+
+	t := newTable("BRANCH", "PATH", "SIZE", "IGNORED")
+	for _, u := range usages {
+		t.row(nil, u.wt.Branch, displayPath(info, u.wt.Path), formatBytes(u.size), formatBytes(u.ignored))
+	}
+	if err := t.flush(os.Stderr); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "\nTotal in %s: %s\n", info.WorktreesDir, formatBytes(diskUsage(info.WorktreesDir)))
+
+	if !gcClean {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr)
+	var freed int64
+	for _, u := range usages {
+		if u.ignored == 0 {
+			continue
+		}
+		ok, err := confirmDestructive(fmt.Sprintf("Delete %s of ignored files in %s?", formatBytes(u.ignored), u.wt.Branch))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := git.CleanIgnored(ctx, u.wt.Path); err != nil {
+			return err
+		}
+		freed += u.ignored
+	}
+	fmt.Fprintf(os.Stderr, "Freed %s.\n", formatBytes(freed))
+	return nil
+}
+
+// diskUsage returns the total size of the regular files at or under path.
+// Unreadable entries are skipped; a missing path has size zero.
+func diskUsage(path string) int64 {
+	var total int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				total += fi.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// formatBytes renders n bytes with a binary unit, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
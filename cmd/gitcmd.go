@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/theme"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var gitCmd = &cobra.Command{
+	Use:   "git <git-args>... [--all-worktrees | --branch <glob>]",
+	Short: "Run a git command in several worktrees",
+	Long: `Run a git command in the current worktree, in every worktree with
+--all-worktrees, or in the worktrees whose branch matches --branch. Each
+worktree's output follows a header naming it.
+
+--all-worktrees and --branch may appear anywhere, and wt's global flags before
+"git"; every other argument goes to git as is, so e.g. 'wt git fetch --all
+--all-worktrees' runs 'git fetch --all' in each worktree. git runs without a
+pager.`,
+	Example: `  wt git status --short --all-worktrees
+  wt git log --oneline -3 --branch 'feature/*'
+  wt git pull --ff-only --all-worktrees`,
+	DisableFlagParsing: true,
+	RunE:               runGit,
+}
+
+func init() {
+	rootCmd.AddCommand(gitCmd)
+}
+
+// gitTargets are the worktree selection options of wt git.
+type gitTargets struct {
+	all    bool
+	branch string
+}
+
+// parseGitArgs separates wt git's own options from the arguments for git.
+func parseGitArgs(args []string) (gitTargets, []string, error) {
+	var t gitTargets
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--all-worktrees":
+			t.all = true
+		case arg == "--branch":
+			if i+1 == len(args) {
+				return t, nil, errors.New("--branch needs a glob, e.g. --branch 'feature/*'")
+			}
+			i++
+			t.branch = args[i]
+		case strings.HasPrefix(arg, "--branch="):
+			t.branch = strings.TrimPrefix(arg, "--branch=")
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if t.all && t.branch != "" {
+		return t, nil, errors.New("--all-worktrees and --branch cannot be used together")
+	}
+	if _, err := path.Match(t.branch, ""); err != nil {
+		return t, nil, fmt.Errorf("invalid --branch pattern %q: %w", t.branch, err)
+	}
+	return t, rest, nil
+}
+
+// leadingGlobalFlags returns how many of args, from the start, are wt's
+// global flags (with their values). Flag parsing is disabled for wt git, so
+// e.g. 'wt --no-color git log' passes --no-color along with git's arguments.
+func leadingGlobalFlags(args []string) int {
+	flags := rootCmd.PersistentFlags()
+	n := 0
+	for n < len(args) && strings.HasPrefix(args[n], "-") && args[n] != "--" {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[n], "-"), "=")
+		var f *pflag.Flag
+		if strings.HasPrefix(args[n], "--") {
+			f = flags.Lookup(name)
+		} else if len(name) == 1 {
+			f = flags.ShorthandLookup(name)
+		}
+		if f == nil {
+			break
+		}
+		n++
+		if f.Value.Type() != "bool" && !hasValue {
+			n++
+		}
+	}
+	return min(n, len(args))
+}
+
+func runGit(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if n := leadingGlobalFlags(args); n > 0 {
+		if err := rootCmd.PersistentFlags().Parse(args[:n]); err != nil {
+			return err
+		}
+		if err := persistentPreRun(cmd, nil); err != nil {
+			return err
+		}
+		args = args[n:]
+	}
+	targets, gitArgs, err := parseGitArgs(args)
+	if err != nil {
+		return err
+	}
+	if len(gitArgs) == 0 || gitArgs[0] == "-h" || gitArgs[0] == "--help" {
+		return cmd.Help()
+	}
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	var selected []git.Worktree
+	switch {
+	case targets.all:
+		selected = worktrees
+	case targets.branch != "":
+		for _, wt := range worktrees {
+			if globMatch(targets.branch, wt.Branch) {
+				selected = append(selected, wt)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("no worktree's branch matches %q", targets.branch)
+		}
+	default:
+		wt, ok := currentWorktree(worktrees)
+		if !ok {
+			return errors.New("not inside a worktree; use --all-worktrees or --branch to choose worktrees")
+		}
+		selected = []git.Worktree{wt}
+	}
+
+	header := theme.Current().Highlight
+	failed := 0
+	for i, wt := range selected {
+		if wt.Prunable != "" {
+			continue
+		}
+		if len(selected) > 1 {
+			if i > 0 {
+				fmt.Fprintln(os.Stderr)
+			}
+			fmt.Fprintln(os.Stderr, header.Render(fmt.Sprintf("==> %s (%s)", worktreeName(wt), displayPath(info, wt.Path))))
+		}
+		// Output goes to stderr like the rest of wt's, as shell wrappers from
+		// older wt versions capture stdout until wt exits
+		err := git.Passthrough(ctx, wt.Path, os.Stderr, os.Stderr, append([]string{"--no-pager"}, gitArgs...)...)
+		if interrupted(ctx) {
+			return err
+		}
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		if len(selected) == 1 {
+			return fmt.Errorf("git %s failed", gitArgs[0])
+		}
+		return fmt.Errorf("git %s failed in %d of %d worktrees", gitArgs[0], failed, len(selected))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/history"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyOp     string
+	historyBranch string
+	historyUser   string
+	historySince  string
+	historyFailed bool
+	historyLimit  int
+)
+
+var historyOps = []string{history.Create, history.Remove, history.Switch, history.Prune}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the log of wt operations",
+	Long: `Show the worktrees created, removed, switched to, and pruned in this
+repository, with who did it and whether it succeeded. The log is kept in
+.git/wt/history.log and shared by all worktrees.
+
+--since takes an age (e.g. 48h, 7d, or 2w) or a date (2006-01-02). Only the most
+recent --limit matching events are shown.`,
+	Args: cobra.NoArgs,
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVar(&historyOp, "op", "", "Only show one operation: create, remove, switch, or prune")
+	historyCmd.Flags().StringVar(&historyBranch, "branch", "", "Only show events whose branch matches the glob")
+	historyCmd.Flags().StringVar(&historyUser, "user", "", "Only show events by this user")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "Only show events since an age ago (e.g. 7d) or a date")
+	historyCmd.Flags().BoolVar(&historyFailed, "failed", false, "Only show failed operations")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 50, "Show at most this many events (0 for all)")
+	historyCmd.RegisterFlagCompletionFunc("op", cobra.FixedCompletions(historyOps, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	if historyOp != "" && !slices.Contains(historyOps, historyOp) {
+		return fmt.Errorf("unknown operation %q (valid: create, remove, switch, prune)", historyOp)
+	}
+	if _, err := path.Match(historyBranch, ""); err != nil {
+		return fmt.Errorf("invalid --branch pattern %q: %w", historyBranch, err)
+	}
+	since, err := parseSince(historySince, time.Now())
+	if err != nil {
+		return err
+	}
+
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	events, err := history.New(info.StateDir()).Read()
+	if err != nil {
+		return err
+	}
+
+	var shown []history.Event
+	for _, e := range events {
+		switch {
+		case historyOp != "" && e.Op != historyOp:
+		case historyBranch != "" && !globMatch(historyBranch, e.Branch):
+		case historyUser != "" && e.User != historyUser:
+		case e.Time.Before(since):
+		case historyFailed && e.Outcome == history.OK:
+		default:
+			shown = append(shown, e)
+		}
+	}
+	if historyLimit > 0 && len(shown) > historyLimit {
+		shown = shown[len(shown)-historyLimit:]
+	}
+
+	if len(shown) == 0 {
+		fmt.Fprintln(os.Stderr, "No matching history.")
+		return nil
+	}
+
+	t := newTable("TIME", "OP", "BRANCH", "PATH", "USER", "OUTCOME")
+	failedStyle := theme.Current().Disabled
+	for _, e := range shown {
+		var style *lipgloss.Style
+		if e.Outcome != history.OK {
+			style = &failedStyle
+		}
+		rel := e.Path
+		if e.Path != "" {
+			rel = displayPath(info, e.Path)
+		}
+		outcome, _, _ := strings.Cut(e.Outcome, "\n")
+		t.row(style, e.Time.Local().Format("2006-01-02 15:04:05"), e.Op, e.Branch, rel, e.User, outcome)
+	}
+	return t.flush(os.Stderr)
+}
+
+// parseSince turns a --since value into a point in time: an age before now
+// (see config.ParseAge) or a calendar date in local time. Empty means the
+// beginning of time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := config.ParseAge(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: want an age like 48h or 90d, or a date like 2006-01-02", value)
+}
+
+// globMatch reports whether name matches a validated glob pattern.
+func globMatch(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
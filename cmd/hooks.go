@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/hooks"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Inspect and run the configured hooks",
+	Long: `Inspect and run the commands configured in the [hooks] section of the config.
+
+post-create commands run in a new worktree after wt create; post-switch
+commands run in your shell, through the shell wrapper, after it changes into
+a worktree.
+
+Each linked worktree has a slot: a small number no other worktree has, kept
+for as long as the worktree exists, in WT_SLOT and {{slot}} (0 in the main
+worktree). Derive what must differ between worktrees running side by side from
+it, e.g. a dev server port with $((3000 + WT_SLOT)) or a docker compose project
+name with app-{{slot}}.
+
+Hooks from a repository's .wt.toml were written by whoever controls the
+repository, so they only run once you have approved them: wt asks on a
+terminal, or approve them with wt hooks trust after reviewing them with
+wt hooks list. The same goes for the repository's [alias] table and editor
+setting. When they change, they need approval again. Hooks, aliases, and the
+editor from your user config always run.`,
+}
+
+var hooksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the configured hook commands",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksList,
+}
+
+var hooksRunCmd = &cobra.Command{
+	Use:   "run <hook> [branch]",
+	Short: "Run a hook in an existing worktree",
+	Long: `Run the commands of a hook in an existing worktree, by default the one you are
+in, e.g. to re-run the post-create bootstrap after pulling dependency changes.
+
+post-create commands run in the worktree with the same WT_* variables and
+placeholders as after wt create; {{base}} is the ref the branch was created
+from, if wt recorded it. post-switch commands are handed to the shell wrapper,
+which changes into the worktree and runs them in your shell.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runHooksRun,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch len(args) {
+		case 0:
+			return hooks.Names, cobra.ShellCompDirectiveNoFileComp
+		case 1:
+			return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+var hooksTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Approve the commands of the repository's .wt.toml",
+	Long: `Approve the hook commands, the command aliases, and the editor of the
+repository's .wt.toml to run, after reviewing them with wt hooks list. The
+approval holds until they change.`,
+	Args: cobra.NoArgs,
+	RunE: runHooksTrust,
+}
+
+func init() {
+	hooksCmd.AddCommand(hooksListCmd)
+	hooksCmd.AddCommand(hooksRunCmd)
+	hooksCmd.AddCommand(hooksTrustCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
+
+// hookCommands returns the configured commands of the named hook.
+func hookCommands(name string) ([]string, error) {
+	switch name {
+	case hooks.PostCreate:
+		return cfg.Hooks.PostCreate, nil
+	case hooks.PostSwitch:
+		return cfg.Hooks.PostSwitch, nil
+	}
+	return nil, fmt.Errorf("unknown hook %q (hooks: %s)", name, strings.Join(hooks.Names, ", "))
+}
+
+func runHooksList(cmd *cobra.Command, args []string) error {
+	t := newTable("HOOK", "COMMAND")
+	empty := true
+	for _, name := range hooks.Names {
+		commands, _ := hookCommands(name)
+		for _, command := range commands {
+			// Multi-line commands are shown by their first line
+			first, _, more := strings.Cut(command, "\n")
+			if more {
+				first += " …"
+			}
+			t.row(nil, name, first)
+			empty = false
+		}
+	}
+	if empty {
+		fmt.Fprintln(os.Stderr, "No hooks configured. Add commands under [hooks] in .wt.toml, e.g. post-create = [\"npm install\"]")
+	} else if err := t.flush(os.Stderr); err != nil {
+		return err
+	}
+	// Aliases and the editor of the repository need approval too, so they
+	// are shown here
+	var others []repoCommand
+	for _, rc := range repoCommands(cfg) {
+		if rc.kind != "hook" {
+			others = append(others, rc)
+		}
+	}
+	if len(others) > 0 {
+		fmt.Fprintf(os.Stderr, "\nOther commands of %s:\n", config.RepoFileName)
+		for _, rc := range others {
+			if rc.kind == "editor" {
+				fmt.Fprintf(os.Stderr, "  editor = %s\n", rc.command)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "  %s %s = %s\n", rc.kind, rc.name, rc.command)
+		}
+	}
+	if info, err := repo.Resolve(); err == nil && !repoCommandsTrusted(info, cfg) {
+		fmt.Fprintf(os.Stderr, "\nThe commands of %s have not been approved to run; approve them with: wt hooks trust\n", config.RepoFileName)
+	}
+	return nil
+}
+
+func runHooksTrust(cmd *cobra.Command, args []string) error {
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	digest := repoCommandsDigest(cfg)
+	if digest == "" {
+		fmt.Fprintf(os.Stderr, "%s has no commands to approve.\n", config.RepoFileName)
+		return nil
+	}
+	printRepoCommands(cfg)
+	if err := trustRepoCommands(info, digest); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Approved the commands of %s.\n", config.RepoFileName)
+	return nil
+}
+
+// repoCommand is a command the repository's .wt.toml sets for wt to run.
+type repoCommand struct {
+	kind    string // "hook", "alias", or "editor"
+	name    string // the hook or alias name
+	command string
+}
+
+// repoCommands lists the commands c takes from the repository's .wt.toml:
+// its hook commands, its aliases, which may add flags such as --exec to a
+// command line, and its editor. They only run once the user has approved
+// them.
+func repoCommands(c *config.Config) []repoCommand {
+	var commands []repoCommand
+	for _, name := range hooks.Names {
+		if !c.SetByRepo("hooks." + name) {
+			continue
+		}
+		list := c.Hooks.PostCreate
+		if name == hooks.PostSwitch {
+			list = c.Hooks.PostSwitch
+		}
+		for _, command := range list {
+			commands = append(commands, repoCommand{"hook", name, command})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Alias)) {
+		if c.SetByRepo("alias." + name) {
+			commands = append(commands, repoCommand{"alias", name, c.Alias[name]})
+		}
+	}
+	if c.Editor != "" && c.SetByRepo("editor") {
+		commands = append(commands, repoCommand{"editor", "editor", c.Editor})
+	}
+	return commands
+}
+
+// repoCommandsDigest returns a digest of the commands of the repository's
+// .wt.toml (see repoCommands), or "" when it sets none.
+func repoCommandsDigest(c *config.Config) string {
+	commands := repoCommands(c)
+	if len(commands) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, rc := range commands {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", rc.kind, rc.name, rc.command)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// repoCommandsTrusted reports whether the commands of the repository's
+// .wt.toml in c, if it has any, are the ones the user approved.
+func repoCommandsTrusted(info *repo.Info, c *config.Config) bool {
+	digest := repoCommandsDigest(c)
+	if digest == "" {
+		return true
+	}
+	st, err := state.New(info.StateDir()).Load()
+	return err == nil && st.TrustedHooks == digest
+}
+
+// trustRepoCommands records the commands with digest as approved.
+func trustRepoCommands(info *repo.Info, digest string) error {
+	return state.New(info.StateDir()).Update(func(st *state.State) error {
+		st.TrustedHooks = digest
+		return nil
+	})
+}
+
+// printRepoCommands lists the commands of the repository's .wt.toml on
+// stderr, in full.
+func printRepoCommands(c *config.Config) {
+	fmt.Fprintf(os.Stderr, "Commands of %s:\n", config.RepoFileName)
+	for _, rc := range repoCommands(c) {
+		fmt.Fprintf(os.Stderr, "  %s %s: %s\n", rc.kind, rc.name, strings.ReplaceAll(rc.command, "\n", "\n    "))
+	}
+}
+
+// repoCommandsAllowed reports whether the commands of the repository's
+// .wt.toml may run, asking the user on a terminal when they have not been
+// approved yet. what names the command about to run, for the warning shown
+// when it is skipped.
+func repoCommandsAllowed(info *repo.Info, what string) bool {
+	if repoCommandsTrusted(info, cfg) {
+		return true
+	}
+	if isInteractive() {
+		printRepoCommands(cfg)
+		if confirm(fmt.Sprintf("Run the commands of %s? They were written by whoever controls the repository", config.RepoFileName)) {
+			if err := trustRepoCommands(info, repoCommandsDigest(cfg)); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+			}
+			return true
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Skipped %s of %s: it has not been approved to run; review it with 'wt hooks list' and approve it with 'wt hooks trust'\n", what, config.RepoFileName)
+	return false
+}
+
+// hooksAllowed reports whether the commands of the named hook may run: always
+// when they come from the user config, and when they come from the
+// repository's .wt.toml, once the user approved them. On a terminal the user
+// is asked; otherwise unapproved commands are skipped with a warning.
+func hooksAllowed(info *repo.Info, name string) bool {
+	return !cfg.SetByRepo("hooks."+name) || repoCommandsAllowed(info, "the "+name+" hook")
+}
+
+func runHooksRun(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	name := args[0]
+	commands, err := hookCommands(name)
+	if err != nil {
+		return err
+	}
+	if len(commands) == 0 {
+		return fmt.Errorf("no %s hook is configured", name)
+	}
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	if !hooksAllowed(info, name) {
+		return fmt.Errorf("the %s hook has not been approved to run", name)
+	}
+
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+	var wt git.Worktree
+	if len(args) == 2 {
+		if wt, err = matchWorktree(worktrees, worktreeAliases(info), args[1]); err != nil {
+			return err
+		}
+	} else {
+		var ok bool
+		if wt, ok = currentWorktree(worktrees); !ok {
+			return fmt.Errorf("not inside a worktree; name the branch whose worktree to run the %s hook in", name)
+		}
+	}
+
+	if name == hooks.PostSwitch {
+		emitSwitch(wt.Path, wt.Branch)
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Running %s hook in %s\n", name, displayPath(info, wt.Path))
+	return hooks.RunContext(ctx, name, commands, hookContext(info, wt), wt.Path, os.Stderr)
+}
+
+// hookContext describes wt to the commands run for it, with the base and
+// environment variables recorded for it.
+func hookContext(info *repo.Info, wt git.Worktree) hooks.Context {
+	hc := hooks.Context{
+		Branch:       wt.Branch,
+		Path:         wt.Path,
+		MainWorktree: info.MainWorktree,
+		RepoName:     info.RepoName,
+		Slot:         worktreeSlot(info, wt.Path),
+	}
+	if st, err := state.New(info.StateDir()).Load(); err == nil {
+		if rec, ok := st.Lookup(wt.Path); ok {
+			hc.Base = rec.Base
+			hc.WorktreeEnv = rec.Env
+		}
+	}
+	return hc
+}
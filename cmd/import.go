@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var importMove bool
+
+var importCmd = &cobra.Command{
+	Use:   "import <path>",
+	Short: "Adopt a worktree created outside wt",
+	Long:  "Adopt an existing worktree created with 'git worktree add' so wt tracks it.\nWith --move, the worktree is relocated into the worktrees directory under its sanitized branch name.",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runImport,
+}
+
+func init() {
+	importCmd.Flags().BoolVar(&importMove, "move", false, "Move the worktree into the worktrees directory")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+
+	path, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	var target *git.Worktree
+	for i := range worktrees {
+		if worktrees[i].Path == path {
+			target = &worktrees[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("%s is not a worktree of this repository; create it with 'git worktree add' or 'wt create'", path)
+	}
+	if target.Path == info.MainWorktree {
+		return fmt.Errorf("%s is the main worktree", path)
+	}
+
+	finalPath := target.Path
+	if importMove {
+		dest := worktreeDir(info, worktrees, target.Branch)
+		if dest != target.Path {
+			if _, err := os.Stat(dest); err == nil {
+				return fmt.Errorf("cannot move worktree: %s already exists", dest)
+			}
+			if err := prepareWorktreesDir(info, worktrees); err != nil {
+				return err
+			}
+			if err := git.MoveWorktree(ctx, target.Path, dest); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Moved worktree from %s to %s\n", target.Path, dest)
+			finalPath = dest
+		}
+	}
+
+	err = state.New(info.StateDir()).Update(func(st *state.State) error {
+		if finalPath != target.Path {
+			st.Forget(target.Path)
+		}
+		wt := st.Worktree(finalPath)
+		wt.Branch = target.Branch
+		if wt.Flags == nil {
+			wt.Flags = make(map[string]bool)
+		}
+		wt.Flags["imported"] = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Imported worktree for branch %q at %s\n", target.Branch, finalPath)
+	if finalPath != target.Path {
+		emitSwitch(finalPath, target.Branch)
+	}
+	return nil
+}
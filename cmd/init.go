@@ -1,21 +1,29 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/provenimpact/wt/internal/shell"
 	"github.com/spf13/cobra"
 )
 
+var (
+	initCompletion bool
+	initBind       string
+)
+
 var initCmd = &cobra.Command{
 	Use:   "init <shell>",
 	Short: "Output shell integration function",
-	Long:  "Output a shell function that wraps the wt binary to enable directory changing.\n\nSupported shells: bash, zsh, fish\n\nAdd to your shell config:\n  eval \"$(wt init bash)\"   # for .bashrc\n  eval \"$(wt init zsh)\"    # for .zshrc\n  wt init fish | source    # for config.fish",
+	Long:  "Output a shell function that wraps the wt binary to enable directory changing.\n\nSupported shells: bash, zsh, fish\n\nAdd to your shell config:\n  eval \"$(wt init bash)\"   # for .bashrc\n  eval \"$(wt init zsh)\"    # for .zshrc\n  wt init fish | source    # for config.fish\n\nWith --completion, the completion script is included as well, so the one\nline sets up everything. For zsh it works whether it runs before or after\ncompinit, without touching fpath:\n  eval \"$(wt init zsh --completion)\"\n\nWith --bind, a key such as ctrl-g or alt-w is bound in the shell's line editor\nto the interactive selector; choosing a worktree changes into it:\n  eval \"$(wt init bash --bind ctrl-g)\"",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runInit,
 }
 
 func init() {
+	initCmd.Flags().BoolVar(&initCompletion, "completion", false, "Include the completion script")
+	initCmd.Flags().StringVar(&initBind, "bind", "", "Bind a key (ctrl-<letter> or alt-<letter>) to the interactive selector")
 	rootCmd.AddCommand(initCmd)
 }
 
@@ -26,6 +34,23 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if initCompletion {
+		var script bytes.Buffer
+		if err := generateCompletion(&script, shellName); err != nil {
+			return err
+		}
+		if code, err = shell.GenerateWithCompletion(shellName, script.String()); err != nil {
+			return err
+		}
+	}
+
+	if initBind != "" {
+		binding, err := shell.Binding(shellName, initBind)
+		if err != nil {
+			return err
+		}
+		code += "\n" + binding
+	}
 
 	// Shell function code goes to stdout so it can be eval'd
 	fmt.Print(code)
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// errInterrupted is the cause of the command context's cancellation when wt
+// receives Ctrl-C or SIGTERM.
+var errInterrupted = errors.New("interrupted")
+
+// interruptContext returns a context that is cancelled with errInterrupted on
+// the first interrupt, so running git commands and hooks are stopped and the
+// command can clean up. A second interrupt terminates wt at once.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+	return ctx, func() { cancel(nil) }
+}
+
+// interrupted reports whether ctx was cancelled by an interrupt.
+func interrupted(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errInterrupted)
+}
@@ -1,35 +1,51 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"path/filepath"
-	"text/tabwriter"
+	"slices"
 
 	"github.com/provenimpact/wt/internal/git"
 	"github.com/provenimpact/wt/internal/repo"
 	"github.com/spf13/cobra"
 )
 
+var (
+	listLong   bool
+	listFilter worktreeFilter
+	listLayout tableLayout
+)
+
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all worktrees",
-	Long:  "List all git worktrees for the current repository.",
+	Long:  "List all git worktrees for the current repository.\nWith --long, branch descriptions (see wt describe) are shown as well.\n\n--branch, --tag, --dirty, --clean, --ahead, and --behind limit the list to\nmatching worktrees; combined filters must all match. As in wt status, untracked\nfiles do not make a worktree dirty.\n\nWorktrees created with wt create --project show their monorepo project, and\ntagged worktrees their tags (see wt tag).\n\n--columns picks the columns to show, in order, from: branch, path, status,\nupstream, ahead, behind, vs, main, age, note, project, tags, and description, e.g.\n--columns branch,path,ahead,behind,note. --sort orders the worktrees by branch,\npath, age (oldest first), or status (those needing attention first); without it\ngit's order is kept. Columns and sort keys that need each worktree's status\ncheck it as wt status does.",
 	Args:  cobra.NoArgs,
 	RunE:  runList,
 }
 
 func init() {
+	listCmd.Flags().BoolVarP(&listLong, "long", "l", false, "Show branch descriptions")
+	listFilter.register(listCmd)
+	listLayout.register(listCmd)
 	rootCmd.AddCommand(listCmd)
 }
 
 func runList(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if err := listFilter.validate(); err != nil {
+		return err
+	}
+	if err := listLayout.validate(); err != nil {
+		return err
+	}
 	info, err := repo.Resolve()
 	if err != nil {
 		return err
 	}
 
-	worktrees, err := git.ListWorktrees()
+	worktrees, err := git.ListWorktrees(ctx)
 	if err != nil {
 		return err
 	}
@@ -48,17 +64,81 @@ func runList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "BRANCH\tPATH\tMAIN")
+	if listFilter.active() {
+		if worktrees, err = listFilter.filterWorktrees(ctx, info, worktrees); err != nil {
+			return err
+		}
+		if len(worktrees) == 0 {
+			fmt.Fprintln(os.Stderr, "No worktrees match the filters.")
+			return nil
+		}
+	}
 
-	for _, wt := range worktrees {
-		isMain := ""
-		if wt.Path == info.MainWorktree {
-			isMain = "*"
+	rows, err := listRows(ctx, info, worktrees)
+	if err != nil {
+		return err
+	}
+	// Projects and tags are only known once annotated, but whether
+	// descriptions are needed does not depend on them
+	annotateRows(ctx, info, rows, listColumns(nil))
+	columns := listColumns(rows)
+	if !slices.Contains(columns, "status") {
+		for i := range rows {
+			if rows[i].wt.Prunable != "" {
+				rows[i].rel += " (prunable)"
+			}
+		}
+	}
+	listLayout.sortRows(rows)
+
+	if err := renderTable(os.Stderr, rows, columns, ""); err != nil {
+		return err
+	}
+	printPruneHint(os.Stderr, worktrees)
+	return nil
+}
+
+// listRows returns a row for each of worktrees. Their status is only
+// collected when the chosen columns or sort order need it.
+func listRows(ctx context.Context, info *repo.Info, worktrees []git.Worktree) ([]statusRow, error) {
+	if !listLayout.needsStatus() {
+		rows := make([]statusRow, len(worktrees))
+		for i, wt := range worktrees {
+			rows[i] = statusRow{wt: wt, rel: displayPath(info, wt.Path), isMain: wt.Path == info.MainWorktree}
 		}
-		rel, _ := filepath.Rel(filepath.Dir(info.MainWorktree), wt.Path)
-		fmt.Fprintf(w, "%s\t%s\t%s\n", wt.Branch, rel, isMain)
+		return rows, nil
 	}
 
-	return w.Flush()
+	all, _, err := collectStatus(ctx, info, "", false, false)
+	if err != nil {
+		return nil, err
+	}
+	var rows []statusRow
+	for _, row := range all {
+		if slices.ContainsFunc(worktrees, func(wt git.Worktree) bool { return wt.Path == row.wt.Path }) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// listColumns returns the --columns, or else the branch, path, and main
+// columns with the project column once a monorepo project worktree exists and
+// the tags column once a worktree is tagged. --long adds the description
+// column to either.
+func listColumns(rows []statusRow) []string {
+	columns := slices.Clone(listLayout.columns)
+	if len(columns) == 0 {
+		columns = []string{"branch", "path", "main"}
+		if slices.ContainsFunc(rows, func(r statusRow) bool { return r.project != "" }) {
+			columns = append(columns, "project")
+		}
+		if slices.ContainsFunc(rows, func(r statusRow) bool { return len(r.tags) > 0 }) {
+			columns = append(columns, "tags")
+		}
+	}
+	if listLong && !slices.Contains(columns, "description") {
+		columns = append(columns, "description")
+	}
+	return columns
 }
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var maintenanceNoSchedule bool
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Turn git maintenance on or off for the repository",
+	Long: `Turn git's background maintenance on or off for the repository. All
+worktrees share one object store, so maintenance run for the repository speeds
+up git in every worktree. 'wt doctor --maintenance' shows its state.`,
+}
+
+var maintenanceEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Keep the repository maintained in the background",
+	Long: `Register the repository for git maintenance and schedule it with the
+system scheduler (git maintenance start): hourly prefetch, commit-graph, and
+loose object tasks, and a daily incremental repack with a multi-pack-index.
+
+With --no-schedule, the repository is only registered (git maintenance
+register), for when a schedule already exists or maintenance is run by hand
+with 'git maintenance run --schedule=hourly'.`,
+	Args: cobra.NoArgs,
+	RunE: runMaintenanceEnable,
+}
+
+var maintenanceDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Stop maintaining the repository in the background",
+	Long: `Unregister the repository from git maintenance. The system schedule is
+left in place for other registered repositories; 'git maintenance stop'
+removes it.`,
+	Args: cobra.NoArgs,
+	RunE: runMaintenanceDisable,
+}
+
+func init() {
+	maintenanceEnableCmd.Flags().BoolVar(&maintenanceNoSchedule, "no-schedule", false, "Register the repository without scheduling maintenance")
+	maintenanceCmd.AddCommand(maintenanceEnableCmd, maintenanceDisableCmd)
+	rootCmd.AddCommand(maintenanceCmd)
+}
+
+func runMaintenanceEnable(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	if err := git.StartMaintenance(ctx, info.MainWorktree, !maintenanceNoSchedule); err != nil {
+		return err
+	}
+	if maintenanceNoSchedule {
+		fmt.Fprintf(os.Stderr, "Registered %s for git maintenance; it runs with 'git maintenance run --schedule=hourly'.\n", info.MainWorktree)
+	} else {
+		fmt.Fprintf(os.Stderr, "Enabled background maintenance for %s.\n", info.MainWorktree)
+	}
+	return nil
+}
+
+func runMaintenanceDisable(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	m, err := git.MaintenanceState(ctx, info.MainWorktree, info.GitCommonDir)
+	if err != nil {
+		return err
+	}
+	if !m.Registered {
+		fmt.Fprintf(os.Stderr, "Maintenance is not enabled for %s.\n", info.MainWorktree)
+		return nil
+	}
+	if err := git.StopMaintenance(ctx, info.MainWorktree); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Disabled background maintenance for %s.\n", info.MainWorktree)
+	return nil
+}
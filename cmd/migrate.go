@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/names"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var migrateDryRun bool
+
+var migrateLayoutCmd = &cobra.Command{
+	Use:   "migrate-layout",
+	Short: "Move worktrees to their conventional directory names",
+	Long: `Find worktrees in the worktrees directory whose directory does not match
+the sanitized branch name used by wt create, such as nested fix/bug-123
+directories from older versions, and move them with git worktree move. After
+changing the [names] table of the config, this moves the worktrees to the new
+names:
+
+  [names]
+  nested = true       # keep "/" in branch names as directories
+  replacement = "_"   # for unsafe characters, instead of "-"
+  max-length = 40     # cut longer names, ending them in a hash
+  lowercase = true
+
+With --dry-run, only show what would be moved. Worktrees whose conventional
+directory is already taken are skipped.`,
+	Args: cobra.NoArgs,
+	RunE: runMigrateLayout,
+}
+
+func init() {
+	migrateLayoutCmd.Flags().BoolVarP(&migrateDryRun, "dry-run", "n", false, "Show what would be moved without changing anything")
+	rootCmd.AddCommand(migrateLayoutCmd)
+}
+
+// layoutMove is a worktree whose directory does not follow the convention.
+type layoutMove struct {
+	wt   git.Worktree
+	dest string
+}
+
+func runMigrateLayout(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	moves := layoutMoves(info, worktrees)
+	if len(moves) == 0 {
+		fmt.Fprintln(os.Stderr, "All worktrees already follow the layout.")
+		return nil
+	}
+
+	cwd, _ := os.Getwd()
+	var cdTarget string
+	failed := 0
+	for _, m := range moves {
+		from := displayPath(info, m.wt.Path)
+		to := displayPath(info, m.dest)
+		if _, err := os.Stat(m.dest); err == nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %s already exists\n", from, to)
+			failed++
+			continue
+		}
+		if migrateDryRun {
+			fmt.Fprintf(os.Stderr, "Would move %s to %s\n", from, to)
+			continue
+		}
+		// A nested name needs its parent directories
+		if err := os.MkdirAll(filepath.Dir(m.dest), 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to move %s: %s\n", from, err)
+			failed++
+			continue
+		}
+		if err := git.MoveWorktree(ctx, m.wt.Path, m.dest); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to move %s: %s\n", from, err)
+			failed++
+			continue
+		}
+		state.New(info.StateDir()).Update(func(st *state.State) error {
+			st.Move(m.wt.Path, m.dest)
+			return nil
+		})
+		info.CleanEmptyParents(m.wt.Path)
+		fmt.Fprintf(os.Stderr, "Moved %s to %s\n", from, to)
+
+		// The shell is still in the old directory; follow the move
+		if rest, ok := pathWithin(cwd, m.wt.Path); ok {
+			cdTarget = filepath.Join(m.dest, rest)
+		}
+	}
+
+	if !migrateDryRun && failed < len(moves) {
+		syncWorkspace(ctx, info)
+	}
+	if cdTarget != "" {
+		emitDirectives("__wt_cd:" + cdTarget)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d worktree(s) could not be moved", failed)
+	}
+	return nil
+}
+
+// layoutMoves returns the worktrees inside the worktrees directory that are
+// not at their conventional path. Detached and missing worktrees are left
+// alone, since they have no branch name to derive it from or nothing to move.
+// A worktree whose sanitized name belongs to another worktree goes to, or may
+// stay at, the disambiguated name that wt create would have given it.
+// Worktrees named with wt create --dir-name keep their names.
+func layoutMoves(info *repo.Info, worktrees []git.Worktree) []layoutMove {
+	st, err := state.New(info.StateDir()).Load()
+	if err != nil {
+		// Best effort: without state, chosen directory names are not known
+		st = &state.State{}
+	}
+	var moves []layoutMove
+	for _, wt := range worktrees {
+		if wt.Path == info.MainWorktree || wt.Prunable != "" || wt.Branch == "" || wt.Branch == "(detached)" {
+			continue
+		}
+		if rec, ok := st.Lookup(wt.Path); ok && rec.DirName == filepath.Base(wt.Path) {
+			continue
+		}
+		if _, ok := pathWithin(wt.Path, info.WorktreesDir); !ok {
+			continue
+		}
+		dest := filepath.Join(info.WorktreesDir, names.Sanitize(wt.Branch, nameOptions))
+		unique := filepath.Join(info.WorktreesDir, names.Disambiguate(wt.Branch, nameOptions))
+		if other, ok := worktreeAt(worktrees, dest); ok && other.Path != wt.Path {
+			dest = unique
+		}
+		if dest != wt.Path && unique != wt.Path {
+			moves = append(moves, layoutMove{wt: wt, dest: dest})
+		}
+	}
+	return moves
+}
+
+// pathWithin reports whether path is dir or inside it, and returns the part
+// of path below dir.
+func pathWithin(path, dir string) (string, bool) {
+	if path == dir {
+		return "", true
+	}
+	rest, ok := strings.CutPrefix(path, dir+string(filepath.Separator))
+	return rest, ok
+}
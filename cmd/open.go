@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/editor"
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open [name]",
+	Short: "Open a worktree in your editor",
+	Long:  "Open a worktree in the editor set by the 'editor' config key, $VISUAL, or $EDITOR.\nIf no name is given, an interactive selector is shown.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runOpen,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(openCmd)
+}
+
+// configuredEditor returns the editor setting of the config. An editor set
+// by the repository's .wt.toml runs as the user like its hooks, so it is
+// ignored with a warning until approved with wt hooks trust, and $VISUAL or
+// $EDITOR is used instead.
+func configuredEditor(info *repo.Info) string {
+	if cfg.Editor == "" || !cfg.SetByRepo("editor") || repoCommandsTrusted(info, cfg) {
+		return cfg.Editor
+	}
+	fmt.Fprintf(os.Stderr, "Warning: ignoring editor %q from %s: it has not been approved to run; review it with 'wt hooks list' and approve it with 'wt hooks trust'\n", cfg.Editor, config.RepoFileName)
+	return ""
+}
+
+func runOpen(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	spec, err := editor.Resolve(configuredEditor(info))
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	var target git.Worktree
+	if len(args) == 1 {
+		if target, err = matchWorktree(worktrees, worktreeAliases(info), args[0]); err != nil {
+			return err
+		}
+	} else {
+		if err := requireInteractive("'wt open <name>'"); err != nil {
+			return err
+		}
+		descs := branchDescriptions(ctx)
+		aliases := worktreeAliases(info)
+		tags := worktreeTags(info)
+		current, _ := currentWorktree(worktrees)
+		var entries []tui.Entry
+		for _, wt := range worktrees {
+			entries = append(entries, tui.Entry{Branch: wt.Branch, Path: wt.Path, Rel: displayPath(info, wt.Path), Alias: aliases[wt.Path], Description: descs[wt.Branch], Tags: tags[wt.Path], Current: wt.Path == current.Path})
+		}
+		selected, err := tui.Select(entries, current.Path, selectorStatus(ctx))
+		if err != nil {
+			return err
+		}
+		if selected == "" {
+			return nil // User cancelled
+		}
+		for _, wt := range worktrees {
+			if wt.Path == selected {
+				target = wt
+			}
+		}
+	}
+
+	recordUse(info, target.Path, target.Branch)
+	fmt.Fprintf(os.Stderr, "Opening %s in %s\n", target.Path, spec)
+	return openInEditor(spec, target.Path)
+}
+
+// openInEditor runs the editor spec on path.
+func openInEditor(spec, path string) error {
+	if err := editor.Open(spec, path); err != nil {
+		return fmt.Errorf("opening editor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var pathCmd = &cobra.Command{
+	Use:   "path [name]",
+	Short: "Print the absolute path of a worktree",
+	Long:  "Print the absolute path of a worktree to stdout, without the cd sentinel.\nIf no name is given, the main worktree path is printed.\n\nUseful when the shell wrapper is not installed:\n  cd \"$(wt path feature-x)\"",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runPath,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pathCmd)
+}
+
+func runPath(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		fmt.Println(info.MainWorktree)
+		return nil
+	}
+
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	wt, ok := findWorktree(worktrees, worktreeAliases(info), args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Worktree %q not found.\n", args[0])
+		return fmt.Errorf("worktree %q not found", args[0])
+	}
+
+	fmt.Println(wt.Path)
+	return nil
+}
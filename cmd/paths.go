@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/names"
+	"github.com/provenimpact/wt/internal/repo"
+)
+
+// Path styles, set with path-style in the config or --path-style.
+const (
+	pathStyleParent   = "parent"
+	pathStyleCwd      = "cwd"
+	pathStyleAbsolute = "absolute"
+	pathStyleHome     = "home"
+)
+
+var pathStyles = []string{pathStyleParent, pathStyleCwd, pathStyleAbsolute, pathStyleHome}
+
+// pathStyle is the active path style, applied by persistentPreRun.
+var pathStyle = pathStyleParent
+
+// applyPathStyle activates the --path-style flag or, without it, the
+// configured style.
+func applyPathStyle() error {
+	style := globalPathStyle
+	if style == "" {
+		style = cfg.PathStyle
+	}
+	if err := checkPathStyle(style); err != nil {
+		return err
+	}
+	pathStyle = cmp.Or(style, pathStyleParent)
+	return nil
+}
+
+// checkPathStyle rejects an unknown path style; empty means the default.
+func checkPathStyle(style string) error {
+	if style != "" && !slices.Contains(pathStyles, style) {
+		return fmt.Errorf("unknown path style %q; use one of: %s", style, strings.Join(pathStyles, ", "))
+	}
+	return nil
+}
+
+// nameOptions are how the directory names of new worktrees are derived from
+// their branches, set from the [names] config by persistentPreRun.
+var nameOptions names.Options
+
+// applyNames activates the [names] config.
+func applyNames() error {
+	opts, err := cfg.Names.Options()
+	if err != nil {
+		return err
+	}
+	nameOptions = opts
+	return nil
+}
+
+// displayPath formats path for output in the active path style. By default
+// it is relative to the directory holding the main worktree and the
+// worktrees directory, e.g. "repo-worktrees/feature".
+func displayPath(info *repo.Info, path string) string {
+	switch pathStyle {
+	case pathStyleAbsolute:
+		return path
+	case pathStyleHome:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return path
+		}
+		if rest, ok := pathWithin(path, home); ok {
+			return filepath.Join("~", rest)
+		}
+		return path
+	case pathStyleCwd:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return path
+		}
+		// git reports paths with symlinks resolved
+		if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+			cwd = resolved
+		}
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			return rel
+		}
+		return path
+	}
+	rel, err := filepath.Rel(filepath.Dir(info.MainWorktree), path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
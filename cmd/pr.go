@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/forge"
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/history"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prSyncMine    bool
+	prSyncNoHooks bool
+)
+
+var prCmd = &cobra.Command{
+	Use:   "pr",
+	Short: "Manage worktrees for pull requests",
+	Long: `Manage worktrees for the pull requests of the repository's forge: GitHub,
+GitLab (merge requests), or Gitea and Forgejo.
+
+The forge is detected from the URL of the origin remote (github.com,
+gitlab.com, codeberg.org, or hosts named gitlab.*, gitea.*, and so on) or set
+in the [forge] section of the config: provider ("github", "gitlab", or
+"gitea"), remote, repo ("owner/name"), and api-url (for self-hosted
+instances). Requests are authenticated with a token from the environment
+($GITHUB_TOKEN or $GH_TOKEN, $GITLAB_TOKEN, or $GITEA_TOKEN), the one stored
+with 'wt auth login', or for GitHub the login of the GitHub CLI.`,
+}
+
+var prSyncCmd = &cobra.Command{
+	Use:   "sync --mine",
+	Short: "Keep a worktree for each of your open pull requests",
+	Long: `Create a worktree for each open pull request you authored or are assigned
+to, and fast-forward the worktrees created earlier to the pull requests' latest
+commits. Worktrees with uncommitted changes are left alone.
+
+Pull requests from forks are fetched from the forge and checked out on a local
+branch named <owner>/<branch>. Worktrees that wt pr sync created for pull
+requests that have since been merged or closed are removed after confirmation
+(or with --yes); the branches are kept.`,
+	Args: cobra.NoArgs,
+	RunE: runPRSync,
+}
+
+func init() {
+	prSyncCmd.Flags().BoolVar(&prSyncMine, "mine", false, "Sync the pull requests you authored or are assigned to")
+	prSyncCmd.Flags().BoolVar(&prSyncNoHooks, "no-hooks", false, "Skip the post-create hooks in new worktrees")
+	prSyncCmd.MarkFlagRequired("mine")
+	prCmd.AddCommand(prSyncCmd)
+	rootCmd.AddCommand(prCmd)
+}
+
+// openForge returns the forge configured for the repository and the remote
+// that pull requests are fetched from.
+func openForge(ctx context.Context) (forge.Forge, string, error) {
+	remote := cfg.Forge.Remote
+	if remote == "" {
+		remote = "origin"
+	}
+	url, err := git.RemoteURL(ctx, remote)
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := forge.New(url, forge.Options{
+		Provider: cfg.Forge.Provider,
+		Repo:     cfg.Forge.Repo,
+		APIURL:   forgeAPIURL(url),
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return f, remote, nil
+}
+
+// forgeAPIURL returns the configured api-url for the forge of remoteURL. The
+// user's token is sent there, so an api-url from the repository's .wt.toml
+// is only used when it is on the remote's host; anywhere else it is ignored
+// and the API is found from the remote.
+func forgeAPIURL(remoteURL string) string {
+	apiURL := cfg.Forge.APIURL
+	if apiURL == "" || !cfg.SetByRepo("forge.api-url") {
+		return apiURL
+	}
+	remote, err := forge.ParseRemoteURL(remoteURL)
+	if err == nil && forge.ServesHost(apiURL, remote.Host) {
+		return apiURL
+	}
+	fmt.Fprintf(os.Stderr, "Warning: ignoring api-url %s from %s: it is not on the remote's host; set it in your user config if it is right\n", apiURL, config.RepoFileName)
+	return ""
+}
+
+func runPRSync(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	f, remote, err := openForge(ctx)
+	if err != nil {
+		return err
+	}
+	prs, err := f.MyPRs(ctx)
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+	if err := prepareWorktreesDir(info, worktrees); err != nil {
+		return err
+	}
+
+	if len(prs) == 0 {
+		fmt.Fprintln(os.Stderr, "You have no open pull requests.")
+	}
+	open := make(map[int]bool)
+	failed := 0
+	for _, pr := range prs {
+		open[pr.Number] = true
+		if err := syncPR(ctx, info, f, remote, pr, worktrees); err != nil {
+			if interrupted(ctx) {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: #%d: %s\n", pr.Number, err)
+			failed++
+		}
+	}
+
+	if err := removeClosedPRs(ctx, info, f, open); err != nil {
+		return err
+	}
+	syncWorkspace(ctx, info)
+	if failed > 0 {
+		return fmt.Errorf("%d pull request(s) could not be synced", failed)
+	}
+	return nil
+}
+
+// prBranch is the local branch for a pull request. Branches from forks are
+// prefixed with the fork's owner, so that e.g. two forks' "main" branches or
+// a fork's branch and a same-named one in the repository do not collide.
+func prBranch(pr forge.PR) string {
+	if !pr.Fork {
+		return pr.Branch
+	}
+	owner := pr.HeadOwner
+	if owner == "" {
+		owner = fmt.Sprintf("pr-%d", pr.Number)
+	}
+	return owner + "/" + pr.Branch
+}
+
+// syncPR fetches a pull request's head and creates its worktree, or
+// fast-forwards the existing one.
+func syncPR(ctx context.Context, info *repo.Info, f forge.Forge, remote string, pr forge.PR, worktrees []git.Worktree) error {
+	branch := prBranch(pr)
+	var ref string
+	var err error
+	if pr.Fork {
+		ref = fmt.Sprintf("%s/pr/%d", remote, pr.Number)
+		err = git.Fetch(ctx, remote, fmt.Sprintf("+%s:refs/remotes/%s", f.HeadRef(pr.Number), ref))
+	} else {
+		ref = remote + "/" + pr.Branch
+		err = git.FetchBranch(ctx, remote, pr.Branch)
+	}
+	if err != nil {
+		return err
+	}
+
+	// A worktree that is already there is only updated. Worktrees are marked
+	// as the pull request's only when sync creates them, so that sync never
+	// removes one the user made
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			return updatePRWorktree(ctx, info, wt.Path, ref, pr)
+		}
+	}
+
+	wtPath := worktreeDir(info, worktrees, branch)
+	existing := git.LocalBranchExists(ctx, branch)
+	switch {
+	case existing:
+		err = git.AddWorktree(ctx, wtPath, branch, false, "")
+	case pr.Fork:
+		// Forks usually cannot be pushed to, so the branch tracks nothing
+		err = git.AddWorktree(ctx, wtPath, branch, true, ref)
+	default:
+		err = git.AddTrackingWorktree(ctx, wtPath, branch, ref)
+	}
+	recordEvent(info, history.Create, branch, wtPath, err)
+	if err != nil {
+		return err
+	}
+	recordCreated(info, worktrees, wtPath, branch)
+	markPR(info, wtPath, branch, pr.Number)
+	applyWorktreeConfig(ctx, info, wtPath, branch)
+	if existing {
+		if err := git.FastForward(ctx, wtPath, ref); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: #%d: %s\n", pr.Number, err)
+		}
+	}
+	copyTemplate(info, wtPath, branch)
+	if !prSyncNoHooks {
+		runPostCreateHooks(ctx, info, wtPath, branch, ref)
+	}
+	fmt.Fprintf(os.Stderr, "Created %s for #%d %s\n", displayPath(info, wtPath), pr.Number, pr.Title)
+	return nil
+}
+
+// updatePRWorktree fast-forwards a pull request's existing worktree to ref,
+// unless it has uncommitted changes.
+func updatePRWorktree(ctx context.Context, info *repo.Info, path, ref string, pr forge.PR) error {
+	dirty, err := git.IsDirty(ctx, path, true)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		fmt.Fprintf(os.Stderr, "Skipped %s for #%d: it has uncommitted changes\n", displayPath(info, path), pr.Number)
+		return nil
+	}
+	if err := git.FastForward(ctx, path, ref); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Updated %s for #%d %s\n", displayPath(info, path), pr.Number, pr.Title)
+	return nil
+}
+
+// markPR records that the worktree at path belongs to pull request number.
+func markPR(info *repo.Info, path, branch string, number int) {
+	state.New(info.StateDir()).Update(func(st *state.State) error {
+		wt := st.Worktree(path)
+		wt.Branch = branch
+		wt.PR = number
+		return nil
+	})
+}
+
+// closedPR is a worktree whose pull request was merged or closed.
+type closedPR struct {
+	path   string
+	branch string
+	pr     forge.PR
+}
+
+// removeClosedPRs offers to remove the worktrees created for pull requests
+// that are no longer open. Pull requests that are open but no longer the
+// user's keep their worktrees.
+func removeClosedPRs(ctx context.Context, info *repo.Info, f forge.Forge, open map[int]bool) error {
+	st, err := state.New(info.StateDir()).Load()
+	if err != nil {
+		return err
+	}
+	var closed []closedPR
+	for path, wt := range st.Worktrees {
+		if wt.PR == 0 || open[wt.PR] {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue // Already gone; wt prune cleans up
+		}
+		pr, err := f.PR(ctx, wt.PR)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: #%d: %s\n", wt.PR, err)
+			continue
+		}
+		if pr.State != forge.Open {
+			closed = append(closed, closedPR{path: path, branch: wt.Branch, pr: pr})
+		}
+	}
+	if len(closed) == 0 {
+		return nil
+	}
+	sort.Slice(closed, func(i, j int) bool { return closed[i].pr.Number < closed[j].pr.Number })
+
+	fmt.Fprintln(os.Stderr, "Worktrees of pull requests that are no longer open:")
+	for _, c := range closed {
+		fmt.Fprintf(os.Stderr, "  %s (#%d, %s)\n", displayPath(info, c.path), c.pr.Number, c.pr.State)
+	}
+	ok, err := confirmDestructive(fmt.Sprintf("Remove %d worktree(s)?", len(closed)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Kept them.")
+		return nil
+	}
+
+	for _, c := range closed {
+		err := git.RemoveWorktree(ctx, c.path, false)
+		recordEvent(info, history.Remove, c.branch, c.path, err)
+		switch {
+		case errors.Is(err, git.ErrDirty):
+			fmt.Fprintf(os.Stderr, "Kept %s: it has uncommitted changes\n", displayPath(info, c.path))
+			continue
+		case err != nil:
+			return err
+		}
+		forgetWorktree(info, c.path)
+		info.CleanEmptyParents(c.path)
+		fmt.Fprintf(os.Stderr, "Removed %s\n", displayPath(info, c.path))
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// isInteractive reports whether the user can answer prompts: stdin must be a
+// terminal, and stderr (where prompts are written) must be one too. The global
+// --no-interactive flag turns prompts off even on a terminal.
+func isInteractive() bool {
+	return !globalNoInteractive && isTerminal(os.Stdin) && isTerminal(os.Stderr)
+}
+
+// requireInteractive returns an error naming the non-interactive alternative
+// when a full-screen selector cannot be shown.
+func requireInteractive(alternative string) error {
+	if isInteractive() {
+		return nil
+	}
+	return fmt.Errorf("interactive mode needs a terminal; use %s instead", alternative)
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// choose asks the user to pick one of options on stderr and returns the
+// chosen option's key. Each option is matched by its key or its full label;
+// an empty or unrecognized answer returns def.
+func choose(question string, options []choice, def string) string {
+	labels := make([]string, len(options))
+	for i, o := range options {
+		labels[i] = fmt.Sprintf("[%s] %s", o.key, o.label)
+	}
+	fmt.Fprintf(os.Stderr, "%s %s: ", question, strings.Join(labels, ", "))
+	answer, err := readAnswer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr)
+		return def
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	for _, o := range options {
+		if answer == o.key || answer == strings.ToLower(o.label) {
+			return o.key
+		}
+	}
+	return def
+}
+
+// readAnswer reads one line from stdin. An interrupt while waiting ends the
+// read as if input had ended, so Ctrl-C at a prompt still stops wt.
+func readAnswer() (string, error) {
+	type line struct {
+		text string
+		err  error
+	}
+	lines := make(chan line, 1)
+	go func() {
+		text, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		lines <- line{text, err}
+	}()
+	select {
+	case l := <-lines:
+		return l.text, l.err
+	case <-rootCmd.Context().Done():
+		return "", context.Cause(rootCmd.Context())
+	}
+}
+
+// choice is one answer offered by choose.
+type choice struct {
+	key   string
+	label string
+}
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin.
+// Anything other than "y" or "yes" counts as no.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := readAnswer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// confirmDefaultYes is like confirm, but an empty answer counts as yes.
+func confirmDefaultYes(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [Y/n] ", question)
+	answer, err := readAnswer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr)
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "y", "yes":
+		return true
+	}
+	return false
+}
+
+// ask asks for a line of text on stderr. An empty answer returns def.
+func ask(question, def string) string {
+	if def != "" {
+		question += fmt.Sprintf(" [%s]", def)
+	}
+	fmt.Fprintf(os.Stderr, "%s: ", question)
+	answer, err := readAnswer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr)
+		return def
+	}
+	if answer = strings.TrimSpace(answer); answer != "" {
+		return answer
+	}
+	return def
+}
+
+// confirmDestructive asks before an operation that destroys data. With the
+// global --yes flag it proceeds without asking; without a terminal to ask on
+// it returns an error telling the user to pass --yes.
+func confirmDestructive(question string) (bool, error) {
+	if globalYes {
+		return true, nil
+	}
+	if !isInteractive() {
+		return false, fmt.Errorf("confirmation required (%s); rerun with --yes to proceed", strings.TrimSuffix(question, "?"))
+	}
+	return confirm(question), nil
+}
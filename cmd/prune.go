@@ -0,0 +1,358 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/history"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneBroken  bool
+	pruneExpired bool
+	pruneDryRun  bool
+	pruneTags    []string
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Clean up broken worktrees",
+	Long: `Clean up worktrees that no longer work.
+
+With --broken, git's records of worktrees whose directories were deleted are
+pruned (git worktree prune), and directories in the worktrees directory that
+do not belong to any worktree are deleted after confirmation (or with --yes).
+Directories are only deleted from a worktrees directory whose .wt-root marker
+names this repository, as wt writes it when it creates the directory.
+
+With --expired, the worktrees older than the after age of the [expire] config
+table are removed after confirmation (or with --yes); their branches are kept,
+and worktrees with uncommitted changes are left alone. For example:
+
+  [expire]
+  after = "30d"                   # or "2w", "36h"
+  exclude = ["main", "release/*"] # branches that never expire
+
+A worktree's age counts from when wt created it, or, for worktrees created
+otherwise, from when git did.
+
+--tag limits either to the worktrees with the tag (see wt tag), e.g.
+wt prune --expired --tag experiment; orphan directories have no tags and are
+then left alone.`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().BoolVar(&pruneBroken, "broken", false, "Prune missing worktrees and delete orphan directories")
+	pruneCmd.Flags().BoolVar(&pruneExpired, "expired", false, "Remove worktrees older than the [expire] policy allows")
+	pruneCmd.Flags().BoolVarP(&pruneDryRun, "dry-run", "n", false, "Show what would be pruned without changing anything")
+	registerTagFilter(pruneCmd, &pruneTags)
+	rootCmd.AddCommand(pruneCmd)
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if !pruneBroken && !pruneExpired {
+		return fmt.Errorf("nothing to prune; pass --broken or --expired")
+	}
+
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pruneBroken {
+		if err := pruneBrokenWorktrees(ctx, info, worktrees); err != nil {
+			return err
+		}
+	}
+	if pruneExpired {
+		return pruneExpiredWorktrees(ctx, info, worktrees)
+	}
+	return nil
+}
+
+// pruneBrokenWorktrees prunes missing worktrees and deletes orphan
+// directories in the worktrees directory.
+func pruneBrokenWorktrees(ctx context.Context, info *repo.Info, worktrees []git.Worktree) error {
+	tags := worktreeTags(info)
+	var prunable []git.Worktree
+	for _, wt := range worktrees {
+		if wt.Prunable != "" && hasTags(tags[wt.Path], pruneTags) {
+			prunable = append(prunable, wt)
+		}
+	}
+
+	var live []string
+	for _, wt := range worktrees {
+		if wt.Prunable == "" {
+			live = append(live, wt.Path)
+		}
+	}
+	var orphans []string
+	if len(pruneTags) == 0 {
+		owned, err := info.OwnsWorktreesDir()
+		if err != nil {
+			return err
+		}
+		if orphans, err = findOrphans(info.WorktreesDir, live); err != nil {
+			return err
+		}
+		if !owned && len(orphans) > 0 {
+			// Without a marker naming this repository, the directories may
+			// well be someone else's
+			fmt.Fprintf(os.Stderr, "Left %d director(ies) in %s alone: it is not marked as this repository's worktrees directory.\n", len(orphans), displayPath(info, info.WorktreesDir))
+			fmt.Fprintf(os.Stderr, "If it is, mark it with: echo %s > %s\n", info.MainWorktree, filepath.Join(info.WorktreesDir, repo.RootMarker))
+			orphans = nil
+		}
+	}
+
+	if len(prunable) == 0 && len(orphans) == 0 {
+		fmt.Fprintln(os.Stderr, "No broken worktrees to prune.")
+		return nil
+	}
+
+	verb := "Pruned"
+	if pruneDryRun {
+		verb = "Would prune"
+	}
+	for _, wt := range prunable {
+		fmt.Fprintf(os.Stderr, "%s worktree %s (%s): %s\n", verb, wt.Branch, wt.Path, wt.Prunable)
+	}
+	switch {
+	case len(prunable) == 0 || pruneDryRun:
+	case len(pruneTags) > 0:
+		// git worktree prune would prune the untagged ones too; removing a
+		// missing worktree only drops git's records of it
+		for _, wt := range prunable {
+			err := git.RemoveWorktree(ctx, wt.Path, false)
+			recordEvent(info, history.Prune, wt.Branch, wt.Path, err)
+			if err != nil {
+				return err
+			}
+			forgetWorktree(info, wt.Path)
+		}
+	default:
+		err := git.PruneWorktrees(ctx)
+		for _, wt := range prunable {
+			recordEvent(info, history.Prune, wt.Branch, wt.Path, err)
+		}
+		if err != nil {
+			return err
+		}
+		for _, wt := range prunable {
+			forgetWorktree(info, wt.Path)
+		}
+	}
+
+	if len(orphans) == 0 {
+		return nil
+	}
+	if pruneDryRun {
+		for _, dir := range orphans {
+			fmt.Fprintf(os.Stderr, "Would delete orphan directory %s\n", dir)
+		}
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "Orphan directories (not part of any worktree):")
+	for _, dir := range orphans {
+		fmt.Fprintf(os.Stderr, "  %s\n", dir)
+	}
+	ok, err := confirmDestructive(fmt.Sprintf("Delete %d orphan director(ies) and their contents?", len(orphans)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Aborted.")
+		return nil
+	}
+	for _, dir := range orphans {
+		err := os.RemoveAll(dir)
+		recordEvent(info, history.Prune, "", dir, err)
+		if err != nil {
+			return fmt.Errorf("deleting orphan directory: %w", err)
+		}
+		info.CleanEmptyParents(dir)
+		fmt.Fprintf(os.Stderr, "Deleted orphan directory %s\n", dir)
+	}
+	return nil
+}
+
+// pruneExpiredWorktrees removes the worktrees that have outlived the
+// [expire] policy, after confirmation.
+func pruneExpiredWorktrees(ctx context.Context, info *repo.Info, worktrees []git.Worktree) error {
+	exp, err := loadExpiry(info)
+	if err != nil {
+		return err
+	}
+	if exp == nil {
+		return errors.New("no expiry policy; set after in the [expire] table of the config, e.g. after = \"30d\"")
+	}
+
+	tags := worktreeTags(info)
+	var expired []git.Worktree
+	var ages []time.Duration
+	for _, wt := range worktrees {
+		if !hasTags(tags[wt.Path], pruneTags) {
+			continue
+		}
+		if age, ok := exp.expired(info, wt); ok {
+			expired = append(expired, wt)
+			ages = append(ages, age)
+		}
+	}
+	if len(expired) == 0 {
+		fmt.Fprintf(os.Stderr, "No worktrees are older than %s.\n", cfg.Expire.After)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Worktrees older than %s:\n", cfg.Expire.After)
+	for i, wt := range expired {
+		fmt.Fprintf(os.Stderr, "  %s (%s, created %s ago)\n", displayPath(info, wt.Path), worktreeName(wt), formatAge(ages[i]))
+	}
+	if pruneDryRun {
+		fmt.Fprintf(os.Stderr, "Would remove %d expired worktree(s).\n", len(expired))
+		return nil
+	}
+	ok, err := confirmDestructive(fmt.Sprintf("Remove %d worktree(s) older than %s?", len(expired), cfg.Expire.After))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Kept them.")
+		return nil
+	}
+
+	// A worktree that cannot be removed, e.g. as it is locked, is reported
+	// and the others are still removed
+	failed := 0
+	for _, wt := range expired {
+		err := git.RemoveWorktree(ctx, wt.Path, false)
+		recordEvent(info, history.Remove, wt.Branch, wt.Path, err)
+		switch {
+		case errors.Is(err, git.ErrDirty):
+			fmt.Fprintf(os.Stderr, "Kept %s: it has uncommitted changes\n", displayPath(info, wt.Path))
+			continue
+		case err != nil && interrupted(ctx):
+			return err
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Failed to remove %s: %s\n", displayPath(info, wt.Path), err)
+			failed++
+			continue
+		}
+		forgetWorktree(info, wt.Path)
+		info.CleanEmptyParents(wt.Path)
+		fmt.Fprintf(os.Stderr, "Removed %s\n", displayPath(info, wt.Path))
+	}
+	syncWorkspace(ctx, info)
+	if failed > 0 {
+		return fmt.Errorf("%d expired worktree(s) could not be removed", failed)
+	}
+	return nil
+}
+
+// findOrphans returns directories under root that neither are nor contain
+// one of the worktree paths in live. Paths are compared with symlinks
+// resolved, since git lists worktrees by their resolved paths, and a
+// directory holding a .git file or directory is never an orphan. A missing
+// root has no orphans.
+func findOrphans(root string, live []string) ([]string, error) {
+	resolved := make([]string, len(live))
+	for i, p := range live {
+		resolved[i] = resolvePath(p)
+	}
+	return findOrphansIn(root, resolved)
+}
+
+// findOrphansIn is findOrphans with the live paths already resolved.
+func findOrphansIn(root string, live []string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading worktrees directory: %w", err)
+	}
+
+	var orphans []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		resolved := resolvePath(dir)
+		switch {
+		case containsPath(live, resolved, false):
+			// A worktree itself
+		case exists(filepath.Join(dir, ".git")):
+			// A worktree or repository git does not list here; never delete it
+		case containsPath(live, resolved, true):
+			// A parent of nested worktrees; look inside
+			nested, err := findOrphansIn(dir, live)
+			if err != nil {
+				return nil, err
+			}
+			orphans = append(orphans, nested...)
+		default:
+			orphans = append(orphans, dir)
+		}
+	}
+	return orphans, nil
+}
+
+// containsPath reports whether paths contains dir itself or, with below set,
+// a path strictly inside dir.
+func containsPath(paths []string, dir string, below bool) bool {
+	for _, p := range paths {
+		if !below && p == dir {
+			return true
+		}
+		if below && strings.HasPrefix(p, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath returns path with symlinks resolved, or path itself when it
+// cannot be resolved.
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// exists reports whether path exists.
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// printPruneHint tells the user how to clean up prunable worktrees, if any.
+func printPruneHint(out io.Writer, worktrees []git.Worktree) {
+	n := 0
+	for _, wt := range worktrees {
+		if wt.Prunable != "" {
+			n++
+		}
+	}
+	if n > 0 {
+		fmt.Fprintf(out, "\n%d worktree(s) are missing; run 'wt prune --broken' to clean up.\n", n)
+	}
+}
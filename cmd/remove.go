@@ -1,44 +1,77 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/history"
 	"github.com/provenimpact/wt/internal/repo"
 	"github.com/provenimpact/wt/internal/tui"
 	"github.com/spf13/cobra"
 )
 
-var removeForce bool
+var (
+	removeForce        bool
+	removeStash        bool
+	removeDeleteBranch bool
+	removeDeleteRemote bool
+	removeCurrent      bool
+	removeAllMatching  bool
+)
 
 var removeCmd = &cobra.Command{
-	Use:   "remove [name]",
+	Use:   "remove [name | path]",
 	Short: "Remove a worktree",
-	Long:  "Remove a git worktree. If no name is given, an interactive selector is shown.",
+	Long:  "Remove a git worktree. If no name is given, an interactive selector is shown.\n\nA path such as '.' or '../api' removes the worktree containing it, and --current\nremoves the worktree you are in. When you are inside the removed worktree, the\nshell integration takes you back to the main worktree.\n\nWith --all-matching, the name is a glob and every worktree whose branch matches\nit is removed after confirmation (or with --yes), e.g. wt remove 'tmp/*' --all-matching.\nWorktrees with uncommitted changes are kept unless --force is given.\n\nWorktrees with uncommitted changes are only removed with --force (discarding the changes,\nafter confirmation in a terminal) or --stash (saving them as a stash entry on the branch first).\n\nWith --delete-branch, the worktree's branch is deleted as well; a branch that is not fully\nmerged is only deleted after confirmation (or with --yes). With --delete-remote, the branch\nof the same name is also deleted on the remote the branch tracks, or else on a remote that has\none, after confirmation (or with --yes). A different upstream, such as origin/main for a branch\nstarted from it, is never deleted, and neither is the remote's default branch.",
 	Args:  cobra.MaximumNArgs(1),
 	RunE:  runRemove,
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
-		return completeLinkedWorktreeBranches(), cobra.ShellCompDirectiveNoFileComp
+		return completeLinkedWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
 	},
 }
 
 func init() {
 	removeCmd.Flags().BoolVarP(&removeForce, "force", "f", false, "Force removal even with uncommitted changes")
+	removeCmd.Flags().BoolVar(&removeStash, "stash", false, "Stash uncommitted changes (including untracked files) before removing")
+	removeCmd.Flags().BoolVar(&removeDeleteBranch, "delete-branch", false, "Delete the worktree's branch after removing it")
+	removeCmd.Flags().BoolVar(&removeDeleteRemote, "delete-remote", false, "Delete the worktree's branch on its remote after removing it")
+	removeCmd.Flags().BoolVar(&removeCurrent, "current", false, "Remove the worktree containing the current directory")
+	removeCmd.Flags().BoolVar(&removeAllMatching, "all-matching", false, "Remove every worktree whose branch matches the glob given as name")
+	removeCmd.MarkFlagsMutuallyExclusive("force", "stash")
+	removeCmd.MarkFlagsMutuallyExclusive("all-matching", "current")
+	removeCmd.MarkFlagsMutuallyExclusive("all-matching", "stash")
+	removeCmd.MarkFlagsMutuallyExclusive("all-matching", "delete-remote")
 	rootCmd.AddCommand(removeCmd)
 }
 
 func runRemove(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if removeCurrent && len(args) > 0 {
+		return errors.New("--current removes the worktree you are in; drop the worktree name")
+	}
+	if removeAllMatching {
+		if len(args) == 0 {
+			return errors.New("--all-matching needs a branch glob, e.g. wt remove 'tmp/*' --all-matching")
+		}
+		if _, err := path.Match(args[0], ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", args[0], err)
+		}
+	}
 	info, err := repo.Resolve()
 	if err != nil {
 		return err
 	}
 
-	worktrees, err := git.ListWorktrees()
+	worktrees, err := git.ListWorktrees(ctx)
 	if err != nil {
 		return err
 	}
@@ -56,35 +89,53 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if removeAllMatching {
+		return removeMatching(ctx, info, worktrees, linked, args[0])
+	}
+
 	var targetPath string
 	var targetBranch string
 
-	if len(args) == 1 {
-		// Find by name
-		name := args[0]
-		for _, wt := range linked {
-			if wt.Branch == name || filepath.Base(wt.Path) == name {
-				targetPath = wt.Path
-				targetBranch = wt.Branch
-				break
-			}
+	switch {
+	case removeCurrent || len(args) == 1 && isPathArg(args[0]):
+		wt, err := removalAt(info, worktrees, args)
+		if err != nil {
+			return err
 		}
-		if targetPath == "" {
-			return fmt.Errorf("worktree %q not found", name)
+		targetPath = wt.Path
+		targetBranch = wt.Branch
+	case len(args) == 1:
+		wt, err := matchWorktree(linked, worktreeAliases(info), args[0])
+		if err != nil {
+			return err
 		}
-	} else {
+		targetPath = wt.Path
+		targetBranch = wt.Branch
+	default:
 		// Interactive selector
+		if err := requireInteractive("'wt remove <name>'"); err != nil {
+			return err
+		}
+		descs := branchDescriptions(ctx)
+		aliases := worktreeAliases(info)
+		tags := worktreeTags(info)
+		current, _ := currentWorktree(linked)
 		var entries []tui.Entry
 		for _, wt := range linked {
-			rel, _ := filepath.Rel(filepath.Dir(info.MainWorktree), wt.Path)
 			entries = append(entries, tui.Entry{
-				Branch: wt.Branch,
-				Path:   wt.Path,
-				Rel:    rel,
+				Branch:      wt.Branch,
+				Path:        wt.Path,
+				Rel:         displayPath(info, wt.Path),
+				Alias:       aliases[wt.Path],
+				Description: descs[wt.Branch],
+				Tags:        tags[wt.Path],
+				Current:     wt.Path == current.Path,
 			})
 		}
 
-		selected, err := tui.Select(entries)
+		// The cursor deliberately starts at the top rather than on the
+		// worktree the user is in
+		selected, err := tui.Select(entries, "", selectorStatus(ctx))
 		if err != nil {
 			return err
 		}
@@ -101,37 +152,329 @@ func runRemove(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Check dirty state
-	if !removeForce {
-		dirty, err := git.IsDirty(targetPath)
-		if err != nil {
+	if targetBranch == "" && (removeDeleteBranch || removeDeleteRemote) {
+		return fmt.Errorf("the worktree at %s has no branch to delete", displayPath(info, targetPath))
+	}
+
+	// The remote branch is looked up, and deleting it confirmed, before
+	// anything is removed: deleting the local branch drops its upstream, and a
+	// missing --yes should not leave the cleanup half done
+	var remote string
+	if removeDeleteRemote {
+		if remote, err = remoteBranchOf(ctx, targetBranch); err != nil {
+			return err
+		}
+		if remote != "" {
+			ok, err := confirmDestructive(fmt.Sprintf("Delete branch %q on %s?", targetBranch, remote))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				remote = ""
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "Branch %q is not on any remote; nothing to delete there\n", targetBranch)
+		}
+	}
+
+	// Check dirty state; a worktree whose directory is gone has nothing to lose
+	force := removeForce
+	var changed []string
+	if _, err := os.Stat(targetPath); err == nil {
+		if changed, err = git.ChangedFiles(ctx, targetPath, true); err != nil {
+			return err
+		}
+	}
+	switch {
+	case len(changed) == 0:
+	case removeStash:
+		if err := stashBeforeRemoval(ctx, targetPath, targetBranch); err != nil {
 			return err
 		}
-		if dirty {
-			return fmt.Errorf("worktree %q has uncommitted changes; use --force to remove anyway", targetBranch)
+		force = true
+	case removeForce:
+		// --force alone is consent enough in scripts; only a terminal gets
+		// the changes shown and a last chance to keep them.
+		if isInteractive() && !globalYes {
+			previewChanges(targetBranch, changed)
+			if !confirm(fmt.Sprintf("Discard these changes and remove %q?", targetBranch)) {
+				fmt.Fprintln(os.Stderr, "Aborted.")
+				return nil
+			}
+		}
+	default:
+		proceed, err := resolveDirtyRemoval(ctx, targetPath, targetBranch, changed)
+		if err != nil || !proceed {
+			return err
 		}
+		force = true
 	}
 
-	if err := git.RemoveWorktree(targetPath, removeForce); err != nil {
+	// Standing in the removed worktree would leave wt, and the shell, in a
+	// deleted directory
+	current, _ := currentWorktree(worktrees)
+	leaving := current.Path == targetPath
+	if leaving {
+		if err := os.Chdir(info.MainWorktree); err != nil {
+			return err
+		}
+	}
+
+	err = git.RemoveWorktree(ctx, targetPath, force)
+	recordEvent(info, history.Remove, targetBranch, targetPath, err)
+	if err != nil {
 		return err
 	}
 
+	forgetWorktree(info, targetPath)
+	if leaving {
+		emitDirectives("__wt_cd:" + info.MainWorktree)
+	}
+
 	// Clean up empty parent directories between the removed path and worktrees dir
-	cleanEmptyParents(targetPath, info.WorktreesDir)
+	info.CleanEmptyParents(targetPath)
+	syncWorkspace(ctx, info)
 
 	fmt.Fprintf(os.Stderr, "Removed worktree %q\n", targetBranch)
+
+	if removeDeleteBranch {
+		if err := deleteRemovedBranch(ctx, targetBranch); err != nil {
+			return err
+		}
+	}
+	if remote != "" {
+		if err := git.DeleteRemoteBranch(ctx, remote, targetBranch); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Deleted branch %q on %s\n", targetBranch, remote)
+	}
 	return nil
 }
 
-// cleanEmptyParents walks upward from path toward stopAt, removing empty directories.
-func cleanEmptyParents(path, stopAt string) {
-	dir := filepath.Dir(path)
-	for dir != stopAt && len(dir) > len(stopAt) {
-		entries, err := os.ReadDir(dir)
-		if err != nil || len(entries) > 0 {
+// removeMatching removes the linked worktrees whose branch matches pattern,
+// after confirmation. Those with uncommitted changes are kept without --force.
+func removeMatching(ctx context.Context, info *repo.Info, worktrees, linked []git.Worktree, pattern string) error {
+	var matched []git.Worktree
+	for _, wt := range linked {
+		if wt.Branch != "" && globMatch(pattern, wt.Branch) {
+			matched = append(matched, wt)
+		}
+	}
+	if len(matched) == 0 {
+		fmt.Fprintf(os.Stderr, "No worktrees match %q.\n", pattern)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Worktrees matching %q:\n", pattern)
+	for _, wt := range matched {
+		fmt.Fprintf(os.Stderr, "  %s (%s)\n", displayPath(info, wt.Path), wt.Branch)
+	}
+	question := fmt.Sprintf("Remove %d worktree(s)?", len(matched))
+	if removeForce {
+		question = fmt.Sprintf("Remove %d worktree(s), discarding their uncommitted changes?", len(matched))
+	}
+	ok, err := confirmDestructive(question)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Kept them.")
+		return nil
+	}
+
+	// A worktree that cannot be removed, e.g. as it is locked, is reported
+	// and the others are still removed
+	current, _ := currentWorktree(worktrees)
+	failed := 0
+	for _, wt := range matched {
+		if wt.Path == current.Path {
+			if err := os.Chdir(info.MainWorktree); err != nil {
+				return err
+			}
+		}
+		err := git.RemoveWorktree(ctx, wt.Path, removeForce)
+		recordEvent(info, history.Remove, wt.Branch, wt.Path, err)
+		switch {
+		case errors.Is(err, git.ErrDirty):
+			fmt.Fprintf(os.Stderr, "Kept %s: it has uncommitted changes; use --force to remove it\n", displayPath(info, wt.Path))
+			continue
+		case err != nil && interrupted(ctx):
+			return err
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Failed to remove %s: %s\n", displayPath(info, wt.Path), err)
+			failed++
+			continue
+		}
+		forgetWorktree(info, wt.Path)
+		info.CleanEmptyParents(wt.Path)
+		if wt.Path == current.Path {
+			emitDirectives("__wt_cd:" + info.MainWorktree)
+		}
+		fmt.Fprintf(os.Stderr, "Removed worktree %q\n", wt.Branch)
+		if removeDeleteBranch {
+			if err := deleteRemovedBranch(ctx, wt.Branch); err != nil {
+				if interrupted(ctx) {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Failed to delete branch %q: %s\n", wt.Branch, err)
+				failed++
+			}
+		}
+	}
+	syncWorkspace(ctx, info)
+	if failed > 0 {
+		return fmt.Errorf("removing %d of %d worktree(s) failed", failed, len(matched))
+	}
+	return nil
+}
+
+// isPathArg reports whether arg names a path rather than a worktree. Paths
+// are told apart by their leading "/", "." or ".."; git branch names cannot
+// start with either.
+func isPathArg(arg string) bool {
+	return filepath.IsAbs(arg) || arg == "." || arg == ".." ||
+		strings.HasPrefix(arg, "."+string(filepath.Separator)) ||
+		strings.HasPrefix(arg, ".."+string(filepath.Separator))
+}
+
+// removalAt returns the linked worktree containing the path in args, or the
+// current directory without one.
+func removalAt(info *repo.Info, worktrees []git.Worktree, args []string) (git.Worktree, error) {
+	path := "."
+	if len(args) == 1 {
+		path = args[0]
+	}
+	wt, ok := worktreeContaining(worktrees, path)
+	switch {
+	case !ok && len(args) == 0:
+		return git.Worktree{}, errors.New("not inside a worktree; name the worktree to remove")
+	case !ok:
+		return git.Worktree{}, fmt.Errorf("%s is not inside a worktree", path)
+	case wt.Path == info.MainWorktree:
+		return git.Worktree{}, errors.New("the main worktree cannot be removed; name a linked worktree or cd into one")
+	}
+	return wt, nil
+}
+
+// remoteBranchOf returns the remote that has a branch of the same name as
+// branch: the remote branch tracks when its upstream has that name, or else
+// the remote with such a branch, origin first. Only a branch of the same
+// name is ever returned, never a different upstream such as origin/main that
+// a feature branch was started from. remote is empty when no remote has the
+// branch. Deleting the remote's default branch is refused.
+func remoteBranchOf(ctx context.Context, branch string) (remote string, err error) {
+	upstreamRemote, upstreamBranch, err := git.UpstreamBranch(ctx, branch)
+	if err != nil {
+		return "", err
+	}
+	if upstreamRemote != "" && upstreamBranch == branch {
+		remote = upstreamRemote
+	} else {
+		// Remote names may contain "/", so the branch is looked for on each
+		// remote rather than split off a remote-tracking ref; origin wins
+		remotes, err := git.ListRemotes(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, r := range remotes {
+			if !git.RefExists(ctx, "refs/remotes/"+r+"/"+branch) {
+				continue
+			}
+			if r == "origin" {
+				remote = r
+				break
+			}
+			if remote == "" {
+				remote = r
+			}
+		}
+	}
+	if remote == "" {
+		return "", nil
+	}
+	head := git.RemoteHead(ctx, remote)
+	if head == "" && remote == "origin" {
+		head, _ = git.DefaultBranch(ctx)
+	}
+	if branch == head {
+		return "", fmt.Errorf("refusing to delete %q on %s: it is the remote's default branch", branch, remote)
+	}
+	return remote, nil
+}
+
+// deleteRemovedBranch deletes the branch of a removed worktree. A branch that
+// is not fully merged is only deleted after confirmation, or with --yes;
+// otherwise it is kept.
+func deleteRemovedBranch(ctx context.Context, branch string) error {
+	err := git.DeleteBranch(ctx, branch, false)
+	if errors.Is(err, git.ErrNotMerged) {
+		if !globalYes && (!isInteractive() || !confirm(fmt.Sprintf("Branch %q is not fully merged. Delete it anyway?", branch))) {
+			fmt.Fprintf(os.Stderr, "Kept branch %q, which is not fully merged; delete it with 'git branch -D %s'\n", branch, branch)
+			return nil
+		}
+		err = git.DeleteBranch(ctx, branch, true)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Deleted branch %q\n", branch)
+	return nil
+}
+
+// previewChanges lists up to dirtyPreviewLimit changed files of a worktree.
+func previewChanges(branch string, changed []string) {
+	fmt.Fprintf(os.Stderr, "Worktree %q has uncommitted changes:\n", branch)
+	for i, line := range changed {
+		if i == dirtyPreviewLimit {
+			fmt.Fprintf(os.Stderr, "  ... and %d more\n", len(changed)-dirtyPreviewLimit)
 			break
 		}
-		os.Remove(dir)
-		dir = filepath.Dir(dir)
+		fmt.Fprintf(os.Stderr, "  %s\n", line)
 	}
 }
+
+// dirtyPreviewLimit caps how many changed files are listed before removal.
+const dirtyPreviewLimit = 10
+
+// resolveDirtyRemoval shows the uncommitted changes in a worktree and, when
+// interactive, asks whether to force-remove, stash then remove, or abort.
+// Returns true if removal should proceed (with force).
+func resolveDirtyRemoval(ctx context.Context, path, branch string, changed []string) (bool, error) {
+	previewChanges(branch, changed)
+
+	refusal := fmt.Errorf("worktree %q has uncommitted changes; use --force to remove anyway", branch)
+	if !isInteractive() {
+		return false, refusal
+	}
+
+	switch choose("Remove anyway?", []choice{
+		{"f", "force"},
+		{"s", "stash"},
+		{"a", "abort"},
+	}, "a") {
+	case "f":
+		return true, nil
+	case "s":
+		if err := stashBeforeRemoval(ctx, path, branch); err != nil {
+			return false, err
+		}
+		return true, nil
+	default:
+		fmt.Fprintln(os.Stderr, "Aborted.")
+		return false, nil
+	}
+}
+
+// stashBeforeRemoval stashes the worktree's changes so they survive removal.
+// The stash is shared by every worktree, so it can be applied anywhere.
+func stashBeforeRemoval(ctx context.Context, path, branch string) error {
+	if err := git.Stash(ctx, path, stashMessage(branch)); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Stashed changes as %q; restore with 'git stash apply' from any worktree\n", stashMessage(branch))
+	return nil
+}
+
+// stashMessage is the stash description used when stashing before removal.
+func stashMessage(branch string) string {
+	return "wt: uncommitted changes from " + branch
+}
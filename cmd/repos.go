@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/registry"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/spf13/cobra"
+)
+
+var reposAddName string
+
+var reposCmd = &cobra.Command{
+	Use:   "repos",
+	Short: "List repositories known to wt",
+	Long:  "List repositories known to wt. Repositories are registered automatically by wt create and wt switch inside them,\nand can be targeted from anywhere with --repo <name> or 'wt switch <repo>/<worktree>'.",
+	Args:  cobra.NoArgs,
+	RunE:  runReposList,
+}
+
+var reposAddCmd = &cobra.Command{
+	Use:   "add [path]",
+	Short: "Register a repository",
+	Long:  "Register the repository containing path (default: the current directory).",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runReposAdd,
+}
+
+var reposRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Unregister a repository",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runReposRemove,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeRepoNames(), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	reposAddCmd.Flags().StringVar(&reposAddName, "name", "", "Name to register the repository under (default: directory name)")
+	reposCmd.AddCommand(reposAddCmd, reposRemoveCmd)
+	rootCmd.AddCommand(reposCmd)
+	rootCmd.RegisterFlagCompletionFunc("repo", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeRepoNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+func openRegistry() (*registry.Registry, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return registry.New(dir), nil
+}
+
+func runReposList(cmd *cobra.Command, args []string) error {
+	reg, err := openRegistry()
+	if err != nil {
+		return err
+	}
+	repos, err := reg.List()
+	if err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		fmt.Fprintln(os.Stderr, "No repositories registered. Run wt inside a repository or use: wt repos add <path>")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH")
+	for _, r := range repos {
+		fmt.Fprintf(w, "%s\t%s\n", r.Name, r.Path)
+	}
+	return w.Flush()
+}
+
+func runReposAdd(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		if err := os.Chdir(args[0]); err != nil {
+			return err
+		}
+	}
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	name := reposAddName
+	if name == "" {
+		name = info.RepoName
+	}
+
+	reg, err := openRegistry()
+	if err != nil {
+		return err
+	}
+	if err := reg.Add(name, info.MainWorktree); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Registered repository %q at %s\n", name, info.MainWorktree)
+	return nil
+}
+
+func runReposRemove(cmd *cobra.Command, args []string) error {
+	reg, err := openRegistry()
+	if err != nil {
+		return err
+	}
+	if err := reg.Remove(args[0]); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Unregistered repository %q\n", args[0])
+	return nil
+}
+
+// chdirToRepo changes the working directory to the registered repository name.
+func chdirToRepo(name string) error {
+	reg, err := openRegistry()
+	if err != nil {
+		return err
+	}
+	r, ok, err := reg.Lookup(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("repository %q is not registered; see 'wt repos'", name)
+	}
+	if err := os.Chdir(r.Path); err != nil {
+		return fmt.Errorf("entering repository %q: %w", name, err)
+	}
+	return nil
+}
+
+// autoRegisterRepo records the repository of info in the registry. It runs
+// on wt create and wt switch only, rather than on every command such as
+// completion, as it reads and may write the registry file. Errors are
+// ignored: the registry is a convenience and must never block a command.
+func autoRegisterRepo(info *repo.Info) {
+	reg, err := openRegistry()
+	if err != nil {
+		return
+	}
+	reg.AutoRegister(filepath.Base(info.MainWorktree), info.MainWorktree)
+}
+
+// completeRepoNames returns registered repository names for tab completion.
+func completeRepoNames() []string {
+	reg, err := openRegistry()
+	if err != nil {
+		return nil
+	}
+	repos, err := reg.List()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, r := range repos {
+		names = append(names, r.Name)
+	}
+	return names
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/names"
+)
+
+// findWorktree looks up a worktree by branch name, alias (see wt alias; the
+// aliases of worktreeAliases), directory name, or the sanitized form of name.
+// An exact match wins, a branch name before an alias and a directory name,
+// since e.g. "fix/bug" sanitizes to the directory of the branch "fix-bug";
+// otherwise a unique case-insensitive match is accepted. Returns false if no
+// worktree matches.
+func findWorktree(worktrees []git.Worktree, aliases map[string]string, name string) (git.Worktree, bool) {
+	sanitized := names.Sanitize(name, nameOptions)
+	matches := func(wt git.Worktree, eq func(a, b string) bool) bool {
+		dir := filepath.Base(wt.Path)
+		return eq(wt.Branch, name) || eq(dir, name) || eq(pathTail(wt.Path, sanitized), sanitized)
+	}
+
+	for _, wt := range worktrees {
+		if wt.Branch == name {
+			return wt, true
+		}
+	}
+	if wt, ok := aliasedWorktree(worktrees, aliases, name); ok {
+		return wt, true
+	}
+	for _, wt := range worktrees {
+		if matches(wt, func(a, b string) bool { return a == b }) {
+			return wt, true
+		}
+	}
+
+	var found []git.Worktree
+	for _, wt := range worktrees {
+		if matches(wt, strings.EqualFold) {
+			found = append(found, wt)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+	return git.Worktree{}, false
+}
+
+// pathTail returns the last elements of path, as many as the
+// slash-separated name has, joined with "/": the directory name of a flat
+// name, or the directories of a nested one (see the nested key of [names]).
+func pathTail(path, name string) string {
+	elems := strings.Split(filepath.ToSlash(path), "/")
+	n := min(strings.Count(name, "/")+1, len(elems))
+	return strings.Join(elems[len(elems)-n:], "/")
+}
+
+// matchWorktree resolves name like findWorktree and falls back to worktrees
+// whose branch or directory name contains name, ignoring case. The fallback
+// only succeeds when exactly one worktree matches; several matches produce an
+// error listing the candidates.
+func matchWorktree(worktrees []git.Worktree, aliases map[string]string, name string) (git.Worktree, error) {
+	if wt, ok := findWorktree(worktrees, aliases, name); ok {
+		return wt, nil
+	}
+	return matchSubstring(worktrees, name)
+}
+
+// matchSubstring is the substring fallback of matchWorktree.
+func matchSubstring(worktrees []git.Worktree, name string) (git.Worktree, error) {
+	needle := strings.ToLower(name)
+	var found []git.Worktree
+	for _, wt := range worktrees {
+		if strings.Contains(strings.ToLower(wt.Branch), needle) ||
+			strings.Contains(strings.ToLower(filepath.Base(wt.Path)), needle) {
+			found = append(found, wt)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return git.Worktree{}, fmt.Errorf("worktree %q not found", name)
+	case 1:
+		return found[0], nil
+	}
+	candidates := make([]string, len(found))
+	for i, wt := range found {
+		candidates[i] = wt.Branch
+	}
+	return git.Worktree{}, &ambiguousError{name: name, candidates: candidates}
+}
+
+// ambiguousError reports a name that matches several worktrees.
+type ambiguousError struct {
+	name       string
+	candidates []string
+}
+
+func (e *ambiguousError) Error() string {
+	return fmt.Sprintf("worktree %q is ambiguous; candidates: %s", e.name, strings.Join(e.candidates, ", "))
+}
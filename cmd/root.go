@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/debug"
+	"github.com/provenimpact/wt/internal/forge"
 	"github.com/provenimpact/wt/internal/git"
 	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/theme"
 	"github.com/provenimpact/wt/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -14,44 +19,178 @@ import (
 var rootCmd = &cobra.Command{
 	Use:   "wt",
 	Short: "Git worktree manager",
-	Long:  "A CLI tool for creating, managing, and switching between git worktrees.",
-	// When invoked with no subcommand, run the interactive selector.
-	RunE: runSelector,
+	Long:  "A CLI tool for creating, managing, and switching between git worktrees.\n\nShort commands can be defined in the [alias] table of the config, like git\naliases:\n\n  [alias]\n  co = \"switch\"\n  rm = \"remove --delete-branch\"\n\nArguments after an alias are passed on, so wt rm api runs\nwt remove --delete-branch api. Built-in commands cannot be redefined. Aliases\nfrom a repository's .wt.toml only run once approved with wt hooks trust.",
+	// When invoked with no subcommand, the interactive selector runs; RunE is
+	// set in init, as the selector can prompt, and prompts refer to rootCmd.
+	PersistentPreRunE: persistentPreRun,
 	// Silence default usage/error output so we control what goes to stderr.
 	SilenceUsage:  true,
 	SilenceErrors: true,
 }
 
+var (
+	globalDir     string
+	globalRepo    string
+	globalNoColor bool
+	globalVerbose bool
+	globalYes     bool
+
+	globalNoInteractive bool
+	globalPathStyle     string
+)
+
+// cfg is the merged user and repository configuration, loaded before any
+// subcommand runs. It is never nil once persistentPreRun has succeeded.
+var cfg = &config.Config{Theme: theme.Default}
+
+func init() {
+	rootCmd.RunE = runSelector
+	rootCmd.PersistentFlags().StringVarP(&globalDir, "directory", "C", "", "Run as if wt was started in this directory, like git -C")
+	rootCmd.PersistentFlags().StringVar(&globalRepo, "repo", "", "Operate on a registered repository by name (see 'wt repos')")
+	rootCmd.PersistentFlags().BoolVar(&globalNoColor, "no-color", false, "Disable colored output (also honors NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVarP(&globalYes, "yes", "y", false, "Answer yes to confirmation prompts for destructive operations")
+	rootCmd.PersistentFlags().BoolVar(&globalNoInteractive, "no-interactive", false, "Never show selectors or prompts, even on a terminal")
+	rootCmd.PersistentFlags().BoolVarP(&globalVerbose, "verbose", "v", false, "Log every git invocation to stderr (or set WT_DEBUG=1, or WT_DEBUG=<file>)")
+	rootCmd.PersistentFlags().StringVar(&globalPathStyle, "path-style", "", "Show paths relative to the repository's parent directory (parent), the current directory (cwd), in full (absolute), or with ~ for the home directory (home)")
+	rootCmd.MarkPersistentFlagDirname("directory")
+	rootCmd.RegisterFlagCompletionFunc("path-style", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return pathStyles, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
 func Execute() error {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := interruptContext()
+	defer stop()
+	args, err := expandArgs(os.Args[1:])
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
 		return err
 	}
+	rootCmd.SetArgs(args)
+	err = rootCmd.ExecuteContext(ctx)
+	if err == nil && interrupted(ctx) {
+		// The command wound down after an interrupt, e.g. at a prompt
+		err = errInterrupted
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		if hint := errorHint(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+		}
+		return err
+	}
+	return nil
+}
+
+// errorHint suggests how to recover from common kinds of git failure.
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, git.ErrNotARepo):
+		return "run wt inside a git repository, or point it at one with -C <path> or --repo"
+	case errors.Is(err, git.ErrBranchCheckedOut):
+		return "a branch can only be checked out in one worktree; use 'wt switch <branch>' to go to it"
+	case errors.Is(err, git.ErrDirty):
+		return "commit or stash the changes first"
+	case errors.Is(err, git.ErrNoUpstream):
+		return "set one with 'git branch --set-upstream-to <remote>/<branch>'"
+	case errors.Is(err, forge.ErrUnauthorized):
+		return "store a token with 'wt auth login <provider>', or set $GITHUB_TOKEN, $GITLAB_TOKEN, or $GITEA_TOKEN"
+	}
+	return ""
+}
+
+// persistentPreRun applies global flags before any subcommand runs.
+func persistentPreRun(cmd *cobra.Command, args []string) error {
+	if err := debug.EnableFromEnv(); err != nil {
+		return err
+	}
+	if globalVerbose {
+		debug.Enable(os.Stderr)
+	}
+	if globalDir != "" {
+		if err := os.Chdir(globalDir); err != nil {
+			return fmt.Errorf("entering directory: %w", err)
+		}
+	}
+	if globalRepo != "" {
+		if err := chdirToRepo(globalRepo); err != nil {
+			return err
+		}
+	}
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	if err := applyPathStyle(); err != nil {
+		return err
+	}
+	if err := applyNames(); err != nil {
+		return err
+	}
+	return applyTheme()
+}
+
+// loadConfig reads the user config and, inside a repository, its .wt.toml.
+func loadConfig() error {
+	mainWorktree := ""
+	if info, err := repo.Resolve(); err == nil {
+		mainWorktree = info.MainWorktree
+	}
+	loaded, err := config.Load(mainWorktree)
+	if err != nil {
+		return err
+	}
+	cfg = loaded
+	return nil
+}
+
+// applyTheme activates the configured color theme for the TUI and tables,
+// and the other settings of the selectors.
+func applyTheme() error {
+	palette, err := cfg.Palette()
+	if err != nil {
+		return err
+	}
+	theme.Apply(palette, theme.Enabled(globalNoColor) && cfg.Theme != theme.NoColor)
+	tui.ApplyTheme(theme.Current())
+	debounce, err := cfg.Debounce()
+	if err != nil {
+		return err
+	}
+	tui.SetFilterDebounce(debounce)
 	return nil
 }
 
 func runSelector(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
 	info, err := repo.Resolve()
 	if err != nil {
 		return err
 	}
 
-	worktrees, err := git.ListWorktrees()
+	worktrees, err := git.ListWorktrees(ctx)
 	if err != nil {
 		return err
 	}
 
 	// Filter to only linked worktrees (not the main one)
+	descs := branchDescriptions(ctx)
+	aliases := worktreeAliases(info)
+	tags := worktreeTags(info)
+	current, _ := currentWorktree(worktrees)
 	var entries []tui.Entry
 	for _, wt := range worktrees {
 		if wt.Path == info.MainWorktree {
 			continue
 		}
-		rel, _ := filepath.Rel(filepath.Dir(info.MainWorktree), wt.Path)
 		entries = append(entries, tui.Entry{
-			Branch: wt.Branch,
-			Path:   wt.Path,
-			Rel:    rel,
+			Branch:      wt.Branch,
+			Path:        wt.Path,
+			Rel:         displayPath(info, wt.Path),
+			Alias:       aliases[wt.Path],
+			Description: descs[wt.Branch],
+			Tags:        tags[wt.Path],
+			Current:     wt.Path == current.Path,
+			Dimmed:      wt.Path == current.Path,
 		})
 	}
 
@@ -60,14 +199,37 @@ func runSelector(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	selected, err := tui.Select(entries)
+	if err := requireInteractive("'wt switch <name>' or 'wt list'"); err != nil {
+		return err
+	}
+	// The worktree the user is in is not a switch target, so start on the
+	// one used last before it
+	selected, err := tui.Select(entries, lastUsedWorktree(info, current.Path), selectorStatus(ctx))
 	if err != nil {
 		return err
 	}
 
-	if selected != "" {
-		// Output cd sentinel to stdout for shell wrapper
-		fmt.Printf("__wt_cd:%s", selected)
+	for _, wt := range worktrees {
+		if wt.Path == selected {
+			return switchTo(ctx, info, worktrees, wt)
+		}
 	}
 	return nil
 }
+
+// selectorStatus returns the function that loads the dirty state and upstream
+// divergence shown next to each worktree in the selector. Missing upstreams
+// simply show no counts.
+func selectorStatus(ctx context.Context) tui.StatusFunc {
+	return func(path string) (tui.Status, error) {
+		dirty, err := git.IsDirty(ctx, path, true)
+		if err != nil {
+			return tui.Status{}, err
+		}
+		st := tui.Status{Dirty: dirty}
+		if ahead, behind, err := git.AheadBehind(ctx, path); err == nil {
+			st.Ahead, st.Behind = ahead, behind
+		}
+		return st, nil
+	}
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/shell"
+	"github.com/provenimpact/wt/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+// setupVerifyTimeout bounds how long the test shell may take to start.
+const setupVerifyTimeout = 15 * time.Second
+
+// Worktree layouts offered by wt setup: branch feature/login in the
+// directory feature-login, or in feature/login ([names] nested).
+const (
+	setupLayoutFlat   = "flat"
+	setupLayoutNested = "nested"
+)
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Set up shell integration and a starter config",
+	Long: `Walk through the first-time setup of wt:
+
+  1. detect the shell from $SHELL (or ask for it),
+  2. add the shell integration and completion to its startup files, as
+     'wt completion install' does,
+  3. write a starter user config (config.toml) with the chosen theme,
+     editor, worktree layout (flat, e.g. feature-login, or nested, e.g.
+     feature/login), and create settings, unless one exists,
+  4. start a new shell to check that the wt function gets defined.
+
+With --yes, every question is answered with its default, so setup can run
+from a script.`,
+	Args: cobra.NoArgs,
+	RunE: runSetup,
+}
+
+func init() {
+	rootCmd.AddCommand(setupCmd)
+}
+
+func runSetup(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if !globalYes && !isInteractive() {
+		return errors.New("wt setup asks questions and needs a terminal; rerun with --yes to accept the defaults")
+	}
+
+	shellName, err := setupShell()
+	if err != nil {
+		return err
+	}
+	installed := false
+	if setupConfirm(fmt.Sprintf("Add wt's shell integration and completion for %s?", shellName)) {
+		if err := installShellIntegration(shellName); err != nil {
+			return err
+		}
+		installed = true
+	}
+
+	fmt.Fprintln(os.Stderr)
+	if err := setupConfig(); err != nil {
+		return err
+	}
+
+	if !installed {
+		fmt.Fprintf(os.Stderr, "\nSkipped the shell integration; add it later with 'wt completion install %s'.\n", shellName)
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "\nStarting a new %s to check the installation...\n", shellName)
+	verifyCtx, cancel := context.WithTimeout(ctx, setupVerifyTimeout)
+	defer cancel()
+	if err := shell.Verify(verifyCtx, shellName); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wt is set up. Open a new terminal, or run 'exec %s', to start using it.\n", shellName)
+	return nil
+}
+
+// setupShell returns the shell detected from $SHELL, or asks for one when it
+// cannot be detected.
+func setupShell() (string, error) {
+	detected, err := shell.DetectShell()
+	if err == nil {
+		fmt.Fprintf(os.Stderr, "Detected shell: %s\n", detected)
+		return detected, nil
+	}
+	if globalYes {
+		return "", err
+	}
+	fmt.Fprintln(os.Stderr, err)
+	return choose("Which shell do you use?", []choice{
+		{key: "bash", label: "bash"},
+		{key: "zsh", label: "zsh"},
+		{key: "fish", label: "fish"},
+	}, "bash"), nil
+}
+
+// setupConfirm asks a yes/no question defaulting to yes; --yes answers it.
+func setupConfirm(question string) bool {
+	if globalYes {
+		return true
+	}
+	return confirmDefaultYes(question)
+}
+
+// setupAsk asks for a value; --yes takes the default.
+func setupAsk(question, def string) string {
+	if globalYes {
+		return def
+	}
+	return ask(question, def)
+}
+
+// setupConfig writes a starter user config from the user's answers. An
+// existing config is left alone.
+func setupConfig() error {
+	dir, err := config.Dir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, config.FileName)
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(os.Stderr, "Keeping your existing config at %s.\n", path)
+		return nil
+	}
+	if !setupConfirm(fmt.Sprintf("Create a starter config at %s?", path)) {
+		return nil
+	}
+
+	themeName := setupAsk(fmt.Sprintf("Color theme (%s)", strings.Join(theme.Names(), ", ")), theme.Default)
+	for {
+		if _, err := theme.Lookup(themeName); err == nil {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "Unknown theme %q.\n", themeName)
+		themeName = ask(fmt.Sprintf("Color theme (%s)", strings.Join(theme.Names(), ", ")), theme.Default)
+	}
+	editor := setupAsk("Editor for wt open, e.g. 'code --new-window' (empty: $VISUAL or $EDITOR)", "")
+	layoutQuestion := fmt.Sprintf("Worktree layout for branch feature/login: %s (directory feature-login) or %s (feature/login)", setupLayoutFlat, setupLayoutNested)
+	layout := setupAsk(layoutQuestion, setupLayoutFlat)
+	for layout != setupLayoutFlat && layout != setupLayoutNested {
+		fmt.Fprintf(os.Stderr, "Unknown layout %q.\n", layout)
+		layout = ask(layoutQuestion, setupLayoutFlat)
+	}
+	fetchBase := !globalYes && confirm("Fetch a remote base such as origin/main before branching from it?")
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(starterConfig(themeName, editor, layout == setupLayoutNested, fetchBase)), 0o644); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return nil
+}
+
+// starterConfig is the text of a new user config, commented so that it
+// doubles as a guide to the most common settings.
+func starterConfig(themeName, editor string, nested, fetchBase bool) string {
+	var b strings.Builder
+	b.WriteString("# wt user configuration. A repository's .wt.toml overrides these settings.\n\n")
+	fmt.Fprintf(&b, "# Color theme: %s\n", strings.Join(theme.Names(), ", "))
+	fmt.Fprintf(&b, "theme = %s\n\n", strconv.Quote(themeName))
+	b.WriteString("# Command for wt open and wt create --edit; defaults to $VISUAL, then $EDITOR\n")
+	if editor != "" {
+		fmt.Fprintf(&b, "editor = %s\n\n", strconv.Quote(editor))
+	} else {
+		b.WriteString("# editor = \"code --new-window\"\n\n")
+	}
+	b.WriteString("# Worktrees are created next to the repository, in <repo>-worktrees/<branch>,\n")
+	b.WriteString("# with unsafe characters in branch names replaced. Run wt migrate-layout after\n")
+	b.WriteString("# changing these settings to rename existing worktrees.\n")
+	b.WriteString("[names]\n")
+	b.WriteString("# Keep \"/\" in branch names as nested directories instead of replacing it\n")
+	fmt.Fprintf(&b, "nested = %t\n", nested)
+	b.WriteString("# replacement = \"-\"  # or \"_\" or \".\"\n")
+	b.WriteString("# max-length = 40   # cut longer names, ending them in a hash of the branch\n")
+	b.WriteString("# lowercase = true\n\n")
+	b.WriteString("[create]\n")
+	b.WriteString("# Fetch a remote-tracking --base such as origin/main before branching from it\n")
+	fmt.Fprintf(&b, "fetch-base = %t\n\n", fetchBase)
+	b.WriteString("[hooks]\n")
+	b.WriteString("# Commands run in each new worktree, e.g. to install dependencies\n")
+	b.WriteString("# post-create = [\"npm install\"]\n")
+	return b.String()
+}
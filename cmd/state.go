@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"time"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/history"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+)
+
+// recordUse stamps the worktree at path as the most recently used one.
+// Errors are ignored: state is a convenience and must never block a command.
+func recordUse(info *repo.Info, path, branch string) {
+	state.New(info.StateDir()).Update(func(st *state.State) error {
+		wt := st.Worktree(path)
+		wt.Branch = branch
+		wt.LastUsed = time.Now()
+		return nil
+	})
+}
+
+// recordCreated stamps the worktree at path as created now, which its age
+// for [expire] is counted from, and gives it a slot (see
+// state.Worktree.Slot). The other worktrees of the repository that have no
+// slot yet, such as those made with git worktree add, get theirs first, so
+// that switching never has to write state to look one up.
+func recordCreated(info *repo.Info, worktrees []git.Worktree, path, branch string) {
+	state.New(info.StateDir()).Update(func(st *state.State) error {
+		for _, other := range worktrees {
+			if other.Path != info.MainWorktree {
+				st.AssignSlot(other.Path)
+			}
+		}
+		wt := st.Worktree(path)
+		wt.Branch = branch
+		wt.Created = time.Now()
+		st.AssignSlot(path)
+		return nil
+	})
+}
+
+// worktreeSlot returns the slot of the worktree at path; the main worktree's
+// is 0. wt create assigns slots, so state is only written here the first time
+// a worktree made without it, e.g. with git worktree add, is looked up. When
+// state cannot be saved, the slot is 0 as well.
+func worktreeSlot(info *repo.Info, path string) int {
+	if path == info.MainWorktree {
+		return 0
+	}
+	store := state.New(info.StateDir())
+	if st, err := store.Load(); err == nil {
+		if rec, ok := st.Lookup(path); ok && rec.Slot > 0 {
+			return rec.Slot
+		}
+	}
+	var slot int
+	if err := store.Update(func(st *state.State) error {
+		slot = st.AssignSlot(path)
+		return nil
+	}); err != nil {
+		return 0
+	}
+	return slot
+}
+
+// recordBase records the ref a new branch was created from, for
+// wt status --against base. An explicit base is also remembered for
+// --base -; without one the branch started at the current worktree's HEAD.
+func recordBase(ctx context.Context, info *repo.Info, path, base string) {
+	explicit := base != ""
+	if !explicit {
+		branch, commit, err := git.CurrentBranch(ctx)
+		if err != nil {
+			return
+		}
+		base = cmp.Or(branch, commit)
+	}
+	state.New(info.StateDir()).Update(func(st *state.State) error {
+		st.Worktree(path).Base = base
+		if explicit {
+			st.LastBase = base
+		}
+		return nil
+	})
+}
+
+// recordProject records the monorepo project the worktree at path checks out.
+func recordProject(info *repo.Info, path, project string) {
+	state.New(info.StateDir()).Update(func(st *state.State) error {
+		st.Worktree(path).Project = project
+		return nil
+	})
+}
+
+// recordDirName records that the worktree at path was given its directory
+// name with wt create --dir-name.
+func recordDirName(info *repo.Info, path, name string) {
+	state.New(info.StateDir()).Update(func(st *state.State) error {
+		st.Worktree(path).DirName = name
+		return nil
+	})
+}
+
+// lastUsedWorktree returns the path of the most recently used worktree that
+// still exists, other than exclude, or "" if none was recorded.
+func lastUsedWorktree(info *repo.Info, exclude string) string {
+	st, err := state.New(info.StateDir()).Load()
+	if err != nil {
+		return ""
+	}
+	var path string
+	var last time.Time
+	for p, wt := range st.Worktrees {
+		if p != exclude && wt.LastUsed.After(last) {
+			if _, err := os.Stat(p); err == nil {
+				path, last = p, wt.LastUsed
+			}
+		}
+	}
+	return path
+}
+
+// forgetWorktree drops any metadata recorded for the worktree at path.
+func forgetWorktree(info *repo.Info, path string) {
+	state.New(info.StateDir()).Update(func(st *state.State) error {
+		st.Forget(path)
+		return nil
+	})
+}
+
+// recordEvent appends an operation and its outcome to the history log. Like
+// state, history must never block a command, so errors are ignored.
+func recordEvent(info *repo.Info, op, branch, path string, opErr error) {
+	outcome := history.OK
+	if opErr != nil {
+		outcome = opErr.Error()
+	}
+	history.New(info.StateDir()).Append(history.Event{
+		Op:      op,
+		Branch:  branch,
+		Path:    path,
+		Outcome: outcome,
+	})
+}
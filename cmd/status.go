@@ -1,68 +1,642 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"text/tabwriter"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/debug"
 	"github.com/provenimpact/wt/internal/git"
 	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/provenimpact/wt/internal/tui"
 	"github.com/spf13/cobra"
 )
 
+var (
+	statusAgainst     string
+	statusWatch       bool
+	statusInterval    time.Duration
+	statusCheck       bool
+	statusCheckOn     []string
+	statusFiles       bool
+	statusFilter      worktreeFilter
+	statusLayout      tableLayout
+	statusCurrent     bool
+	statusPorcelain   bool
+	statusUntracked   bool
+	statusAllRepos    bool
+	statusRoot        string
+	statusSkipMissing bool
+)
+
+// Conditions accepted by wt status --check-on and [status] check.
+const (
+	checkDirty    = "dirty"
+	checkBehind   = "behind"
+	checkAhead    = "ahead"
+	checkError    = "error"
+	checkPrunable = "prunable"
+	checkExpired  = "expired"
+)
+
+var (
+	checkConditions        = []string{checkDirty, checkBehind, checkAhead, checkError, checkPrunable, checkExpired}
+	defaultCheckConditions = []string{checkDirty, checkBehind}
+)
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show status of all worktrees",
-	Long:  "Show the status of all worktrees including branch, clean/dirty state, and ahead/behind counts.",
+	Long:  "Show the status of all worktrees including branch, clean/dirty state, the upstream each branch tracks\n(fix it with 'wt set-upstream'), ahead/behind counts against it, and divergence from the repository's default branch (or the ref given with --against;\n--against base compares each worktree with the ref its branch was created from).\n\n--branch, --tag, --dirty, --clean, --ahead, and --behind limit the table (and\n--check) to matching worktrees; combined filters must all match. Tagged\nworktrees show their tags (see wt tag).\n\nUntracked files do not make a worktree dirty unless --untracked is given, as\nlisting them can take long in worktrees with large unignored trees such as\nnode_modules or build output. With --verbose, the time taken by each worktree\nis logged.\n\nWith --files, the modified files of each dirty worktree are listed below the\ntable, grouped by branch; untracked files are listed too with --untracked.\n\nWith --watch, the table is shown full-screen and refreshed every --interval.\n\nWith --check, wt status exits non-zero if any worktree matches one of the\ncheck conditions (dirty, behind, ahead, error, prunable, expired). The conditions default to\n\"dirty,behind\" and can be set with --check-on or the [status] check config key.\nThe error condition covers worktrees that could not be checked at all.\n\nA worktree whose directory cannot be read is shown as missing, locked (when\nlocked with git worktree lock, e.g. while its volume is unmounted), or\ninaccessible, with the reason below the table; --skip-missing leaves these and\nprunable worktrees out.\n\nWith --current, only the worktree containing the current directory is shown.\nAdding --porcelain prints it as one line for shell prompts, reading the\nworktree's state with a single git status call:\n\n  <branch> <dirty> <ahead> <behind> <linked>\n\nwhere branch is \"(detached)\" for a detached HEAD, dirty and linked (not the\nmain worktree) are 1 or 0, and ahead and behind count commits against the\nupstream (0 without one).\n\nWorktrees older than the after age of the [expire] config table are marked\n\"expired\"; 'wt prune --expired' offers to remove them.\n\nWith --all-repos, the status of every registered repository (see 'wt repos') is\nshown, one table per repository; with --root, the repositories found under that\ndirectory are shown instead. wt status need not run inside a repository then.\n\n--columns and --sort choose the columns and the order of the table as for\nwt list.",
 	Args:  cobra.NoArgs,
 	RunE:  runStatus,
 }
 
 func init() {
+	statusCmd.Flags().StringVar(&statusAgainst, "against", "", "Ref to compare each worktree against, or \"base\" for the one each branch was created from (default: the repository's default branch)")
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "Continuously refresh the status in a full-screen view")
+	statusCmd.Flags().DurationVar(&statusInterval, "interval", 2*time.Second, "Refresh interval for --watch")
+	statusCmd.Flags().BoolVar(&statusFiles, "files", false, "List the changed files of each dirty worktree")
+	statusCmd.Flags().BoolVar(&statusUntracked, "untracked", false, "Count untracked files as changes (slow in worktrees with large untracked trees)")
+	statusCmd.Flags().BoolVar(&statusCheck, "check", false, "Exit non-zero if any worktree matches a check condition")
+	statusCmd.Flags().StringSliceVar(&statusCheckOn, "check-on", nil, "Conditions that fail --check: dirty, behind, ahead, error, prunable, expired (default: dirty,behind)")
+	statusCmd.Flags().BoolVar(&statusCurrent, "current", false, "Show only the worktree containing the current directory")
+	statusCmd.Flags().BoolVar(&statusPorcelain, "porcelain", false, "With --current, print one machine-readable line for shell prompts")
+	statusCmd.Flags().BoolVar(&statusAllRepos, "all-repos", false, "Show the status of every registered repository")
+	statusCmd.Flags().StringVar(&statusRoot, "root", "", "With --all-repos, show the repositories found under this directory instead")
+	statusCmd.MarkFlagDirname("root")
+	statusCmd.Flags().BoolVar(&statusSkipMissing, "skip-missing", false, "Leave out worktrees whose directory is missing, locked away, or inaccessible")
+	statusFilter.register(statusCmd)
+	statusLayout.register(statusCmd)
+	statusCmd.MarkFlagsMutuallyExclusive("check", "watch")
+	statusCmd.MarkFlagsMutuallyExclusive("porcelain", "watch")
+	statusCmd.MarkFlagsMutuallyExclusive("porcelain", "check")
+	for _, flag := range []string{"current", "watch", "check"} {
+		statusCmd.MarkFlagsMutuallyExclusive("all-repos", flag)
+	}
+	statusCmd.RegisterFlagCompletionFunc("check-on", cobra.FixedCompletions(checkConditions, cobra.ShellCompDirectiveNoFileComp))
 	rootCmd.AddCommand(statusCmd)
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	if err := statusFilter.validate(); err != nil {
+		return err
+	}
+	if err := statusLayout.validate(); err != nil {
+		return err
+	}
+	if statusPorcelain && !statusCurrent {
+		return errors.New("--porcelain needs --current")
+	}
+	if statusRoot != "" && !statusAllRepos {
+		return errors.New("--root needs --all-repos")
+	}
+	if statusAllRepos {
+		return writeAllReposStatus(ctx, os.Stderr)
+	}
 	info, err := repo.Resolve()
 	if err != nil {
 		return err
 	}
+	if statusPorcelain {
+		return printPromptStatus(ctx, info)
+	}
+
+	if statusWatch {
+		if err := requireInteractive("'wt status' without --watch"); err != nil {
+			return err
+		}
+		if statusInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+		return tui.Watch("Worktree status", statusInterval, func() (string, error) {
+			var buf bytes.Buffer
+			if err := writeStatus(ctx, &buf, info); err != nil {
+				return "", err
+			}
+			return buf.String(), nil
+		})
+	}
+
+	if statusCheck {
+		return checkStatus(cmd, info)
+	}
+
+	return writeStatus(ctx, os.Stderr, info)
+}
+
+// checkStatus prints the status table and returns an error naming every
+// worktree that matches one of the configured check conditions.
+func checkStatus(cmd *cobra.Command, info *repo.Info) error {
+	ctx := cmd.Context()
+	conditions := defaultCheckConditions
+	if len(cfg.Status.Check) > 0 {
+		conditions = cfg.Status.Check
+	}
+	if cmd.Flags().Changed("check-on") {
+		conditions = statusCheckOn
+	}
+	for _, c := range conditions {
+		if !slices.Contains(checkConditions, c) {
+			return fmt.Errorf("unknown check condition %q (valid: %s)", c, strings.Join(checkConditions, ", "))
+		}
+	}
 
-	worktrees, err := git.ListWorktrees()
+	rows, against, err := collectStatus(ctx, info, statusAgainst, statusFiles, statusUntracked)
 	if err != nil {
 		return err
 	}
+	rows = selectRows(rows)
+	if err := renderStatus(ctx, os.Stderr, info, rows, against); err != nil {
+		return err
+	}
 
-	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "BRANCH\tPATH\tSTATUS\tAHEAD\tBEHIND\tMAIN")
+	var failures []string
+	for _, row := range rows {
+		if failed := row.failedChecks(conditions); len(failed) > 0 {
+			failures = append(failures, fmt.Sprintf("%s (%s)", row.wt.Branch, strings.Join(failed, ", ")))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d worktree(s) failed status check: %s", len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
 
-	for _, wt := range worktrees {
-		isMain := ""
-		if wt.Path == info.MainWorktree {
-			isMain = "*"
+// failedChecks returns the conditions in conditions that row matches.
+func (r statusRow) failedChecks(conditions []string) []string {
+	var failed []string
+	for _, c := range conditions {
+		var match bool
+		switch c {
+		case checkDirty:
+			match = r.status == "dirty"
+		case checkBehind:
+			match = r.upstreamErr == nil && r.behind > 0
+		case checkAhead:
+			match = r.upstreamErr == nil && r.ahead > 0
+		case checkError:
+			// Including worktrees whose directory could not be read
+			match = r.err != nil
+		case checkPrunable:
+			match = r.status == "prunable"
+		case checkExpired:
+			match = r.expired
+		}
+		if match {
+			failed = append(failed, c)
 		}
+	}
+	return failed
+}
 
-		rel, _ := filepath.Rel(filepath.Dir(info.MainWorktree), wt.Path)
+// errPrunable marks the upstream counts of a worktree whose directory is gone.
+var errPrunable = errors.New("worktree directory is missing")
 
-		status := "clean"
-		dirty, err := git.IsDirty(wt.Path)
-		if err != nil {
-			status = "error"
-		} else if dirty {
-			status = "dirty"
+// statusRow is the collected status of one worktree.
+type statusRow struct {
+	wt     git.Worktree
+	rel    string
+	isMain bool
+	// status is "clean", "dirty", "error", or "prunable", or why the
+	// worktree's directory could not be read: "missing", "locked" (and
+	// unavailable), or "inaccessible".
+	status string
+	// err is why the worktree could not be checked, shown below the table.
+	err error
+	// upstream is the short name of the branch's upstream, e.g.
+	// "origin/feature"; empty when none is configured.
+	upstream     string
+	upstreamGone bool
+	ahead        int
+	behind       int
+	// upstreamErr is set when ahead/behind against the upstream failed.
+	upstreamErr error
+	vs          string
+	// files lists the porcelain status lines of a dirty worktree; it is only
+	// collected for --files.
+	files []string
+	// expired is set when the worktree is older than the [expire] policy
+	// allows.
+	expired bool
+	// created, note, project, tags, and description are what wt records
+	// about the worktree, for the columns of the same names (see
+	// annotateRows).
+	created     time.Time
+	note        string
+	project     string
+	tags        []string
+	description string
+}
+
+// statusWorkers bounds how many worktrees are checked at once.
+const statusWorkers = 8
+
+// errDetached marks the upstream counts of a worktree that is not on a branch.
+var errDetached = errors.New("worktree is not on a branch")
+
+// againstBase is the --against value comparing each worktree with the base
+// recorded when wt create made its branch.
+const againstBase = "base"
+
+// collectStatus gathers status for every worktree. against is the ref used
+// for the divergence column; empty means the default branch, and againstBase
+// each worktree's recorded base. With files set, the changed files of each
+// worktree are collected as well. Untracked files only make a worktree dirty
+// with untracked set.
+//
+// Upstream tracking for all branches is read with a single git call, as is
+// divergence from against where git supports it. Only the dirty check needs
+// a git call per worktree, and those run concurrently; how long each
+// worktree took is logged with --verbose.
+func collectStatus(ctx context.Context, info *repo.Info, against string, files, untracked bool) ([]statusRow, string, error) {
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if against == "" {
+		// Best effort: without a default branch the column shows "-"
+		against, _ = git.DefaultBranch(ctx)
+	}
+
+	tracking, err := git.BranchTracking(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	exp, err := loadExpiry(info)
+	if err != nil {
+		return nil, "", err
+	}
+	tags := worktreeTags(info)
+	var bases map[string]string
+	if against == againstBase {
+		bases = recordedBases(info)
+	}
+	var counts map[string][2]int
+	batched := false
+	if against != "" && bases == nil {
+		// On failure, fall back to comparing each worktree on its own
+		counts, batched, _ = git.AheadBehindAll(ctx, against)
+	}
+
+	rows := make([]statusRow, len(worktrees))
+	sem := make(chan struct{}, statusWorkers)
+	var wg sync.WaitGroup
+	for i, wt := range worktrees {
+		row := &rows[i]
+		row.wt = wt
+		row.isMain = wt.Path == info.MainWorktree
+		row.rel = displayPath(info, wt.Path)
+		row.tags = tags[wt.Path]
+		_, row.expired = exp.expired(info, wt)
+
+		if wt.Prunable != "" {
+			// The directory is gone; git cannot report anything about it
+			row.status = "prunable"
+			row.upstreamErr = errPrunable
+			row.vs = "-"
+			continue
+		}
+
+		if t, ok := tracking[wt.Branch]; ok {
+			row.ahead, row.behind = t.Ahead, t.Behind
+			row.upstream, row.upstreamGone = t.Upstream, t.Gone
+		} else {
+			row.upstreamErr = errDetached
+		}
+		c, haveCounts := counts[wt.Branch]
+		if batched && haveCounts {
+			row.vs = fmt.Sprintf("↑%d ↓%d", c[0], c[1])
+		}
+		if row.status, row.err = unreachableStatus(wt); row.err != nil {
+			// The branch is still known to the repository, but git cannot
+			// look inside the directory
+			if row.vs == "" {
+				row.vs = "-"
+			}
+			continue
+		}
+
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			start := time.Now()
+			defer func() { debug.LogStep("status", time.Since(start), "worktree", wt.Path) }()
+
+			row.status = "clean"
+			var dirty bool
+			var err error
+			if files {
+				row.files, err = git.ChangedFiles(ctx, wt.Path, untracked)
+				dirty = len(row.files) > 0
+			} else {
+				dirty, err = git.IsDirty(ctx, wt.Path, untracked)
+			}
+			if err != nil {
+				row.status, row.err = "error", err
+			} else if dirty {
+				row.status = "dirty"
+			}
+			if row.vs == "" {
+				ref := against
+				if bases != nil {
+					ref = bases[wt.Path]
+				}
+				row.vs = divergence(ctx, wt.Path, ref)
+			}
+		})
+	}
+	wg.Wait()
+	return rows, against, nil
+}
+
+// unreachableStatuses are the statuses of worktrees whose directory could not
+// be read, which --skip-missing leaves out along with prunable ones.
+var unreachableStatuses = []string{"missing", "locked", "inaccessible"}
+
+// unreachableStatus returns the status of a worktree whose directory cannot
+// be read, e.g. on an unmounted volume, and why; both are empty for a
+// readable one. A missing directory of a locked worktree is reported as
+// locked, since git keeps such worktrees for when their volume returns.
+func unreachableStatus(wt git.Worktree) (string, error) {
+	_, err := os.Stat(wt.Path)
+	switch {
+	case err == nil:
+		return "", nil
+	case errors.Is(err, fs.ErrNotExist) && wt.Locked != "":
+		return "locked", fmt.Errorf("directory is unavailable; the worktree is locked (%s)", wt.Locked)
+	case errors.Is(err, fs.ErrNotExist):
+		return "missing", errors.New("directory is missing; remove the worktree or restore the directory")
+	}
+	return "inaccessible", err
+}
+
+// recordedBases returns the base recorded for each worktree, keyed by path.
+func recordedBases(info *repo.Info) map[string]string {
+	bases := make(map[string]string)
+	st, err := state.New(info.StateDir()).Load()
+	if err != nil {
+		return bases
+	}
+	for path, wt := range st.Worktrees {
+		if wt.Base != "" {
+			bases[path] = wt.Base
+		}
+	}
+	return bases
+}
+
+// writeStatus renders the status table for all worktrees to out.
+func writeStatus(ctx context.Context, out io.Writer, info *repo.Info) error {
+	rows, against, err := collectStatus(ctx, info, statusAgainst, statusFiles, statusUntracked)
+	if err != nil {
+		return err
+	}
+	return renderStatus(ctx, out, info, selectRows(rows), against)
+}
+
+// statusRepo is a repository shown by wt status --all-repos.
+type statusRepo struct {
+	name string
+	path string
+}
+
+// writeAllReposStatus renders one status table per repository, either the
+// registered ones or those found under --root. A repository that cannot be
+// read is reported in its place. Each one is shown with its own
+// configuration, e.g. for [expire].
+func writeAllReposStatus(ctx context.Context, out io.Writer) error {
+	repos, err := statusRepos()
+	if err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		if statusRoot != "" {
+			fmt.Fprintf(out, "No repositories found under %s.\n", statusRoot)
+		} else {
+			fmt.Fprintln(out, "No repositories registered. Run wt inside a repository or use: wt repos add <path>")
+		}
+		return nil
+	}
+
+	defer func(saved *config.Config) { cfg = saved }(cfg)
+	for i, r := range repos {
+		if i > 0 {
+			fmt.Fprintln(out)
 		}
+		fmt.Fprintf(out, "==> %s (%s)\n", r.name, r.path)
+		if err := writeRepoStatus(ctx, out, r.path); err != nil {
+			fmt.Fprintf(out, "Error: %s\n", err)
+		}
+	}
+	return nil
+}
+
+// writeRepoStatus renders the status table of the repository at path. Like
+// --repo, it runs in the repository's main worktree, and changes back to the
+// current directory afterwards.
+func writeRepoStatus(ctx context.Context, out io.Writer, path string) error {
+	info, err := repo.ResolveDir(ctx, path)
+	if err != nil {
+		return err
+	}
+	if cfg, err = config.Load(info.MainWorktree); err != nil {
+		return err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(info.MainWorktree); err != nil {
+		return err
+	}
+	defer os.Chdir(cwd)
+	return writeStatus(ctx, out, info)
+}
 
-		ahead, behind, err := git.AheadBehind(wt.Path)
-		aheadStr := fmt.Sprintf("%d", ahead)
-		behindStr := fmt.Sprintf("%d", behind)
+// statusRepos returns the repositories for --all-repos: those found under
+// --root, named by their path below it, or else the registered ones.
+func statusRepos() ([]statusRepo, error) {
+	if statusRoot == "" {
+		reg, err := openRegistry()
 		if err != nil {
-			aheadStr = "-"
-			behindStr = "-"
+			return nil, err
+		}
+		registered, err := reg.List()
+		if err != nil {
+			return nil, err
+		}
+		repos := make([]statusRepo, len(registered))
+		for i, r := range registered {
+			repos[i] = statusRepo{name: r.Name, path: r.Path}
+		}
+		return repos, nil
+	}
+
+	root, err := filepath.Abs(statusRoot)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := repo.Discover(root)
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]statusRepo, len(paths))
+	for i, path := range paths {
+		name, err := filepath.Rel(root, path)
+		if err != nil || name == "." {
+			name = filepath.Base(path)
 		}
+		repos[i] = statusRepo{name: filepath.ToSlash(name), path: path}
+	}
+	return repos, nil
+}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", wt.Branch, rel, status, aheadStr, behindStr, isMain)
+// selectRows applies the --branch and other filters, --skip-missing, and
+// --current to rows.
+func selectRows(rows []statusRow) []statusRow {
+	rows = statusFilter.filterRows(rows)
+	if statusSkipMissing {
+		rows = slices.DeleteFunc(rows, func(r statusRow) bool {
+			return r.status == "prunable" || slices.Contains(unreachableStatuses, r.status)
+		})
 	}
+	return filterCurrentRow(rows)
+}
 
-	return w.Flush()
+// filterCurrentRow keeps only the current worktree's row with --current.
+func filterCurrentRow(rows []statusRow) []statusRow {
+	if !statusCurrent {
+		return rows
+	}
+	worktrees := make([]git.Worktree, len(rows))
+	for i, row := range rows {
+		worktrees[i] = row.wt
+	}
+	current, ok := currentWorktree(worktrees)
+	if !ok {
+		return nil
+	}
+	for _, row := range rows {
+		if row.wt.Path == current.Path {
+			return []statusRow{row}
+		}
+	}
+	return nil
+}
+
+// printPromptStatus prints the --current --porcelain line to stdout. As
+// prompts run it on every command, the branch, changes, and upstream counts
+// all come from one git status call; finding the repository and loading its
+// config take a few rev-parse calls on top of that.
+func printPromptStatus(ctx context.Context, info *repo.Info) error {
+	s, err := git.StatusSummary(ctx, ".")
+	if err != nil {
+		return err
+	}
+	branch := s.Branch
+	if branch == "" {
+		branch = "(detached)"
+	}
+	linked := false
+	if cwd, err := os.Getwd(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+			cwd = resolved
+		}
+		_, inMain := pathWithin(cwd, info.MainWorktree)
+		linked = !inMain
+	}
+	fmt.Printf("%s %d %d %d %d\n", branch, boolDigit(s.Dirty), s.Ahead, s.Behind, boolDigit(linked))
+	return nil
+}
+
+func boolDigit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// statusColumns are the columns of wt status without --columns.
+var statusColumns = []string{"branch", "path", "status", "upstream", "ahead", "behind", "vs", "main"}
+
+func renderStatus(ctx context.Context, out io.Writer, info *repo.Info, rows []statusRow, against string) error {
+	columns := statusLayout.columnsOr(statusColumns)
+	if len(statusLayout.columns) == 0 && slices.ContainsFunc(rows, func(r statusRow) bool { return len(r.tags) > 0 }) {
+		columns = append(slices.Clone(columns), "tags")
+	}
+	annotateRows(ctx, info, rows, columns)
+	statusLayout.sortRows(rows)
+	if err := renderTable(out, rows, columns, against); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if len(row.files) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "\n%s (%s):\n", row.wt.Branch, row.rel)
+		for _, f := range row.files {
+			fmt.Fprintf(out, "  %s\n", f)
+		}
+	}
+	var unchecked []statusRow
+	for _, row := range rows {
+		if row.err != nil {
+			unchecked = append(unchecked, row)
+		}
+	}
+	if len(unchecked) > 0 {
+		fmt.Fprintln(out, "\nCould not check:")
+		for _, row := range unchecked {
+			fmt.Fprintf(out, "  %s (%s): %s\n", row.wt.Branch, row.rel, row.err)
+		}
+	}
+	var worktrees []git.Worktree
+	for _, row := range rows {
+		worktrees = append(worktrees, row.wt)
+	}
+	printPruneHint(out, worktrees)
+	expired := 0
+	for _, row := range rows {
+		if row.expired {
+			expired++
+		}
+	}
+	if expired > 0 {
+		fmt.Fprintf(out, "\n%d worktree(s) are older than %s; run 'wt prune --expired' to remove them.\n", expired, cfg.Expire.After)
+	}
+	return nil
+}
+
+// divergence formats how far the worktree at path has diverged from ref,
+// e.g. "↑2 ↓1". Returns "-" if ref is empty or cannot be compared.
+func divergence(ctx context.Context, path, ref string) string {
+	if ref == "" {
+		return "-"
+	}
+	ahead, behind, err := git.AheadBehindRef(ctx, path, ref)
+	if err != nil {
+		return "-"
+	}
+	return fmt.Sprintf("↑%d ↓%d", ahead, behind)
+}
+
+func displayRef(ref string) string {
+	if ref == "" {
+		return "DEFAULT"
+	}
+	return ref
 }
@@ -1,53 +1,83 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/provenimpact/wt/internal/git"
-	"github.com/provenimpact/wt/internal/names"
+	"github.com/provenimpact/wt/internal/history"
+	"github.com/provenimpact/wt/internal/hooks"
+	"github.com/provenimpact/wt/internal/registry"
 	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/shell"
 	"github.com/spf13/cobra"
 )
 
+// switchExec is the --exec command template of the selector and wt switch.
+var switchExec string
+
+var switchPull bool
+
 var switchCmd = &cobra.Command{
 	Use:   "switch <name>",
 	Short: "Switch to a worktree",
-	Long:  "Switch to a specific worktree by branch name.",
+	Long:  "Switch to a specific worktree by branch name.\n\nWith --exec, the shell integration runs the given command instead of changing\ninto the worktree. Its {{worktree_path}}, {{branch}}, {{dir_name}},\n{{repo_name}}, {{main_worktree}}, and {{slot}} placeholders are replaced with\nthe worktree's (shell-quoted) values; without any, the path is appended:\n\n  wt switch api --exec 'code {{worktree_path}}'\n  wt --exec 'tmux new-window -c {{worktree_path}} -n {{branch}}'\n\nWith --pull, the worktree's branch is fast-forwarded to its upstream (git pull\n--ff-only) before changing into it. A worktree with uncommitted changes is not\npulled, and neither is one whose pull fails, e.g. because the branch has\ndiverged; wt warns and switches anyway.",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runSwitch,
 	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
 			return nil, cobra.ShellCompDirectiveNoFileComp
 		}
-		return completeWorktreeBranches(), cobra.ShellCompDirectiveNoFileComp
+		return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
 	},
 }
 
 func init() {
+	for _, c := range []*cobra.Command{rootCmd, switchCmd} {
+		c.Flags().StringVar(&switchExec, "exec", "", "Run this command for the chosen worktree instead of changing into it ({{worktree_path}} and other placeholders)")
+		c.Flags().BoolVar(&switchPull, "pull", false, "Fast-forward the worktree's branch to its upstream before switching")
+	}
 	rootCmd.AddCommand(switchCmd)
 }
 
 func runSwitch(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
 	name := args[0]
 
 	info, err := repo.Resolve()
 	if err != nil {
+		// Outside a repository, "<repo>/<worktree>" can still name a target
+		if wt, ok := findCrossRepoWorktree(ctx, name); ok {
+			return emitTarget(ctx, wt)
+		}
 		return err
 	}
 
-	worktrees, err := git.ListWorktrees()
+	worktrees, err := git.ListWorktrees(ctx)
 	if err != nil {
 		return err
 	}
 
-	sanitized := names.Sanitize(name)
-	for _, wt := range worktrees {
-		if wt.Branch == name || filepath.Base(wt.Path) == name || filepath.Base(wt.Path) == sanitized {
-			fmt.Printf("__wt_cd:%s", wt.Path)
-			return nil
-		}
+	if wt, ok := findWorktree(worktrees, worktreeAliases(info), name); ok {
+		return switchTo(ctx, info, worktrees, wt)
+	}
+
+	if wt, ok := findCrossRepoWorktree(ctx, name); ok {
+		return emitTarget(ctx, wt)
+	}
+
+	wt, err := matchSubstring(worktrees, name)
+	if err == nil {
+		return switchTo(ctx, info, worktrees, wt)
+	}
+	var ambiguous *ambiguousError
+	if errors.As(err, &ambiguous) {
+		return err
 	}
 
 	// Not found -- show available worktrees
@@ -60,3 +90,236 @@ func runSwitch(cmd *cobra.Command, args []string) error {
 	}
 	return fmt.Errorf("worktree %q not found", name)
 }
+
+// switchTo changes into wt, or runs the --exec command for it. When the user
+// is already inside it, there is nothing to do: no directory change is
+// emitted, so post-switch hooks do not run again either.
+func switchTo(ctx context.Context, info *repo.Info, worktrees []git.Worktree, wt git.Worktree) error {
+	autoRegisterRepo(info)
+	pullBeforeSwitch(ctx, wt)
+	if switchExec != "" {
+		recordUse(info, wt.Path, wt.Branch)
+		return emitExec(wt)
+	}
+	if current, ok := currentWorktree(worktrees); ok && current.Path == wt.Path {
+		fmt.Fprintf(os.Stderr, "Already in %s\n", worktreeName(wt))
+		return nil
+	}
+	recordUse(info, wt.Path, wt.Branch)
+	recordEvent(info, history.Switch, wt.Branch, wt.Path, nil)
+	emitSwitch(wt.Path, wt.Branch)
+	return nil
+}
+
+// emitTarget emits the directory change to wt, or its --exec command.
+func emitTarget(ctx context.Context, wt git.Worktree) error {
+	pullBeforeSwitch(ctx, wt)
+	if switchExec != "" {
+		return emitExec(wt)
+	}
+	emitSwitch(wt.Path, wt.Branch)
+	return nil
+}
+
+// pullBeforeSwitch fast-forwards the branch of wt to its upstream for --pull.
+// Failing to is only worth a warning: the switch itself goes ahead.
+func pullBeforeSwitch(ctx context.Context, wt git.Worktree) {
+	if !switchPull {
+		return
+	}
+	name := worktreeName(wt)
+	if wt.Branch == "" || wt.Branch == "(detached)" {
+		fmt.Fprintf(os.Stderr, "Warning: not pulling %s: it is not on a branch\n", name)
+		return
+	}
+	dirty, err := git.IsDirty(ctx, wt.Path, false)
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: not pulling %s: %s\n", name, err)
+		return
+	case dirty:
+		fmt.Fprintf(os.Stderr, "Warning: not pulling %s: it has uncommitted changes\n", name)
+		return
+	}
+	if err := git.Pull(ctx, wt.Path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not pulling %s: %s\n", name, err)
+		if hint := errorHint(err); hint != "" {
+			fmt.Fprintf(os.Stderr, "Hint: %s\n", hint)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Pulled %s\n", name)
+}
+
+// worktreeName is the branch of wt, or its directory name when detached.
+func worktreeName(wt git.Worktree) string {
+	if wt.Branch != "" {
+		return wt.Branch
+	}
+	return filepath.Base(wt.Path)
+}
+
+// currentWorktree returns the worktree containing the current directory.
+func currentWorktree(worktrees []git.Worktree) (git.Worktree, bool) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return git.Worktree{}, false
+	}
+	return worktreeContaining(worktrees, cwd)
+}
+
+// worktreeContaining returns the innermost worktree containing path, which
+// may be relative to the working directory.
+func worktreeContaining(worktrees []git.Worktree, path string) (git.Worktree, bool) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return git.Worktree{}, false
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	var found git.Worktree
+	ok := false
+	for _, wt := range worktrees {
+		_, inside := pathWithin(path, wt.Path)
+		// Worktrees may be nested in the main one; the innermost wins
+		if inside && len(wt.Path) > len(found.Path) {
+			found, ok = wt, true
+		}
+	}
+	return found, ok
+}
+
+// findCrossRepoWorktree resolves "<repo>/<worktree>" against the repository
+// registry and returns the worktree. The working directory is changed to the
+// target repository as a side effect.
+func findCrossRepoWorktree(ctx context.Context, target string) (git.Worktree, bool) {
+	reg, err := openRegistry()
+	if err != nil {
+		return git.Worktree{}, false
+	}
+	r, name, ok, err := reg.Split(target)
+	if err != nil || !ok {
+		return git.Worktree{}, false
+	}
+	return worktreeInRepo(ctx, r, name)
+}
+
+func worktreeInRepo(ctx context.Context, r registry.Repo, name string) (git.Worktree, bool) {
+	if err := os.Chdir(r.Path); err != nil {
+		return git.Worktree{}, false
+	}
+	info, err := repo.Resolve()
+	if err != nil {
+		return git.Worktree{}, false
+	}
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return git.Worktree{}, false
+	}
+	wt, ok := findWorktree(worktrees, worktreeAliases(info), name)
+	if !ok {
+		return git.Worktree{}, false
+	}
+	recordUse(info, wt.Path, wt.Branch)
+	recordEvent(info, history.Switch, wt.Branch, wt.Path, nil)
+	return wt, true
+}
+
+// emitSwitch hands the shell wrapper the cd sentinel, followed by one
+// __wt_run line per post-switch hook command for the wrapper to run after it
+// has changed directory. Commands from the repository's .wt.toml are only
+// handed over once approved, as the wrapper evaluates them in the user's
+// shell.
+func emitSwitch(path, branch string) {
+	directives := []string{"__wt_cd:" + path}
+	defer func() { emitDirectives(directives...) }()
+	if len(cfg.Hooks.PostSwitch) == 0 {
+		return
+	}
+	info, err := repo.Resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: skipped the post-switch hook: %s\n", err)
+		return
+	}
+	if !hooksAllowed(info, hooks.PostSwitch) {
+		return
+	}
+
+	ctx := hooks.Context{
+		Branch:       branch,
+		Path:         path,
+		MainWorktree: info.MainWorktree,
+		RepoName:     info.RepoName,
+		Slot:         worktreeSlot(info, path),
+	}
+	script, err := hooks.Script(hooks.PostSwitch, cfg.Hooks.PostSwitch, ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+		return
+	}
+	for _, command := range script {
+		directives = append(directives, "__wt_run:"+command)
+	}
+}
+
+// emitExec hands the --exec command to the shell wrapper as a lone __wt_run
+// line, which it runs instead of changing directory. The command's
+// placeholders are those of hooks; a command without any gets the worktree's
+// path as its last argument.
+func emitExec(wt git.Worktree) error {
+	c := hooks.Context{Branch: wt.Branch, Path: wt.Path}
+	if info, err := repo.Resolve(); err == nil {
+		c.MainWorktree = info.MainWorktree
+		c.RepoName = info.RepoName
+		c.Slot = worktreeSlot(info, wt.Path)
+	}
+	template := switchExec
+	if !strings.Contains(template, "{{") {
+		template += " {{worktree_path}}"
+	}
+	command := hooks.Expand(template, c)
+	if strings.ContainsAny(command, "\r\n") {
+		return errors.New("the --exec command must be a single line")
+	}
+	emitDirectives("__wt_run:" + command)
+	return nil
+}
+
+// emitDirectives hands lines such as "__wt_cd:<path>" to the shell wrapper.
+// The wrappers of wt init name a file for them in WT_DIRECTIVE_FILE, which
+// leaves stdout to the terminal and to $(wt ...); without one, e.g. under a
+// wrapper from an older wt, they are printed to stdout.
+func emitDirectives(lines ...string) {
+	warnStaleWrapper()
+	if file := os.Getenv(shell.DirectiveFileEnv); file != "" {
+		f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0)
+		if err == nil {
+			_, err = io.WriteString(f, strings.Join(lines, "\n")+"\n")
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		if err == nil {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Warning: writing to the shell integration: %s\n", err)
+	}
+	fmt.Print(strings.Join(lines, "\n"))
+}
+
+// warnStaleWrapper warns when the shell wrapper speaks another directive
+// protocol than this wt, which would otherwise break switching silently.
+func warnStaleWrapper() {
+	err := shell.CheckWrapper(os.Getenv(shell.WrapperVersionEnv))
+	switch {
+	case errors.Is(err, shell.ErrOutdatedWrapper):
+		reload := "wt init <shell>"
+		if name, err := shell.DetectShell(); err == nil {
+			reload = shell.ReloadCommand(name)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s. Open a new terminal, or reload it with: %s\n", err, reload)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+}
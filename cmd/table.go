@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// table aligns tab-separated columns and applies styles after alignment, so
+// escape sequences never skew column widths. Styles apply to whole lines, or
+// replace single cells with a styled rendering of the same text.
+type table struct {
+	buf    bytes.Buffer
+	tw     *tabwriter.Writer
+	styles []*lipgloss.Style // one per line; nil means unstyled
+	cells  [][]string
+	styled []map[int]string // per line, the styled text of a cell by index
+}
+
+func newTable(header ...string) *table {
+	t := &table{}
+	t.tw = tabwriter.NewWriter(&t.buf, 0, 0, 2, ' ', 0)
+	t.row(nil, header...)
+	return t
+}
+
+// row adds a line of cells rendered with style (nil for the default style).
+func (t *table) row(style *lipgloss.Style, cells ...string) {
+	fmt.Fprintln(t.tw, strings.Join(cells, "\t"))
+	t.styles = append(t.styles, style)
+	t.cells = append(t.cells, cells)
+	t.styled = append(t.styled, nil)
+}
+
+// styleCell shows cell i of the last row added as styled, which must render
+// the same text as the cell.
+func (t *table) styleCell(i int, styled string) {
+	last := len(t.styled) - 1
+	if t.styled[last] == nil {
+		t.styled[last] = make(map[int]string)
+	}
+	t.styled[last][i] = styled
+}
+
+// flush writes the aligned, styled table to out.
+func (t *table) flush(out io.Writer) error {
+	if err := t.tw.Flush(); err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimSuffix(t.buf.String(), "\n"), "\n")
+	for i, line := range lines {
+		if i < len(t.styles) {
+			line = t.render(i, line)
+		}
+		if _, err := fmt.Fprintln(out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// render styles the aligned line i. The styled cells are found in it by
+// their text, in order, and the line's style is applied around them.
+func (t *table) render(i int, line string) string {
+	style := t.styles[i]
+	if style != nil {
+		line = strings.TrimRight(line, " ")
+	}
+	plain := func(s string) string {
+		if style == nil || s == "" {
+			return s
+		}
+		return style.Render(s)
+	}
+	if len(t.styled[i]) == 0 {
+		return plain(line)
+	}
+
+	// from is where the text not yet written starts, pos where the search
+	// for the next cell does
+	var b strings.Builder
+	from, pos := 0, 0
+	for j, cell := range t.cells[i] {
+		at := strings.Index(line[pos:], cell)
+		if at < 0 {
+			break
+		}
+		at += pos
+		pos = at + len(cell)
+		if styled, ok := t.styled[i][j]; ok {
+			b.WriteString(plain(line[from:at]))
+			b.WriteString(styled)
+			from = pos
+		}
+	}
+	b.WriteString(plain(line[from:]))
+	return b.String()
+}
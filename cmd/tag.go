@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+	"github.com/provenimpact/wt/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var tagDelete bool
+
+// suggestedTags are offered in completion besides the tags in use.
+var suggestedTags = []string{"review", "hotfix", "experiment"}
+
+var tagCmd = &cobra.Command{
+	Use:   "tag [worktree] [tag...]",
+	Short: "Label worktrees with their purpose",
+	Long: `Tag a worktree with what it is for, to keep many worktrees apart:
+
+  wt tag fix/login hotfix
+  wt tag feature/api review experiment
+  wt list --tag review
+
+A worktree can have any number of tags, such as review, hotfix, or experiment.
+Tags are shown as colored chips in wt list, wt status, and the selectors, and
+--tag limits wt list, wt status, and wt prune to the worktrees with a tag. A
+tag cannot contain "," or whitespace.
+
+Without arguments, all tagged worktrees are listed. With only a worktree, its
+tags are printed to stdout, one per line. --delete removes the given tags, or
+all of the worktree's tags when none are given.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: runTag,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 0 {
+			return completeWorktreeBranches(cmd.Context()), cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeTags(), cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	tagCmd.Flags().BoolVarP(&tagDelete, "delete", "d", false, "Remove the tags (all of them when none are given)")
+	rootCmd.AddCommand(tagCmd)
+}
+
+func runTag(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		if tagDelete {
+			return fmt.Errorf("--delete takes the worktree to remove tags from")
+		}
+		return listTags(info, worktrees)
+	}
+	target, err := matchWorktree(worktrees, worktreeAliases(info), args[0])
+	if err != nil {
+		return err
+	}
+	tags := args[1:]
+	if len(tags) == 0 && !tagDelete {
+		for _, tag := range worktreeTags(info)[target.Path] {
+			fmt.Println(tag)
+		}
+		return nil
+	}
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return err
+		}
+	}
+
+	store := state.New(info.StateDir())
+	if err := store.Update(func(st *state.State) error {
+		wt := st.Worktree(target.Path)
+		switch {
+		case tagDelete && len(tags) == 0:
+			wt.Tags = nil
+		case tagDelete:
+			wt.Tags = slices.DeleteFunc(wt.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+		default:
+			for _, tag := range tags {
+				if !slices.Contains(wt.Tags, tag) {
+					wt.Tags = append(wt.Tags, tag)
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	switch {
+	case tagDelete && len(tags) == 0:
+		fmt.Fprintf(os.Stderr, "Removed the tags of %s\n", worktreeName(target))
+	case tagDelete:
+		fmt.Fprintf(os.Stderr, "Untagged %s: %s\n", worktreeName(target), strings.Join(tags, ", "))
+	default:
+		fmt.Fprintf(os.Stderr, "Tagged %s: %s\n", worktreeName(target), strings.Join(tags, ", "))
+	}
+	return nil
+}
+
+// validateTag rejects tags that --tag could not name.
+func validateTag(tag string) error {
+	if tag == "" || strings.ContainsAny(tag, ", \t\n") {
+		return fmt.Errorf("invalid tag %q: it must be non-empty without \",\" or whitespace", tag)
+	}
+	return nil
+}
+
+// listTags prints a table of the tagged worktrees.
+func listTags(info *repo.Info, worktrees []git.Worktree) error {
+	tags := worktreeTags(info)
+	t := newTable("BRANCH", "TAGS", "PATH")
+	tagged := 0
+	for _, wt := range worktrees {
+		if len(tags[wt.Path]) == 0 {
+			continue
+		}
+		t.row(nil, worktreeName(wt), tui.TagChips(tags[wt.Path], false), displayPath(info, wt.Path))
+		t.styleCell(1, tui.TagChips(tags[wt.Path], true))
+		tagged++
+	}
+	if tagged == 0 {
+		fmt.Fprintln(os.Stderr, "No tagged worktrees. Tag one with: wt tag <worktree> <tag>")
+		return nil
+	}
+	return t.flush(os.Stderr)
+}
+
+// worktreeTags returns the tags of each tagged worktree, by path. They are
+// only a convenience, so a failure to read them yields none.
+func worktreeTags(info *repo.Info) map[string][]string {
+	st, err := state.New(info.StateDir()).Load()
+	if err != nil {
+		return nil
+	}
+	tags := make(map[string][]string)
+	for path, wt := range st.Worktrees {
+		if len(wt.Tags) > 0 {
+			tags[path] = wt.Tags
+		}
+	}
+	return tags
+}
+
+// hasTags reports whether have includes every tag in want.
+func hasTags(have, want []string) bool {
+	for _, tag := range want {
+		if !slices.Contains(have, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// completeTags returns the tags in use in the repository and the suggested
+// ones for tab completion.
+func completeTags() []string {
+	names := slices.Clone(suggestedTags)
+	if info, err := repo.Resolve(); err == nil {
+		for _, tags := range worktreeTags(info) {
+			for _, tag := range tags {
+				if !slices.Contains(names, tag) {
+					names = append(names, tag)
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/spf13/cobra"
+)
+
+var setUpstreamCmd = &cobra.Command{
+	Use:   "set-upstream <branch> <remote>",
+	Short: "Set the remote a branch tracks",
+	Long: `Make a local branch track the branch of the same name on remote, e.g.
+"wt set-upstream feature-x fork" sets its upstream to fork/feature-x. The
+remote branch is fetched first, so it only has to exist on the remote.
+
+The UPSTREAM column of wt status shows what each worktree tracks.`,
+	Args: cobra.ExactArgs(2),
+	RunE: runSetUpstream,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		switch len(args) {
+		case 0:
+			names, _ = git.ListLocalBranches(cmd.Context())
+		case 1:
+			names, _ = git.ListRemotes(cmd.Context())
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setUpstreamCmd)
+}
+
+func runSetUpstream(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	branch, remote := args[0], args[1]
+	if !git.LocalBranchExists(ctx, branch) {
+		return fmt.Errorf("no local branch %q", branch)
+	}
+	remotes, err := git.ListRemotes(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(remotes, remote) {
+		return fmt.Errorf("no remote %q", remote)
+	}
+
+	upstream := remote + "/" + branch
+	if err := git.FetchBranch(ctx, remote, branch); err != nil && !git.RefExists(ctx, upstream) {
+		return fmt.Errorf("%w; push it first with: git push -u %s %s", err, remote, branch)
+	}
+	if err := git.SetUpstream(ctx, branch, upstream); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Branch %q now tracks %s\n", branch, upstream)
+	return nil
+}
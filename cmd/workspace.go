@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+var workspaceOutput string
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Generate a VS Code workspace listing all worktrees",
+	Long: `Generate or update a multi-root VS Code workspace (.code-workspace) with
+one folder per worktree. Other settings in an existing workspace file are kept.
+
+The file is written next to the main worktree as <repo>.code-workspace unless
+--output or the [workspace] path config key says otherwise. Set
+[workspace] sync = true to update it automatically on wt create and wt remove.`,
+	Args: cobra.NoArgs,
+	RunE: runWorkspace,
+}
+
+func init() {
+	workspaceCmd.Flags().StringVarP(&workspaceOutput, "output", "o", "", "Path of the workspace file to write")
+	rootCmd.AddCommand(workspaceCmd)
+}
+
+func runWorkspace(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	info, err := repo.Resolve()
+	if err != nil {
+		return err
+	}
+
+	path := workspaceOutput
+	if path == "" {
+		path = workspacePath(info)
+	}
+	n, err := writeWorkspace(ctx, info, path)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote workspace with %d folder(s) to %s\n", n, path)
+	return nil
+}
+
+// workspacePath returns the configured workspace file location.
+func workspacePath(info *repo.Info) string {
+	parent := filepath.Dir(info.MainWorktree)
+	switch p := cfg.Workspace.Path; {
+	case p == "":
+		return filepath.Join(parent, info.RepoName+workspace.Extension)
+	case filepath.IsAbs(p):
+		return p
+	default:
+		return filepath.Join(parent, p)
+	}
+}
+
+// writeWorkspace writes every existing worktree, main first, to the workspace
+// file at path and returns the number of folders.
+func writeWorkspace(ctx context.Context, info *repo.Info, path string) (int, error) {
+	worktrees, err := git.ListWorktrees(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var folders []workspace.Folder
+	for _, wt := range worktrees {
+		if wt.Prunable != "" {
+			continue
+		}
+		name := wt.Branch
+		if wt.Path == info.MainWorktree {
+			name = info.RepoName
+		}
+		folders = append(folders, workspace.Folder{Name: name, Path: wt.Path})
+	}
+	if err := workspace.Write(path, folders); err != nil {
+		return 0, err
+	}
+	return len(folders), nil
+}
+
+// syncWorkspace refreshes the workspace file after worktrees were added or
+// removed, if [workspace] sync is enabled. Failures are only reported.
+func syncWorkspace(ctx context.Context, info *repo.Info) {
+	if !cfg.Workspace.Sync {
+		return
+	}
+	if _, err := writeWorkspace(ctx, info, workspacePath(info)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", err)
+	}
+}
@@ -0,0 +1,64 @@
+// Package auth keeps forge API tokens in the operating system's credential
+// store, so that they need not be kept in plain text in the environment or
+// the config: the Keychain on macOS, the Secret Service (through libsecret's
+// secret-tool) on Linux and other Unix systems, and the Credential Manager on
+// Windows.
+//
+// Tokens are stored per provider and host under the service name "wt".
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// service names wt's entries in the credential store.
+const service = "wt"
+
+// ErrNotFound means no token is stored for a provider and host.
+var ErrNotFound = errors.New("no stored token")
+
+// account is the key of a provider's token for host, e.g. "github:github.com".
+func account(provider, host string) string {
+	return provider + ":" + host
+}
+
+// Set stores token for provider at host, replacing any earlier one.
+func Set(provider, host, token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return errors.New("the token is empty")
+	}
+	if strings.ContainsAny(token, " \t\r\n'\"\\") {
+		return errors.New("the token contains whitespace or quotes; check that it was pasted whole")
+	}
+	if err := storeSecret(account(provider, host), token); err != nil {
+		return fmt.Errorf("storing token: %w", err)
+	}
+	return nil
+}
+
+// Get returns the token stored for provider at host, or ErrNotFound.
+func Get(provider, host string) (string, error) {
+	token, err := lookupSecret(account(provider, host))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return "", err
+		}
+		return "", fmt.Errorf("reading token: %w", err)
+	}
+	return token, nil
+}
+
+// Delete removes the token stored for provider at host. Deleting a token
+// that is not stored returns ErrNotFound.
+func Delete(provider, host string) error {
+	if err := deleteSecret(account(provider, host)); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return err
+		}
+		return fmt.Errorf("deleting token: %w", err)
+	}
+	return nil
+}
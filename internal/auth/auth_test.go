@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeSecretTool puts a secret-tool on $PATH that keeps one secret per
+// account in a directory.
+func fakeSecretTool(t *testing.T) {
+	t.Helper()
+	bin := t.TempDir()
+	store := t.TempDir()
+	script := `#!/bin/sh
+store=` + store + `
+cmd=$1; shift
+while [ $# -gt 0 ]; do
+  case $1 in
+    --label) shift 2 ;;
+    account) account=$2; shift 2 ;;
+    *) shift 2 ;;
+  esac
+done
+case $cmd in
+  store) cat > "$store/$account" ;;
+  lookup) [ -f "$store/$account" ] || exit 1; cat "$store/$account" ;;
+  clear) rm -f "$store/$account" ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSetGetDelete(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		t.Skip("uses the secret-tool backend")
+	}
+	fakeSecretTool(t)
+
+	if _, err := Get("github", "github.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() before Set() error = %v, want ErrNotFound", err)
+	}
+	if err := Set("github", "github.com", " ghp_secret\n"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if token, err := Get("github", "github.com"); err != nil || token != "ghp_secret" {
+		t.Errorf("Get() = %q, %v; want the trimmed token", token, err)
+	}
+	if _, err := Get("gitlab", "github.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("tokens should be kept per provider, got %v", err)
+	}
+	if err := Delete("github", "github.com"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if err := Delete("github", "github.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSet_RejectsMalformedTokens(t *testing.T) {
+	for _, token := range []string{"", "  ", "two words", "it's"} {
+		if err := Set("github", "github.com", token); err == nil {
+			t.Errorf("Set(%q) should fail", token)
+		}
+	}
+}
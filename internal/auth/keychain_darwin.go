@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// securityNotFound is the exit status of security(1) for a missing item.
+const securityNotFound = 44
+
+// storeSecret adds the secret to the login keychain. The command is passed
+// to "security -i" on stdin, so the secret never appears in a process list.
+func storeSecret(account, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s '%s' -a '%s' -l '%s' -w '%s'\n", service, account, service+" "+account, secret))
+	return runSecurity(cmd)
+}
+
+func lookupSecret(account string) (string, error) {
+	cmd := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	out, err := cmd.Output()
+	if err := securityError(err); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func deleteSecret(account string) error {
+	return runSecurity(exec.Command("security", "delete-generic-password", "-s", service, "-a", account))
+}
+
+func runSecurity(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := securityError(cmd.Run())
+	if err != nil && !errors.Is(err, ErrNotFound) && stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
+
+func securityError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == securityNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+//go:build unix && !darwin
+
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSilent is returned by secretTool when it fails without a message, which
+// it does when a lookup matches nothing.
+var errSilent = errors.New("secret-tool failed")
+
+// secretTool runs libsecret's secret-tool, which talks to the Secret Service
+// of the desktop session (GNOME Keyring, KWallet).
+func secretTool(stdin string, args ...string) (string, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return "", errors.New("secret-tool was not found; install libsecret (e.g. the libsecret-tools package)")
+	}
+	cmd := exec.Command("secret-tool", args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		msg := strings.TrimSpace(stderr.String())
+		switch {
+		case msg != "":
+			return "", fmt.Errorf("secret-tool: %s", msg)
+		case errors.As(err, &exitErr):
+			return "", errSilent
+		}
+		return "", fmt.Errorf("secret-tool: %w", err)
+	}
+	return stdout.String(), nil
+}
+
+// storeSecret stores the secret, which secret-tool reads from stdin.
+func storeSecret(account, secret string) error {
+	_, err := secretTool(secret, "store", "--label", service+" "+account, "service", service, "account", account)
+	return err
+}
+
+func lookupSecret(account string) (string, error) {
+	out, err := secretTool("", "lookup", "service", service, "account", account)
+	if err != nil && !errors.Is(err, errSilent) {
+		return "", err
+	}
+	if out = strings.TrimSpace(out); out == "" {
+		return "", ErrNotFound
+	}
+	return out, nil
+}
+
+func deleteSecret(account string) error {
+	if _, err := lookupSecret(account); err != nil {
+		return err
+	}
+	_, err := secretTool("", "clear", "service", service, "account", account)
+	return err
+}
@@ -0,0 +1,192 @@
+// Package cache keeps the results of slow git listings in
+// .git/wt/cache.json so interactive commands can show them instantly.
+//
+// Each cached listing carries the ref-state key it was computed under (see
+// RefKey). A listing whose key no longer matches is stale but still usable
+// as a placeholder while a fresh one is loaded. The file is only ever
+// replaced atomically; being a cache, concurrent writers simply race and the
+// last one wins.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/provenimpact/wt/internal/state"
+)
+
+const fileName = "cache.json"
+
+// Branches is a cached branch listing.
+type Branches struct {
+	// Key is the RefKey the listing was computed under.
+	Key    string   `json:"key"`
+	Local  []string `json:"local"`
+	Remote []string `json:"remote"`
+	// LocalCommits and RemoteCommits hold the last commit of each branch in
+	// Local and Remote, keyed the same way.
+	LocalCommits  map[string]Commit `json:"local_commits,omitempty"`
+	RemoteCommits map[string]Commit `json:"remote_commits,omitempty"`
+	Time          time.Time         `json:"time"`
+}
+
+// Commit is the last commit of a cached branch.
+type Commit struct {
+	Date   time.Time `json:"date"`
+	Author string    `json:"author"`
+}
+
+// Completion is a cached list of tab-completion candidates.
+type Completion struct {
+	// Key is the CompletionKey the candidates were computed under.
+	Key   string    `json:"key"`
+	Items []string  `json:"items"`
+	Time  time.Time `json:"time"`
+}
+
+// file is the on-disk layout of cache.json.
+type file struct {
+	Branches *Branches `json:"branches,omitempty"`
+	// Completions holds the cached candidates of each completion, by name.
+	Completions map[string]*Completion `json:"completions,omitempty"`
+}
+
+// Store reads and writes the cache file in a directory.
+type Store struct {
+	dir string
+}
+
+// New returns a Store backed by dir, typically repo.Info.StateDir().
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Branches returns the cached branch listing, or nil if there is none or the
+// cache cannot be read.
+func (s *Store) Branches() *Branches {
+	return s.read().Branches
+}
+
+// SaveBranches replaces the cached branch listing.
+func (s *Store) SaveBranches(b *Branches) error {
+	f := s.read()
+	f.Branches = b
+	return s.write(f)
+}
+
+// Completion returns the cached candidates of the named completion, or nil if
+// there are none or the cache cannot be read.
+func (s *Store) Completion(name string) *Completion {
+	return s.read().Completions[name]
+}
+
+// SaveCompletion replaces the cached candidates of the named completion. As
+// shells complete on every keystroke, the file is left alone when the key and
+// candidates are those already cached, keeping their earlier Time.
+func (s *Store) SaveCompletion(name string, c *Completion) error {
+	f := s.read()
+	if old := f.Completions[name]; old != nil && old.Key == c.Key && slices.Equal(old.Items, c.Items) {
+		return nil
+	}
+	if f.Completions == nil {
+		f.Completions = make(map[string]*Completion)
+	}
+	f.Completions[name] = c
+	return s.write(f)
+}
+
+func (s *Store) read() *file {
+	var f file
+	data, err := os.ReadFile(filepath.Join(s.dir, fileName))
+	if err == nil {
+		// A corrupt cache is treated as empty and overwritten on next save
+		json.Unmarshal(data, &f)
+	}
+	return &f
+}
+
+func (s *Store) write(f *file) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("encoding cache: %w", err)
+	}
+	return state.WriteFileAtomic(filepath.Join(s.dir, fileName), data)
+}
+
+// RefKey fingerprints the ref state of the repository whose shared git
+// directory is gitCommonDir: the name, size, and modification time of
+// packed-refs and of every loose ref under refs/. Any branch creation,
+// deletion, commit, or fetch changes the key. It reads only file metadata,
+// so it is far cheaper than listing refs through git.
+func RefKey(gitCommonDir string) (string, error) {
+	h := sha256.New()
+	add := func(path string, fi fs.FileInfo) {
+		rel, _ := filepath.Rel(gitCommonDir, path)
+		fmt.Fprintf(h, "%s\x00%d\x00%d\n", rel, fi.Size(), fi.ModTime().UnixNano())
+	}
+
+	if fi, err := os.Stat(filepath.Join(gitCommonDir, "packed-refs")); err == nil {
+		add(filepath.Join(gitCommonDir, "packed-refs"), fi)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	err := filepath.WalkDir(filepath.Join(gitCommonDir, "refs"), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		add(path, fi)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("reading ref state: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// CompletionKey extends RefKey with the HEAD of every worktree of the
+// repository whose shared git directory is gitCommonDir, so that adding or
+// removing a worktree, or checking out another branch in one, changes the key
+// as well.
+func CompletionKey(gitCommonDir string) (string, error) {
+	key, err := RefKey(gitCommonDir)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, key)
+	heads := []string{filepath.Join(gitCommonDir, "HEAD")}
+	linked, err := os.ReadDir(filepath.Join(gitCommonDir, "worktrees"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("reading worktree state: %w", err)
+	}
+	for _, e := range linked {
+		heads = append(heads, filepath.Join(gitCommonDir, "worktrees", e.Name(), "HEAD"))
+	}
+	for _, head := range heads {
+		// A worktree being added or removed may lack its HEAD for a moment
+		if fi, err := os.Stat(head); err == nil {
+			rel, _ := filepath.Rel(gitCommonDir, head)
+			fmt.Fprintf(h, "%s\x00%d\x00%d\n", rel, fi.Size(), fi.ModTime().UnixNano())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gitInit(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	return dir
+}
+
+func TestRefKey_ChangesWithRefs(t *testing.T) {
+	dir := gitInit(t)
+	gitDir := filepath.Join(dir, ".git")
+
+	before, err := RefKey(gitDir)
+	if err != nil {
+		t.Fatalf("RefKey() error: %v", err)
+	}
+	if again, _ := RefKey(gitDir); again != before {
+		t.Error("RefKey() should be stable while refs are unchanged")
+	}
+
+	cmd := exec.Command("git", "branch", "feature")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git branch failed: %v\n%s", err, out)
+	}
+	if after, _ := RefKey(gitDir); after == before {
+		t.Error("RefKey() should change when a branch is created")
+	}
+}
+
+func TestStore_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "wt")
+	s := New(dir)
+
+	if s.Branches() != nil {
+		t.Error("Branches() should be nil before anything is cached")
+	}
+
+	want := &Branches{Key: "k", Local: []string{"main"}, Remote: []string{"feature"}}
+	if err := s.SaveBranches(want); err != nil {
+		t.Fatalf("SaveBranches() error: %v", err)
+	}
+	got := New(dir).Branches()
+	if got == nil || got.Key != want.Key || !reflect.DeepEqual(got.Local, want.Local) || !reflect.DeepEqual(got.Remote, want.Remote) {
+		t.Errorf("Branches() = %+v, want %+v", got, want)
+	}
+
+	os.WriteFile(filepath.Join(dir, fileName), []byte("{corrupt"), 0o644)
+	if New(dir).Branches() != nil {
+		t.Error("a corrupt cache should read as empty")
+	}
+}
+
+func TestCompletionKey_ChangesWithWorktrees(t *testing.T) {
+	dir := gitInit(t)
+	gitDir := filepath.Join(dir, ".git")
+
+	before, err := CompletionKey(gitDir)
+	if err != nil {
+		t.Fatalf("CompletionKey() error: %v", err)
+	}
+	if again, _ := CompletionKey(gitDir); again != before {
+		t.Error("CompletionKey() should be stable while nothing changes")
+	}
+
+	cmd := exec.Command("git", "branch", "feature")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git branch failed: %v\n%s", err, out)
+	}
+	// Checking out an existing branch in a new worktree leaves refs alone
+	refs, _ := RefKey(gitDir)
+	withBranch, _ := CompletionKey(gitDir)
+	cmd = exec.Command("git", "worktree", "add", filepath.Join(t.TempDir(), "feature"), "feature")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git worktree add failed: %v\n%s", err, out)
+	}
+	if again, _ := RefKey(gitDir); again != refs {
+		t.Skip("this git version touches refs when adding a worktree")
+	}
+	if after, _ := CompletionKey(gitDir); after == withBranch {
+		t.Error("CompletionKey() should change when a worktree is added")
+	}
+}
+
+func TestStore_Completion(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "wt")
+	s := New(dir)
+	if s.Completion("worktrees") != nil {
+		t.Error("Completion() should be nil before anything is cached")
+	}
+
+	s.SaveBranches(&Branches{Key: "b"})
+	want := &Completion{Key: "k", Items: []string{"feature\tworktree"}}
+	if err := s.SaveCompletion("worktrees", want); err != nil {
+		t.Fatalf("SaveCompletion() error: %v", err)
+	}
+	s = New(dir)
+	if got := s.Completion("worktrees"); got == nil || got.Key != want.Key || !reflect.DeepEqual(got.Items, want.Items) {
+		t.Errorf("Completion() = %+v, want %+v", got, want)
+	}
+	if s.Completion("refs") != nil {
+		t.Error("Completion() of another name should be nil")
+	}
+	if b := s.Branches(); b == nil || b.Key != "b" {
+		t.Error("saving a completion should keep the cached branches")
+	}
+
+	// Unchanged candidates leave the file alone; changed ones replace them
+	before, _ := os.Stat(filepath.Join(dir, fileName))
+	past := before.ModTime().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, fileName), past, past)
+	if err := s.SaveCompletion("worktrees", &Completion{Key: "k", Items: want.Items, Time: time.Now()}); err != nil {
+		t.Fatalf("SaveCompletion() error: %v", err)
+	}
+	if fi, _ := os.Stat(filepath.Join(dir, fileName)); !fi.ModTime().Equal(past) {
+		t.Error("saving unchanged candidates should not rewrite the cache")
+	}
+	s.SaveCompletion("worktrees", &Completion{Key: "k", Items: []string{"other"}})
+	if got := s.Completion("worktrees"); got == nil || !reflect.DeepEqual(got.Items, []string{"other"}) {
+		t.Errorf("Completion() = %+v, want the changed candidates", got)
+	}
+}
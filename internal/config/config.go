@@ -0,0 +1,421 @@
+// Package config locates and loads wt's configuration.
+//
+// Settings are read from the user-level file (config.toml in Dir) and then
+// from the repository-level .wt.toml in the main worktree. Keys present in the
+// repository file override the user-level values.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/provenimpact/wt/internal/names"
+	"github.com/provenimpact/wt/internal/theme"
+)
+
+// DirEnv overrides the user configuration directory when set.
+const DirEnv = "WT_CONFIG_DIR"
+
+const (
+	// FileName is the user-level config file inside Dir.
+	FileName = "config.toml"
+	// RepoFileName is the repository-level config file in the main worktree.
+	RepoFileName = ".wt.toml"
+)
+
+// Config holds all wt settings.
+type Config struct {
+	// Theme names a built-in color theme (see theme.Names).
+	Theme string `toml:"theme"`
+	// Colors overrides individual colors of the theme.
+	Colors theme.Palette `toml:"colors"`
+	// Editor is the command used by wt open and create --edit, e.g.
+	// "code --new-window". Defaults to $VISUAL, then $EDITOR.
+	Editor string `toml:"editor"`
+	// PathStyle sets how paths are shown: "parent" (relative to the directory
+	// holding the repository, the default), "cwd", "absolute", or "home"
+	// (absolute with the home directory as ~).
+	PathStyle string `toml:"path-style"`
+	// FilterDebounce is how long the branch selectors wait for typing to
+	// pause before filtering a list of thousands of branches, e.g. "50ms".
+	// Empty filters after every keystroke.
+	FilterDebounce string `toml:"filter-debounce"`
+	// Status holds settings for wt status.
+	Status Status `toml:"status"`
+	// Names holds how branch names become worktree directory names.
+	Names Names `toml:"names"`
+	// Hooks holds commands run at points in a worktree's lifecycle.
+	Hooks Hooks `toml:"hooks"`
+	// Create holds settings for wt create.
+	Create Create `toml:"create"`
+	// Workspace holds settings for wt workspace.
+	Workspace Workspace `toml:"workspace"`
+	// Forge holds settings for the pull request commands (wt pr).
+	Forge Forge `toml:"forge"`
+	// Monorepo holds the projects of a monorepo for wt create --project.
+	Monorepo Monorepo `toml:"monorepo"`
+	// Expire holds the policy for expiring old worktrees.
+	Expire Expire `toml:"expire"`
+	// Env holds settings for the environment variables of worktrees.
+	Env Env `toml:"env"`
+	// Alias maps command aliases to what they stand for, e.g. rm to
+	// "remove --delete-branch". The definition is split on whitespace.
+	// Built-in commands take precedence over aliases of the same name.
+	Alias map[string]string `toml:"alias"`
+	// WorktreeConfig holds git config values written to the own config of
+	// every new worktree, e.g. user.email or core.hooksPath. Values may use
+	// the placeholders of worktree templates. See GitConfig.
+	WorktreeConfig map[string]any `toml:"worktree-config"`
+
+	// repoKeys holds the dotted keys the repository config sets, such as
+	// "forge.api-url"; see SetByRepo.
+	repoKeys map[string]bool
+}
+
+// SetByRepo reports whether the repository's .wt.toml sets key, a dotted key
+// such as "forge.api-url", or a key inside the table key names. Settings that
+// choose where credentials go or what runs on the user's behalf must not be
+// taken from a repository someone else controls without the user's consent.
+func (c *Config) SetByRepo(key string) bool {
+	for k := range c.repoKeys {
+		if k == key || strings.HasPrefix(k, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// RepoGitConfig lists the git config keys a repository's .wt.toml may set in
+// [worktree-config], with entries ending in "." standing for a whole section.
+// Others, such as core.hooksPath, core.fsmonitor, or core.sshCommand, make
+// git run commands, and can only come from the user config.
+var RepoGitConfig = []string{
+	"user.", "author.", "committer.",
+	"commit.gpgsign", "tag.gpgsign",
+	"core.autocrlf", "core.eol", "core.ignorecase",
+	"fetch.prune", "pull.ff", "pull.rebase",
+	"push.autosetupremote", "push.default",
+	"rebase.autosquash", "rebase.autostash", "rerere.enabled",
+}
+
+// repoGitConfigAllowed reports whether key is in RepoGitConfig. Git config
+// keys are compared without regard to case, as git does for sections and
+// names.
+func repoGitConfigAllowed(key string) bool {
+	key = strings.ToLower(key)
+	for _, allowed := range RepoGitConfig {
+		if key == allowed || strings.HasSuffix(allowed, ".") && strings.HasPrefix(key, allowed) && !strings.Contains(key[len(allowed):], ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// GitConfig returns the worktree-config table as git config keys and values.
+// Nested tables, which is what dotted TOML keys such as user.email produce,
+// are joined with dots; strings, numbers, and booleans are accepted as values.
+// Keys the repository config sets must be in RepoGitConfig.
+func (c *Config) GitConfig() (map[string]string, error) {
+	out := make(map[string]string)
+	var flatten func(prefix string, table map[string]any) error
+	flatten = func(prefix string, table map[string]any) error {
+		for k, v := range table {
+			key := prefix + k
+			switch v := v.(type) {
+			case map[string]any:
+				if err := flatten(key+".", v); err != nil {
+					return err
+				}
+			case string, bool, int64, float64:
+				if c.SetByRepo("worktree-config."+key) && !repoGitConfigAllowed(key) {
+					return fmt.Errorf("worktree-config: %s cannot be set in %s, since it could make git run commands; set it in your user config instead", key, RepoFileName)
+				}
+				out[key] = fmt.Sprint(v)
+			default:
+				return fmt.Errorf("worktree-config: %s must be a string, number, or boolean", key)
+			}
+		}
+		return nil
+	}
+	if err := flatten("", c.WorktreeConfig); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Debounce returns FilterDebounce as a duration; zero means no debounce.
+func (c *Config) Debounce() (time.Duration, error) {
+	if c.FilterDebounce == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(c.FilterDebounce)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid filter-debounce %q: want a duration like 50ms", c.FilterDebounce)
+	}
+	return d, nil
+}
+
+// Monorepo describes the projects of a monorepo. A worktree created for a
+// project is a sparse checkout of the project's directories and the shared
+// ones; files at the top level of the repository are always included.
+type Monorepo struct {
+	// Shared lists directories every project needs, e.g. build tooling.
+	Shared []string `toml:"shared"`
+	// Projects maps project names to their directories, relative to the
+	// repository root.
+	Projects map[string][]string `toml:"projects"`
+}
+
+// ProjectDirs returns the directories to check out for project: its own and
+// the shared ones.
+func (m Monorepo) ProjectDirs(project string) ([]string, error) {
+	dirs, ok := m.Projects[project]
+	if !ok {
+		if len(m.Projects) == 0 {
+			return nil, fmt.Errorf("unknown project %q: no projects are configured in [monorepo.projects]", project)
+		}
+		return nil, fmt.Errorf("unknown project %q (configured: %s)", project, strings.Join(m.ProjectNames(), ", "))
+	}
+	var out []string
+	for _, d := range append(slices.Clone(dirs), m.Shared...) {
+		d = strings.Trim(filepath.ToSlash(d), "/")
+		if d != "" && !slices.Contains(out, d) {
+			out = append(out, d)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("project %q has no directories", project)
+	}
+	return out, nil
+}
+
+// ProjectNames returns the configured project names, sorted.
+func (m Monorepo) ProjectNames() []string {
+	names := make([]string, 0, len(m.Projects))
+	for name := range m.Projects {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Forge selects the service hosting the repository. Every setting is
+// optional when the remote is hosted on a recognized forge such as github.com.
+type Forge struct {
+	// Provider names the forge software: "github", "gitlab", or "gitea"
+	// (also for Forgejo).
+	Provider string `toml:"provider"`
+	// Remote is the remote whose forge is used and from which pull requests
+	// are fetched. Defaults to "origin".
+	Remote string `toml:"remote"`
+	// Repo is the repository on the forge as "owner/name"; by default it is
+	// read from the remote's URL.
+	Repo string `toml:"repo"`
+	// APIURL overrides the API endpoint, e.g. for GitHub Enterprise or a
+	// GitLab instance that is not named gitlab.*. Since the user's token is
+	// sent there, a repository's .wt.toml can only set it to an endpoint on
+	// the remote's host.
+	APIURL string `toml:"api-url"`
+}
+
+// Env holds settings for the environment variables set with wt env.
+type Env struct {
+	// File writes a worktree's variables to a .env.wt file in it whenever
+	// they change, for tools that read dotenv files.
+	File bool `toml:"file"`
+}
+
+// Expire is the policy by which linked worktrees expire: wt status marks
+// expired worktrees and wt prune --expired offers to remove them.
+type Expire struct {
+	// After is the age since creation at which a worktree expires, e.g.
+	// "30d", "2w", or "36h". Empty disables expiry.
+	After string `toml:"after"`
+	// Exclude lists glob patterns of branches that never expire, e.g.
+	// "release/*".
+	Exclude []string `toml:"exclude"`
+}
+
+// Duration returns After as a duration; zero means expiry is disabled.
+func (e Expire) Duration() (time.Duration, error) {
+	if e.After == "" {
+		return 0, nil
+	}
+	d, err := ParseAge(e.After)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("expire: invalid after %q: want an age like 30d, 2w, or 36h", e.After)
+	}
+	for _, pattern := range e.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return 0, fmt.Errorf("expire: invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+	return d, nil
+}
+
+// Excluded reports whether branch matches one of the exclude patterns.
+func (e Expire) Excluded(branch string) bool {
+	for _, pattern := range e.Exclude {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAge parses an age: a Go duration, or a whole number of days ("30d")
+// or weeks ("2w").
+func ParseAge(s string) (time.Duration, error) {
+	day := 24 * time.Hour
+	for suffix, unit := range map[string]time.Duration{"d": day, "w": 7 * day} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// Create holds settings for wt create.
+type Create struct {
+	// FetchBase fetches a remote-tracking --base (e.g. origin/main) before
+	// branching from it, as if --fetch-base were given.
+	FetchBase bool `toml:"fetch-base"`
+	// PreferRemoteBase branches from the freshly fetched remote branch of
+	// the base, e.g. origin/main for main, as if --base-remote were given.
+	PreferRemoteBase bool `toml:"prefer-remote-base"`
+	// Atomic removes a new worktree again when applying --apply changes or
+	// running post-create hooks fails, as if --atomic were given.
+	Atomic bool `toml:"atomic"`
+	// Sort orders the branches in the selector of wt create: "date" (by
+	// last commit, newest first; the default), "name", or "author".
+	Sort string `toml:"sort"`
+	// Since hides branches whose last commit is older than this age, e.g.
+	// "90d", from the selector of wt create.
+	Since string `toml:"since"`
+}
+
+// Workspace holds settings for the generated VS Code workspace file.
+type Workspace struct {
+	// Path of the workspace file; relative paths are taken from the directory
+	// containing the main worktree. Defaults to <repo>.code-workspace there.
+	Path string `toml:"path"`
+	// Sync regenerates the workspace file after wt create and wt remove.
+	Sync bool `toml:"sync"`
+}
+
+// Hooks lists shell commands per lifecycle event. Commands run in order and
+// may use {{name}} placeholders (see package hooks).
+type Hooks struct {
+	// PostCreate runs in a new worktree after wt create.
+	PostCreate []string `toml:"post-create"`
+	// PostSwitch runs in the user's shell, through the shell wrapper, after
+	// it changes into a worktree.
+	PostSwitch []string `toml:"post-switch"`
+}
+
+// Names holds how the directory name of a branch's worktree is derived from
+// the branch name.
+type Names struct {
+	// Nested keeps "/" in branch names, so that the worktree of feature/login
+	// is in feature/login rather than feature-login.
+	Nested bool `toml:"nested"`
+	// Replacement is the character that replaces unsafe ones: "-" (the
+	// default), "_", or ".".
+	Replacement string `toml:"replacement"`
+	// MaxLength caps the length of directory names; longer ones are cut and
+	// end in a short hash of the branch. Zero means no limit.
+	MaxLength int `toml:"max-length"`
+	// Lowercase lowercases directory names.
+	Lowercase bool `toml:"lowercase"`
+}
+
+// Options validates the settings and returns them as sanitization options.
+func (n Names) Options() (names.Options, error) {
+	if n.Replacement != "" && !slices.Contains(names.Replacements, n.Replacement) {
+		return names.Options{}, fmt.Errorf("names: invalid replacement %q (valid: %s)", n.Replacement, strings.Join(names.Replacements, ", "))
+	}
+	if n.MaxLength != 0 && n.MaxLength < names.MinLength {
+		return names.Options{}, fmt.Errorf("names: max-length must be at least %d, got %d", names.MinLength, n.MaxLength)
+	}
+	return names.Options{Nested: n.Nested, Replacement: n.Replacement, MaxLength: n.MaxLength, Lowercase: n.Lowercase}, nil
+}
+
+// Status holds settings for wt status.
+type Status struct {
+	// Check lists the conditions that make wt status --check fail
+	// (see cmd/status.go for the accepted names).
+	Check []string `toml:"check"`
+}
+
+// Dir returns the directory holding wt's user-level files: $WT_CONFIG_DIR if
+// set, otherwise "wt" inside the OS user configuration directory
+// (e.g. ~/.config/wt on Linux).
+func Dir() (string, error) {
+	if dir := os.Getenv(DirEnv); dir != "" {
+		return dir, nil
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user config directory: %w", err)
+	}
+	return filepath.Join(base, "wt"), nil
+}
+
+// Load reads the user-level config and, if mainWorktree is non-empty, the
+// repository's .wt.toml on top of it. Missing files are not an error.
+func Load(mainWorktree string) (*Config, error) {
+	cfg := &Config{Theme: theme.Default}
+
+	dir, err := Dir()
+	if err == nil {
+		if _, err := decodeFile(filepath.Join(dir, FileName), cfg); err != nil {
+			return nil, err
+		}
+	}
+	if mainWorktree != "" {
+		md, err := decodeFile(filepath.Join(mainWorktree, RepoFileName), cfg)
+		if err != nil {
+			return nil, err
+		}
+		cfg.repoKeys = make(map[string]bool)
+		for _, key := range md.Keys() {
+			// Joined unquoted, so that "a.b" = 1 and a.b = 1 are the same
+			cfg.repoKeys[strings.Join(key, ".")] = true
+		}
+	}
+	return cfg, nil
+}
+
+// decodeFile decodes the TOML file at path into cfg, leaving keys that are
+// absent from the file untouched, and returns the file's metadata.
+func decodeFile(path string, cfg *Config) (toml.MetaData, error) {
+	md, err := toml.DecodeFile(path, cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return toml.MetaData{}, nil
+	}
+	if err != nil {
+		return md, fmt.Errorf("reading config %s: %w", path, err)
+	}
+	return md, nil
+}
+
+// Palette returns the configured theme's palette with color overrides applied.
+func (c *Config) Palette() (theme.Palette, error) {
+	p, err := theme.Lookup(c.Theme)
+	if err != nil {
+		return theme.Palette{}, err
+	}
+	return p.Merge(c.Colors), nil
+}
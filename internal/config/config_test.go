@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Setenv(DirEnv, t.TempDir())
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Theme != "default" {
+		t.Errorf("Theme = %q, want default", cfg.Theme)
+	}
+}
+
+func TestLoad_RepoOverridesUser(t *testing.T) {
+	userDir := t.TempDir()
+	repoDir := t.TempDir()
+	t.Setenv(DirEnv, userDir)
+
+	os.WriteFile(filepath.Join(userDir, FileName), []byte("theme = \"solarized\"\n[colors]\nselected = \"1\"\ndim = \"2\"\n"), 0o644)
+	os.WriteFile(filepath.Join(repoDir, RepoFileName), []byte("[colors]\nselected = \"9\"\n"), 0o644)
+
+	cfg, err := Load(repoDir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Theme != "solarized" {
+		t.Errorf("Theme = %q, want solarized from user config", cfg.Theme)
+	}
+	if cfg.Colors.Selected != "9" {
+		t.Errorf("Colors.Selected = %q, want repo override 9", cfg.Colors.Selected)
+	}
+	if cfg.Colors.Dim != "2" {
+		t.Errorf("Colors.Dim = %q, want user value 2", cfg.Colors.Dim)
+	}
+
+	p, err := cfg.Palette()
+	if err != nil {
+		t.Fatalf("Palette() error: %v", err)
+	}
+	if p.Selected != "9" || p.Highlight != "#b58900" {
+		t.Errorf("Palette() = %+v, want overrides on top of solarized", p)
+	}
+}
+
+// Aliases of the user and repository configs are merged, the repository's
+// winning.
+func TestLoad_MergesAliases(t *testing.T) {
+	userDir := t.TempDir()
+	repoDir := t.TempDir()
+	t.Setenv(DirEnv, userDir)
+
+	os.WriteFile(filepath.Join(userDir, FileName), []byte("alias.co = \"switch\"\nalias.rm = \"remove\"\n"), 0o644)
+	os.WriteFile(filepath.Join(repoDir, RepoFileName), []byte("[alias]\nrm = \"remove --delete-branch\"\n"), 0o644)
+
+	cfg, err := Load(repoDir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := map[string]string{"co": "switch", "rm": "remove --delete-branch"}
+	if !maps.Equal(cfg.Alias, want) {
+		t.Errorf("Alias = %v, want %v", cfg.Alias, want)
+	}
+}
+
+// SetByRepo tells the settings of the repository config from the user's.
+func TestLoad_SetByRepo(t *testing.T) {
+	userDir := t.TempDir()
+	repoDir := t.TempDir()
+	t.Setenv(DirEnv, userDir)
+
+	os.WriteFile(filepath.Join(userDir, FileName), []byte("[forge]\nprovider = \"gitlab\"\n"), 0o644)
+	os.WriteFile(filepath.Join(repoDir, RepoFileName), []byte("[forge]\napi-url = \"https://example.com\"\n[hooks]\npost-create = [\"make\"]\n"), 0o644)
+
+	cfg, err := Load(repoDir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	for key, want := range map[string]bool{"forge.api-url": true, "forge.provider": false, "forge": true, "hooks": true, "hooks.post-switch": false, "alias": false} {
+		if got := cfg.SetByRepo(key); got != want {
+			t.Errorf("SetByRepo(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestLoad_InvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(DirEnv, dir)
+	os.WriteFile(filepath.Join(dir, FileName), []byte("theme = "), 0o644)
+
+	if _, err := Load(""); err == nil {
+		t.Error("Load() should fail on malformed TOML")
+	}
+}
+
+func TestMonorepo_ProjectDirs(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(DirEnv, t.TempDir())
+	os.WriteFile(filepath.Join(dir, RepoFileName), []byte(`[monorepo]
+shared = ["tools/", "libs/proto"]
+
+[monorepo.projects]
+api = ["services/api", "libs/proto"]
+web = ["apps/web"]
+`), 0o644)
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	dirs, err := cfg.Monorepo.ProjectDirs("api")
+	if want := []string{"services/api", "libs/proto", "tools"}; err != nil || !slices.Equal(dirs, want) {
+		t.Errorf("ProjectDirs(api) = %q, %v; want %q", dirs, err, want)
+	}
+	if _, err := cfg.Monorepo.ProjectDirs("docs"); err == nil || !strings.Contains(err.Error(), "api, web") {
+		t.Errorf("ProjectDirs(docs) error = %v, want the configured projects listed", err)
+	}
+}
+
+func TestGitConfig(t *testing.T) {
+	dir := t.TempDir()
+	userDir := t.TempDir()
+	t.Setenv(DirEnv, userDir)
+	os.WriteFile(filepath.Join(userDir, FileName), []byte(`[worktree-config]
+core.hooksPath = "{{main_worktree}}/.githooks"
+`), 0o644)
+	os.WriteFile(filepath.Join(dir, RepoFileName), []byte(`[worktree-config]
+user.email = "me@work.example"
+"rerere.enabled" = true
+`), 0o644)
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	got, err := cfg.GitConfig()
+	if err != nil {
+		t.Fatalf("GitConfig() error: %v", err)
+	}
+	want := map[string]string{
+		"user.email":     "me@work.example",
+		"core.hooksPath": "{{main_worktree}}/.githooks",
+		"rerere.enabled": "true",
+	}
+	if len(got) != len(want) {
+		t.Errorf("GitConfig() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("GitConfig()[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+
+	// Keys that make git run commands cannot come from the repository
+	for _, repoConfig := range []string{"core.hooksPath = \"/tmp\"\n", "\"core.fsmonitor\" = \"x\"\n", "[worktree-config.core]\nsshCommand = \"x\"\n", "user.name.evil = 1\n"} {
+		os.WriteFile(filepath.Join(dir, RepoFileName), []byte("[worktree-config]\n"+repoConfig), 0o644)
+		cfg, err := Load(dir)
+		if err != nil {
+			t.Fatalf("Load() error: %v", err)
+		}
+		if _, err := cfg.GitConfig(); err == nil || !strings.Contains(err.Error(), "user config") {
+			t.Errorf("GitConfig() with %q from the repository: error = %v, want a refusal", repoConfig, err)
+		}
+	}
+
+	cfg.WorktreeConfig = map[string]any{"core": map[string]any{"x": []any{"a"}}}
+	if _, err := cfg.GitConfig(); err == nil || !strings.Contains(err.Error(), "core.x") {
+		t.Errorf("GitConfig() with a list value: error = %v, want one naming core.x", err)
+	}
+}
+
+func TestConfig_Debounce(t *testing.T) {
+	for s, want := range map[string]time.Duration{"": 0, "50ms": 50 * time.Millisecond, "0s": 0} {
+		got, err := (&Config{FilterDebounce: s}).Debounce()
+		if err != nil || got != want {
+			t.Errorf("Debounce(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"fast", "-5ms", "50"} {
+		if _, err := (&Config{FilterDebounce: s}).Debounce(); err == nil {
+			t.Errorf("Debounce(%q) should fail", s)
+		}
+	}
+}
+
+func TestNames_Options(t *testing.T) {
+	opts, err := Names{Nested: true, Replacement: "_", MaxLength: 40, Lowercase: true}.Options()
+	if err != nil || !opts.Nested || opts.Replacement != "_" || opts.MaxLength != 40 || !opts.Lowercase {
+		t.Errorf("Options() = %+v, %v; want the settings", opts, err)
+	}
+	for _, n := range []Names{{Replacement: "/"}, {Replacement: "--"}, {MaxLength: 5}, {MaxLength: -1}} {
+		if _, err := n.Options(); err == nil {
+			t.Errorf("Options() of %+v should fail", n)
+		}
+	}
+}
+
+func TestExpire_Duration(t *testing.T) {
+	day := 24 * time.Hour
+	for after, want := range map[string]time.Duration{"": 0, "30d": 30 * day, "2w": 14 * day, "36h": 36 * time.Hour} {
+		got, err := Expire{After: after}.Duration()
+		if err != nil || got != want {
+			t.Errorf("Duration(%q) = %v, %v; want %v", after, got, err, want)
+		}
+	}
+	for _, after := range []string{"soon", "0d", "-1w", "1.5d"} {
+		if _, err := (Expire{After: after}).Duration(); err == nil {
+			t.Errorf("Duration(%q) should fail", after)
+		}
+	}
+	if _, err := (Expire{After: "1d", Exclude: []string{"["}}).Duration(); err == nil {
+		t.Error("a malformed exclude pattern should fail")
+	}
+
+	e := Expire{Exclude: []string{"main", "release/*"}}
+	for branch, want := range map[string]bool{"main": true, "release/1.0": true, "feature/x": false} {
+		if got := e.Excluded(branch); got != want {
+			t.Errorf("Excluded(%q) = %v, want %v", branch, got, want)
+		}
+	}
+}
+
+func TestProfile_Merge(t *testing.T) {
+	p := Profile{"theme": "nord", "hooks": map[string]any{"post-create": []any{"make"}}, "alias": map[string]any{"rm": "remove"}}
+	p.Merge(Profile{"theme": "solarized", "hooks": map[string]any{"post-switch": []any{"ls"}}})
+
+	if p["theme"] != "solarized" {
+		t.Errorf("theme = %v, want the merged value", p["theme"])
+	}
+	hooks := p["hooks"].(map[string]any)
+	if hooks["post-create"] == nil || hooks["post-switch"] == nil {
+		t.Errorf("tables should be merged key by key, got %v", hooks)
+	}
+	if p["alias"] == nil {
+		t.Error("settings absent from the merged profile should be kept")
+	}
+	if got := strings.Join(p.Keys(), ","); got != "alias,hooks,theme" {
+		t.Errorf("Keys() = %s", got)
+	}
+}
+
+func TestParseProfile(t *testing.T) {
+	p, err := ParseProfile([]byte("[names]\nnested = true\n[hooks]\npost-create = [\"make\"]\n"))
+	if err != nil {
+		t.Fatalf("ParseProfile() error: %v", err)
+	}
+	data, err := p.Encode("a profile")
+	if err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# a profile\n") {
+		t.Errorf("Encode() should start with the header, got %q", data)
+	}
+	again, err := ParseProfile(data)
+	if err != nil || !maps.EqualFunc(p, again, func(a, b any) bool { return fmt.Sprint(a) == fmt.Sprint(b) }) {
+		t.Errorf("an encoded profile should parse back to itself, got %v (%v)", again, err)
+	}
+
+	for _, bad := range []string{"[hook]\npost-create = [\"make\"]\n", "theme = \"nope\"\n", "[names]\nmax-length = 3\n", "not toml"} {
+		if _, err := ParseProfile([]byte(bad)); err == nil {
+			t.Errorf("ParseProfile(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/provenimpact/wt/internal/theme"
+)
+
+// Profile holds settings as written in config files: only the keys that are
+// set, as nested tables. Profiles carry a wt setup from one place to another,
+// e.g. with wt config export and wt config import.
+type Profile map[string]any
+
+// ReadProfile reads the config file at path as a profile. A missing file
+// gives an empty profile.
+func ReadProfile(path string) (Profile, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Profile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+	p := Profile{}
+	if _, err := toml.Decode(string(data), &p); err != nil {
+		return nil, fmt.Errorf("reading config %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ParseProfile parses a profile and checks it, so that it can be installed
+// without breaking wt: unknown keys, which are likely typos, and invalid
+// values are errors.
+func ParseProfile(data []byte) (Profile, error) {
+	p := Profile{}
+	if _, err := toml.Decode(string(data), &p); err != nil {
+		return nil, err
+	}
+	cfg := &Config{Theme: theme.Default}
+	md, err := toml.Decode(string(data), cfg)
+	if err != nil {
+		return nil, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("unknown settings: %s", strings.Join(keys, ", "))
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Merge adds the settings of other to p, replacing those p has. Tables are
+// merged key by key and other values replaced, as Load does with the
+// repository config on top of the user-level one.
+func (p Profile) Merge(other Profile) {
+	for key, value := range other {
+		table, isTable := value.(map[string]any)
+		if have, ok := p[key].(map[string]any); ok && isTable {
+			merged := Profile(maps.Clone(have))
+			merged.Merge(table)
+			p[key] = map[string]any(merged)
+			continue
+		}
+		p[key] = value
+	}
+}
+
+// Keys returns the top-level settings and tables of p, sorted.
+func (p Profile) Keys() []string {
+	return slices.Sorted(maps.Keys(p))
+}
+
+// Encode returns p as TOML, after header, which is written as comment lines.
+func (p Profile) Encode(header string) ([]byte, error) {
+	var b bytes.Buffer
+	for _, line := range strings.Split(header, "\n") {
+		if line == "" {
+			b.WriteString("#\n")
+			continue
+		}
+		b.WriteString("# " + line + "\n")
+	}
+	if header != "" {
+		b.WriteString("\n")
+	}
+	if err := toml.NewEncoder(&b).Encode(map[string]any(p)); err != nil {
+		return nil, fmt.Errorf("encoding config: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// Validate checks the settings whose values are restricted, which are
+// otherwise only checked when they are used.
+func (c *Config) Validate() error {
+	if _, err := c.Palette(); err != nil {
+		return err
+	}
+	if _, err := c.Debounce(); err != nil {
+		return err
+	}
+	if _, err := c.Names.Options(); err != nil {
+		return err
+	}
+	if _, err := c.Expire.Duration(); err != nil {
+		return err
+	}
+	if _, err := c.GitConfig(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Package debug logs the external commands wt runs and the web requests it
+// makes, for diagnosing failures.
+//
+// Logging is off by default. It is switched on by the --verbose flag or the
+// WT_DEBUG environment variable and writes one structured line per command:
+// its arguments, working directory, duration, and exit code. Requests are
+// logged with their method, URL, duration, and status.
+package debug
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Env enables logging when set: "1", "true", or "stderr" log to stderr; any
+// other value is taken as the path of a file to append to.
+const Env = "WT_DEBUG"
+
+var logger = slog.New(slog.DiscardHandler)
+
+// Enable sends the log to w.
+func Enable(w io.Writer) {
+	logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+// EnableFromEnv enables logging as requested by $WT_DEBUG, if set.
+func EnableFromEnv() error {
+	switch v := os.Getenv(Env); strings.ToLower(v) {
+	case "", "0", "false":
+		return nil
+	case "1", "true", "stderr":
+		Enable(os.Stderr)
+		return nil
+	default:
+		f, err := os.OpenFile(v, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("opening %s log file: %w", Env, err)
+		}
+		Enable(f)
+		return nil
+	}
+}
+
+// LogCommand records a finished command. err is the error returned by running
+// it; its exit code is logged, or -1 if the command could not be started.
+func LogCommand(cmd *exec.Cmd, elapsed time.Duration, err error) {
+	exit := 0
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exit = exitErr.ExitCode()
+	} else if err != nil {
+		exit = -1
+	}
+	logger.Debug("exec",
+		"cmd", strings.Join(cmd.Args, " "),
+		"dir", cmd.Dir,
+		"duration", elapsed.Round(time.Microsecond),
+		"exit", exit,
+	)
+}
+
+// LogStep records how long a step of wt's own work took, such as checking
+// one worktree, with attrs as further key-value pairs.
+func LogStep(name string, elapsed time.Duration, attrs ...any) {
+	logger.Debug(name, append([]any{"duration", elapsed.Round(time.Microsecond)}, attrs...)...)
+}
+
+// LogRequest records a finished HTTP request. status is the response status
+// code, or 0 if no response was received.
+func LogRequest(req *http.Request, elapsed time.Duration, status int) {
+	logger.Debug("http",
+		"method", req.Method,
+		"url", req.URL.Redacted(),
+		"duration", elapsed.Round(time.Microsecond),
+		"status", status,
+	)
+}
@@ -0,0 +1,62 @@
+package debug
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogCommand(t *testing.T) {
+	var buf bytes.Buffer
+	Enable(&buf)
+	t.Cleanup(func() { Enable(io.Discard) })
+
+	cmd := exec.Command("git", "--no-such-flag")
+	cmd.Dir = t.TempDir()
+	err := cmd.Run()
+	LogCommand(cmd, 1500*time.Microsecond, err)
+
+	line := buf.String()
+	for _, want := range []string{`cmd="git --no-such-flag"`, "dir=" + cmd.Dir, "duration=1.5ms", "exit=129"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("log line missing %q: %s", want, line)
+		}
+	}
+}
+
+func TestLogStep(t *testing.T) {
+	var buf bytes.Buffer
+	Enable(&buf)
+	t.Cleanup(func() { Enable(io.Discard) })
+
+	LogStep("status", 2500*time.Microsecond, "worktree", "/repo/feature")
+
+	line := buf.String()
+	for _, want := range []string{"msg=status", "duration=2.5ms", "worktree=/repo/feature"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("log line missing %q: %s", want, line)
+		}
+	}
+}
+
+func TestEnableFromEnv_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wt.log")
+	t.Setenv(Env, path)
+	if err := EnableFromEnv(); err != nil {
+		t.Fatalf("EnableFromEnv() error: %v", err)
+	}
+	t.Cleanup(func() { Enable(io.Discard) })
+
+	cmd := exec.Command("git", "--version")
+	LogCommand(cmd, time.Millisecond, cmd.Run())
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `cmd="git --version"`) || !strings.Contains(string(data), "exit=0") {
+		t.Errorf("log file = %q, want the logged command", data)
+	}
+}
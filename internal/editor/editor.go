@@ -0,0 +1,48 @@
+// Package editor launches the user's editor on a worktree.
+package editor
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/provenimpact/wt/internal/debug"
+)
+
+// ErrNotConfigured is returned when no editor is configured anywhere.
+var ErrNotConfigured = errors.New("no editor configured; set editor in config.toml, $VISUAL, or $EDITOR")
+
+// Resolve returns the editor command to use: configured if non-empty,
+// otherwise $VISUAL, otherwise $EDITOR.
+func Resolve(configured string) (string, error) {
+	for _, spec := range []string{configured, os.Getenv("VISUAL"), os.Getenv("EDITOR")} {
+		if strings.TrimSpace(spec) != "" {
+			return spec, nil
+		}
+	}
+	return "", ErrNotConfigured
+}
+
+// Command builds the command that opens path with the editor spec, which may
+// include arguments (e.g. "code --new-window"). path is appended as the last
+// argument.
+func Command(spec, path string) *exec.Cmd {
+	fields := strings.Fields(spec)
+	args := append(fields[1:], path)
+	return exec.Command(fields[0], args...)
+}
+
+// Open runs the editor spec on path and waits for it to exit. The editor is
+// attached to the terminal through stdin and stderr; stdout is left to wt.
+func Open(spec, path string) error {
+	cmd := Command(spec, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	start := time.Now()
+	err := cmd.Run()
+	debug.LogCommand(cmd, time.Since(start), err)
+	return err
+}
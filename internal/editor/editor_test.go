@@ -0,0 +1,37 @@
+package editor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolve_Precedence(t *testing.T) {
+	t.Setenv("VISUAL", "visual-editor")
+	t.Setenv("EDITOR", "plain-editor")
+
+	if got, _ := Resolve("code --wait"); got != "code --wait" {
+		t.Errorf("Resolve(configured) = %q, want configured editor", got)
+	}
+	if got, _ := Resolve(""); got != "visual-editor" {
+		t.Errorf("Resolve(\"\") = %q, want $VISUAL", got)
+	}
+
+	t.Setenv("VISUAL", "")
+	if got, _ := Resolve(""); got != "plain-editor" {
+		t.Errorf("Resolve(\"\") = %q, want $EDITOR", got)
+	}
+
+	t.Setenv("EDITOR", "")
+	if _, err := Resolve(""); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Resolve() error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestCommand_AppendsPath(t *testing.T) {
+	cmd := Command("code  --new-window", "/wt/feature")
+	want := []string{"code", "--new-window", "/wt/feature"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("Command().Args = %q, want %q", cmd.Args, want)
+	}
+}
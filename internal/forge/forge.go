@@ -0,0 +1,292 @@
+// Package forge talks to the service hosting a repository's remote, such as
+// GitHub, GitLab, or Gitea, to list its pull requests (GitLab's merge
+// requests).
+//
+// A Forge is chosen from the remote's URL or from explicit settings; see New.
+// Requests authenticate with a token taken from the environment or stored in
+// the operating system's credential store by wt auth login.
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/provenimpact/wt/internal/auth"
+	"github.com/provenimpact/wt/internal/debug"
+)
+
+// Pull request states.
+const (
+	Open   = "open"
+	Closed = "closed"
+	Merged = "merged"
+)
+
+// PR is a pull request.
+type PR struct {
+	Number int
+	Title  string
+	URL    string
+	// State is Open, Closed, or Merged.
+	State string
+	// Branch is the name of the head branch in the repository it lives in.
+	Branch string
+	// Fork is set when the head branch lives in a fork rather than the
+	// repository itself; HeadOwner then names the fork's owner.
+	Fork      bool
+	HeadOwner string
+}
+
+// Forge is a code hosting service.
+type Forge interface {
+	// Name identifies the provider, e.g. "github".
+	Name() string
+	// Host is the host name of the forge, e.g. "github.com".
+	Host() string
+	// Login returns the name of the user the token authenticates.
+	Login(ctx context.Context) (string, error)
+	// MyPRs returns the open pull requests authored by or assigned to the
+	// authenticated user.
+	MyPRs(ctx context.Context) ([]PR, error)
+	// PR returns a pull request by number.
+	PR(ctx context.Context, number int) (PR, error)
+	// HeadRef returns the ref under which the remote serves a pull request's
+	// head commit, for fetching pull requests from forks.
+	HeadRef(number int) string
+}
+
+// Repo identifies a repository on a forge.
+type Repo struct {
+	Host  string
+	Owner string
+	Name  string
+}
+
+// String returns the repository as "owner/name".
+func (r Repo) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+var (
+	// ErrUnknownForge means no provider could be determined for a remote.
+	ErrUnknownForge = errors.New("unknown forge")
+	// ErrUnauthorized means the forge rejected the credentials, or none were
+	// found.
+	ErrUnauthorized = errors.New("not authorized")
+)
+
+// Options select and configure a forge. Empty fields are derived from the
+// remote URL passed to New.
+type Options struct {
+	// Provider is the provider name: "github", "gitlab", or "gitea" (also
+	// "forgejo").
+	Provider string
+	// Repo is "owner/name" on the forge.
+	Repo string
+	// APIURL is the base URL of the forge's API.
+	APIURL string
+	// Token authenticates requests; when empty it is read from the
+	// provider's usual environment variables, then from the token stored
+	// with wt auth login (see package auth).
+	Token string
+}
+
+// New returns the forge hosting remoteURL, as configured by opts.
+func New(remoteURL string, opts Options) (Forge, error) {
+	repo, err := ParseRemoteURL(remoteURL)
+	if opts.Repo != "" {
+		owner, name, ok := strings.Cut(opts.Repo, "/")
+		if !ok || owner == "" || name == "" {
+			return nil, fmt.Errorf("invalid forge repository %q: want owner/name", opts.Repo)
+		}
+		repo.Owner, repo.Name, err = owner, name, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := opts.Provider
+	if provider == "" {
+		provider = detectProvider(repo.Host)
+	}
+	if provider == "" {
+		return nil, fmt.Errorf("%w for %s; set provider in the [forge] config", ErrUnknownForge, remoteURL)
+	}
+	return newProvider(provider, repo, opts)
+}
+
+// ForHost returns provider's forge at host without a repository, for
+// requests about the user such as Login. An empty host means the provider's
+// public service.
+func ForHost(provider, host string, opts Options) (Forge, error) {
+	if host == "" {
+		host = publicHosts[provider]
+	}
+	return newProvider(provider, Repo{Host: host}, opts)
+}
+
+// publicHosts are the hosts of the providers' public services.
+var publicHosts = map[string]string{
+	"github":  "github.com",
+	"gitlab":  "gitlab.com",
+	"gitea":   "codeberg.org",
+	"forgejo": "codeberg.org",
+}
+
+func newProvider(provider string, repo Repo, opts Options) (Forge, error) {
+	switch provider {
+	case "github":
+		return newGitHub(repo, opts), nil
+	case "gitlab":
+		return newGitLab(repo, opts), nil
+	case "gitea", "forgejo":
+		if repo.Host == "" && opts.APIURL == "" {
+			return nil, errors.New("set api-url in the [forge] config: the remote has no host to find the Gitea API on")
+		}
+		return newGitea(repo, opts), nil
+	default:
+		return nil, fmt.Errorf("%w %q (supported: github, gitlab, gitea)", ErrUnknownForge, provider)
+	}
+}
+
+// detectProvider guesses the provider from a remote's host name: the public
+// services, and self-hosted instances named after their software, such as
+// gitlab.example.com.
+func detectProvider(host string) string {
+	switch {
+	case host == "github.com" || strings.HasPrefix(host, "github."):
+		return "github"
+	case host == "gitlab.com" || strings.HasPrefix(host, "gitlab."):
+		return "gitlab"
+	case host == "codeberg.org" || strings.HasPrefix(host, "gitea.") || strings.HasPrefix(host, "forgejo."):
+		return "gitea"
+	}
+	return ""
+}
+
+// ParseRemoteURL extracts the host, owner, and name from a git remote URL in
+// any of the usual forms: https://host/owner/name.git,
+// ssh://git@host/owner/name, or git@host:owner/name.git.
+func ParseRemoteURL(remote string) (Repo, error) {
+	var host, path string
+	if u, err := url.Parse(remote); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remote, ":"); ok && !strings.Contains(at, "/") {
+		// scp-like syntax: [user@]host:owner/name
+		_, host, _ = strings.Cut(at, "@")
+		if host == "" {
+			host = at
+		}
+		path = rest
+	} else {
+		return Repo{}, fmt.Errorf("cannot tell the forge repository from remote URL %q", remote)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	i := strings.LastIndex(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		return Repo{}, fmt.Errorf("cannot tell the forge repository from remote URL %q", remote)
+	}
+	return Repo{Host: host, Owner: path[:i], Name: path[i+1:]}, nil
+}
+
+// APIHost returns the host name of an API URL, or "" if it has none.
+func APIHost(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// ServesHost reports whether the API at apiURL belongs to host: it is on host
+// itself or on its api subdomain, as api.github.com is for github.com.
+func ServesHost(apiURL, host string) bool {
+	h := APIHost(apiURL)
+	return h != "" && (strings.EqualFold(h, host) || strings.EqualFold(h, "api."+host))
+}
+
+// tokenHost returns the host whose stored credentials may be sent to the API
+// at apiURL: the API's own host, or the host its api subdomain serves, such
+// as github.com for api.github.com. Keying credentials on where they go,
+// rather than on the remote, keeps them from a configured API elsewhere.
+func tokenHost(apiURL string) string {
+	host := APIHost(apiURL)
+	if rest, ok := strings.CutPrefix(host, "api."); ok {
+		return rest
+	}
+	return host
+}
+
+// storedToken returns the token stored for provider at host, or "".
+func storedToken(provider, host string) string {
+	token, _ := auth.Get(provider, host)
+	return token
+}
+
+// apiClient sends authenticated requests to a forge's JSON API.
+type apiClient struct {
+	// name is the forge's name in error messages, e.g. "GitHub".
+	name    string
+	baseURL string
+	// prepare sets the headers every request needs, such as the token.
+	prepare func(*http.Request)
+	client  *http.Client
+}
+
+func newAPIClient(name, baseURL string, prepare func(*http.Request)) *apiClient {
+	return &apiClient{
+		name:    name,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		prepare: prepare,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// get requests path from the API and decodes the JSON response into v.
+func (c *apiClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	c.prepare(req)
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		debug.LogRequest(req, time.Since(start), 0)
+		return fmt.Errorf("contacting %s: %w", c.name, err)
+	}
+	debug.LogRequest(req, time.Since(start), resp.StatusCode)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		// GitHub and Gitea report "message"; GitLab uses "message" or "error"
+		var apiErr struct {
+			Message any    `json:"message"`
+			Error   string `json:"error"`
+		}
+		json.Unmarshal(body, &apiErr)
+		detail := apiErr.Error
+		if apiErr.Message != nil {
+			detail = fmt.Sprint(apiErr.Message)
+		}
+		err := fmt.Errorf("%s API %s: %s %s", c.name, path, resp.Status, detail)
+		if resp.StatusCode == http.StatusUnauthorized {
+			err = fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decoding %s API response for %s: %w", c.name, path, err)
+	}
+	return nil
+}
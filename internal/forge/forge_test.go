@@ -0,0 +1,234 @@
+package forge
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Repo
+	}{
+		{"https://github.com/acme/widget.git", Repo{"github.com", "acme", "widget"}},
+		{"https://github.com/acme/widget", Repo{"github.com", "acme", "widget"}},
+		{"ssh://git@github.example.com:2222/acme/widget.git", Repo{"github.example.com", "acme", "widget"}},
+		{"git@github.com:acme/widget.git", Repo{"github.com", "acme", "widget"}},
+		{"gitlab.com:group/sub/widget", Repo{"gitlab.com", "group/sub", "widget"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseRemoteURL(tt.url)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRemoteURL(%q) = %+v, %v; want %+v", tt.url, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"/srv/git/widget.git", "https://github.com/widget", "../widget"} {
+		if _, err := ParseRemoteURL(bad); err == nil {
+			t.Errorf("ParseRemoteURL(%q) should fail", bad)
+		}
+	}
+}
+
+func TestServesHost(t *testing.T) {
+	for apiURL, want := range map[string]bool{
+		"https://api.github.com":              true,
+		"https://github.com/api/v3":           true,
+		"https://GitHub.com/api/v3":           true,
+		"https://evil.example/api/v3":         false,
+		"https://github.com.evil.example/api": false,
+		"not a url":                           false,
+	} {
+		if got := ServesHost(apiURL, "github.com"); got != want {
+			t.Errorf("ServesHost(%q, github.com) = %v, want %v", apiURL, got, want)
+		}
+	}
+}
+
+// Stored tokens are looked up for the host of the API they are sent to.
+func TestTokenHost(t *testing.T) {
+	for apiURL, want := range map[string]string{
+		"https://api.github.com":            "github.com",
+		"https://ghe.example.com/api/v3":    "ghe.example.com",
+		"https://gitlab.example.com/api/v4": "gitlab.example.com",
+		"https://collector.example/api/v1":  "collector.example",
+		"http://127.0.0.1:8080/api/v1":      "127.0.0.1",
+		"":                                  "",
+	} {
+		if got := tokenHost(apiURL); got != want {
+			t.Errorf("tokenHost(%q) = %q, want %q", apiURL, got, want)
+		}
+	}
+}
+
+func TestNew_SelectsProvider(t *testing.T) {
+	if f, err := New("git@github.com:acme/widget.git", Options{Token: "x"}); err != nil || f.Name() != "github" {
+		t.Errorf("New(github.com) = %v, %v; want github", f, err)
+	}
+	if _, err := New("/srv/git/widget.git", Options{Token: "x"}); err == nil {
+		t.Error("New(local path) should fail without a configured repository")
+	}
+	if _, err := New("https://git.example.com/acme/widget", Options{Token: "x"}); !errors.Is(err, ErrUnknownForge) {
+		t.Errorf("New(unknown host) error = %v, want ErrUnknownForge", err)
+	}
+	for url, want := range map[string]string{
+		"git@gitlab.com:group/sub/widget.git":       "gitlab",
+		"https://gitlab.example.com/acme/widget":    "gitlab",
+		"https://codeberg.org/acme/widget.git":      "gitea",
+		"ssh://git@forgejo.example.com/acme/widget": "gitea",
+	} {
+		if f, err := New(url, Options{Token: "x"}); err != nil || f.Name() != want {
+			t.Errorf("New(%s) = %v, %v; want %s", url, f, err, want)
+		}
+	}
+	if f, err := New("https://git.example.com/acme/widget", Options{Provider: "forgejo", Token: "x"}); err != nil || f.Name() != "gitea" {
+		t.Errorf("New(provider forgejo) = %v, %v; want gitea", f, err)
+	}
+	f, err := New("/srv/git/widget.git", Options{Provider: "github", Repo: "acme/widget", Token: "x"})
+	if err != nil || f.(*gitHub).repo.String() != "acme/widget" {
+		t.Errorf("New(configured) = %v, %v; want github for acme/widget", f, err)
+	}
+}
+
+func TestGitHub_MyPRsAndPR(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"login":"me"}`)
+	})
+	mux.HandleFunc("/repos/acme/widget/pulls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"number":1,"title":"Mine","state":"open","user":{"login":"me"},
+			 "head":{"ref":"feat","user":{"login":"me"},"repo":{"full_name":"acme/widget"}}},
+			{"number":2,"title":"Assigned fork","state":"open","user":{"login":"ext"},"assignees":[{"login":"Me"}],
+			 "head":{"ref":"fix","user":{"login":"ext"},"repo":{"full_name":"ext/widget"}}},
+			{"number":3,"title":"Other","state":"open","user":{"login":"someone"},
+			 "head":{"ref":"other","user":{"login":"someone"},"repo":{"full_name":"acme/widget"}}}
+		]`)
+	})
+	mux.HandleFunc("/repos/acme/widget/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":7,"state":"closed","merged_at":"2026-01-02T03:04:05Z",
+			"head":{"ref":"old","user":{"login":"me"},"repo":null}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f, err := New("git@github.com:acme/widget.git", Options{APIURL: srv.URL, Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prs, err := f.MyPRs(t.Context())
+	if err != nil {
+		t.Fatalf("MyPRs() error: %v", err)
+	}
+	if len(prs) != 2 || prs[0].Branch != "feat" || prs[0].Fork || prs[1].Branch != "fix" || !prs[1].Fork || prs[1].HeadOwner != "ext" {
+		t.Errorf("MyPRs() = %+v, want feat from the repository and fix from ext's fork", prs)
+	}
+
+	pr, err := f.PR(t.Context(), 7)
+	if err != nil || pr.State != Merged || !pr.Fork {
+		t.Errorf("PR(7) = %+v, %v; want a merged PR whose fork is gone", pr, err)
+	}
+
+	bad, _ := New("git@github.com:acme/widget.git", Options{APIURL: srv.URL, Token: "wrong"})
+	if _, err := bad.MyPRs(t.Context()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("MyPRs() with a bad token: error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestGitLab_MyPRsAndPR(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"username":"me"}`)
+	})
+	mux.HandleFunc("/projects/group%2Fsub%2Fwidget/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("state") != "opened" {
+			t.Errorf("merge requests queried with state %q", r.URL.Query().Get("state"))
+		}
+		fmt.Fprint(w, `[
+			{"iid":1,"title":"Mine","state":"opened","source_branch":"feat","source_project_id":5,"target_project_id":5,
+			 "author":{"username":"me"}},
+			{"iid":2,"title":"Assigned fork","state":"opened","source_branch":"fix","source_project_id":9,"target_project_id":5,
+			 "author":{"username":"ext"},"assignees":[{"username":"Me"}]},
+			{"iid":3,"title":"Other","state":"opened","source_branch":"other","source_project_id":5,"target_project_id":5,
+			 "author":{"username":"someone"}}
+		]`)
+	})
+	mux.HandleFunc("/projects/group%2Fsub%2Fwidget/merge_requests/7", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"iid":7,"state":"merged","source_branch":"old","source_project_id":5,"target_project_id":5}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f, err := New("git@gitlab.com:group/sub/widget.git", Options{APIURL: srv.URL, Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prs, err := f.MyPRs(t.Context())
+	if err != nil {
+		t.Fatalf("MyPRs() error: %v", err)
+	}
+	if len(prs) != 2 || prs[0].Branch != "feat" || prs[0].Fork || prs[0].State != Open || prs[1].Branch != "fix" || !prs[1].Fork || prs[1].HeadOwner != "ext" {
+		t.Errorf("MyPRs() = %+v, want feat from the project and fix from ext's fork", prs)
+	}
+	if pr, err := f.PR(t.Context(), 7); err != nil || pr.State != Merged {
+		t.Errorf("PR(7) = %+v, %v; want a merged merge request", pr, err)
+	}
+	if ref := f.HeadRef(7); ref != "refs/merge-requests/7/head" {
+		t.Errorf("HeadRef(7) = %q", ref)
+	}
+
+	bad, _ := New("git@gitlab.com:group/sub/widget.git", Options{APIURL: srv.URL, Token: "wrong"})
+	if _, err := bad.MyPRs(t.Context()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("MyPRs() with a bad token: error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestGitea_MyPRsAndPR(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, `{"message":"token is required"}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"login":"me"}`)
+	})
+	mux.HandleFunc("/repos/acme/widget/pulls", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"number":1,"title":"Mine","state":"open","user":{"login":"me"},
+			 "head":{"ref":"feat","repo":{"full_name":"acme/widget","owner":{"login":"acme"}}}},
+			{"number":2,"title":"Assigned fork","state":"open","user":{"login":"ext"},"assignees":[{"login":"me"}],
+			 "head":{"ref":"fix","repo":{"full_name":"ext/widget","owner":{"login":"ext"}}}}
+		]`)
+	})
+	mux.HandleFunc("/repos/acme/widget/pulls/7", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"number":7,"state":"closed","merged":true,"head":{"ref":"old","repo":null}}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f, err := New("https://codeberg.org/acme/widget.git", Options{APIURL: srv.URL, Token: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prs, err := f.MyPRs(t.Context())
+	if err != nil {
+		t.Fatalf("MyPRs() error: %v", err)
+	}
+	if len(prs) != 2 || prs[0].Fork || !prs[1].Fork || prs[1].HeadOwner != "ext" {
+		t.Errorf("MyPRs() = %+v, want feat from the repository and fix from ext's fork", prs)
+	}
+	if pr, err := f.PR(t.Context(), 7); err != nil || pr.State != Merged {
+		t.Errorf("PR(7) = %+v, %v; want a merged pull request", pr, err)
+	}
+}
@@ -0,0 +1,145 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// giteaPageSize is the number of pull requests requested per page; Gitea
+// caps it at 50 by default.
+const giteaPageSize = 50
+
+// gitea is the API (v1) of Gitea and its fork Forgejo, which Codeberg runs.
+type gitea struct {
+	repo Repo
+	api  *apiClient
+}
+
+func newGitea(repo Repo, opts Options) *gitea {
+	api := opts.APIURL
+	if api == "" {
+		api = "https://" + repo.Host + "/api/v1"
+	}
+	token := opts.Token
+	if token == "" {
+		token = envToken("GITEA_TOKEN", "FORGEJO_TOKEN")
+	}
+	if token == "" {
+		token = storedToken("gitea", tokenHost(api))
+	}
+	return &gitea{
+		repo: repo,
+		api: newAPIClient("Gitea", api, func(req *http.Request) {
+			if token != "" {
+				req.Header.Set("Authorization", "token "+token)
+			}
+		}),
+	}
+}
+
+func (g *gitea) Name() string { return "gitea" }
+
+func (g *gitea) Host() string { return g.repo.Host }
+
+func (g *gitea) Login(ctx context.Context) (string, error) {
+	var me giteaUser
+	if err := g.api.get(ctx, "/user", &me); err != nil {
+		return "", err
+	}
+	return me.Login, nil
+}
+
+func (g *gitea) HeadRef(number int) string {
+	return fmt.Sprintf("refs/pull/%d/head", number)
+}
+
+// giteaPull is the subset of a pull request object that wt uses.
+type giteaPull struct {
+	Number    int         `json:"number"`
+	Title     string      `json:"title"`
+	HTMLURL   string      `json:"html_url"`
+	State     string      `json:"state"`
+	Merged    bool        `json:"merged"`
+	MergedAt  *time.Time  `json:"merged_at"`
+	User      giteaUser   `json:"user"`
+	Assignees []giteaUser `json:"assignees"`
+	Head      struct {
+		Ref  string `json:"ref"`
+		Repo *struct {
+			FullName string    `json:"full_name"`
+			Owner    giteaUser `json:"owner"`
+		} `json:"repo"`
+	} `json:"head"`
+}
+
+type giteaUser struct {
+	Login string `json:"login"`
+}
+
+func (p giteaPull) pr(repo Repo) PR {
+	pr := PR{
+		Number: p.Number,
+		Title:  p.Title,
+		URL:    p.HTMLURL,
+		State:  p.State,
+		Branch: p.Head.Ref,
+	}
+	if p.Merged || p.MergedAt != nil {
+		pr.State = Merged
+	}
+	// A deleted fork leaves no head repository
+	pr.Fork = p.Head.Repo == nil || !strings.EqualFold(p.Head.Repo.FullName, repo.String())
+	if p.Head.Repo != nil {
+		pr.HeadOwner = p.Head.Repo.Owner.Login
+	}
+	return pr
+}
+
+// involves reports whether login authored or is assigned to the pull request.
+func (p giteaPull) involves(login string) bool {
+	if strings.EqualFold(p.User.Login, login) {
+		return true
+	}
+	for _, a := range p.Assignees {
+		if strings.EqualFold(a.Login, login) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *gitea) MyPRs(ctx context.Context) ([]PR, error) {
+	login, err := g.Login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PR
+	for page := 1; ; page++ {
+		var pulls []giteaPull
+		path := fmt.Sprintf("/repos/%s/pulls?state=open&limit=%d&page=%d", g.repo, giteaPageSize, page)
+		if err := g.api.get(ctx, path, &pulls); err != nil {
+			return nil, err
+		}
+		for _, p := range pulls {
+			if p.involves(login) {
+				prs = append(prs, p.pr(g.repo))
+			}
+		}
+		if len(pulls) < giteaPageSize {
+			return prs, nil
+		}
+	}
+}
+
+func (g *gitea) PR(ctx context.Context, number int) (PR, error) {
+	var p giteaPull
+	if err := g.api.get(ctx, "/repos/"+g.repo.String()+"/pulls/"+strconv.Itoa(number), &p); err != nil {
+		return PR{}, err
+	}
+	return p.pr(g.repo), nil
+}
@@ -0,0 +1,170 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// githubPageSize is the number of pull requests requested per page.
+const githubPageSize = 100
+
+// gitHub is the GitHub and GitHub Enterprise REST API.
+type gitHub struct {
+	repo Repo
+	api  *apiClient
+}
+
+func newGitHub(repo Repo, opts Options) *gitHub {
+	api := opts.APIURL
+	switch {
+	case api != "":
+	case repo.Host == "" || repo.Host == "github.com":
+		api = "https://api.github.com"
+	default:
+		api = "https://" + repo.Host + "/api/v3"
+	}
+	token := opts.Token
+	if token == "" {
+		token = gitHubToken(tokenHost(api))
+	}
+	return &gitHub{
+		repo: repo,
+		api: newAPIClient("GitHub", api, func(req *http.Request) {
+			req.Header.Set("Accept", "application/vnd.github+json")
+			req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}),
+	}
+}
+
+// gitHubToken reads a token from GITHUB_TOKEN or GH_TOKEN, falling back to
+// the token stored with wt auth login for host and then the GitHub CLI's
+// login to it.
+func gitHubToken(host string) string {
+	if token := envToken("GITHUB_TOKEN", "GH_TOKEN"); token != "" {
+		return token
+	}
+	if host == "" {
+		return ""
+	}
+	if token := storedToken("github", host); token != "" {
+		return token
+	}
+	out, err := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (g *gitHub) Name() string { return "github" }
+
+func (g *gitHub) Host() string {
+	if g.repo.Host == "" {
+		return "github.com"
+	}
+	return g.repo.Host
+}
+
+func (g *gitHub) Login(ctx context.Context) (string, error) {
+	var me gitHubUser
+	if err := g.api.get(ctx, "/user", &me); err != nil {
+		return "", err
+	}
+	return me.Login, nil
+}
+
+func (g *gitHub) HeadRef(number int) string {
+	return fmt.Sprintf("refs/pull/%d/head", number)
+}
+
+// gitHubPull is the subset of a pull request object that wt uses.
+type gitHubPull struct {
+	Number    int          `json:"number"`
+	Title     string       `json:"title"`
+	HTMLURL   string       `json:"html_url"`
+	State     string       `json:"state"`
+	MergedAt  *time.Time   `json:"merged_at"`
+	User      gitHubUser   `json:"user"`
+	Assignees []gitHubUser `json:"assignees"`
+	Head      struct {
+		Ref  string     `json:"ref"`
+		User gitHubUser `json:"user"`
+		Repo *struct {
+			FullName string `json:"full_name"`
+		} `json:"repo"`
+	} `json:"head"`
+}
+
+type gitHubUser struct {
+	Login string `json:"login"`
+}
+
+func (p gitHubPull) pr(repo Repo) PR {
+	pr := PR{
+		Number:    p.Number,
+		Title:     p.Title,
+		URL:       p.HTMLURL,
+		State:     p.State,
+		Branch:    p.Head.Ref,
+		HeadOwner: p.Head.User.Login,
+	}
+	if p.MergedAt != nil {
+		pr.State = Merged
+	}
+	// A deleted fork leaves no head repository
+	pr.Fork = p.Head.Repo == nil || !strings.EqualFold(p.Head.Repo.FullName, repo.String())
+	return pr
+}
+
+func (g *gitHub) MyPRs(ctx context.Context) ([]PR, error) {
+	login, err := g.Login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PR
+	for page := 1; ; page++ {
+		var pulls []gitHubPull
+		path := fmt.Sprintf("/repos/%s/pulls?state=open&per_page=%d&page=%d", g.repo, githubPageSize, page)
+		if err := g.api.get(ctx, path, &pulls); err != nil {
+			return nil, err
+		}
+		for _, p := range pulls {
+			if p.involves(login) {
+				prs = append(prs, p.pr(g.repo))
+			}
+		}
+		if len(pulls) < githubPageSize {
+			return prs, nil
+		}
+	}
+}
+
+// involves reports whether login authored or is assigned to the pull request.
+func (p gitHubPull) involves(login string) bool {
+	if strings.EqualFold(p.User.Login, login) {
+		return true
+	}
+	for _, a := range p.Assignees {
+		if strings.EqualFold(a.Login, login) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *gitHub) PR(ctx context.Context, number int) (PR, error) {
+	var p gitHubPull
+	if err := g.api.get(ctx, "/repos/"+g.repo.String()+"/pulls/"+strconv.Itoa(number), &p); err != nil {
+		return PR{}, err
+	}
+	return p.pr(g.repo), nil
+}
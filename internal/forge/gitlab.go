@@ -0,0 +1,161 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// gitlabPageSize is the number of merge requests requested per page.
+const gitlabPageSize = 100
+
+// gitLab is the GitLab REST API (v4), for gitlab.com and self-managed
+// instances. Merge requests are reported as pull requests.
+type gitLab struct {
+	repo Repo
+	api  *apiClient
+}
+
+func newGitLab(repo Repo, opts Options) *gitLab {
+	if repo.Host == "" {
+		repo.Host = "gitlab.com"
+	}
+	api := opts.APIURL
+	if api == "" {
+		api = "https://" + repo.Host + "/api/v4"
+	}
+	token := opts.Token
+	if token == "" {
+		token = envToken("GITLAB_TOKEN", "GL_TOKEN")
+	}
+	if token == "" {
+		token = storedToken("gitlab", tokenHost(api))
+	}
+	return &gitLab{
+		repo: repo,
+		api: newAPIClient("GitLab", api, func(req *http.Request) {
+			if token != "" {
+				req.Header.Set("PRIVATE-TOKEN", token)
+			}
+		}),
+	}
+}
+
+func (g *gitLab) Name() string { return "gitlab" }
+
+func (g *gitLab) Host() string { return g.repo.Host }
+
+func (g *gitLab) Login(ctx context.Context) (string, error) {
+	var me gitLabUser
+	if err := g.api.get(ctx, "/user", &me); err != nil {
+		return "", err
+	}
+	return me.Username, nil
+}
+
+func (g *gitLab) HeadRef(number int) string {
+	return fmt.Sprintf("refs/merge-requests/%d/head", number)
+}
+
+// project is the repository's path-encoded project ID, e.g. "group%2Fwidget".
+func (g *gitLab) project() string {
+	return url.PathEscape(g.repo.String())
+}
+
+// gitLabMR is the subset of a merge request object that wt uses.
+type gitLabMR struct {
+	IID             int          `json:"iid"`
+	Title           string       `json:"title"`
+	WebURL          string       `json:"web_url"`
+	State           string       `json:"state"`
+	SourceBranch    string       `json:"source_branch"`
+	SourceProjectID int          `json:"source_project_id"`
+	TargetProjectID int          `json:"target_project_id"`
+	Author          gitLabUser   `json:"author"`
+	Assignees       []gitLabUser `json:"assignees"`
+}
+
+type gitLabUser struct {
+	Username string `json:"username"`
+}
+
+func (m gitLabMR) pr() PR {
+	pr := PR{
+		Number: m.IID,
+		Title:  m.Title,
+		URL:    m.WebURL,
+		Branch: m.SourceBranch,
+		Fork:   m.SourceProjectID != m.TargetProjectID,
+		// Merge requests name the fork's project only by ID; its author
+		// usually owns it
+		HeadOwner: m.Author.Username,
+	}
+	switch m.State {
+	case "opened":
+		pr.State = Open
+	case "merged":
+		pr.State = Merged
+	default: // closed, locked
+		pr.State = Closed
+	}
+	return pr
+}
+
+// involves reports whether username authored or is assigned to the merge
+// request.
+func (m gitLabMR) involves(username string) bool {
+	if strings.EqualFold(m.Author.Username, username) {
+		return true
+	}
+	for _, a := range m.Assignees {
+		if strings.EqualFold(a.Username, username) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *gitLab) MyPRs(ctx context.Context) ([]PR, error) {
+	username, err := g.Login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var prs []PR
+	for page := 1; ; page++ {
+		var mrs []gitLabMR
+		path := fmt.Sprintf("/projects/%s/merge_requests?state=opened&per_page=%d&page=%d", g.project(), gitlabPageSize, page)
+		if err := g.api.get(ctx, path, &mrs); err != nil {
+			return nil, err
+		}
+		for _, m := range mrs {
+			if m.involves(username) {
+				prs = append(prs, m.pr())
+			}
+		}
+		if len(mrs) < gitlabPageSize {
+			return prs, nil
+		}
+	}
+}
+
+func (g *gitLab) PR(ctx context.Context, number int) (PR, error) {
+	var m gitLabMR
+	if err := g.api.get(ctx, fmt.Sprintf("/projects/%s/merge_requests/%d", g.project(), number), &m); err != nil {
+		return PR{}, err
+	}
+	return m.pr(), nil
+}
+
+// envToken returns the first of the environment variables that is set.
+func envToken(names ...string) string {
+	for _, name := range names {
+		if token := os.Getenv(name); token != "" {
+			return token
+		}
+	}
+	return ""
+}
@@ -1,6 +1,11 @@
 package fuzzy
 
-import "unicode/utf8"
+import (
+	"slices"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
 
 // Scoring constants
 const (
@@ -20,27 +25,83 @@ type Match struct {
 }
 
 // Score scores str against pattern using a greedy forward-scan algorithm
-// with contextual bonuses. Case-insensitive matching.
+// with contextual bonuses. Matching ignores case in any script, by Unicode
+// simple case folding: "ŻÓŁW" matches "żółw" and "ΣΟΦΙΑ" matches "σοφια".
 func Score(str, pattern string) Match {
 	if pattern == "" {
 		return Match{Score: 0, Matched: true, Positions: nil}
 	}
-	if str == "" {
-		return Match{Score: 0, Matched: false, Positions: nil}
+	var s scorer
+	s.reset(pattern)
+	m := s.score(str)
+	if m.Matched {
+		m.Positions = slices.Clone(m.Positions)
 	}
+	return m
+}
 
-	strLower := toLower(str)
-	patLower := toLower(pattern)
+// ScoreBatch scores each of strs against pattern, like Score. The pattern is
+// folded once and the scratch buffers are shared across strs, so filtering a
+// long list allocates little more than the positions of the matches.
+func ScoreBatch(strs []string, pattern string) []Match {
+	matches := make([]Match, len(strs))
+	if pattern == "" {
+		for i := range matches {
+			matches[i].Matched = true
+		}
+		return matches
+	}
+	var s scorer
+	s.reset(pattern)
+	var slab []int
+	for i, str := range strs {
+		m := s.score(str)
+		if m.Matched {
+			// Positions of all matches share one backing array
+			if cap(slab)-len(slab) < len(m.Positions) {
+				slab = make([]int, 0, max(len(m.Positions)*64, 1024))
+			}
+			n := len(slab)
+			slab = append(slab, m.Positions...)
+			m.Positions = slab[n:len(slab):len(slab)]
+		}
+		matches[i] = m
+	}
+	return matches
+}
+
+// scorer holds a folded pattern and the buffers reused across the strings
+// scored against it.
+type scorer struct {
+	pat       []rune
+	str, orig []rune
+	positions []int
+}
 
-	strRunes := []rune(strLower)
-	patRunes := []rune(patLower)
-	origRunes := []rune(str)
+// reset prepares s to score strings against pattern.
+func (s *scorer) reset(pattern string) {
+	s.pat = appendFolded(s.pat[:0], pattern)
+	s.positions = slices.Grow(s.positions[:0], len(s.pat))
+}
 
-	if len(patRunes) > len(strRunes) {
+// score scores str against the pattern. The returned positions are only
+// valid until the next call.
+func (s *scorer) score(str string) Match {
+	if str == "" {
 		return Match{Score: 0, Matched: false, Positions: nil}
 	}
 
-	positions := make([]int, 0, len(patRunes))
+	s.str = appendFolded(s.str[:0], str)
+	if len(s.pat) > len(s.str) {
+		return Match{Score: 0, Matched: false, Positions: nil}
+	}
+	s.orig = s.orig[:0]
+	for _, r := range str {
+		s.orig = append(s.orig, r)
+	}
+	strRunes, patRunes, origRunes := s.str, s.pat, s.orig
+
+	positions := s.positions[:0]
 	score := 0
 	pi := 0
 	prevMatchIdx := -1
@@ -87,6 +148,7 @@ func Score(str, pattern string) Match {
 		prevMatchIdx = si
 		pi++
 	}
+	s.positions = positions
 
 	if pi < len(patRunes) {
 		return Match{Score: 0, Matched: false, Positions: nil}
@@ -95,29 +157,92 @@ func Score(str, pattern string) Match {
 	return Match{Score: score, Matched: true, Positions: positions}
 }
 
+// Distance returns the edit distance between a and b: the number of runes
+// to insert, delete, or substitute to turn one into the other, ignoring case.
+func Distance(a, b string) int {
+	ar, br := appendFolded(nil, a), appendFolded(nil, b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+// maxTypos is the edit distance up to which Closest treats a candidate as a
+// misspelling of the input.
+const maxTypos = 2
+
+// Closest returns up to n of candidates that the input most likely meant:
+// first those within a couple of typos of it, nearest first, then those it
+// matches as a fuzzy pattern, best score first.
+func Closest(candidates []string, input string, n int) []string {
+	type ranked struct {
+		s     string
+		typos int
+		score int
+	}
+	var found []ranked
+	for _, c := range candidates {
+		if d := Distance(c, input); d <= maxTypos {
+			found = append(found, ranked{s: c, typos: d})
+		} else if m := Score(c, input); m.Matched {
+			found = append(found, ranked{s: c, typos: maxTypos + 1, score: m.Score})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].typos != found[j].typos {
+			return found[i].typos < found[j].typos
+		}
+		return found[i].score > found[j].score
+	})
+	var out []string
+	for _, r := range found[:min(n, len(found))] {
+		out = append(out, r.s)
+	}
+	return out
+}
+
 func isSeparator(r rune) bool {
 	return r == '-' || r == '_' || r == '.' || r == '/'
 }
 
 func isLower(r rune) bool {
-	return r >= 'a' && r <= 'z'
+	return unicode.IsLower(r)
 }
 
 func isUpper(r rune) bool {
-	return r >= 'A' && r <= 'Z'
+	return unicode.IsUpper(r)
 }
 
-func toLower(s string) string {
-	b := make([]byte, 0, len(s))
-	for i := 0; i < len(s); {
-		r, size := utf8.DecodeRuneInString(s[i:])
-		if r >= 'A' && r <= 'Z' {
-			r += 'a' - 'A'
+// appendFolded appends the runes of s to dst with each replaced by the
+// smallest rune of its case folding orbit, so that runes differing only in
+// case compare equal. Every rune maps to exactly one, keeping match positions
+// valid for s.
+func appendFolded(dst []rune, s string) []rune {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			r -= 'a' - 'A' // Fast path: the orbits of ASCII letters start in ASCII
+		case r >= utf8.RuneSelf:
+			folded := r
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				folded = min(folded, f)
+			}
+			r = folded
 		}
-		buf := make([]byte, utf8.RuneLen(r))
-		utf8.EncodeRune(buf, r)
-		b = append(b, buf...)
-		i += size
+		dst = append(dst, r)
 	}
-	return string(b)
+	return dst
 }
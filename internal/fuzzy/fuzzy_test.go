@@ -1,8 +1,12 @@
 package fuzzy
 
 import (
+	"fmt"
+	"math"
+	"slices"
 	"sort"
 	"testing"
+	"time"
 )
 
 func TestScore_ExactMatch(t *testing.T) {
@@ -138,6 +142,48 @@ func TestScore_CaseInsensitive(t *testing.T) {
 	}
 }
 
+func TestScore_UnicodeCaseFolding(t *testing.T) {
+	tests := []struct {
+		str, pattern string
+	}{
+		{"feature/ŻÓŁW", "żółw"},
+		{"fix/Ärger", "ärger"},
+		{"ΣΟΦΙΑ", "σοφια"},
+		{"docs/ΟΔΟΣ", "οδος"}, // final sigma folds like capital sigma
+		{"temp-\u212a", "k"},  // Kelvin sign
+		{"release/ПРИВЕТ", "привет"},
+	}
+	for _, tt := range tests {
+		m := Score(tt.str, tt.pattern)
+		if !m.Matched {
+			t.Errorf("Score(%q, %q) should match regardless of case", tt.str, tt.pattern)
+		}
+	}
+}
+
+func TestScore_NonASCIIPositions(t *testing.T) {
+	// Positions index runes, so they stay valid for highlighting multibyte
+	// and wide characters
+	m := Score("機能/ログイン画面", "ログ")
+	if !m.Matched {
+		t.Fatal("CJK pattern should match")
+	}
+	if len(m.Positions) != 2 || m.Positions[0] != 3 || m.Positions[1] != 4 {
+		t.Errorf("positions = %v, want [3 4]", m.Positions)
+	}
+	if withSep, inWord := Score("機能/ログイン", "ロ"), Score("機能ログイン", "ロ"); withSep.Score <= inWord.Score {
+		t.Errorf("match after a separator (%d) should score above one inside a word (%d)", withSep.Score, inWord.Score)
+	}
+
+	m = Score("fix/żółwŚmiały", "śm")
+	if !m.Matched || m.Positions[0] != 8 {
+		t.Fatalf("Score should match at the camelCase boundary, got %+v", m)
+	}
+	if camel, plain := Score("żółwŚmiały", "ś"), Score("żółwśmiały", "ś"); camel.Score <= plain.Score {
+		t.Errorf("non-ASCII camelCase boundary (%d) should score above a plain match (%d)", camel.Score, plain.Score)
+	}
+}
+
 func TestScore_SortOrder(t *testing.T) {
 	entries := []string{
 		"some-random-thing",
@@ -173,3 +219,155 @@ func TestScore_SortOrder(t *testing.T) {
 		t.Errorf("expected 'some-random-thing' to rank last, got %q", results[len(results)-1].entry)
 	}
 }
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"main", "main", 0},
+		{"main", "mian", 2},
+		{"Main", "main", 0},
+		{"feature/x", "feature-x", 1},
+		{"", "dev", 3},
+		{"release", "relase", 1},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDistance_Unicode(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"ŻÓŁW", "żółw", 0},
+		{"żółw", "zolw", 3},
+		{"功能", "功能", 0},
+		{"功能", "功用", 1},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"main", "origin/main", "develop", "feature/login", "v1.0.0"}
+
+	if got := Closest(candidates, "mian", 3); len(got) == 0 || got[0] != "main" {
+		t.Errorf("Closest(mian) = %q, want main first", got)
+	}
+	if got := Closest(candidates, "login", 3); len(got) != 1 || got[0] != "feature/login" {
+		t.Errorf("Closest(login) = %q, want [feature/login]", got)
+	}
+	if got := Closest(candidates, "zzz", 3); len(got) != 0 {
+		t.Errorf("Closest(zzz) = %q, want none", got)
+	}
+	if got := Closest(candidates, "in", 2); len(got) != 2 {
+		t.Errorf("Closest(in, 2) = %q, want 2 results", got)
+	}
+}
+
+// benchEntries returns n branch-like names, a mix of ASCII and non-ASCII.
+func benchEntries(n int) []string {
+	prefixes := []string{"feature", "fix", "release", "chore", "docs", "hotfix/żółw", "功能"}
+	words := []string{"auth", "gateway", "Rewrite", "login-page", "cache", "ΣΟΦΙΑ", "metrics", "retry"}
+	entries := make([]string, n)
+	for i := range entries {
+		entries[i] = fmt.Sprintf("%s/%s-%s-%d", prefixes[i%len(prefixes)], words[i%len(words)], words[(i/len(words))%len(words)], i)
+	}
+	return entries
+}
+
+func TestScoreBatch_MatchesScore(t *testing.T) {
+	entries := append(benchEntries(200), "", "x")
+	for _, pattern := range []string{"", "fa", "ŻÓŁW", "σοφ", "功能", "retry-cache-1"} {
+		got := ScoreBatch(entries, pattern)
+		if len(got) != len(entries) {
+			t.Fatalf("ScoreBatch(%q) returned %d matches, want %d", pattern, len(got), len(entries))
+		}
+		for i, e := range entries {
+			want := Score(e, pattern)
+			if got[i].Matched != want.Matched || got[i].Score != want.Score || !slices.Equal(got[i].Positions, want.Positions) {
+				t.Errorf("ScoreBatch(%q)[%q] = %+v, Score = %+v", pattern, e, got[i], want)
+			}
+		}
+	}
+}
+
+func TestScoreBatch_PositionsDoNotAlias(t *testing.T) {
+	got := ScoreBatch([]string{"abc", "xabc"}, "ab")
+	got[0].Positions = append(got[0].Positions, 99)
+	if !slices.Equal(got[1].Positions, []int{1, 2}) {
+		t.Errorf("appending to one match's positions changed another's: %v", got[1].Positions)
+	}
+}
+
+// frameBudget is the time filtering may take per keystroke for typing in a
+// selector to keep up with a 60Hz display. BenchmarkScoreBatch_Typing
+// measures against it; the test below only catches gross regressions.
+const frameBudget = 16 * time.Millisecond
+
+func TestScoreBatch_FrameBudget(t *testing.T) {
+	entries := benchEntries(10000)
+	patterns := []string{"f", "fa", "fau", "faut"}
+	// The best of a few runs against several frames, so a busy machine or
+	// the race detector does not fail the test
+	const limit = 10 * frameBudget
+	best := time.Duration(math.MaxInt64)
+	for range 5 {
+		start := time.Now()
+		for _, pattern := range patterns {
+			ScoreBatch(entries, pattern)
+		}
+		best = min(best, time.Since(start)/time.Duration(len(patterns)))
+	}
+	if best > limit {
+		t.Errorf("filtering %d entries took %v per keystroke, over %v", len(entries), best, limit)
+	}
+}
+
+// BenchmarkScoreBatch_Typing filters as a query is typed. ms/keystroke should
+// stay under frameBudget.
+func BenchmarkScoreBatch_Typing(b *testing.B) {
+	entries := benchEntries(10000)
+	patterns := []string{"f", "fa", "fau", "faut"}
+	for b.Loop() {
+		for _, pattern := range patterns {
+			ScoreBatch(entries, pattern)
+		}
+	}
+	perKeystroke := b.Elapsed() / time.Duration(b.N*len(patterns))
+	b.ReportMetric(float64(perKeystroke)/float64(time.Millisecond), "ms/keystroke")
+}
+
+func BenchmarkScore(b *testing.B) {
+	entries := benchEntries(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, e := range entries {
+			Score(e, "fau")
+		}
+	}
+}
+
+func BenchmarkScoreBatch(b *testing.B) {
+	entries := benchEntries(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		ScoreBatch(entries, "fau")
+	}
+}
+
+func BenchmarkScoreBatch_NonASCII(b *testing.B) {
+	entries := benchEntries(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		ScoreBatch(entries, "żółw")
+	}
+}
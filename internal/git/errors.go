@@ -0,0 +1,92 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Kinds of git failure that callers commonly need to tell apart. Errors
+// returned by this package wrap one of these when git's output identifies the
+// cause, so callers can test for them with errors.Is instead of matching
+// message text.
+var (
+	// ErrNotARepo means the command ran outside any git repository.
+	ErrNotARepo = errors.New("not a git repository")
+	// ErrNoUpstream means the branch has no upstream configured.
+	ErrNoUpstream = errors.New("no upstream configured")
+	// ErrBranchCheckedOut means the branch is already checked out in another
+	// worktree.
+	ErrBranchCheckedOut = errors.New("branch is already checked out")
+	// ErrDirty means the worktree has uncommitted changes that the operation
+	// would lose.
+	ErrDirty = errors.New("worktree has uncommitted changes")
+	// ErrUnknownRevision means a ref or revision does not exist.
+	ErrUnknownRevision = errors.New("unknown revision")
+	// ErrNotMerged means a branch was not deleted because it has commits
+	// that are not merged.
+	ErrNotMerged = errors.New("branch is not fully merged")
+)
+
+// errUnsupported means the installed git does not understand an option or
+// format used by the command.
+var errUnsupported = errors.New("not supported by this git version")
+
+// Error is a failed git invocation. Its message is the exit status followed by
+// what git printed; Unwrap exposes both the underlying error and the kind of
+// failure, when recognized.
+type Error struct {
+	// Args are the arguments git was run with.
+	Args []string
+	// Output is git's trimmed error output.
+	Output string
+	// Err is the underlying error, typically an *exec.ExitError.
+	Err error
+
+	kind error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Err, e.Output)
+}
+
+func (e *Error) Unwrap() []error {
+	if e.kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Err, e.kind}
+}
+
+// errorPatterns maps fragments of git's messages to the kind of failure they
+// report. Some causes are worded differently across git versions.
+var errorPatterns = []struct {
+	fragment string
+	kind     error
+}{
+	{"not a git repository", ErrNotARepo},
+	{"no upstream configured", ErrNoUpstream},
+	{"no upstream branch", ErrNoUpstream},
+	{"no tracking information", ErrNoUpstream},
+	{"is already checked out at", ErrBranchCheckedOut},
+	{"is already used by worktree at", ErrBranchCheckedOut},
+	{"contains modified or untracked files", ErrDirty},
+	{"local changes to the following files would be overwritten", ErrDirty},
+	{"unknown revision", ErrUnknownRevision},
+	{"invalid reference", ErrUnknownRevision},
+	{"is not fully merged", ErrNotMerged},
+	{"unknown field name", errUnsupported},
+}
+
+// newError builds the error for a git invocation that failed with err and
+// printed output.
+func newError(args []string, err error, output string) *Error {
+	output = strings.TrimSpace(output)
+	e := &Error{Args: args, Output: output, Err: err}
+	for _, p := range errorPatterns {
+		if strings.Contains(output, p.fragment) {
+			e.kind = p.kind
+			break
+		}
+	}
+	return e
+}
@@ -1,11 +1,21 @@
 package git
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/provenimpact/wt/internal/debug"
+	"github.com/provenimpact/wt/internal/shell"
 )
 
 // Worktree represents a single git worktree.
@@ -14,12 +24,25 @@ type Worktree struct {
 	Branch string
 	HEAD   string
 	Bare   bool
+	// Prunable is git's reason the worktree can be pruned (typically its
+	// directory was deleted); empty for healthy worktrees.
+	Prunable string
+	// Locked is git's reason the worktree is locked (see git worktree lock),
+	// or "locked" when none was given; empty for unlocked worktrees. A locked
+	// worktree is never prunable, e.g. while on an unmounted volume.
+	Locked string
 }
 
 // ListWorktrees returns all worktrees for the repository.
 // It must be called from within a git repository (main or linked worktree).
-func ListWorktrees() ([]Worktree, error) {
-	out, err := gitOutput("worktree", "list", "--porcelain")
+func ListWorktrees(ctx context.Context) ([]Worktree, error) {
+	return ListWorktreesDir(ctx, "")
+}
+
+// ListWorktreesDir is like ListWorktrees for the repository containing dir;
+// an empty dir means the current directory.
+func ListWorktreesDir(ctx context.Context, dir string) ([]Worktree, error) {
+	out, err := gitOutputDir(ctx, dir, "worktree", "list", "--porcelain")
 	if err != nil {
 		return nil, fmt.Errorf("listing worktrees: %w", err)
 	}
@@ -38,6 +61,11 @@ func ListWorktrees() ([]Worktree, error) {
 			// branch is in refs/heads/... format
 			branch := strings.TrimPrefix(line, "branch ")
 			current.Branch = strings.TrimPrefix(branch, "refs/heads/")
+		case line == "prunable" || strings.HasPrefix(line, "prunable "):
+			// A bare "prunable" line carries no reason; keep the word itself
+			current.Prunable = strings.TrimPrefix(line, "prunable ")
+		case line == "locked" || strings.HasPrefix(line, "locked "):
+			current.Locked = strings.TrimPrefix(line, "locked ")
 		case line == "bare":
 			current.Bare = true
 		case line == "detached":
@@ -63,7 +91,13 @@ func ListWorktrees() ([]Worktree, error) {
 // If createBranch is true, a new branch is created. When createBranch is true
 // and base is non-empty, the new branch starts from the specified base reference
 // instead of HEAD.
-func AddWorktree(path, branch string, createBranch bool, base string) error {
+func AddWorktree(ctx context.Context, path, branch string, createBranch bool, base string) error {
+	return AddWorktreeDir(ctx, "", path, branch, createBranch, base)
+}
+
+// AddWorktreeDir is like AddWorktree for the repository containing dir; an
+// empty dir means the current directory.
+func AddWorktreeDir(ctx context.Context, dir, path, branch string, createBranch bool, base string) error {
 	args := []string{"worktree", "add"}
 	if createBranch {
 		args = append(args, "-b", branch, path)
@@ -74,76 +108,847 @@ func AddWorktree(path, branch string, createBranch bool, base string) error {
 		args = append(args, path, branch)
 	}
 
-	if err := gitRun(args...); err != nil {
+	if err := gitRunDir(ctx, dir, args...); err != nil {
+		return fmt.Errorf("creating worktree: %w", err)
+	}
+	return nil
+}
+
+// AddTrackingWorktree creates a worktree at path on a new local branch that
+// starts at and tracks the remote-tracking ref upstream (e.g. "origin/feature").
+func AddTrackingWorktree(ctx context.Context, path, branch, upstream string) error {
+	return AddTrackingWorktreeDir(ctx, "", path, branch, upstream)
+}
+
+// AddTrackingWorktreeDir is like AddTrackingWorktree for the repository
+// containing dir; an empty dir means the current directory.
+func AddTrackingWorktreeDir(ctx context.Context, dir, path, branch, upstream string) error {
+	if err := gitRunDir(ctx, dir, "worktree", "add", "--track", "-b", branch, path, upstream); err != nil {
 		return fmt.Errorf("creating worktree: %w", err)
 	}
 	return nil
 }
 
+// BranchOptions control how a worktree's branch is created from its start
+// point.
+type BranchOptions struct {
+	// Track sets the start point, a remote-tracking ref, as the upstream of
+	// the branch. NoTrack sets none, even where branch.autoSetupMerge would,
+	// and drops the upstream of a branch that is reset.
+	Track   bool
+	NoTrack bool
+	// Reset resets an existing branch to the start point, like
+	// git checkout -B, instead of failing.
+	Reset bool
+}
+
+// args returns the worktree add options creating branch.
+func (o BranchOptions) args(branch string) []string {
+	var args []string
+	switch {
+	case o.Track:
+		args = append(args, "--track")
+	case o.NoTrack:
+		args = append(args, "--no-track")
+	}
+	if o.Reset {
+		return append(args, "-B", branch)
+	}
+	return append(args, "-b", branch)
+}
+
+// finish drops the upstream that git keeps when an existing branch is reset
+// with --no-track.
+func (o BranchOptions) finish(ctx context.Context, branch string) error {
+	if !o.Reset || !o.NoTrack {
+		return nil
+	}
+	if _, err := gitOutput(ctx, "config", "--get", "branch."+branch+".merge"); err != nil {
+		return nil // No upstream to drop
+	}
+	if err := gitRun(ctx, "branch", "--unset-upstream", branch); err != nil {
+		return fmt.Errorf("removing the upstream of %s: %w", branch, err)
+	}
+	return nil
+}
+
+// AddDetachedWorktree creates a worktree at path with HEAD detached at ref,
+// which may be a branch that is checked out in another worktree.
+func AddDetachedWorktree(ctx context.Context, path, ref string) error {
+	if err := gitRun(ctx, "worktree", "add", "--detach", path, ref); err != nil {
+		return fmt.Errorf("creating worktree: %w", err)
+	}
+	return nil
+}
+
+// AddBranchWorktree creates a worktree at path on branch, created at start
+// (a commit-ish) as opts says.
+func AddBranchWorktree(ctx context.Context, path, branch, start string, opts BranchOptions) error {
+	args := append([]string{"worktree", "add"}, opts.args(branch)...)
+	if err := gitRun(ctx, append(args, path, start)...); err != nil {
+		return fmt.Errorf("creating worktree: %w", err)
+	}
+	return opts.finish(ctx, branch)
+}
+
+// AddSparseWorktree creates a worktree at path in which only the directories
+// in dirs, and the files at the top level of the repository, are checked out
+// (a cone-mode sparse checkout). With an empty start, the existing branch is
+// checked out; otherwise branch is created at start as opts says. The other
+// files are never written, which is what makes sparse worktrees of large
+// repositories fast to create.
+func AddSparseWorktree(ctx context.Context, path, branch, start string, opts BranchOptions, dirs []string) error {
+	args := []string{"worktree", "add", "--no-checkout"}
+	if start == "" {
+		args = append(args, path, branch)
+	} else {
+		args = append(append(args, opts.args(branch)...), path, start)
+	}
+	if err := gitRun(ctx, args...); err != nil {
+		return fmt.Errorf("creating worktree: %w", err)
+	}
+	if start != "" {
+		if err := opts.finish(ctx, branch); err != nil {
+			return err
+		}
+	}
+
+	err := gitRunDir(ctx, path, append([]string{"sparse-checkout", "set", "--cone"}, dirs...)...)
+	if err == nil {
+		err = gitRunDir(ctx, path, "checkout")
+	}
+	if err != nil {
+		return fmt.Errorf("setting up sparse checkout in %s: %w", path, err)
+	}
+	return nil
+}
+
 // RemoveWorktree removes the worktree at the given path.
-func RemoveWorktree(path string, force bool) error {
+func RemoveWorktree(ctx context.Context, path string, force bool) error {
+	return RemoveWorktreeDir(ctx, "", path, force)
+}
+
+// RemoveWorktreeDir is like RemoveWorktree for the repository containing dir;
+// an empty dir means the current directory.
+func RemoveWorktreeDir(ctx context.Context, dir, path string, force bool) error {
 	args := []string{"worktree", "remove"}
 	if force {
 		args = append(args, "--force")
 	}
 	args = append(args, path)
 
-	if err := gitRun(args...); err != nil {
+	if err := gitRunDir(ctx, dir, args...); err != nil {
 		return fmt.Errorf("removing worktree: %w", err)
 	}
 	return nil
 }
 
-// IsDirty returns true if the worktree at the given path has uncommitted changes.
-func IsDirty(path string) (bool, error) {
-	out, err := gitOutput("-C", path, "status", "--porcelain")
+// PruneWorktrees removes git's bookkeeping for worktrees whose directories
+// no longer exist.
+func PruneWorktrees(ctx context.Context) error {
+	if err := gitRun(ctx, "worktree", "prune"); err != nil {
+		return fmt.Errorf("pruning worktrees: %w", err)
+	}
+	return nil
+}
+
+// MoveWorktree moves the worktree at src to dst, updating git's bookkeeping.
+func MoveWorktree(ctx context.Context, src, dst string) error {
+	if err := gitRun(ctx, "worktree", "move", src, dst); err != nil {
+		return fmt.Errorf("moving worktree: %w", err)
+	}
+	return nil
+}
+
+// IsDirty returns true if the worktree at the given path has uncommitted
+// changes. Untracked files only count with untracked set: leaving them out
+// spares git from walking large untracked trees, which is where most of the
+// time of git status goes in worktrees with unignored build output.
+func IsDirty(ctx context.Context, path string, untracked bool) (bool, error) {
+	out, err := gitOutput(ctx, statusArgs(path, untracked)...)
 	if err != nil {
 		return false, fmt.Errorf("checking dirty state: %w", err)
 	}
 	return strings.TrimSpace(out) != "", nil
 }
 
+// statusArgs returns the git status call listing the changes in the
+// worktree at path.
+func statusArgs(path string, untracked bool) []string {
+	args := []string{"-C", path, "status", "--porcelain"}
+	if !untracked {
+		args = append(args, "--untracked-files=no")
+	}
+	return args
+}
+
+// ChangedFiles returns the porcelain status lines (e.g. " M file.go",
+// "?? new.txt") for uncommitted changes in the worktree at path, including
+// untracked files if untracked is set.
+func ChangedFiles(ctx context.Context, path string, untracked bool) ([]string, error) {
+	out, err := gitOutput(ctx, statusArgs(path, untracked)...)
+	if err != nil {
+		return nil, fmt.Errorf("listing changed files: %w", err)
+	}
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if strings.TrimSpace(line) != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// IgnoredPaths returns the ignored files and directories in the worktree at
+// path, relative to its root. Wholly ignored directories are reported once,
+// with a trailing slash, rather than file by file.
+func IgnoredPaths(ctx context.Context, path string) ([]string, error) {
+	out, err := gitOutput(ctx, "-C", path, "status", "--porcelain", "-z", "--ignored")
+	if err != nil {
+		return nil, fmt.Errorf("listing ignored files: %w", err)
+	}
+	var paths []string
+	for _, entry := range strings.Split(out, "\x00") {
+		if p, ok := strings.CutPrefix(entry, "!! "); ok {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// CleanIgnored deletes all ignored files and directories in the worktree at
+// path (git clean -Xdf). Untracked files that are not ignored are kept.
+func CleanIgnored(ctx context.Context, path string) error {
+	if err := gitRun(ctx, "-C", path, "clean", "-X", "-d", "-f"); err != nil {
+		return fmt.Errorf("cleaning ignored files: %w", err)
+	}
+	return nil
+}
+
+// ApplyPatch applies patchFile to the worktree at path. Patches produced by
+// git format-patch (mbox) are committed with git am; plain diffs are applied
+// to the working tree and index and left uncommitted. A failed git am is left
+// in progress so it can be resolved in the worktree.
+func ApplyPatch(ctx context.Context, path, patchFile string) error {
+	data, err := os.ReadFile(patchFile)
+	if err != nil {
+		return fmt.Errorf("reading patch: %w", err)
+	}
+	if bytes.HasPrefix(data, []byte("From ")) {
+		err = gitRun(ctx, "-C", path, "am", "--3way", patchFile)
+	} else {
+		err = gitRun(ctx, "-C", path, "apply", "--index", patchFile)
+	}
+	if err != nil {
+		return fmt.Errorf("applying %s: %w", patchFile, err)
+	}
+	return nil
+}
+
+// CherryPick applies the given commit or commit range (a..b) to the worktree
+// at path. A conflicting cherry-pick is left in progress.
+func CherryPick(ctx context.Context, path, commits string) error {
+	if err := gitRun(ctx, "-C", path, "cherry-pick", commits); err != nil {
+		return fmt.Errorf("cherry-picking %s: %w", commits, err)
+	}
+	return nil
+}
+
+// Stash saves all uncommitted changes in the worktree at path, including
+// untracked files, as a stash entry with the given message. Stashes are
+// shared by all worktrees of a repository.
+func Stash(ctx context.Context, path, message string) error {
+	if err := gitRun(ctx, "-C", path, "stash", "push", "--include-untracked", "-m", message); err != nil {
+		return fmt.Errorf("stashing changes: %w", err)
+	}
+	return nil
+}
+
+// Summary is the state of a worktree as shown in a shell prompt.
+type Summary struct {
+	// Branch is the checked-out branch, or "" when HEAD is detached.
+	Branch string
+	Dirty  bool
+	// Ahead and Behind count commits against the upstream; both are 0 when
+	// the branch has no upstream or it no longer exists.
+	Ahead  int
+	Behind int
+}
+
+// StatusSummary returns the branch, dirty state, and upstream counts of the
+// worktree at path using a single git invocation, for callers such as shell
+// prompts that must return quickly.
+func StatusSummary(ctx context.Context, path string) (Summary, error) {
+	out, err := gitOutput(ctx, "-C", path, "status", "--porcelain=v2", "--branch")
+	if err != nil {
+		return Summary{}, fmt.Errorf("reading status: %w", err)
+	}
+	var s Summary
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.head "):
+			if head := strings.TrimPrefix(line, "# branch.head "); head != "(detached)" {
+				s.Branch = head
+			}
+		case strings.HasPrefix(line, "# branch.ab "):
+			fmt.Sscanf(strings.TrimPrefix(line, "# branch.ab "), "+%d -%d", &s.Ahead, &s.Behind)
+		case strings.HasPrefix(line, "#"):
+		default:
+			s.Dirty = true
+		}
+	}
+	return s, nil
+}
+
 // AheadBehind returns the number of commits ahead and behind the upstream.
 // Returns (0, 0, nil) if there is no upstream configured.
-func AheadBehind(path string) (ahead int, behind int, err error) {
-	out, err := gitOutput("-C", path, "rev-list", "--left-right", "--count", "HEAD...@{upstream}")
+func AheadBehind(ctx context.Context, path string) (ahead int, behind int, err error) {
+	ahead, behind, err = revListCounts(ctx, path, "HEAD...@{upstream}")
 	if err != nil {
-		// No upstream configured is not an error
-		if strings.Contains(err.Error(), "no upstream") || strings.Contains(err.Error(), "unknown revision") {
+		// No upstream configured, or one that no longer exists, is not an error
+		if errors.Is(err, ErrNoUpstream) || errors.Is(err, ErrUnknownRevision) {
 			return 0, 0, nil
 		}
 		return 0, 0, fmt.Errorf("checking ahead/behind: %w", err)
 	}
+	return ahead, behind, nil
+}
+
+// Tracking describes a local branch's relationship to its upstream.
+type Tracking struct {
+	// Upstream is the short name of the upstream ref, e.g. "origin/main";
+	// empty when none is configured.
+	Upstream string
+	Ahead    int
+	Behind   int
+	// Gone is set when the upstream is configured but no longer exists.
+	Gone bool
+}
+
+// BranchTracking returns upstream tracking information for every local
+// branch, keyed by branch name, using a single git invocation.
+func BranchTracking(ctx context.Context) (map[string]Tracking, error) {
+	return BranchTrackingDir(ctx, "")
+}
+
+// BranchTrackingDir is like BranchTracking for the repository containing dir;
+// an empty dir means the current directory.
+func BranchTrackingDir(ctx context.Context, dir string) (map[string]Tracking, error) {
+	out, err := gitOutputDir(ctx, dir, "for-each-ref", "--format=%(refname)%00%(upstream:short)%00%(upstream:track,nobracket)", "refs/heads")
+	if err != nil {
+		return nil, fmt.Errorf("reading branch tracking: %w", err)
+	}
+
+	tracking := make(map[string]Tracking)
+	for _, line := range parseLines(out) {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		t := Tracking{Upstream: fields[1]}
+		for _, part := range strings.Split(fields[2], ", ") {
+			switch {
+			case part == "gone":
+				t.Gone = true
+			case strings.HasPrefix(part, "ahead "):
+				t.Ahead, _ = strconv.Atoi(strings.TrimPrefix(part, "ahead "))
+			case strings.HasPrefix(part, "behind "):
+				t.Behind, _ = strconv.Atoi(strings.TrimPrefix(part, "behind "))
+			}
+		}
+		tracking[strings.TrimPrefix(fields[0], "refs/heads/")] = t
+	}
+	return tracking, nil
+}
+
+// AheadBehindAll returns, for every local branch, the number of commits it is
+// ahead of and behind ref, using a single git invocation. ok is false when the
+// installed git is too old to compute this in one call (before 2.41); callers
+// should then fall back to AheadBehindRef per worktree.
+func AheadBehindAll(ctx context.Context, ref string) (counts map[string][2]int, ok bool, err error) {
+	out, err := gitOutput(ctx, "for-each-ref", "--format=%(refname)%00%(ahead-behind:"+ref+")", "refs/heads")
+	if err != nil {
+		if errors.Is(err, errUnsupported) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("checking ahead/behind %s: %w", ref, err)
+	}
+
+	counts = make(map[string][2]int)
+	for _, line := range parseLines(out) {
+		name, ab, found := strings.Cut(line, "\x00")
+		parts := strings.Fields(ab)
+		if !found || len(parts) != 2 {
+			continue
+		}
+		ahead, _ := strconv.Atoi(parts[0])
+		behind, _ := strconv.Atoi(parts[1])
+		counts[strings.TrimPrefix(name, "refs/heads/")] = [2]int{ahead, behind}
+	}
+	return counts, true, nil
+}
+
+// AheadBehindRef returns the number of commits the worktree's HEAD is ahead
+// of and behind the given ref.
+func AheadBehindRef(ctx context.Context, path, ref string) (ahead int, behind int, err error) {
+	ahead, behind, err = revListCounts(ctx, path, "HEAD..."+ref)
+	if err != nil {
+		return 0, 0, fmt.Errorf("checking ahead/behind %s: %w", ref, err)
+	}
+	return ahead, behind, nil
+}
+
+func revListCounts(ctx context.Context, path, rangeSpec string) (int, int, error) {
+	out, err := gitOutput(ctx, "-C", path, "rev-list", "--left-right", "--count", rangeSpec)
+	if err != nil {
+		return 0, 0, err
+	}
 
 	parts := strings.Fields(strings.TrimSpace(out))
 	if len(parts) != 2 {
 		return 0, 0, nil
 	}
 
-	ahead, _ = strconv.Atoi(parts[0])
-	behind, _ = strconv.Atoi(parts[1])
+	ahead, _ := strconv.Atoi(parts[0])
+	behind, _ := strconv.Atoi(parts[1])
 	return ahead, behind, nil
 }
 
+// DefaultBranch returns the repository's default branch name. It prefers the
+// branch that origin/HEAD points at and falls back to a local main or master.
+func DefaultBranch(ctx context.Context) (string, error) {
+	if out, err := gitOutput(ctx, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		if name := strings.TrimPrefix(strings.TrimSpace(out), "origin/"); name != "" {
+			return name, nil
+		}
+	}
+
+	for _, name := range []string{"main", "master"} {
+		if gitRun(ctx, "show-ref", "--verify", "--quiet", "refs/heads/"+name) == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine default branch; set origin/HEAD with: git remote set-head origin --auto")
+}
+
+// RemoteHead returns the default branch of remote, as its remote HEAD
+// (refs/remotes/<remote>/HEAD) points at, or "" when that is not known.
+func RemoteHead(ctx context.Context, remote string) string {
+	out, err := gitOutput(ctx, "symbolic-ref", "--quiet", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.TrimSpace(out), "refs/remotes/"+remote+"/")
+}
+
+// DiffMode selects the output format of Diff.
+type DiffMode int
+
+const (
+	// DiffShortStat summarizes files changed, insertions, and deletions on one line.
+	DiffShortStat DiffMode = iota
+	// DiffStat lists per-file insertion/deletion counts.
+	DiffStat
+	// DiffNameOnly lists the names of changed files.
+	DiffNameOnly
+)
+
+// Diff returns the diff between the from and to commits in the given mode.
+// An empty result means the two commits have identical trees.
+func Diff(ctx context.Context, from, to string, mode DiffMode) (string, error) {
+	args := []string{"diff"}
+	switch mode {
+	case DiffStat:
+		args = append(args, "--stat")
+	case DiffNameOnly:
+		args = append(args, "--name-only")
+	default:
+		args = append(args, "--shortstat")
+	}
+	args = append(args, from, to, "--")
+
+	out, err := gitOutput(ctx, args...)
+	if err != nil {
+		return "", fmt.Errorf("diffing %s..%s: %w", from, to, err)
+	}
+	return strings.TrimRight(out, "\n"), nil
+}
+
 // BranchExists checks if a branch exists locally or remotely.
-func BranchExists(name string) (bool, error) {
-	// Check local
-	err := gitRun("show-ref", "--verify", "--quiet", "refs/heads/"+name)
-	if err == nil {
+func BranchExists(ctx context.Context, name string) (bool, error) {
+	if LocalBranchExists(ctx, name) {
 		return true, nil
 	}
 
 	// Check remote (any remote)
-	out, err := gitOutput("branch", "-r", "--list", "*/"+name)
+	out, err := gitOutput(ctx, "branch", "-r", "--list", "*/"+name)
 	if err != nil {
 		return false, fmt.Errorf("checking remote branches: %w", err)
 	}
 	return strings.TrimSpace(out) != "", nil
 }
 
+// LocalBranchExists reports whether refs/heads/<name> exists.
+func LocalBranchExists(ctx context.Context, name string) bool {
+	return LocalBranchExistsDir(ctx, "", name)
+}
+
+// LocalBranchExistsDir is like LocalBranchExists for the repository
+// containing dir; an empty dir means the current directory.
+func LocalBranchExistsDir(ctx context.Context, dir, name string) bool {
+	return gitRunDir(ctx, dir, "show-ref", "--verify", "--quiet", "refs/heads/"+name) == nil
+}
+
+// SetUpstream makes the local branch track upstream, a remote-tracking ref
+// such as "origin/feature".
+func SetUpstream(ctx context.Context, branch, upstream string) error {
+	if err := gitRun(ctx, "branch", "--set-upstream-to="+upstream, branch); err != nil {
+		return fmt.Errorf("setting upstream of %s: %w", branch, err)
+	}
+	return nil
+}
+
+// UpstreamBranch returns the remote that branch tracks and the name of the
+// branch there, such as "origin" and "feature". Both are empty when branch
+// has no upstream on a remote.
+func UpstreamBranch(ctx context.Context, branch string) (remote, remoteBranch string, err error) {
+	out, err := gitOutput(ctx, "for-each-ref", "--format=%(upstream:remotename)%00%(upstream:remoteref)", "refs/heads/"+branch)
+	if err != nil {
+		return "", "", fmt.Errorf("reading upstream of %s: %w", branch, err)
+	}
+	remote, ref, _ := strings.Cut(strings.TrimSpace(out), "\x00")
+	if remote == "" || remote == "." || !strings.HasPrefix(ref, "refs/heads/") {
+		return "", "", nil
+	}
+	return remote, strings.TrimPrefix(ref, "refs/heads/"), nil
+}
+
+// DeleteRemoteBranch deletes branch on remote (git push --delete).
+func DeleteRemoteBranch(ctx context.Context, remote, branch string) error {
+	if err := gitRun(ctx, "push", "--quiet", remote, "--delete", branch); err != nil {
+		return fmt.Errorf("deleting %s on %s: %w", branch, remote, err)
+	}
+	return nil
+}
+
+// MoveBranch points the local branch name at commit (git branch -f). The
+// branch must not be checked out.
+func MoveBranch(ctx context.Context, name, commit string) error {
+	if err := gitRun(ctx, "branch", "--force", name, commit); err != nil {
+		return fmt.Errorf("moving branch %s to %s: %w", name, commit, err)
+	}
+	return nil
+}
+
+// DeleteBranch deletes a local branch. Without force, git refuses to delete a
+// branch that is not merged into its upstream or HEAD.
+func DeleteBranch(ctx context.Context, name string, force bool) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	if err := gitRun(ctx, "branch", flag, name); err != nil {
+		return fmt.Errorf("deleting branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// BranchDescriptions returns the descriptions set with
+// "git branch --edit-description" (branch.<name>.description), keyed by
+// branch name.
+func BranchDescriptions(ctx context.Context) (map[string]string, error) {
+	out, err := gitOutput(ctx, "config", "-z", "--list")
+	if err != nil {
+		return nil, fmt.Errorf("reading git config: %w", err)
+	}
+	descriptions := make(map[string]string)
+	for _, entry := range strings.Split(out, "\x00") {
+		key, value, _ := strings.Cut(entry, "\n")
+		name, ok := strings.CutPrefix(key, "branch.")
+		if !ok {
+			continue
+		}
+		if name, ok = strings.CutSuffix(name, ".description"); ok {
+			descriptions[name] = strings.TrimSpace(value)
+		}
+	}
+	return descriptions, nil
+}
+
+// SetBranchDescription sets the description of a local branch; an empty
+// description removes it.
+func SetBranchDescription(ctx context.Context, branch, description string) error {
+	key := "branch." + branch + ".description"
+	if description == "" {
+		if gitRun(ctx, "config", "--get", key) != nil {
+			return nil
+		}
+		if err := gitRun(ctx, "config", "--unset", key); err != nil {
+			return fmt.Errorf("clearing description of %s: %w", branch, err)
+		}
+		return nil
+	}
+	if err := gitRun(ctx, "config", key, description); err != nil {
+		return fmt.Errorf("describing %s: %w", branch, err)
+	}
+	return nil
+}
+
+// ListRemotes returns the names of the configured remotes.
+func ListRemotes(ctx context.Context) ([]string, error) {
+	out, err := gitOutput(ctx, "remote")
+	if err != nil {
+		return nil, fmt.Errorf("listing remotes: %w", err)
+	}
+	return parseLines(out), nil
+}
+
+// FetchBranch fetches a single branch from remote and updates its
+// remote-tracking ref (refs/remotes/<remote>/<branch>).
+func FetchBranch(ctx context.Context, remote, branch string) error {
+	refspec := fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", branch, remote, branch)
+	if err := gitRun(ctx, "fetch", "--quiet", "--no-tags", remote, refspec); err != nil {
+		return fmt.Errorf("fetching %s/%s: %w", remote, branch, err)
+	}
+	return nil
+}
+
+// Fetch fetches refspecs from remote without tags.
+func Fetch(ctx context.Context, remote string, refspecs ...string) error {
+	args := append([]string{"fetch", "--quiet", "--no-tags", remote}, refspecs...)
+	if err := gitRun(ctx, args...); err != nil {
+		return fmt.Errorf("fetching from %s: %w", remote, err)
+	}
+	return nil
+}
+
+// RemoteURL returns the fetch URL of remote.
+func RemoteURL(ctx context.Context, remote string) (string, error) {
+	out, err := gitOutput(ctx, "remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("reading URL of remote %s: %w", remote, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// FastForward advances the branch checked out in the worktree at path to ref,
+// failing if that is not a fast-forward.
+func FastForward(ctx context.Context, path, ref string) error {
+	if err := gitRun(ctx, "-C", path, "merge", "--ff-only", "--quiet", ref); err != nil {
+		return fmt.Errorf("fast-forwarding to %s: %w", ref, err)
+	}
+	return nil
+}
+
+// Pull fetches the upstream of the branch checked out in the worktree at path
+// and fast-forwards the branch to it, failing if that is not a fast-forward.
+func Pull(ctx context.Context, path string) error {
+	if err := gitRun(ctx, "-C", path, "pull", "--ff-only", "--quiet"); err != nil {
+		return fmt.Errorf("pulling: %w", err)
+	}
+	return nil
+}
+
+// Commit returns the full object ID of the commit ref points at.
+func Commit(ctx context.Context, ref string) (string, error) {
+	out, err := gitOutput(ctx, "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ShortCommit returns the abbreviated hash of the commit ref points at.
+func ShortCommit(ctx context.Context, ref string) (string, error) {
+	out, err := gitOutput(ctx, "rev-parse", "--short", "--verify", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", ref, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// RefExists reports whether ref resolves to a commit.
+func RefExists(ctx context.Context, ref string) bool {
+	return gitRun(ctx, "rev-parse", "--verify", "--quiet", ref+"^{commit}") == nil
+}
+
+// EnableWorktreeConfig turns on extensions.worktreeConfig for the repository,
+// so that each worktree can have settings of its own (see SetWorktreeConfig).
+// It reports whether the setting was changed.
+func EnableWorktreeConfig(ctx context.Context) (bool, error) {
+	if out, _ := gitOutput(ctx, "config", "--bool", "extensions.worktreeConfig"); strings.TrimSpace(out) == "true" {
+		return false, nil
+	}
+	if err := gitRun(ctx, "config", "extensions.worktreeConfig", "true"); err != nil {
+		return false, fmt.Errorf("enabling per-worktree config: %w", err)
+	}
+	return true, nil
+}
+
+// SetWorktreeConfig sets key to value in the own config of the worktree at
+// path (git config --worktree). extensions.worktreeConfig must be enabled.
+func SetWorktreeConfig(ctx context.Context, path, key, value string) error {
+	if err := gitRun(ctx, "-C", path, "config", "--worktree", key, value); err != nil {
+		return fmt.Errorf("setting %s in %s: %w", key, path, err)
+	}
+	return nil
+}
+
+// Maintenance is the state of the structures that keep git fast in large
+// repositories, which git maintenance keeps up to date.
+type Maintenance struct {
+	// Registered reports whether the repository is in the global
+	// maintenance.repo list, i.e. whether scheduled maintenance covers it.
+	Registered bool
+	// Strategy is maintenance.strategy, or "" when it is not set.
+	Strategy string
+	// CommitGraph reports whether a commit-graph file or chain exists.
+	CommitGraph bool
+	// MultiPackIndex reports whether a multi-pack-index exists.
+	MultiPackIndex bool
+	// Prefetch reports whether background prefetch has written any refs
+	// under refs/prefetch/.
+	Prefetch bool
+	// Packs and LooseObjects count the object store's pack files and loose
+	// objects.
+	Packs, LooseObjects int
+}
+
+// MaintenanceState inspects the repository whose main worktree is top and
+// whose shared .git directory is commonDir.
+func MaintenanceState(ctx context.Context, top, commonDir string) (Maintenance, error) {
+	var m Maintenance
+	out, _ := gitOutput(ctx, "config", "--global", "--get-all", "maintenance.repo")
+	for _, repo := range parseLines(out) {
+		if samePath(repo, top) || samePath(repo, commonDir) {
+			m.Registered = true
+			break
+		}
+	}
+	out, _ = gitOutput(ctx, "config", "maintenance.strategy")
+	m.Strategy = strings.TrimSpace(out)
+
+	info := filepath.Join(commonDir, "objects", "info")
+	m.CommitGraph = exists(filepath.Join(info, "commit-graph")) ||
+		exists(filepath.Join(info, "commit-graphs", "commit-graph-chain"))
+	m.MultiPackIndex = exists(filepath.Join(commonDir, "objects", "pack", "multi-pack-index"))
+
+	out, err := gitOutput(ctx, "for-each-ref", "--count=1", "--format=%(refname)", "refs/prefetch/")
+	if err != nil {
+		return m, fmt.Errorf("listing prefetch refs: %w", err)
+	}
+	m.Prefetch = strings.TrimSpace(out) != ""
+
+	out, err = gitOutput(ctx, "count-objects", "-v")
+	if err != nil {
+		return m, fmt.Errorf("counting objects: %w", err)
+	}
+	for _, line := range parseLines(out) {
+		key, value, _ := strings.Cut(line, ": ")
+		n, _ := strconv.Atoi(value)
+		switch key {
+		case "count":
+			m.LooseObjects = n
+		case "packs":
+			m.Packs = n
+		}
+	}
+	return m, nil
+}
+
+// StartMaintenance registers the repository whose main worktree is top for
+// git maintenance and, with schedule set, has the system scheduler run it in
+// the background (git maintenance start). Without schedule it is only
+// registered, so that an existing schedule, or a manual
+// 'git maintenance run --schedule', covers it. git registers the worktree it
+// runs in, so top must be the main worktree rather than a linked one.
+func StartMaintenance(ctx context.Context, top string, schedule bool) error {
+	sub := "register"
+	if schedule {
+		sub = "start"
+	}
+	if err := gitRunDir(ctx, top, "maintenance", sub); err != nil {
+		return fmt.Errorf("enabling maintenance: %w", err)
+	}
+	return nil
+}
+
+// StopMaintenance unregisters the repository whose main worktree is top from
+// git maintenance. The system schedule, which other repositories may use, is
+// left in place.
+func StopMaintenance(ctx context.Context, top string) error {
+	if err := gitRunDir(ctx, top, "maintenance", "unregister"); err != nil {
+		return fmt.Errorf("disabling maintenance: %w", err)
+	}
+	return nil
+}
+
+// samePath reports whether a and b name the same directory, resolving
+// symlinks where they exist.
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, errA := filepath.EvalSymlinks(a)
+	rb, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && ra == rb
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// CurrentBranch returns the branch checked out in the current worktree, or ""
+// with the commit hash when HEAD is detached.
+func CurrentBranch(ctx context.Context) (branch, commit string, err error) {
+	if out, err := gitOutput(ctx, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		return strings.TrimSpace(out), "", nil
+	}
+	out, err := gitOutput(ctx, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		return "", "", fmt.Errorf("reading HEAD: %w", err)
+	}
+	return "", strings.TrimSpace(out), nil
+}
+
+// RemoteTrackingRef returns the remote-tracking ref for branch, such as
+// "origin/feature". A ref on origin is preferred when several remotes have the
+// branch; the result is empty if no remote has it.
+func RemoteTrackingRef(ctx context.Context, branch string) (string, error) {
+	return RemoteTrackingRefDir(ctx, "", branch)
+}
+
+// RemoteTrackingRefDir is like RemoteTrackingRef for the repository
+// containing dir; an empty dir means the current directory.
+func RemoteTrackingRefDir(ctx context.Context, dir, branch string) (string, error) {
+	refs, err := listRemoteRefs(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	var found string
+	for _, ref := range refs {
+		if !strings.HasSuffix(ref, "/"+branch) {
+			continue
+		}
+		remote := strings.TrimSuffix(ref, "/"+branch)
+		if strings.Contains(remote, "/") {
+			continue // a longer branch name that happens to end in /branch
+		}
+		if remote == "origin" {
+			return ref, nil
+		}
+		if found == "" {
+			found = ref
+		}
+	}
+	return found, nil
+}
+
 // ListLocalBranches returns sorted local branch names.
-func ListLocalBranches() ([]string, error) {
-	out, err := gitOutput("branch", "--format=%(refname:short)")
+func ListLocalBranches(ctx context.Context) ([]string, error) {
+	out, err := gitOutput(ctx, "branch", "--format=%(refname:short)")
 	if err != nil {
 		return nil, fmt.Errorf("listing local branches: %w", err)
 	}
@@ -152,8 +957,8 @@ func ListLocalBranches() ([]string, error) {
 
 // ListRemoteBranches returns sorted remote branch names with the remote prefix stripped.
 // Deduplicates across remotes and excludes HEAD pointer entries.
-func ListRemoteBranches() ([]string, error) {
-	out, err := gitOutput("branch", "-r", "--format=%(refname:short)")
+func ListRemoteBranches(ctx context.Context) ([]string, error) {
+	out, err := gitOutput(ctx, "branch", "-r", "--format=%(refname:short)")
 	if err != nil {
 		return nil, fmt.Errorf("listing remote branches: %w", err)
 	}
@@ -181,6 +986,78 @@ func ListRemoteBranches() ([]string, error) {
 	return branches, nil
 }
 
+// ListRemoteRefs returns sorted remote-tracking branch names including their
+// remote prefix (e.g. "origin/feature-x"), excluding HEAD pointer entries.
+func ListRemoteRefs(ctx context.Context) ([]string, error) {
+	return listRemoteRefs(ctx, "")
+}
+
+func listRemoteRefs(ctx context.Context, dir string) ([]string, error) {
+	out, err := gitOutputDir(ctx, dir, "branch", "-r", "--format=%(refname:short)")
+	if err != nil {
+		return nil, fmt.Errorf("listing remote branches: %w", err)
+	}
+
+	var refs []string
+	for _, line := range parseLines(out) {
+		if strings.HasSuffix(line, "/HEAD") || !strings.Contains(line, "/") {
+			continue
+		}
+		refs = append(refs, line)
+	}
+	return refs, nil
+}
+
+// BranchRef is a local or remote-tracking branch and its last commit.
+type BranchRef struct {
+	// Name is the short name, e.g. "feature" or "origin/feature".
+	Name   string
+	Remote bool
+	// Date is the committer date of the last commit, and Author its author.
+	Date   time.Time
+	Author string
+}
+
+// ListBranchRefs returns the local branches and then the remote-tracking
+// branches (without HEAD pointers), each sorted by name, with their last
+// commits, using a single git call.
+func ListBranchRefs(ctx context.Context) ([]BranchRef, error) {
+	out, err := gitOutput(ctx, "for-each-ref", "--format=%(refname)%00%(committerdate:unix)%00%(authorname)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, fmt.Errorf("listing branches: %w", err)
+	}
+	var refs []BranchRef
+	for _, line := range parseLines(out) {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		ref := BranchRef{Author: fields[2]}
+		if name, ok := strings.CutPrefix(fields[0], "refs/heads/"); ok {
+			ref.Name = name
+		} else {
+			ref.Name, ref.Remote = strings.TrimPrefix(fields[0], "refs/remotes/"), true
+			if strings.HasSuffix(ref.Name, "/HEAD") || !strings.Contains(ref.Name, "/") {
+				continue
+			}
+		}
+		if secs, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			ref.Date = time.Unix(secs, 0)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// ListTags returns sorted tag names.
+func ListTags(ctx context.Context) ([]string, error) {
+	out, err := gitOutput(ctx, "tag", "--list")
+	if err != nil {
+		return nil, fmt.Errorf("listing tags: %w", err)
+	}
+	return parseLines(out), nil
+}
+
 func parseLines(s string) []string {
 	var lines []string
 	for _, line := range strings.Split(s, "\n") {
@@ -193,22 +1070,85 @@ func parseLines(s string) []string {
 	return lines
 }
 
-func gitOutput(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
+// cancelGrace is how long git may take to clean up after being interrupted
+// before it is killed.
+const cancelGrace = 5 * time.Second
+
+// command prepares a git invocation bound to ctx and run in dir, or in the
+// current directory when dir is empty. When ctx is cancelled git is
+// interrupted rather than killed, so it can remove what it had half created,
+// such as the directory of a worktree being added. git runs in the C locale,
+// as errorPatterns match its untranslated messages, and without the shell
+// wrapper's variables (see shell.Environ), which a wt run by a git hook would
+// otherwise use.
+func command(ctx context.Context, dir string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+	cmd.WaitDelay = cancelGrace
+	cmd.Dir = dir
+	cmd.Env = append(shell.Environ(), "LC_ALL=C")
+	return cmd
+}
+
+func gitOutput(ctx context.Context, args ...string) (string, error) {
+	return gitOutputDir(ctx, "", args...)
+}
+
+func gitOutputDir(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := command(ctx, dir, args...)
+	start := time.Now()
 	out, err := cmd.Output()
+	debug.LogCommand(cmd, time.Since(start), err)
 	if err != nil {
+		if ctx.Err() != nil {
+			return "", context.Cause(ctx)
+		}
 		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", fmt.Errorf("%s: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+			return "", newError(args, err, string(exitErr.Stderr))
 		}
 		return "", err
 	}
 	return string(out), nil
 }
 
-func gitRun(args ...string) error {
-	cmd := exec.Command("git", args...)
-	if out, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("%s: %s", err, strings.TrimSpace(string(out)))
+// Passthrough runs git with args in dir, connecting its output to stdout and
+// stderr instead of capturing it and its input to the terminal's, so git can
+// prompt or open an editor. It is for commands the user typed, so a failure
+// is returned as is: git has already explained it on stderr.
+func Passthrough(ctx context.Context, dir string, stdout, stderr io.Writer, args ...string) error {
+	cmd := command(ctx, dir, args...)
+	cmd.Env = shell.Environ() // The user reads its messages, in their language
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	start := time.Now()
+	err := cmd.Run()
+	debug.LogCommand(cmd, time.Since(start), err)
+	if err != nil && ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return err
+}
+
+func gitRun(ctx context.Context, args ...string) error {
+	return gitRunDir(ctx, "", args...)
+}
+
+func gitRunDir(ctx context.Context, dir string, args ...string) error {
+	cmd := command(ctx, dir, args...)
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	debug.LogCommand(cmd, time.Since(start), err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		return newError(args, err, string(out))
 	}
 	return nil
 }
@@ -11,11 +11,15 @@
 package git
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/provenimpact/wt/internal/shell"
 )
 
 // setupTestRepo creates a temporary git repo and returns its path and a cleanup func.
@@ -55,7 +59,7 @@ func setupTestRepo(t *testing.T) string {
 func TestListWorktrees_MainOnly(t *testing.T) {
 	dir := setupTestRepo(t)
 
-	wts, err := ListWorktrees()
+	wts, err := ListWorktrees(t.Context())
 	if err != nil {
 		t.Fatalf("ListWorktrees() error: %v", err)
 	}
@@ -78,7 +82,7 @@ func TestAddWorktree_NewBranch(t *testing.T) {
 	setupTestRepo(t)
 
 	wtPath := filepath.Join(t.TempDir(), "feature-x")
-	err := AddWorktree(wtPath, "feature-x", true, "")
+	err := AddWorktree(t.Context(), wtPath, "feature-x", true, "")
 	if err != nil {
 		t.Fatalf("AddWorktree() error: %v", err)
 	}
@@ -89,7 +93,7 @@ func TestAddWorktree_NewBranch(t *testing.T) {
 	}
 
 	// Verify it appears in list
-	wts, _ := ListWorktrees()
+	wts, _ := ListWorktrees(t.Context())
 	found := false
 	for _, wt := range wts {
 		if wt.Branch == "feature-x" {
@@ -115,12 +119,12 @@ func TestAddWorktree_ExistingBranch(t *testing.T) {
 	}
 
 	wtPath := filepath.Join(t.TempDir(), "existing-branch")
-	err := AddWorktree(wtPath, "existing-branch", false, "")
+	err := AddWorktree(t.Context(), wtPath, "existing-branch", false, "")
 	if err != nil {
 		t.Fatalf("AddWorktree() error: %v", err)
 	}
 
-	wts, _ := ListWorktrees()
+	wts, _ := ListWorktrees(t.Context())
 	found := false
 	for _, wt := range wts {
 		if wt.Branch == "existing-branch" {
@@ -133,22 +137,74 @@ func TestAddWorktree_ExistingBranch(t *testing.T) {
 	}
 }
 
+// A branch checked out in the main worktree can still be checked out detached.
+func TestAddDetachedWorktree(t *testing.T) {
+	setupTestRepo(t)
+	branch, _, err := CurrentBranch(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wtPath := filepath.Join(t.TempDir(), "detached")
+	if err := AddDetachedWorktree(t.Context(), wtPath, branch); err != nil {
+		t.Fatalf("AddDetachedWorktree() error: %v", err)
+	}
+
+	wts, _ := ListWorktrees(t.Context())
+	if len(wts) != 2 || wts[1].Branch != "(detached)" || wts[1].HEAD != wts[0].HEAD {
+		t.Errorf("ListWorktrees() = %+v, want a second worktree detached at the main worktree's HEAD", wts)
+	}
+}
+
 // WT-012: Remove worktree and directory.
+func TestAddSparseWorktree(t *testing.T) {
+	dir := setupTestRepo(t)
+	for _, f := range []string{"go.mod", "api/main.go", "web/index.html", "libs/proto/a.proto"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, f)), 0o755)
+		os.WriteFile(filepath.Join(dir, f), []byte(f), 0o644)
+	}
+	exec.Command("git", "-C", dir, "add", "-A").Run()
+	cmd := exec.Command("git", "-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "-q", "-m", "projects")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	wtPath := filepath.Join(t.TempDir(), "api")
+	if err := AddSparseWorktree(t.Context(), wtPath, "api-fix", "HEAD", BranchOptions{}, []string{"api", "libs/proto"}); err != nil {
+		t.Fatalf("AddSparseWorktree() error: %v", err)
+	}
+	for _, f := range []string{"go.mod", "api/main.go", "libs/proto/a.proto"} {
+		if _, err := os.Stat(filepath.Join(wtPath, f)); err != nil {
+			t.Errorf("%s should be checked out: %v", f, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(wtPath, "web")); !os.IsNotExist(err) {
+		t.Error("web/ should not be checked out")
+	}
+	if dirty, _ := IsDirty(t.Context(), wtPath, true); dirty {
+		t.Error("a sparse worktree should be clean")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "web", "index.html")); err != nil {
+		t.Errorf("the main worktree should stay complete: %v", err)
+	}
+}
+
 func TestRemoveWorktree(t *testing.T) {
 	setupTestRepo(t)
 
 	wtPath := filepath.Join(t.TempDir(), "to-remove")
-	if err := AddWorktree(wtPath, "to-remove", true, ""); err != nil {
+	if err := AddWorktree(t.Context(), wtPath, "to-remove", true, ""); err != nil {
 		t.Fatalf("AddWorktree() error: %v", err)
 	}
 
-	err := RemoveWorktree(wtPath, false)
+	err := RemoveWorktree(t.Context(), wtPath, false)
 	if err != nil {
 		t.Fatalf("RemoveWorktree() error: %v", err)
 	}
 
 	// Verify removed from list
-	wts, _ := ListWorktrees()
+	wts, _ := ListWorktrees(t.Context())
 	for _, wt := range wts {
 		if wt.Branch == "to-remove" {
 			t.Error("to-remove still in worktree list after removal")
@@ -161,7 +217,7 @@ func TestRemoveWorktree(t *testing.T) {
 func TestIsDirty_CleanRepo(t *testing.T) {
 	setupTestRepo(t)
 
-	dirty, err := IsDirty(".")
+	dirty, err := IsDirty(t.Context(), ".", true)
 	if err != nil {
 		t.Fatalf("IsDirty() error: %v", err)
 	}
@@ -176,20 +232,35 @@ func TestIsDirty_WithChanges(t *testing.T) {
 	// Create an untracked file
 	os.WriteFile(filepath.Join(dir, "new-file.txt"), []byte("hello"), 0o644)
 
-	dirty, err := IsDirty(dir)
+	dirty, err := IsDirty(t.Context(), dir, true)
 	if err != nil {
 		t.Fatalf("IsDirty() error: %v", err)
 	}
 	if !dirty {
 		t.Error("repo with untracked file should be dirty")
 	}
+
+	dirty, err = IsDirty(t.Context(), dir, false)
+	if err != nil {
+		t.Fatalf("IsDirty() error: %v", err)
+	}
+	if dirty {
+		t.Error("an untracked file should not count when untracked files are skipped")
+	}
+	files, err := ChangedFiles(t.Context(), dir, false)
+	if err != nil {
+		t.Fatalf("ChangedFiles() error: %v", err)
+	}
+	if len(files) != 0 {
+		t.Errorf("ChangedFiles(untracked=false) = %q, want none", files)
+	}
 }
 
 // WT-022: ahead/behind with no upstream returns (0, 0, nil)
 func TestAheadBehind_NoUpstream(t *testing.T) {
 	dir := setupTestRepo(t)
 
-	ahead, behind, err := AheadBehind(dir)
+	ahead, behind, err := AheadBehind(t.Context(), dir)
 	if err != nil {
 		t.Fatalf("AheadBehind() error: %v", err)
 	}
@@ -198,11 +269,45 @@ func TestAheadBehind_NoUpstream(t *testing.T) {
 	}
 }
 
+func TestStatusSummary(t *testing.T) {
+	dir := setupTestRepo(t)
+	// A branch tracking main, one commit behind it
+	exec.Command("git", "-C", dir, "branch", "--track", "follower", "main").Run()
+	cmd := exec.Command("git", "-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "-q", "--allow-empty", "-m", "ahead")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git commit failed: %v\n%s", err, out)
+	}
+
+	s, err := StatusSummary(t.Context(), dir)
+	if err != nil {
+		t.Fatalf("StatusSummary() error: %v", err)
+	}
+	if s != (Summary{Branch: "main"}) {
+		t.Errorf("StatusSummary() = %+v, want a clean main without upstream", s)
+	}
+
+	exec.Command("git", "-C", dir, "checkout", "-q", "follower").Run()
+	os.WriteFile(filepath.Join(dir, "new.txt"), []byte("x"), 0o644)
+	s, err = StatusSummary(t.Context(), dir)
+	if err != nil {
+		t.Fatalf("StatusSummary() error: %v", err)
+	}
+	if s != (Summary{Branch: "follower", Dirty: true, Behind: 1}) {
+		t.Errorf("StatusSummary() = %+v, want a dirty follower 1 behind", s)
+	}
+
+	exec.Command("git", "-C", dir, "checkout", "-q", "--detach").Run()
+	if s, _ := StatusSummary(t.Context(), dir); s.Branch != "" {
+		t.Errorf("detached HEAD should have no branch, got %q", s.Branch)
+	}
+}
+
 func TestBranchExists_LocalBranch(t *testing.T) {
 	dir := setupTestRepo(t)
 
 	// 'main' should exist
-	exists, err := BranchExists("main")
+	exists, err := BranchExists(t.Context(), "main")
 	if err != nil {
 		t.Fatalf("BranchExists() error: %v", err)
 	}
@@ -215,7 +320,7 @@ func TestBranchExists_LocalBranch(t *testing.T) {
 	cmd.Dir = dir
 	cmd.CombinedOutput()
 
-	exists, err = BranchExists("test-branch")
+	exists, err = BranchExists(t.Context(), "test-branch")
 	if err != nil {
 		t.Fatalf("BranchExists() error: %v", err)
 	}
@@ -227,7 +332,7 @@ func TestBranchExists_LocalBranch(t *testing.T) {
 func TestBranchExists_NonexistentBranch(t *testing.T) {
 	setupTestRepo(t)
 
-	exists, err := BranchExists("nonexistent-branch-xyz")
+	exists, err := BranchExists(t.Context(), "nonexistent-branch-xyz")
 	if err != nil {
 		t.Fatalf("BranchExists() error: %v", err)
 	}
@@ -241,25 +346,52 @@ func TestRemoveWorktree_ForceWithDirtyState(t *testing.T) {
 	setupTestRepo(t)
 
 	wtPath := filepath.Join(t.TempDir(), "dirty-wt")
-	if err := AddWorktree(wtPath, "dirty-wt", true, ""); err != nil {
+	if err := AddWorktree(t.Context(), wtPath, "dirty-wt", true, ""); err != nil {
 		t.Fatalf("AddWorktree() error: %v", err)
 	}
 
 	// Make it dirty
 	os.WriteFile(filepath.Join(wtPath, "dirty.txt"), []byte("dirty"), 0o644)
 
-	dirty, _ := IsDirty(wtPath)
+	dirty, _ := IsDirty(t.Context(), wtPath, true)
 	if !dirty {
 		t.Fatal("worktree should be dirty after writing file")
 	}
 
 	// Force remove should succeed
-	err := RemoveWorktree(wtPath, true)
+	err := RemoveWorktree(t.Context(), wtPath, true)
 	if err != nil {
 		t.Fatalf("RemoveWorktree(force=true) error: %v", err)
 	}
 }
 
+func TestListBranchRefs(t *testing.T) {
+	upstream := setupTestRepo(t)
+	exec.Command("git", "-C", upstream, "branch", "feature").Run()
+	clone := filepath.Join(t.TempDir(), "clone")
+	if out, err := exec.Command("git", "clone", "-q", upstream, clone).CombinedOutput(); err != nil {
+		t.Fatalf("git clone failed: %v\n%s", err, out)
+	}
+	t.Chdir(clone)
+	exec.Command("git", "branch", "local-only").Run()
+
+	refs, err := ListBranchRefs(t.Context())
+	if err != nil {
+		t.Fatalf("ListBranchRefs() error: %v", err)
+	}
+	var names []string
+	for _, ref := range refs {
+		names = append(names, fmt.Sprintf("%s:%v", ref.Name, ref.Remote))
+		if ref.Date.IsZero() || ref.Author != "test" {
+			t.Errorf("%s: date %v, author %q; want the last commit's", ref.Name, ref.Date, ref.Author)
+		}
+	}
+	want := []string{"local-only:false", "main:false", "origin/feature:true", "origin/main:true"}
+	if strings.Join(names, " ") != strings.Join(want, " ") {
+		t.Errorf("ListBranchRefs() = %v, want %v (no origin/HEAD)", names, want)
+	}
+}
+
 // WT-035, WT-037: ListLocalBranches returns sorted local branch names.
 func TestListLocalBranches(t *testing.T) {
 	dir := setupTestRepo(t)
@@ -272,7 +404,7 @@ func TestListLocalBranches(t *testing.T) {
 	cmd.Dir = dir
 	cmd.CombinedOutput()
 
-	branches, err := ListLocalBranches()
+	branches, err := ListLocalBranches(t.Context())
 	if err != nil {
 		t.Fatalf("ListLocalBranches() error: %v", err)
 	}
@@ -334,7 +466,7 @@ func TestAddWorktree_WithBase(t *testing.T) {
 	cmd.CombinedOutput()
 
 	wtPath := filepath.Join(t.TempDir(), "based-wt")
-	err := AddWorktree(wtPath, "new-from-base", true, "base-branch")
+	err := AddWorktree(t.Context(), wtPath, "new-from-base", true, "base-branch")
 	if err != nil {
 		t.Fatalf("AddWorktree with base error: %v", err)
 	}
@@ -346,3 +478,418 @@ func TestAddWorktree_WithBase(t *testing.T) {
 		t.Errorf("worktree should be based on base-branch, last commit: %s", out)
 	}
 }
+
+func TestDiff_IdenticalCommits(t *testing.T) {
+	setupTestRepo(t)
+
+	out, err := Diff(t.Context(), "HEAD", "HEAD", DiffShortStat)
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	if out != "" {
+		t.Errorf("Diff(HEAD, HEAD) = %q, want empty", out)
+	}
+}
+
+func TestDefaultBranch_FallsBackToMain(t *testing.T) {
+	setupTestRepo(t)
+
+	branch, err := DefaultBranch(t.Context())
+	if err != nil {
+		t.Fatalf("DefaultBranch() error: %v", err)
+	}
+	if branch != "main" {
+		t.Errorf("DefaultBranch() = %q, want %q", branch, "main")
+	}
+}
+
+func TestAheadBehindRef(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	cmd := exec.Command("git", "branch", "old")
+	cmd.Dir = dir
+	cmd.CombinedOutput()
+	cmd = exec.Command("git", "commit", "--allow-empty", "-m", "newer")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test",
+		"GIT_AUTHOR_EMAIL=test@test.com",
+		"GIT_COMMITTER_NAME=test",
+		"GIT_COMMITTER_EMAIL=test@test.com",
+	)
+	cmd.CombinedOutput()
+
+	ahead, behind, err := AheadBehindRef(t.Context(), dir, "old")
+	if err != nil {
+		t.Fatalf("AheadBehindRef() error: %v", err)
+	}
+	if ahead != 1 || behind != 0 {
+		t.Errorf("expected (1, 0), got (%d, %d)", ahead, behind)
+	}
+}
+
+func TestListTags(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	for _, tag := range []string{"v2.0.0", "v1.0.0"} {
+		cmd := exec.Command("git", "tag", tag)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git tag failed: %v\n%s", err, out)
+		}
+	}
+
+	tags, err := ListTags(t.Context())
+	if err != nil {
+		t.Fatalf("ListTags() error: %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "v1.0.0" || tags[1] != "v2.0.0" {
+		t.Errorf("ListTags() = %v, want [v1.0.0 v2.0.0]", tags)
+	}
+}
+
+func TestChangedFilesAndStash(t *testing.T) {
+	dir := setupTestRepo(t)
+	os.WriteFile(filepath.Join(dir, "wip.txt"), []byte("wip"), 0o644)
+
+	files, err := ChangedFiles(t.Context(), dir, true)
+	if err != nil {
+		t.Fatalf("ChangedFiles() error: %v", err)
+	}
+	if len(files) != 1 || files[0] != "?? wip.txt" {
+		t.Errorf("ChangedFiles() = %q, want [\"?? wip.txt\"]", files)
+	}
+
+	cmd := exec.Command("git", "config", "user.email", "test@test.com")
+	cmd.Dir = dir
+	cmd.CombinedOutput()
+	cmd = exec.Command("git", "config", "user.name", "test")
+	cmd.Dir = dir
+	cmd.CombinedOutput()
+
+	if err := Stash(t.Context(), dir, "wt: test"); err != nil {
+		t.Fatalf("Stash() error: %v", err)
+	}
+	if dirty, _ := IsDirty(t.Context(), dir, true); dirty {
+		t.Error("worktree should be clean after stashing")
+	}
+}
+
+func TestListWorktrees_PrunableAndPrune(t *testing.T) {
+	setupTestRepo(t)
+
+	wtPath := filepath.Join(t.TempDir(), "gone")
+	if err := AddWorktree(t.Context(), wtPath, "gone", true, ""); err != nil {
+		t.Fatalf("AddWorktree() error: %v", err)
+	}
+	os.RemoveAll(wtPath)
+
+	wts, _ := ListWorktrees(t.Context())
+	var found bool
+	for _, wt := range wts {
+		if wt.Branch == "gone" {
+			found = true
+			if wt.Prunable == "" {
+				t.Error("deleted worktree should be marked prunable")
+			}
+		} else if wt.Prunable != "" {
+			t.Errorf("worktree %s should not be prunable: %q", wt.Path, wt.Prunable)
+		}
+	}
+	if !found {
+		t.Fatal("deleted worktree should still be listed before pruning")
+	}
+
+	if err := PruneWorktrees(t.Context()); err != nil {
+		t.Fatalf("PruneWorktrees() error: %v", err)
+	}
+	wts, _ = ListWorktrees(t.Context())
+	for _, wt := range wts {
+		if wt.Branch == "gone" {
+			t.Error("pruned worktree still listed")
+		}
+	}
+}
+
+func TestListWorktrees_Locked(t *testing.T) {
+	setupTestRepo(t)
+
+	wtPath := filepath.Join(t.TempDir(), "usb")
+	if err := AddWorktree(t.Context(), wtPath, "usb", true, ""); err != nil {
+		t.Fatalf("AddWorktree() error: %v", err)
+	}
+	if err := gitRun(t.Context(), "worktree", "lock", "--reason", "on a USB drive", wtPath); err != nil {
+		t.Fatalf("git worktree lock: %v", err)
+	}
+	os.RemoveAll(wtPath)
+
+	wts, _ := ListWorktrees(t.Context())
+	for _, wt := range wts {
+		switch {
+		case wt.Branch == "usb" && wt.Locked != "on a USB drive":
+			t.Errorf("Locked = %q, want the lock reason", wt.Locked)
+		case wt.Branch == "usb" && wt.Prunable != "":
+			t.Errorf("a locked worktree should not be prunable: %q", wt.Prunable)
+		case wt.Branch != "usb" && wt.Locked != "":
+			t.Errorf("worktree %s should not be locked: %q", wt.Path, wt.Locked)
+		}
+	}
+}
+
+func TestRemoteTrackingRef(t *testing.T) {
+	dir := setupTestRepo(t)
+	for _, args := range [][]string{
+		{"update-ref", "refs/remotes/upstream/feature", "HEAD"},
+		{"update-ref", "refs/remotes/origin/feature", "HEAD"},
+		{"update-ref", "refs/remotes/origin/team/feature", "HEAD"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	ref, err := RemoteTrackingRef(t.Context(), "feature")
+	if err != nil {
+		t.Fatalf("RemoteTrackingRef() error: %v", err)
+	}
+	if ref != "origin/feature" {
+		t.Errorf("RemoteTrackingRef(feature) = %q, want origin/feature", ref)
+	}
+	if ref, _ := RemoteTrackingRef(t.Context(), "missing"); ref != "" {
+		t.Errorf("RemoteTrackingRef(missing) = %q, want empty", ref)
+	}
+	if LocalBranchExists(t.Context(), "feature") {
+		t.Error("LocalBranchExists(feature) should be false for a remote-only branch")
+	}
+}
+
+func TestBranchTrackingAndAheadBehindAll(t *testing.T) {
+	dir := setupTestRepo(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test",
+			"GIT_AUTHOR_EMAIL=test@test.com",
+			"GIT_COMMITTER_NAME=test",
+			"GIT_COMMITTER_EMAIL=test@test.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	// "tracked" follows main and is one commit behind it
+	run("branch", "tracked")
+	run("branch", "--set-upstream-to=main", "tracked")
+	run("commit", "--allow-empty", "-m", "newer")
+
+	tracking, err := BranchTracking(t.Context())
+	if err != nil {
+		t.Fatalf("BranchTracking() error: %v", err)
+	}
+	if got := tracking["tracked"]; got.Upstream != "main" || got.Ahead != 0 || got.Behind != 1 {
+		t.Errorf("tracking[tracked] = %+v, want upstream main, 1 behind", got)
+	}
+	if got, ok := tracking["main"]; !ok || got.Upstream != "" {
+		t.Errorf("tracking[main] = %+v (present %v), want listed without upstream", got, ok)
+	}
+
+	counts, ok, err := AheadBehindAll(t.Context(), "tracked")
+	if err != nil {
+		t.Fatalf("AheadBehindAll() error: %v", err)
+	}
+	if !ok {
+		t.Skip("git is too old for batched ahead/behind")
+	}
+	if counts["main"] != [2]int{1, 0} {
+		t.Errorf("counts[main] = %v, want [1 0]", counts["main"])
+	}
+}
+
+func TestWorktreeConfig(t *testing.T) {
+	dir := setupTestRepo(t)
+	wtPath := filepath.Join(t.TempDir(), "work")
+	if err := AddWorktree(t.Context(), wtPath, "work", true, ""); err != nil {
+		t.Fatalf("AddWorktree() error: %v", err)
+	}
+
+	if changed, err := EnableWorktreeConfig(t.Context()); err != nil || !changed {
+		t.Fatalf("EnableWorktreeConfig() = %v, %v; want a change", changed, err)
+	}
+	if changed, err := EnableWorktreeConfig(t.Context()); err != nil || changed {
+		t.Errorf("second EnableWorktreeConfig() = %v, %v; want no change", changed, err)
+	}
+	if err := SetWorktreeConfig(t.Context(), wtPath, "user.email", "work@example.com"); err != nil {
+		t.Fatalf("SetWorktreeConfig() error: %v", err)
+	}
+
+	get := func(path string) string {
+		out, _ := exec.Command("git", "-C", path, "config", "user.email").Output()
+		return strings.TrimSpace(string(out))
+	}
+	if got := get(wtPath); got != "work@example.com" {
+		t.Errorf("user.email in the worktree = %q, want work@example.com", got)
+	}
+	if got := get(dir); got == "work@example.com" {
+		t.Error("the main worktree should not see the worktree's user.email")
+	}
+}
+
+func TestBranchDescriptions(t *testing.T) {
+	setupTestRepo(t)
+
+	if err := SetBranchDescription(t.Context(), "feat.v2", "Rework the\nparser"); err != nil {
+		t.Fatalf("SetBranchDescription() error: %v", err)
+	}
+	descs, err := BranchDescriptions(t.Context())
+	if err != nil {
+		t.Fatalf("BranchDescriptions() error: %v", err)
+	}
+	if got := descs["feat.v2"]; got != "Rework the\nparser" {
+		t.Errorf("description of feat.v2 = %q", got)
+	}
+
+	if err := SetBranchDescription(t.Context(), "feat.v2", ""); err != nil {
+		t.Fatalf("clearing description: %v", err)
+	}
+	if err := SetBranchDescription(t.Context(), "feat.v2", ""); err != nil {
+		t.Errorf("clearing a missing description should succeed: %v", err)
+	}
+	descs, _ = BranchDescriptions(t.Context())
+	if _, ok := descs["feat.v2"]; ok {
+		t.Errorf("description still set after clearing: %v", descs)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	dir := setupTestRepo(t)
+	// git's messages are matched untranslated, whatever the user's locale
+	t.Setenv("LANGUAGE", "de")
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+
+	wtPath := filepath.Join(t.TempDir(), "feature")
+	if err := AddWorktree(t.Context(), wtPath, "feature", true, ""); err != nil {
+		t.Fatalf("AddWorktree() error: %v", err)
+	}
+
+	err := AddWorktree(t.Context(), filepath.Join(t.TempDir(), "again"), "feature", false, "")
+	if !errors.Is(err, ErrBranchCheckedOut) {
+		t.Errorf("adding a checked-out branch: got %v, want ErrBranchCheckedOut", err)
+	}
+	var gitErr *Error
+	if !errors.As(err, &gitErr) || gitErr.Args[0] != "worktree" {
+		t.Errorf("errors.As(*Error) = %+v, want the failed worktree invocation", gitErr)
+	}
+
+	os.WriteFile(filepath.Join(wtPath, "dirty.txt"), []byte("dirty"), 0o644)
+	if err := RemoveWorktree(t.Context(), wtPath, false); !errors.Is(err, ErrDirty) {
+		t.Errorf("removing a dirty worktree: got %v, want ErrDirty", err)
+	}
+
+	if _, err := gitOutput(t.Context(), "-C", dir, "rev-parse", "@{upstream}"); !errors.Is(err, ErrNoUpstream) {
+		t.Errorf("reading a missing upstream: got %v, want ErrNoUpstream", err)
+	}
+	if _, err := gitOutput(t.Context(), "-C", dir, "rev-list", "nope"); !errors.Is(err, ErrUnknownRevision) {
+		t.Errorf("listing an unknown revision: got %v, want ErrUnknownRevision", err)
+	}
+	if _, err := gitOutput(t.Context(), "-C", t.TempDir(), "status"); !errors.Is(err, ErrNotARepo) {
+		t.Errorf("running outside a repository: got %v, want ErrNotARepo", err)
+	}
+}
+
+func TestMaintenanceState(t *testing.T) {
+	dir := setupTestRepo(t)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	commonDir := filepath.Join(dir, ".git")
+
+	m, err := MaintenanceState(t.Context(), dir, commonDir)
+	if err != nil {
+		t.Fatalf("MaintenanceState() error: %v", err)
+	}
+	if m.Registered || m.CommitGraph || m.MultiPackIndex || m.Prefetch {
+		t.Errorf("a new repository should have no maintenance state, got %+v", m)
+	}
+	if m.LooseObjects == 0 {
+		t.Errorf("LooseObjects = 0, want the initial commit's objects")
+	}
+
+	if err := StartMaintenance(t.Context(), dir, false); err != nil {
+		t.Fatalf("StartMaintenance() error: %v", err)
+	}
+	if err := gitRun(t.Context(), "commit-graph", "write", "--reachable"); err != nil {
+		t.Fatalf("writing commit-graph: %v", err)
+	}
+	m, err = MaintenanceState(t.Context(), dir, commonDir)
+	if err != nil {
+		t.Fatalf("MaintenanceState() error: %v", err)
+	}
+	if !m.Registered || m.Strategy != "incremental" || !m.CommitGraph {
+		t.Errorf("after registering and writing a commit-graph, got %+v", m)
+	}
+
+	if err := StopMaintenance(t.Context(), dir); err != nil {
+		t.Fatalf("StopMaintenance() error: %v", err)
+	}
+	if m, _ := MaintenanceState(t.Context(), dir, commonDir); m.Registered {
+		t.Error("the repository should no longer be registered")
+	}
+}
+
+func TestUpstreamBranchAndNotMerged(t *testing.T) {
+	dir := setupTestRepo(t)
+	if err := gitRun(t.Context(), "branch", "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if remote, branch, err := UpstreamBranch(t.Context(), "feature"); err != nil || remote != "" || branch != "" {
+		t.Errorf("UpstreamBranch() = %q, %q, %v; want no upstream", remote, branch, err)
+	}
+
+	if err := gitRun(t.Context(), "remote", "add", "origin", dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitRun(t.Context(), "fetch", "-q", "origin"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetUpstream(t.Context(), "feature", "origin/main"); err != nil {
+		t.Fatal(err)
+	}
+	if remote, branch, err := UpstreamBranch(t.Context(), "feature"); err != nil || remote != "origin" || branch != "main" {
+		t.Errorf("UpstreamBranch() = %q, %q, %v; want origin, main", remote, branch, err)
+	}
+
+	if err := gitRun(t.Context(), "-c", "user.name=test", "-c", "user.email=test@test.com", "commit", "-q", "--allow-empty", "-m", "ahead"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitRun(t.Context(), "branch", "unmerged"); err != nil {
+		t.Fatal(err)
+	}
+	if err := gitRun(t.Context(), "reset", "-q", "--hard", "HEAD~1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteBranch(t.Context(), "unmerged", false); !errors.Is(err, ErrNotMerged) {
+		t.Errorf("DeleteBranch() error = %v, want ErrNotMerged", err)
+	}
+}
+
+func TestCommandEnv(t *testing.T) {
+	dir := setupTestRepo(t)
+	// A wt run by a git hook must not write to the wrapper's directive file
+	t.Setenv(shell.DirectiveFileEnv, filepath.Join(t.TempDir(), "directives"))
+	t.Setenv(shell.WrapperVersionEnv, "1")
+
+	out, err := gitOutputDir(t.Context(), dir, "-c", "alias.env=!env", "env")
+	if err != nil {
+		t.Fatalf("running env through git: %v", err)
+	}
+	for _, name := range []string{shell.DirectiveFileEnv, shell.WrapperVersionEnv} {
+		if strings.Contains(out, name+"=") {
+			t.Errorf("git's environment has %s:\n%s", name, out)
+		}
+	}
+	if !strings.Contains(out, "LC_ALL=C") {
+		t.Errorf("git's environment lacks LC_ALL=C:\n%s", out)
+	}
+}
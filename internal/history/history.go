@@ -0,0 +1,118 @@
+// Package history keeps an append-only log of wt operations.
+//
+// The log lives next to the state file (.git/wt/history.log) and holds one
+// JSON object per line. Each event is written with a single append, so
+// concurrent wt invocations interleave whole lines rather than corrupting
+// each other's entries.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the name of the log file in the state directory.
+const FileName = "history.log"
+
+// Operations recorded in the log.
+const (
+	Create = "create"
+	Remove = "remove"
+	Switch = "switch"
+	Prune  = "prune"
+)
+
+// OK is the outcome of a successful operation.
+const OK = "ok"
+
+// Event is one recorded operation.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Op     string    `json:"op"`
+	Branch string    `json:"branch,omitempty"`
+	Path   string    `json:"path,omitempty"`
+	// Outcome is OK or the error the operation failed with.
+	Outcome string `json:"outcome"`
+	User    string `json:"user,omitempty"`
+}
+
+// Log appends to and reads the history file in a directory.
+type Log struct {
+	path string
+}
+
+// New returns a Log backed by the given directory, typically
+// repo.Info.StateDir(). The directory is created on first append.
+func New(dir string) *Log {
+	return &Log{path: filepath.Join(dir, FileName)}
+}
+
+// Append records e. A zero Time is set to now and an empty User to the
+// current user.
+func (l *Log) Append(e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.User == "" {
+		e.User = currentUser()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("creating history directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening history: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("writing history: %w", err)
+	}
+	return f.Close()
+}
+
+// Read returns all recorded events, oldest first. Lines that cannot be
+// parsed are skipped; a missing log has no events.
+func (l *Log) Read() ([]Event, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening history: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var e Event
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			events = append(events, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	return events, nil
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
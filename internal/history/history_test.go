@@ -0,0 +1,50 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRead_MissingLog(t *testing.T) {
+	events, err := New(filepath.Join(t.TempDir(), "wt")).Read()
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("expected no events, got %v", events)
+	}
+}
+
+func TestAppend_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "wt")
+	l := New(dir)
+
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := l.Append(Event{Time: at, Op: Create, Branch: "feat", Path: "/wts/feat", Outcome: OK, User: "ana"}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+	if err := l.Append(Event{Op: Remove, Branch: "feat", Outcome: "boom"}); err != nil {
+		t.Fatalf("Append() error: %v", err)
+	}
+
+	// A corrupt line does not hide the events around it
+	f, _ := os.OpenFile(filepath.Join(dir, FileName), os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString("{not json\n")
+	f.Close()
+
+	events, err := New(dir).Read()
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Read() returned %d events, want 2", len(events))
+	}
+	if e := events[0]; !e.Time.Equal(at) || e.Op != Create || e.Path != "/wts/feat" || e.User != "ana" {
+		t.Errorf("first event = %+v", e)
+	}
+	if e := events[1]; e.Time.IsZero() || e.User == "" || e.Outcome != "boom" {
+		t.Errorf("second event should get a time and user, got %+v", e)
+	}
+}
@@ -0,0 +1,153 @@
+// Package hooks runs user-defined shell commands at points in a worktree's
+// lifecycle.
+//
+// Every hook receives the worktree context in WT_* environment variables, plus
+// the worktree's own variables (see wt env), and {{name}} placeholders in a
+// hook command are replaced with the same values, shell-quoted so they are
+// always passed as single words.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/provenimpact/wt/internal/debug"
+	"github.com/provenimpact/wt/internal/scaffold"
+	"github.com/provenimpact/wt/internal/shell"
+)
+
+// Hook names.
+const (
+	// PostCreate runs in a new worktree after it has been created.
+	PostCreate = "post-create"
+	// PostSwitch runs in the user's shell after it changes into a worktree.
+	PostSwitch = "post-switch"
+)
+
+// Names lists the hooks in the order they run in a worktree's life.
+var Names = []string{PostCreate, PostSwitch}
+
+// Context describes the worktree a hook runs for.
+type Context struct {
+	Branch       string
+	Path         string
+	Base         string // ref the branch was created from; empty if it already existed
+	MainWorktree string
+	RepoName     string
+	Slot         int // the worktree's slot; 0 for the main worktree
+	// WorktreeEnv holds the worktree's own environment variables.
+	WorktreeEnv map[string]string
+}
+
+// Env returns the context as WT_* environment variables, followed by the
+// worktree's own variables in name order.
+func (c Context) Env() []string {
+	env := []string{
+		"WT_BRANCH=" + c.Branch,
+		"WT_PATH=" + c.Path,
+		"WT_BASE=" + c.Base,
+		"WT_MAIN_WORKTREE=" + c.MainWorktree,
+		"WT_REPO_NAME=" + c.RepoName,
+		"WT_SLOT=" + strconv.Itoa(c.Slot),
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.WorktreeEnv)) {
+		env = append(env, name+"="+c.WorktreeEnv[name])
+	}
+	return env
+}
+
+// Vars returns the placeholder values available to hook commands. The names
+// match those of worktree templates, plus base.
+func (c Context) Vars() scaffold.Vars {
+	return scaffold.Vars{
+		"branch":        c.Branch,
+		"worktree_path": c.Path,
+		"dir_name":      filepath.Base(c.Path),
+		"repo_name":     c.RepoName,
+		"main_worktree": c.MainWorktree,
+		"slot":          strconv.Itoa(c.Slot),
+		"base":          c.Base,
+	}
+}
+
+// Expand replaces the {{name}} placeholders in command with shell-quoted
+// context values.
+func Expand(command string, c Context) string {
+	vars := c.Vars()
+	for k, v := range vars {
+		vars[k] = quote(v)
+	}
+	return scaffold.Expand(command, vars)
+}
+
+// Script returns commands with their placeholders expanded, for hooks that
+// are run by the shell wrapper instead of by Run. The WT_* variables are not
+// set there, nor are the worktree's own variables, since the wrapper runs in
+// the user's shell of any kind; commands that need the context use
+// placeholders. A command that spans several lines cannot be passed through
+// the wrapper and is an error.
+func Script(name string, commands []string, c Context) ([]string, error) {
+	script := make([]string, 0, len(commands))
+	for _, command := range commands {
+		expanded := Expand(command, c)
+		if strings.ContainsAny(expanded, "\r\n") {
+			return nil, fmt.Errorf("%s hook %q: command must be a single line", name, command)
+		}
+		script = append(script, expanded)
+	}
+	return script, nil
+}
+
+// Run executes the commands of the named hook one after another in dir,
+// stopping at the first failure. Output from the commands goes to out.
+func Run(name string, commands []string, c Context, dir string, out io.Writer) error {
+	return RunContext(context.Background(), name, commands, c, dir, out)
+}
+
+// killGrace is how long a cancelled hook's output may keep flowing, e.g. from
+// a process it started, before RunContext stops waiting for it.
+const killGrace = time.Second
+
+// RunContext is like Run, but kills the running command when ctx is done.
+func RunContext(ctx context.Context, name string, commands []string, c Context, dir string, out io.Writer) error {
+	for _, command := range commands {
+		cmd := shellCommand(ctx, Expand(command, c))
+		cmd.WaitDelay = killGrace
+		cmd.Dir = dir
+		cmd.Env = append(shell.Environ(), "WT_HOOK="+name)
+		cmd.Env = append(cmd.Env, c.Env()...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		start := time.Now()
+		err := cmd.Run()
+		debug.LogCommand(cmd, time.Since(start), err)
+		if err != nil {
+			return fmt.Errorf("%s hook %q: %w", name, command, err)
+		}
+	}
+	return nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// quote makes s a single word for the platform's shell.
+func quote(s string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
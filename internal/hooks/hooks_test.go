@@ -0,0 +1,93 @@
+//go:build unix
+
+package hooks
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testContext(dir string) Context {
+	return Context{
+		Branch:       "feature/x",
+		Path:         dir,
+		Base:         "main",
+		MainWorktree: "/repo",
+		RepoName:     "repo",
+	}
+}
+
+func TestExpand_QuotesValues(t *testing.T) {
+	c := testContext("/wt/it's here")
+	got := Expand("echo {{branch}} {{dir_name}} {{unknown}}", c)
+	want := `echo 'feature/x' 'it'\''s here' {{unknown}}`
+	if got != want {
+		t.Errorf("Expand() = %q, want %q", got, want)
+	}
+}
+
+func TestScript_ExpandsAndRejectsMultiline(t *testing.T) {
+	c := testContext("/wt/x")
+	got, err := Script(PostSwitch, []string{"cd {{worktree_path}}", "direnv allow"}, c)
+	if err != nil {
+		t.Fatalf("Script() error: %v", err)
+	}
+	if want := []string{"cd '/wt/x'", "direnv allow"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Script() = %q, want %q", got, want)
+	}
+
+	if _, err := Script(PostSwitch, []string{"echo a\necho b"}, c); err == nil {
+		t.Error("Script() should reject multi-line commands")
+	}
+}
+
+func TestRun_PassesEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	var out bytes.Buffer
+	err := Run(PostCreate, []string{
+		`echo "$WT_HOOK $WT_BRANCH $WT_BASE $WT_MAIN_WORKTREE $WT_REPO_NAME" > env.txt`,
+		`echo {{branch}}`,
+	}, testContext(dir), dir, &out)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "env.txt"))
+	if got := strings.TrimSpace(string(data)); got != "post-create feature/x main /repo repo" {
+		t.Errorf("hook environment = %q", got)
+	}
+	if strings.TrimSpace(out.String()) != "feature/x" {
+		t.Errorf("hook output = %q, want expanded branch", out.String())
+	}
+}
+
+func TestRun_PassesWorktreeEnv(t *testing.T) {
+	dir := t.TempDir()
+	c := testContext(dir)
+	c.WorktreeEnv = map[string]string{"PORT": "3001", "API_URL": "http://localhost:3001"}
+	if env := c.Env(); env[len(env)-2] != "API_URL=http://localhost:3001" || env[len(env)-1] != "PORT=3001" {
+		t.Errorf("Env() = %q, want the worktree variables last, in name order", env)
+	}
+
+	var out bytes.Buffer
+	if err := Run(PostCreate, []string{`echo "$PORT $API_URL"`}, c, dir, &out); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "3001 http://localhost:3001" {
+		t.Errorf("hook saw worktree environment %q", got)
+	}
+}
+
+func TestRun_StopsAtFailure(t *testing.T) {
+	dir := t.TempDir()
+	err := Run(PostCreate, []string{"exit 3", "touch ran"}, testContext(dir), dir, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), `post-create hook "exit 3"`) {
+		t.Errorf("Run() error = %v, want failure naming the hook command", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Error("commands after a failure should not run")
+	}
+}
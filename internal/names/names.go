@@ -1,20 +1,125 @@
 package names
 
 import (
+	"cmp"
+	"crypto/sha1"
+	"encoding/hex"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
-var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9\-.]`)
-var multiDash = regexp.MustCompile(`-{2,}`)
-
-// Sanitize converts a branch name into a safe, flat directory name.
-// Characters not matching [a-zA-Z0-9-.] are replaced with "-".
-// Consecutive "-" characters are collapsed into a single "-".
-// Leading and trailing "-" characters are trimmed.
-func Sanitize(branch string) string {
-	s := unsafeChars.ReplaceAllString(branch, "-")
-	s = multiDash.ReplaceAllString(s, "-")
-	s = strings.Trim(s, "-")
+// unsafeChars matches everything but letters, digits, and combining marks in
+// any script, "-", and ".".
+var unsafeChars = regexp.MustCompile(`[^\pL\pN\pM\-.]`)
+
+// Replacements lists the characters Options.Replacement may be.
+var Replacements = []string{"-", "_", "."}
+
+// MinLength is the smallest Options.MaxLength, which leaves room for some of
+// the name besides the hash that ends a shortened one.
+const MinLength = 16
+
+// hashLength is the number of hex digits of the hash that makes a name
+// unique.
+const hashLength = 6
+
+// Options control how branch names become directory names. The zero value
+// gives flat names with "-" for unsafe characters, of any length, in the
+// case of the branch.
+type Options struct {
+	// Nested keeps "/" in branch names as directory separators, so that
+	// feature/login is put in feature/login rather than feature-login. Each
+	// directory name is sanitized on its own.
+	Nested bool
+	// Replacement is the character unsafe characters are replaced with, one
+	// of Replacements; empty means "-".
+	Replacement string
+	// MaxLength caps the number of characters of a directory name (of each
+	// one, when nested); a longer name is cut and ends in a short hash of the
+	// branch instead. Zero means no limit; otherwise it is at least
+	// MinLength.
+	MaxLength int
+	// Lowercase lowercases directory names.
+	Lowercase bool
+}
+
+// Sanitize converts a branch name into a safe directory name.
+// Characters other than letters, digits, "-", and "." are replaced with "-"
+// (or the Replacement of opts); letters and digits outside ASCII are kept, so
+// "feature/żółw" becomes "feature-żółw" and "功能/登录" becomes "功能-登录".
+// Consecutive replacement characters are collapsed into one, and leading and
+// trailing ones are trimmed. See Options for the other choices.
+func Sanitize(branch string, opts Options) string {
+	return opts.name(branch, false)
+}
+
+// Disambiguate returns the sanitized branch name with a short hash of the
+// branch appended, e.g. "fix-bug-5c1e2a" for "fix/bug". It names the
+// directory of a branch whose sanitized name is already taken, such as
+// "fix/bug" next to "fix-bug". A name that Sanitize shortens for MaxLength
+// already ends in the hash, and is the same.
+func Disambiguate(branch string, opts Options) string {
+	return opts.name(branch, true)
+}
+
+// name sanitizes branch, with the hash appended to the last directory name
+// when unique is set.
+func (o Options) name(branch string, unique bool) string {
+	sep := cmp.Or(o.Replacement, "-")
+	sum := sha1.Sum([]byte(branch))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+
+	elems := []string{branch}
+	if o.Nested {
+		elems = strings.Split(branch, "/")
+	}
+	var dirs []string
+	for _, elem := range elems {
+		dir := o.clean(elem, sep)
+		if o.Nested && strings.Trim(dir, ".") == "" {
+			continue // "." and ".." would not be directories of their own
+		}
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	if unique && len(dirs) == 0 {
+		dirs = []string{"branch"}
+	}
+
+	for i, dir := range dirs {
+		switch {
+		case unique && i == len(dirs)-1:
+			dirs[i] = o.cut(dir, sep, len(sep)+hashLength) + sep + hash
+		case o.MaxLength > 0 && utf8.RuneCountInString(dir) > o.MaxLength:
+			dirs[i] = o.cut(dir, sep, len(sep)+hashLength) + sep + hash
+		}
+	}
+	return strings.Join(dirs, "/")
+}
+
+// clean sanitizes a single directory name.
+func (o Options) clean(s, sep string) string {
+	if o.Lowercase {
+		s = strings.ToLower(s)
+	}
+	s = unsafeChars.ReplaceAllLiteralString(s, sep)
+	for strings.Contains(s, sep+sep) {
+		s = strings.ReplaceAll(s, sep+sep, sep)
+	}
+	return strings.Trim(s, sep)
+}
+
+// cut shortens s so that reserve more characters fit within MaxLength,
+// without leaving a trailing separator.
+func (o Options) cut(s, sep string, reserve int) string {
+	if o.MaxLength == 0 {
+		return s
+	}
+	runes := []rune(s)
+	if keep := o.MaxLength - reserve; len(runes) > keep {
+		s = strings.TrimRight(string(runes[:keep]), sep)
+	}
 	return s
 }
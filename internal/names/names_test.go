@@ -1,6 +1,9 @@
 package names
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestSanitize(t *testing.T) {
 	tests := []struct {
@@ -18,16 +21,78 @@ func TestSanitize(t *testing.T) {
 		{"dots.and-dashes", "dots.and-dashes"},
 		{"special@chars#here!", "special-chars-here"},
 		{"///", ""},
+		{"feature/żółw", "feature-żółw"},
+		{"fix/Ärger über Öl", "fix-Ärger-über-Öl"},
+		{"功能/登录", "功能-登录"},
+		{"機能/ログイン画面", "機能-ログイン画面"},
+		{"hotfix/v２", "hotfix-v２"},
+		{"e\u0301te/cafe\u0301", "e\u0301te-cafe\u0301"},
+		{"emoji/🚀-launch", "emoji-launch"},
 		{"a", "a"},
 		{"", ""},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
-			got := Sanitize(tt.input)
+			got := Sanitize(tt.input, Options{})
 			if got != tt.want {
 				t.Errorf("Sanitize(%q) = %q, want %q", tt.input, got, tt.want)
 			}
 		})
 	}
 }
+
+func TestDisambiguate(t *testing.T) {
+	a, b := Disambiguate("fix/bug", Options{}), Disambiguate("fix-bug", Options{})
+	if a == b {
+		t.Errorf("branches with the same sanitized name should differ, both %q", a)
+	}
+	if !strings.HasPrefix(a, "fix-bug-") || len(a) != len("fix-bug-")+6 {
+		t.Errorf("Disambiguate(%q) = %q, want fix-bug- and 6 hex digits", "fix/bug", a)
+	}
+	if Disambiguate("fix/bug", Options{}) != a {
+		t.Error("Disambiguate should be stable")
+	}
+}
+
+func TestSanitize_Options(t *testing.T) {
+	tests := []struct {
+		input string
+		opts  Options
+		want  string
+	}{
+		{"feature/login", Options{Nested: true}, "feature/login"},
+		{"feature//Login Page/", Options{Nested: true}, "feature/Login-Page"},
+		{"a/./b/../c", Options{Nested: true}, "a/b/c"},
+		{"fix/bug-123", Options{Replacement: "_"}, "fix_bug-123"},
+		{"fix/__bug", Options{Replacement: "_"}, "fix_bug"},
+		{"Feature/Login", Options{Lowercase: true}, "feature-login"},
+		{"short", Options{MaxLength: 16}, "short"},
+		{"exactly-16-chars", Options{MaxLength: 16}, "exactly-16-chars"},
+	}
+	for _, tt := range tests {
+		if got := Sanitize(tt.input, tt.opts); got != tt.want {
+			t.Errorf("Sanitize(%q, %+v) = %q, want %q", tt.input, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestSanitize_MaxLength(t *testing.T) {
+	opts := Options{MaxLength: 16}
+	a := Sanitize("feature/a-very-long-branch-name", opts)
+	b := Sanitize("feature/a-very-long-branch-name-2", opts)
+	if len(a) != 16 || !strings.HasPrefix(a, "feature-a-") || a == b {
+		t.Errorf("shortened names = %q, %q; want 16 characters, distinct, and keeping the start", a, b)
+	}
+	if got := Disambiguate("feature/a-very-long-branch-name", opts); got != a {
+		t.Errorf("Disambiguate() = %q, want the shortened name %q, which is unique already", got, a)
+	}
+	if got := Disambiguate("feature/login", opts); len(got) > 16 || !strings.HasPrefix(got, "feature-") {
+		t.Errorf("Disambiguate() = %q, want it shortened to 16 characters", got)
+	}
+
+	nested := Sanitize("team/a-very-long-branch-name", Options{Nested: true, MaxLength: 16})
+	if dir, name, _ := strings.Cut(nested, "/"); dir != "team" || len(name) != 16 {
+		t.Errorf("nested shortened name = %q, want team/ and a 16-character name", nested)
+	}
+}
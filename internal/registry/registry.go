@@ -0,0 +1,170 @@
+// Package registry keeps a user-level list of known repositories so wt can
+// resolve worktrees across repositories, e.g. "myservice/feature-x".
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/provenimpact/wt/internal/state"
+)
+
+const (
+	fileName = "repos.json"
+	lockName = "repos.lock"
+)
+
+// Repo is a registered repository.
+type Repo struct {
+	Name string `json:"name"`
+	// Path is the absolute path to the repository's main worktree.
+	Path string `json:"path"`
+}
+
+// Registry reads and writes the repository list in a directory.
+type Registry struct {
+	dir string
+}
+
+// New returns a Registry backed by dir, typically config.Dir().
+func New(dir string) *Registry {
+	return &Registry{dir: dir}
+}
+
+// List returns all registered repositories sorted by name.
+func (r *Registry) List() ([]Repo, error) {
+	repos, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	return repos, nil
+}
+
+// Lookup returns the repository registered under name.
+func (r *Registry) Lookup(name string) (Repo, bool, error) {
+	repos, err := r.read()
+	if err != nil {
+		return Repo{}, false, err
+	}
+	for _, repo := range repos {
+		if repo.Name == name {
+			return repo, true, nil
+		}
+	}
+	return Repo{}, false, nil
+}
+
+// Add registers path under name. An existing entry with the same name is
+// replaced; registering an already known path under a new name renames it.
+func (r *Registry) Add(name, path string) error {
+	return r.update(func(repos []Repo) ([]Repo, error) {
+		var kept []Repo
+		for _, repo := range repos {
+			if repo.Name != name && repo.Path != path {
+				kept = append(kept, repo)
+			}
+		}
+		return append(kept, Repo{Name: name, Path: path}), nil
+	})
+}
+
+// AutoRegister records path under name unless the path is already known or
+// the name is taken by another repository that still exists on disk.
+// It only writes when something changes.
+func (r *Registry) AutoRegister(name, path string) error {
+	repos, err := r.read()
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if repo.Path == path {
+			return nil
+		}
+		if repo.Name == name {
+			if _, err := os.Stat(repo.Path); err == nil {
+				return nil // Name belongs to a live repo; leave it alone
+			}
+		}
+	}
+	return r.Add(name, path)
+}
+
+// Remove unregisters the repository with the given name.
+func (r *Registry) Remove(name string) error {
+	return r.update(func(repos []Repo) ([]Repo, error) {
+		var kept []Repo
+		found := false
+		for _, repo := range repos {
+			if repo.Name == name {
+				found = true
+				continue
+			}
+			kept = append(kept, repo)
+		}
+		if !found {
+			return nil, fmt.Errorf("repository %q is not registered", name)
+		}
+		return kept, nil
+	})
+}
+
+// Split interprets target as "<repo>/<worktree>" and returns the registered
+// repository and the remaining worktree name. It reports false if the prefix
+// does not name a registered repository.
+func (r *Registry) Split(target string) (Repo, string, bool, error) {
+	name, rest, ok := strings.Cut(target, "/")
+	if !ok || rest == "" {
+		return Repo{}, "", false, nil
+	}
+	repo, found, err := r.Lookup(name)
+	if err != nil || !found {
+		return Repo{}, "", false, err
+	}
+	return repo, rest, true, nil
+}
+
+func (r *Registry) read() ([]Repo, error) {
+	data, err := os.ReadFile(filepath.Join(r.dir, fileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading repository registry: %w", err)
+	}
+	var repos []Repo
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil, fmt.Errorf("parsing repository registry: %w", err)
+	}
+	return repos, nil
+}
+
+func (r *Registry) update(fn func([]Repo) ([]Repo, error)) error {
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	unlock, err := state.Lock(filepath.Join(r.dir, lockName), true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	repos, err := r.read()
+	if err != nil {
+		return err
+	}
+	repos, err = fn(repos)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(repos, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding repository registry: %w", err)
+	}
+	return state.WriteFileAtomic(filepath.Join(r.dir, fileName), append(data, '\n'))
+}
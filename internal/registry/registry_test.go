@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"testing"
+)
+
+func TestAddLookupRemove(t *testing.T) {
+	reg := New(t.TempDir())
+
+	if err := reg.Add("api", "/src/api"); err != nil {
+		t.Fatalf("Add() error: %v", err)
+	}
+	r, ok, err := reg.Lookup("api")
+	if err != nil || !ok || r.Path != "/src/api" {
+		t.Fatalf("Lookup(api) = (%+v, %v, %v)", r, ok, err)
+	}
+
+	if err := reg.Remove("api"); err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if _, ok, _ := reg.Lookup("api"); ok {
+		t.Error("api should be unregistered")
+	}
+	if err := reg.Remove("api"); err == nil {
+		t.Error("removing an unknown repository should fail")
+	}
+}
+
+func TestAutoRegister_KeepsLiveNameOwner(t *testing.T) {
+	live := t.TempDir()
+	reg := New(t.TempDir())
+
+	reg.Add("api", live)
+	if err := reg.AutoRegister("api", "/other/api"); err != nil {
+		t.Fatalf("AutoRegister() error: %v", err)
+	}
+
+	r, _, _ := reg.Lookup("api")
+	if r.Path != live {
+		t.Errorf("api path = %q, want existing live repo %q", r.Path, live)
+	}
+}
+
+func TestAutoRegister_ReplacesStaleNameOwner(t *testing.T) {
+	reg := New(t.TempDir())
+
+	reg.Add("api", "/does/not/exist")
+	reg.AutoRegister("api", "/new/api")
+
+	r, _, _ := reg.Lookup("api")
+	if r.Path != "/new/api" {
+		t.Errorf("api path = %q, want /new/api", r.Path)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	reg := New(t.TempDir())
+	reg.Add("svc", "/src/svc")
+
+	r, rest, ok, err := reg.Split("svc/fix/bug-1")
+	if err != nil || !ok {
+		t.Fatalf("Split() = (%v, %v)", ok, err)
+	}
+	if r.Name != "svc" || rest != "fix/bug-1" {
+		t.Errorf("Split() = (%q, %q), want (svc, fix/bug-1)", r.Name, rest)
+	}
+
+	if _, _, ok, _ := reg.Split("unknown/branch"); ok {
+		t.Error("Split() should not match unregistered prefixes")
+	}
+	if _, _, ok, _ := reg.Split("svc"); ok {
+		t.Error("Split() requires a worktree name after the slash")
+	}
+}
@@ -1,11 +1,18 @@
 package repo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/provenimpact/wt/internal/debug"
+	"github.com/provenimpact/wt/internal/git"
 )
 
 // Info holds resolved repository paths.
@@ -16,27 +23,35 @@ type Info struct {
 	WorktreesDir string
 	// RepoName is the base name of the main repository directory.
 	RepoName string
+	// GitCommonDir is the absolute path to the shared .git directory.
+	GitCommonDir string
 }
 
 // Resolve determines the main repository root and worktrees directory.
 // It works correctly whether invoked from the main repo or from inside any worktree.
 func Resolve() (*Info, error) {
+	return ResolveDir(context.Background(), "")
+}
+
+// ResolveDir is like Resolve for the repository containing dir; an empty dir
+// means the current directory.
+func ResolveDir(ctx context.Context, dir string) (*Info, error) {
 	// git rev-parse --git-common-dir gives us the shared .git directory
 	// For the main worktree, this is just ".git"
 	// For linked worktrees, this is something like "/path/to/main/.git"
-	out, err := gitCommand("rev-parse", "--git-common-dir")
+	out, err := gitCommand(ctx, dir, "rev-parse", "--git-common-dir")
 	if err != nil {
-		return nil, fmt.Errorf("not a git repository: %w", err)
+		return nil, fmt.Errorf("%w: %w", git.ErrNotARepo, err)
 	}
 	commonDir := strings.TrimSpace(out)
 
 	// Make absolute
 	if !filepath.IsAbs(commonDir) {
-		cwd, err := os.Getwd()
+		base, err := filepath.Abs(dir)
 		if err != nil {
 			return nil, fmt.Errorf("cannot determine working directory: %w", err)
 		}
-		commonDir = filepath.Join(cwd, commonDir)
+		commonDir = filepath.Join(base, commonDir)
 	}
 	commonDir = filepath.Clean(commonDir)
 
@@ -51,17 +66,156 @@ func Resolve() (*Info, error) {
 		MainWorktree: mainWorktree,
 		WorktreesDir: worktreesDir,
 		RepoName:     repoName,
+		GitCommonDir: commonDir,
 	}, nil
 }
 
-// EnsureWorktreesDir creates the worktrees directory if it does not exist.
+// RootMarker is the file that marks a worktrees directory as wt's, as
+// opposed to an unrelated directory that happens to have its name. It holds
+// the path of the main worktree of the repository the directory belongs to;
+// an empty one, as made with touch, claims the directory for the next
+// repository that creates a worktree in it.
+const RootMarker = ".wt-root"
+
+// EnsureWorktreesDir creates the worktrees directory, marked with RootMarker,
+// if it does not exist.
 func (info *Info) EnsureWorktreesDir() error {
-	return os.MkdirAll(info.WorktreesDir, 0o755)
+	if _, err := os.Stat(info.WorktreesDir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(info.WorktreesDir, 0o755); err != nil {
+		return err
+	}
+	return info.MarkWorktreesDir()
+}
+
+// MarkWorktreesDir writes the RootMarker of the worktrees directory, naming
+// the repository's main worktree.
+func (info *Info) MarkWorktreesDir() error {
+	if err := os.WriteFile(filepath.Join(info.WorktreesDir, RootMarker), []byte(info.MainWorktree+"\n"), 0o644); err != nil {
+		return fmt.Errorf("marking the worktrees directory: %w", err)
+	}
+	return nil
+}
+
+// WorktreesDirOwner reads the RootMarker of the worktrees directory. It
+// returns the main worktree the marker names, empty when it names none, and
+// whether there is a marker at all.
+func (info *Info) WorktreesDirOwner() (owner string, marked bool, err error) {
+	data, err := os.ReadFile(filepath.Join(info.WorktreesDir, RootMarker))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("reading the worktrees directory marker: %w", err)
+	}
+	return strings.TrimSpace(string(data)), true, nil
+}
+
+// OwnsWorktreesDir reports whether the RootMarker of the worktrees directory
+// names this repository, which makes it safe to delete what is in the
+// directory but not a worktree.
+func (info *Info) OwnsWorktreesDir() (bool, error) {
+	owner, marked, err := info.WorktreesDirOwner()
+	if err != nil || !marked || owner == "" {
+		return false, err
+	}
+	return sameDir(owner, info.MainWorktree), nil
+}
+
+// sameDir reports whether a and b name the same directory, resolving
+// symlinks where they exist.
+func sameDir(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, errA := filepath.EvalSymlinks(a)
+	rb, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && ra == rb
+}
+
+// RemoveWorktreesDir removes the worktrees directory if nothing but its
+// RootMarker is left in it.
+func (info *Info) RemoveWorktreesDir() {
+	entries, err := os.ReadDir(info.WorktreesDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.Name() != RootMarker {
+			return
+		}
+	}
+	os.RemoveAll(info.WorktreesDir)
+}
+
+// StateDir returns the directory where wt keeps its per-repository metadata.
+// It lives inside the shared .git directory so every worktree sees the same state.
+func (info *Info) StateDir() string {
+	return filepath.Join(info.GitCommonDir, "wt")
+}
+
+// TemplateDir returns the directory whose contents are copied into every new worktree.
+func (info *Info) TemplateDir() string {
+	return filepath.Join(info.StateDir(), "worktree-template")
+}
+
+// CleanEmptyParents removes the directories between path and the worktrees
+// directory that were left empty, such as "feature" after removing the
+// worktree for "feature/login".
+func (info *Info) CleanEmptyParents(path string) {
+	dir := filepath.Dir(path)
+	for dir != info.WorktreesDir && len(dir) > len(info.WorktreesDir) {
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		os.Remove(dir)
+		dir = filepath.Dir(dir)
+	}
+}
+
+// Discover returns the main worktrees of the repositories at or under root,
+// sorted by path. Repositories are recognized by their .git directory;
+// linked worktrees, which have a .git file, nested repositories, and hidden
+// directories are not descended into, so the worktrees of a repository are
+// not searched.
+func Discover(root string) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil // unreadable directories are skipped
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		fi, err := os.Stat(filepath.Join(path, ".git"))
+		switch {
+		case err != nil:
+			return nil
+		case fi.IsDir():
+			found = append(found, path)
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("searching %s for repositories: %w", root, err)
+	}
+	return found, nil
 }
 
-func gitCommand(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
+func gitCommand(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	start := time.Now()
 	out, err := cmd.Output()
+	debug.LogCommand(cmd, time.Since(start), err)
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return "", fmt.Errorf("%s: %s", err, string(exitErr.Stderr))
@@ -137,6 +137,51 @@ func TestEnsureWorktreesDir_CreatesDirectory(t *testing.T) {
 	if !stat.IsDir() {
 		t.Error("worktrees dir is not a directory")
 	}
+	if owner, marked, err := info.WorktreesDirOwner(); err != nil || !marked || owner != info.MainWorktree {
+		t.Errorf("WorktreesDirOwner() = %q, %t, %v; want the new directory marked for %s", owner, marked, err, info.MainWorktree)
+	}
+
+	info.RemoveWorktreesDir()
+	if _, err := os.Stat(info.WorktreesDir); !os.IsNotExist(err) {
+		t.Error("RemoveWorktreesDir() should remove a directory holding only the marker")
+	}
+}
+
+// An existing directory is not marked, since it may not be wt's.
+func TestEnsureWorktreesDir_LeavesExistingUnmarked(t *testing.T) {
+	setupTestRepo(t)
+	info, _ := Resolve()
+	os.MkdirAll(info.WorktreesDir, 0o755)
+	os.WriteFile(filepath.Join(info.WorktreesDir, "notes.txt"), []byte("mine"), 0o644)
+
+	if err := info.EnsureWorktreesDir(); err != nil {
+		t.Fatalf("EnsureWorktreesDir() error: %v", err)
+	}
+	if _, marked, _ := info.WorktreesDirOwner(); marked {
+		t.Error("an existing directory should not be marked")
+	}
+	info.RemoveWorktreesDir()
+	if _, err := os.Stat(filepath.Join(info.WorktreesDir, "notes.txt")); err != nil {
+		t.Error("RemoveWorktreesDir() should keep a directory with other files")
+	}
+}
+
+func TestOwnsWorktreesDir(t *testing.T) {
+	setupTestRepo(t)
+	info, _ := Resolve()
+	marker := filepath.Join(info.WorktreesDir, RootMarker)
+	os.MkdirAll(info.WorktreesDir, 0o755)
+
+	for content, want := range map[string]bool{"": false, "/elsewhere\n": false, info.MainWorktree + "\n": true} {
+		os.WriteFile(marker, []byte(content), 0o644)
+		if owned, err := info.OwnsWorktreesDir(); err != nil || owned != want {
+			t.Errorf("OwnsWorktreesDir() with marker %q = %v, %v; want %v", content, owned, err, want)
+		}
+	}
+	os.Remove(marker)
+	if owned, _ := info.OwnsWorktreesDir(); owned {
+		t.Error("an unmarked directory should not be owned")
+	}
 }
 
 func TestEnsureWorktreesDir_Idempotent(t *testing.T) {
@@ -166,3 +211,40 @@ func TestResolve_NotGitRepo(t *testing.T) {
 		t.Errorf("error should mention 'not a git repository', got: %v", err)
 	}
 }
+
+func TestStateDir_InsideGitCommonDir(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	info, err := Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := filepath.Join(dir, ".git", "wt")
+	if info.StateDir() != want {
+		t.Errorf("StateDir() = %q, want %q", info.StateDir(), want)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a/.git", "group/b/.git", "group/b/vendor/c/.git", ".hidden/d/.git", "plain/src"} {
+		os.MkdirAll(filepath.Join(root, dir), 0o755)
+	}
+	// A linked worktree has a .git file
+	os.MkdirAll(filepath.Join(root, "a-worktrees", "feature"), 0o755)
+	os.WriteFile(filepath.Join(root, "a-worktrees", "feature", ".git"), []byte("gitdir: ../../a/.git/worktrees/feature\n"), 0o644)
+
+	got, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover() error: %v", err)
+	}
+	want := []string{filepath.Join(root, "a"), filepath.Join(root, "group", "b")}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Discover() = %q, want %q", got, want)
+	}
+
+	if _, err := Discover(filepath.Join(root, "missing")); err == nil {
+		t.Error("Discover() of a missing root should fail")
+	}
+}
@@ -0,0 +1,97 @@
+// Package scaffold copies template files into newly created worktrees,
+// expanding {{variable}} placeholders in their contents.
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Vars maps placeholder names (without braces) to their values.
+type Vars map[string]string
+
+// Expand replaces every {{name}} placeholder in s with its value from vars.
+// Unknown placeholders are left untouched.
+func Expand(s string, vars Vars) string {
+	if len(vars) == 0 || !strings.Contains(s, "{{") {
+		return s
+	}
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(vars)*2)
+	for _, k := range keys {
+		pairs = append(pairs, "{{"+k+"}}", vars[k])
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// Copy copies every file under srcDir into dstDir, preserving relative paths
+// and file modes. Text files have their placeholders expanded; binary files
+// are copied verbatim. Files that already exist in dstDir are never
+// overwritten. Returns the relative paths of the files written.
+// A missing srcDir is not an error.
+func Copy(srcDir, dstDir string, vars Vars) ([]string, error) {
+	if _, err := os.Stat(srcDir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	var written []string
+	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || rel == "." {
+			return nil
+		}
+
+		dst := filepath.Join(dstDir, rel)
+		if _, err := os.Lstat(dst); err == nil {
+			return nil // Never clobber files checked out from the branch
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if isText(data) {
+			data = []byte(Expand(string(data), vars))
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(dst, data, info.Mode().Perm()); err != nil {
+			return err
+		}
+		written = append(written, rel)
+		return nil
+	})
+	if err != nil {
+		return written, fmt.Errorf("copying template files: %w", err)
+	}
+	return written, nil
+}
+
+// isText reports whether data looks like text safe for placeholder expansion.
+func isText(data []byte) bool {
+	return utf8.Valid(data) && !bytes.ContainsRune(data, 0)
+}
@@ -0,0 +1,83 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	vars := Vars{"branch": "fix/bug-1", "worktree_path": "/wt/fix-bug-1"}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"{{branch}}", "fix/bug-1"},
+		{"cd {{worktree_path}} # {{branch}}", "cd /wt/fix-bug-1 # fix/bug-1"},
+		{"{{unknown}}", "{{unknown}}"},
+		{"no placeholders", "no placeholders"},
+	}
+	for _, tt := range tests {
+		if got := Expand(tt.in, vars); got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCopy_ExpandsAndPreservesLayout(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	os.MkdirAll(filepath.Join(src, ".vscode"), 0o755)
+	os.WriteFile(filepath.Join(src, ".vscode", "settings.json"), []byte(`{"title": "{{branch}}"}`), 0o644)
+	os.WriteFile(filepath.Join(src, "blob.bin"), []byte{0, 1, '{', '{'}, 0o600)
+
+	written, err := Copy(src, dst, Vars{"branch": "feature-x"})
+	if err != nil {
+		t.Fatalf("Copy() error: %v", err)
+	}
+	if len(written) != 2 {
+		t.Errorf("expected 2 files written, got %v", written)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dst, ".vscode", "settings.json"))
+	if string(data) != `{"title": "feature-x"}` {
+		t.Errorf("settings.json = %q, want expanded branch", data)
+	}
+
+	info, err := os.Stat(filepath.Join(dst, "blob.bin"))
+	if err != nil {
+		t.Fatalf("binary file not copied: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("blob.bin mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestCopy_DoesNotOverwrite(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	os.WriteFile(filepath.Join(src, "README.md"), []byte("template"), 0o644)
+	os.WriteFile(filepath.Join(dst, "README.md"), []byte("tracked"), 0o644)
+
+	written, err := Copy(src, dst, nil)
+	if err != nil {
+		t.Fatalf("Copy() error: %v", err)
+	}
+	if len(written) != 0 {
+		t.Errorf("expected no files written, got %v", written)
+	}
+	data, _ := os.ReadFile(filepath.Join(dst, "README.md"))
+	if string(data) != "tracked" {
+		t.Errorf("existing file was overwritten: %q", data)
+	}
+}
+
+func TestCopy_MissingTemplateDir(t *testing.T) {
+	written, err := Copy(filepath.Join(t.TempDir(), "missing"), t.TempDir(), nil)
+	if err != nil || len(written) != 0 {
+		t.Errorf("Copy() with missing dir = (%v, %v), want (nil, nil)", written, err)
+	}
+}
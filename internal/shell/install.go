@@ -0,0 +1,199 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	blockStart = "# >>> wt shell integration >>>"
+	blockEnd   = "# <<< wt shell integration <<<"
+)
+
+// compinitLine matches the line in a .zshrc that initializes completion.
+var compinitLine = regexp.MustCompile(`(?m)^[^#\n]*\bcompinit\b`)
+
+// DetectShell returns the name of the user's login shell from $SHELL.
+func DetectShell() (string, error) {
+	sh := filepath.Base(os.Getenv("SHELL"))
+	switch sh {
+	case "bash", "zsh", "fish":
+		return sh, nil
+	case ".", "":
+		return "", fmt.Errorf("cannot detect shell: $SHELL is not set; pass the shell name explicitly")
+	default:
+		return "", fmt.Errorf("unsupported shell %q; supported: bash, zsh, fish", sh)
+	}
+}
+
+// Install writes the completion script and the wrapper init line for the
+// given shell below home. It is idempotent: files that already have the
+// desired content are left untouched. Returns the paths that were changed.
+func Install(shellName, home string, completion []byte) ([]string, error) {
+	var changed []string
+	write := func(path string, fn func(string) (bool, error)) error {
+		ok, err := fn(path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			changed = append(changed, path)
+		}
+		return nil
+	}
+
+	switch shellName {
+	case "bash":
+		compPath := filepath.Join(dataHome(home), "bash-completion", "completions", "wt")
+		if err := write(compPath, writeIfChanged(completion)); err != nil {
+			return changed, err
+		}
+		block := "eval \"$(wt init bash)\"\n"
+		if err := write(filepath.Join(home, ".bashrc"), upsertBlock(block, false)); err != nil {
+			return changed, err
+		}
+	case "zsh":
+		funcDir := filepath.Join(dataHome(home), "zsh", "site-functions")
+		if err := write(filepath.Join(funcDir, "_wt"), writeIfChanged(completion)); err != nil {
+			return changed, err
+		}
+		block := fmt.Sprintf("fpath=(%q $fpath)\neval \"$(wt init zsh)\"\n", funcDir)
+		zshrc := filepath.Join(zdotdir(home), ".zshrc")
+		if err := write(zshrc, upsertBlock(block, true)); err != nil {
+			return changed, err
+		}
+	case "fish":
+		fishDir := filepath.Join(configHome(home), "fish")
+		if err := write(filepath.Join(fishDir, "completions", "wt.fish"), writeIfChanged(completion)); err != nil {
+			return changed, err
+		}
+		init := []byte("wt init fish | source\n")
+		if err := write(filepath.Join(fishDir, "conf.d", "wt.fish"), writeIfChanged(init)); err != nil {
+			return changed, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported shell %q; supported: bash, zsh, fish", shellName)
+	}
+	return changed, nil
+}
+
+// verifyChecks are commands that succeed when the wt function is defined.
+var verifyChecks = map[string]string{
+	"bash": "declare -F wt >/dev/null",
+	"zsh":  "(( $+functions[wt] ))",
+	"fish": "functions -q wt",
+}
+
+// Verify starts an interactive shell, which reads the same startup files as
+// a new terminal, and checks that they define the wt function.
+func Verify(ctx context.Context, shellName string) error {
+	check, ok := verifyChecks[shellName]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q; supported: bash, zsh, fish", shellName)
+	}
+	if _, err := exec.LookPath(shellName); err != nil {
+		return fmt.Errorf("cannot start %s: %w", shellName, err)
+	}
+	cmd := exec.CommandContext(ctx, shellName, "-i", "-c", check)
+	// Interactive shells complain without a terminal; only the result matters
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = nil, nil, nil
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%s did not start in time: %w", shellName, context.Cause(ctx))
+		}
+		if _, err := exec.LookPath("wt"); err != nil {
+			return fmt.Errorf("a new %s shell does not define the wt function: wt is not on $PATH", shellName)
+		}
+		return fmt.Errorf("a new %s shell does not define the wt function", shellName)
+	}
+	return nil
+}
+
+// writeIfChanged returns a writer that replaces the file only when its
+// content differs from data.
+func writeIfChanged(data []byte) func(string) (bool, error) {
+	return func(path string) (bool, error) {
+		existing, err := os.ReadFile(path)
+		if err == nil && string(existing) == string(data) {
+			return false, nil
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return false, fmt.Errorf("writing %s: %w", path, err)
+		}
+		return true, nil
+	}
+}
+
+// upsertBlock returns a writer that places body between wt's marker lines in
+// an rc file, replacing any previous block. With beforeCompinit, a new block
+// is inserted ahead of the first compinit call so fpath changes take effect.
+func upsertBlock(body string, beforeCompinit bool) func(string) (bool, error) {
+	return func(path string) (bool, error) {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return false, err
+		}
+		content := string(data)
+		block := blockStart + "\n" + body + blockEnd + "\n"
+
+		var updated string
+		start := strings.Index(content, blockStart)
+		end := strings.Index(content, blockEnd)
+		switch {
+		case start >= 0 && end > start:
+			updated = content[:start] + block + strings.TrimPrefix(content[end+len(blockEnd):], "\n")
+		case beforeCompinit && compinitLine.MatchString(content):
+			loc := compinitLine.FindStringIndex(content)
+			updated = content[:loc[0]] + block + content[loc[0]:]
+		default:
+			if content != "" && !strings.HasSuffix(content, "\n") {
+				content += "\n"
+			}
+			if content != "" {
+				content += "\n"
+			}
+			updated = content + block
+		}
+
+		if updated == string(data) {
+			return false, nil
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return false, err
+		}
+		if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+			return false, fmt.Errorf("writing %s: %w", path, err)
+		}
+		return true, nil
+	}
+}
+
+func dataHome(home string) string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".local", "share")
+}
+
+func configHome(home string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".config")
+}
+
+func zdotdir(home string) string {
+	if dir := os.Getenv("ZDOTDIR"); dir != "" {
+		return dir
+	}
+	return home
+}
@@ -0,0 +1,122 @@
+package shell
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func clearShellEnv(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZDOTDIR", "")
+}
+
+func TestInstall_BashIsIdempotent(t *testing.T) {
+	clearShellEnv(t)
+	home := t.TempDir()
+	os.WriteFile(filepath.Join(home, ".bashrc"), []byte("export EDITOR=vim"), 0o644)
+
+	changed, err := Install("bash", home, []byte("# completion"))
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if len(changed) != 2 {
+		t.Errorf("first install should change 2 files, got %v", changed)
+	}
+
+	comp, err := os.ReadFile(filepath.Join(home, ".local", "share", "bash-completion", "completions", "wt"))
+	if err != nil || string(comp) != "# completion" {
+		t.Errorf("completion script not written: %q (%v)", comp, err)
+	}
+	rc, _ := os.ReadFile(filepath.Join(home, ".bashrc"))
+	if !strings.HasPrefix(string(rc), "export EDITOR=vim\n") || !strings.Contains(string(rc), `eval "$(wt init bash)"`) {
+		t.Errorf(".bashrc should keep existing content and gain the init line, got:\n%s", rc)
+	}
+
+	changed, err = Install("bash", home, []byte("# completion"))
+	if err != nil {
+		t.Fatalf("second Install() error: %v", err)
+	}
+	if len(changed) != 0 {
+		t.Errorf("second install should change nothing, got %v", changed)
+	}
+	rc2, _ := os.ReadFile(filepath.Join(home, ".bashrc"))
+	if strings.Count(string(rc2), blockStart) != 1 {
+		t.Errorf(".bashrc should contain exactly one wt block, got:\n%s", rc2)
+	}
+}
+
+func TestInstall_ZshBlockPrecedesCompinit(t *testing.T) {
+	clearShellEnv(t)
+	home := t.TempDir()
+	os.WriteFile(filepath.Join(home, ".zshrc"), []byte("autoload -Uz compinit\ncompinit\n"), 0o644)
+
+	if _, err := Install("zsh", home, []byte("#compdef wt")); err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+
+	rc, _ := os.ReadFile(filepath.Join(home, ".zshrc"))
+	block := strings.Index(string(rc), blockStart)
+	compinit := strings.Index(string(rc), "autoload -Uz compinit")
+	if block < 0 || block > compinit {
+		t.Errorf("wt block should be inserted before compinit, got:\n%s", rc)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".local", "share", "zsh", "site-functions", "_wt")); err != nil {
+		t.Errorf("_wt completion file not written: %v", err)
+	}
+}
+
+func TestInstall_Fish(t *testing.T) {
+	clearShellEnv(t)
+	home := t.TempDir()
+
+	if _, err := Install("fish", home, []byte("complete -c wt")); err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	for _, p := range []string{
+		filepath.Join(home, ".config", "fish", "completions", "wt.fish"),
+		filepath.Join(home, ".config", "fish", "conf.d", "wt.fish"),
+	} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s not written: %v", p, err)
+		}
+	}
+}
+
+func TestDetectShell(t *testing.T) {
+	t.Setenv("SHELL", "/usr/bin/zsh")
+	if sh, err := DetectShell(); err != nil || sh != "zsh" {
+		t.Errorf("DetectShell() = (%q, %v), want zsh", sh, err)
+	}
+
+	t.Setenv("SHELL", "/bin/tcsh")
+	if _, err := DetectShell(); err == nil {
+		t.Error("DetectShell() should reject unsupported shells")
+	}
+}
+
+func TestVerify_Bash(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	clearShellEnv(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	// A stand-in wt whose init output defines the function
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "wt"), []byte("#!/bin/sh\necho 'wt() { :; }'\n"), 0o755)
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	if err := Verify(t.Context(), "bash"); err == nil {
+		t.Error("Verify() should fail before the init line is installed")
+	}
+	if _, err := Install("bash", home, []byte("# completion")); err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if err := Verify(t.Context(), "bash"); err != nil {
+		t.Errorf("Verify() after Install() error: %v", err)
+	}
+}
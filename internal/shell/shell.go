@@ -1,32 +1,243 @@
 package shell
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DirectiveFileEnv names the file the wrapper function reads wt's directives
+// from: a "__wt_cd:<path>" line to change directory to, and "__wt_run:<command>"
+// lines to run, after the cd or, for wt --exec, in its place. Passing them
+// in a file rather than on stdout leaves stdout uncaptured, so interactive
+// commands, their output ordering, and $(wt ...) work as without the wrapper.
+const DirectiveFileEnv = "WT_DIRECTIVE_FILE"
+
+// WrapperVersionEnv names the variable the wrapper function sets to the
+// version of the directive protocol it speaks, so wt can tell a wrapper
+// generated by another version of itself.
+const WrapperVersionEnv = "WT_SHELL_WRAPPER_VERSION"
+
+// WrapperVersion is the directive protocol of the wrappers Generate returns.
+// Version 1 wrappers read the directives from stdout and set no variable;
+// version 2 ones read them from DirectiveFileEnv.
+const WrapperVersion = 2
+
+// Environ returns the environment without DirectiveFileEnv and
+// WrapperVersionEnv, for the commands wt runs: a wt they run in turn would
+// otherwise write its directives to the file of this wt's wrapper.
+func Environ() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if name != DirectiveFileEnv && name != WrapperVersionEnv {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
 
 const bashZshFunc = `wt() {
-  local output
-  output=$(command wt "$@")
-  local exit_code=$?
-  if [[ "$output" == __wt_cd:* ]]; then
-    cd "${output#__wt_cd:}"
-  elif [[ -n "$output" ]]; then
-    echo "$output"
-  fi
+  local directives exit_code line run_status changed_dir=
+  directives=$(mktemp -t wt.XXXXXX) || { command wt "$@"; return; }
+  WT_SHELL_WRAPPER_VERSION=2 WT_DIRECTIVE_FILE="$directives" command wt "$@"
+  exit_code=$?
+  while IFS= read -r line <&3 || [[ -n "$line" ]]; do
+    case "$line" in
+      __wt_cd:*)
+        cd "${line#__wt_cd:}" || { rm -f "$directives"; return 1; }
+        changed_dir=1
+        ;;
+      __wt_run:*)
+        # Post-switch hooks after a cd, or the command of wt --exec in its place
+        eval "${line#__wt_run:}"
+        run_status=$?
+        [[ -n "$changed_dir" ]] || exit_code=$run_status
+        ;;
+    esac
+  done 3< "$directives"
+  rm -f "$directives"
   return $exit_code
 }
 `
 
 const fishFunc = `function wt
-  set -l output (command wt $argv)
+  set -l directives (mktemp -t wt.XXXXXX); or begin
+    command wt $argv
+    return
+  end
+  env WT_SHELL_WRAPPER_VERSION=2 WT_DIRECTIVE_FILE=$directives wt $argv
   set -l exit_code $status
-  if string match -q '__wt_cd:*' $output
-    cd (string replace '__wt_cd:' '' $output)
-  else if test -n "$output"
-    echo $output
+  set -l changed_dir 0
+  for line in (cat $directives)
+    if string match -q '__wt_cd:*' -- $line
+      cd (string replace '__wt_cd:' '' -- $line); or begin
+        rm -f $directives
+        return 1
+      end
+      set changed_dir 1
+    else if string match -q '__wt_run:*' -- $line
+      # Post-switch hooks after a cd, or the command of wt --exec in its place
+      eval (string replace '__wt_run:' '' -- $line)
+      set -l run_status $status
+      test $changed_dir = 1; or set exit_code $run_status
+    end
   end
+  rm -f $directives
   return $exit_code
 end
 `
 
+// zshCompdefGuard registers the completion function in place of the bare
+// compdef call cobra's script starts with. compdef only exists once compinit
+// has run; when wt init is evaluated earlier in .zshrc, registration waits
+// for the first prompt instead of failing.
+const zshCompdefGuard = `if (( $+functions[compdef] )); then
+  compdef _wt wt
+else
+  _wt_compdef() {
+    (( $+functions[compdef] )) && compdef _wt wt
+    add-zsh-hook -d precmd _wt_compdef
+    unfunction _wt_compdef
+  }
+  autoload -Uz add-zsh-hook
+  add-zsh-hook precmd _wt_compdef
+fi`
+
+// GenerateWithCompletion returns the shell function code followed by the
+// completion script, so a single eval line sets up both. For zsh, the script
+// is made safe to evaluate before or after compinit.
+func GenerateWithCompletion(shellName, completion string) (string, error) {
+	code, err := Generate(shellName)
+	if err != nil {
+		return "", err
+	}
+	if shellName == "zsh" {
+		if !strings.Contains(completion, "\ncompdef _wt wt\n") {
+			return "", fmt.Errorf("unexpected zsh completion script: no compdef line")
+		}
+		completion = strings.Replace(completion, "\ncompdef _wt wt\n", "\n"+zshCompdefGuard+"\n", 1)
+	}
+	return code + "\n" + completion, nil
+}
+
+// ErrOutdatedWrapper means the wrapper function was generated by an older wt
+// and needs to be reloaded.
+var ErrOutdatedWrapper = errors.New("the wt shell function is out of date")
+
+// bindKey matches the keys Binding accepts, e.g. "ctrl-g" or "alt-w".
+var bindKey = regexp.MustCompile(`^(ctrl|alt)-([a-z])$`)
+
+// reservedCtrl maps the letters whose ctrl keys a terminal sends as another
+// key or signal to what it sends. Binding them would take over e.g. Enter.
+var reservedCtrl = map[string]string{
+	"m": "Enter",
+	"i": "Tab",
+	"j": "newline",
+	"h": "Backspace",
+	"c": "interrupt",
+	"d": "end of input",
+}
+
+// Binding returns code that binds key, e.g. "ctrl-g", to the interactive
+// selector in the line editor of the given shell, so that choosing a
+// worktree changes into it as wt without arguments does. It relies on the
+// wrapper function of Generate.
+func Binding(shellName, key string) (string, error) {
+	m := bindKey.FindStringSubmatch(strings.ToLower(key))
+	if m == nil {
+		return "", fmt.Errorf("unsupported key %q; use ctrl-<letter> or alt-<letter>", key)
+	}
+	ctrl, letter := m[1] == "ctrl", m[2]
+	if reserved, ok := reservedCtrl[letter]; ctrl && ok {
+		return "", fmt.Errorf("unsupported key %q: terminals send it as %s; choose another letter", key, reserved)
+	}
+	switch shellName {
+	case "bash":
+		seq := `\e` + letter
+		if ctrl {
+			seq = `\C-` + letter
+		}
+		return fmt.Sprintf(bashBinding, seq), nil
+	case "zsh":
+		seq := "^[" + letter
+		if ctrl {
+			seq = "^" + strings.ToUpper(letter)
+		}
+		return fmt.Sprintf(zshBinding, seq), nil
+	case "fish":
+		seq := `\e` + letter
+		if ctrl {
+			seq = `\c` + letter
+		}
+		return fmt.Sprintf(fishBinding, seq, seq), nil
+	default:
+		return "", fmt.Errorf("unsupported shell %q; supported: bash, zsh, fish", shellName)
+	}
+}
+
+// The bindings run the selector through the wrapper function. zsh and fish
+// redraw the prompt afterwards, so it shows the new directory; bash has no
+// way to do so from a key binding, and shows it from the next command on.
+const (
+	bashBinding = `_wt_widget() {
+  wt
+}
+bind -x '"%s": _wt_widget'
+`
+	zshBinding = `_wt_widget() {
+  wt </dev/tty
+  local precmd
+  for precmd in $precmd_functions; do
+    $precmd
+  done
+  zle reset-prompt
+}
+zle -N _wt_widget
+bindkey '%s' _wt_widget
+`
+	fishBinding = `function _wt_widget
+  wt
+  commandline -f repaint
+end
+bind %s _wt_widget
+bind -M insert %s _wt_widget 2>/dev/null
+`
+)
+
+// CheckWrapper reports a wrapper function speaking another directive protocol
+// than this wt, from the WrapperVersionEnv value it set. Without one there is
+// nothing to check: wt runs without a wrapper, e.g. with stdout piped or in a
+// script, or under a version 1 wrapper, which set none and reads the
+// directives from stdout, where they go without a DirectiveFileEnv.
+func CheckWrapper(version string) error {
+	if version == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(version)
+	switch {
+	case err != nil:
+		return fmt.Errorf("invalid %s %q", WrapperVersionEnv, version)
+	case v < WrapperVersion:
+		return fmt.Errorf("%w: it speaks version %d of the directive protocol, this wt version %d", ErrOutdatedWrapper, v, WrapperVersion)
+	case v > WrapperVersion:
+		return fmt.Errorf("wt is older than its shell function: it speaks version %d of the directive protocol, the shell function version %d; update wt", WrapperVersion, v)
+	}
+	return nil
+}
+
+// ReloadCommand returns the command that loads the current wrapper function
+// into a running shell.
+func ReloadCommand(shellName string) string {
+	if shellName == "fish" {
+		return "wt init fish | source"
+	}
+	return fmt.Sprintf("eval \"$(wt init %s)\"", shellName)
+}
+
 // Generate returns the shell function code for the given shell name.
 func Generate(shellName string) (string, error) {
 	switch shellName {
@@ -10,6 +10,12 @@
 package shell
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -93,6 +99,208 @@ func TestGenerate_FishContainsCdLogic(t *testing.T) {
 	}
 }
 
+func TestGenerate_RunsPostSwitchLines(t *testing.T) {
+	for _, sh := range []string{"bash", "fish"} {
+		code, err := Generate(sh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(code, "__wt_run:") || !strings.Contains(code, "eval ") {
+			t.Errorf("%s output does not run __wt_run: lines", sh)
+		}
+	}
+}
+
+// A lone __wt_run: line, from wt --exec, is run without changing directory.
+func TestGenerate_BashRunsExecLine(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	code, err := Generate("bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	os.WriteFile(filepath.Join(bin, "wt"), []byte("#!/bin/sh\necho '__wt_run:echo ran in \"$PWD\"' >> \"$WT_DIRECTIVE_FILE\"\n"), 0o755)
+	dir := t.TempDir()
+	cmd := exec.Command("bash", "-c", code+"\nwt --exec 'echo ran in'")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash failed: %v\n%s", err, out)
+	}
+	if got := strings.TrimSpace(string(out)); got != "ran in "+dir {
+		t.Errorf("output = %q, want the command run in %s", got, dir)
+	}
+}
+
+// Directives come through the file, leaving stdout to the terminal and to
+// command substitution, trailing newlines included.
+func TestGenerate_BashDirectiveFile(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	code, err := Generate("bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin := t.TempDir()
+	target := t.TempDir()
+	script := "#!/bin/sh\n" +
+		"printf 'data\\n\\n'\n" +
+		"[ \"$1\" = switch ] && printf '__wt_cd:%s\\n__wt_run:echo hook in \"$PWD\"\\n' '" + target + "' >> \"$WT_DIRECTIVE_FILE\"\n" +
+		"exit 3\n"
+	os.WriteFile(filepath.Join(bin, "wt"), []byte(script), 0o755)
+	cmd := exec.Command("bash", "-c", code+`
+out=$(wt path x; echo .)
+printf 'captured %q\n' "$out"
+wt switch x
+echo "status $?"
+echo "now in $PWD"
+`)
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), "PATH="+bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash failed: %v\n%s", err, out)
+	}
+	for _, want := range []string{
+		"captured $'data\\n\\n.'",   // stdout of wt is captured as is
+		"data\n\nhook in " + target, // and passes through the wrapper otherwise
+		"status 3",                  // wt's exit status is kept after a cd
+		"now in " + target,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output should contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerate_SetsWrapperVersion(t *testing.T) {
+	want := fmt.Sprintf("%s=%d", WrapperVersionEnv, WrapperVersion)
+	for _, sh := range []string{"bash", "fish"} {
+		code, _ := Generate(sh)
+		if !strings.Contains(code, want) {
+			t.Errorf("%s output should set %s", sh, want)
+		}
+	}
+}
+
+func TestCheckWrapper(t *testing.T) {
+	current := strconv.Itoa(WrapperVersion)
+	tests := []struct {
+		name         string
+		version      string
+		wantErr      bool
+		wantOutdated bool
+	}{
+		{name: "current", version: current},
+		{name: "no wrapper"},
+		{name: "version 1", version: "1", wantErr: true, wantOutdated: true},
+		{name: "newer wrapper", version: strconv.Itoa(WrapperVersion + 1), wantErr: true},
+		{name: "garbage", version: "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckWrapper(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckWrapper() error = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrOutdatedWrapper) != tt.wantOutdated {
+				t.Errorf("CheckWrapper() error = %v, want ErrOutdatedWrapper %v", err, tt.wantOutdated)
+			}
+		})
+	}
+}
+
+func TestEnviron_DropsWrapperVariables(t *testing.T) {
+	t.Setenv(DirectiveFileEnv, "/tmp/wt.1")
+	t.Setenv(WrapperVersionEnv, strconv.Itoa(WrapperVersion))
+	t.Setenv("WT_TEST_KEPT", "1")
+	env := strings.Join(Environ(), "\n")
+	if strings.Contains(env, DirectiveFileEnv) || strings.Contains(env, WrapperVersionEnv) {
+		t.Errorf("Environ() should drop the wrapper's variables, got:\n%s", env)
+	}
+	if !strings.Contains(env, "WT_TEST_KEPT=1") {
+		t.Errorf("Environ() should keep other variables, got:\n%s", env)
+	}
+}
+
+func TestReloadCommand(t *testing.T) {
+	if got := ReloadCommand("zsh"); got != `eval "$(wt init zsh)"` {
+		t.Errorf("ReloadCommand(zsh) = %q", got)
+	}
+	if got := ReloadCommand("fish"); got != "wt init fish | source" {
+		t.Errorf("ReloadCommand(fish) = %q", got)
+	}
+}
+
+func TestGenerateWithCompletion_ZshGuardsCompdef(t *testing.T) {
+	completion := "#compdef wt\ncompdef _wt wt\n\n_wt()\n{\n}\n"
+	code, err := GenerateWithCompletion("zsh", completion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(code, bashZshFunc) {
+		t.Error("output should start with the wrapper function")
+	}
+	if strings.Contains(code, "\ncompdef _wt wt\n") {
+		t.Error("bare compdef call should be replaced")
+	}
+	if !strings.Contains(code, "add-zsh-hook precmd _wt_compdef") || !strings.Contains(code, "_wt()") {
+		t.Errorf("output should defer compdef and keep the completion function, got:\n%s", code)
+	}
+
+	if _, err := GenerateWithCompletion("zsh", "_wt() {}\n"); err == nil {
+		t.Error("a zsh script without compdef line should be rejected")
+	}
+}
+
+func TestBinding(t *testing.T) {
+	tests := []struct {
+		shell, key, want string
+	}{
+		{"bash", "ctrl-g", `bind -x '"\C-g": _wt_widget'`},
+		{"bash", "alt-w", `bind -x '"\ew": _wt_widget'`},
+		{"zsh", "ctrl-g", "bindkey '^G' _wt_widget"},
+		{"zsh", "Alt-W", "bindkey '^[w' _wt_widget"},
+		{"fish", "ctrl-g", `bind \cg _wt_widget`},
+		{"fish", "alt-w", `bind -M insert \ew _wt_widget`},
+	}
+	for _, tt := range tests {
+		code, err := Binding(tt.shell, tt.key)
+		if err != nil {
+			t.Errorf("Binding(%q, %q) error: %v", tt.shell, tt.key, err)
+			continue
+		}
+		if !strings.Contains(code, tt.want) || !strings.Contains(code, "  wt") {
+			t.Errorf("Binding(%q, %q) should run wt and contain %q, got:\n%s", tt.shell, tt.key, tt.want, code)
+		}
+	}
+
+	for _, key := range []string{"", "ctrl-", "ctrl-gg", "shift-g", "ctrl-1", "ctrl-m", "ctrl-i", "ctrl-j", "ctrl-h", "ctrl-c", "Ctrl-D"} {
+		if _, err := Binding("bash", key); err == nil {
+			t.Errorf("Binding(bash, %q) should fail", key)
+		}
+	}
+	if _, err := Binding("powershell", "ctrl-g"); err == nil {
+		t.Error("Binding() should reject an unsupported shell")
+	}
+}
+
+// The binding is only active in interactive shells, where bind works.
+func TestBinding_BashBinds(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	code, _ := Binding("bash", "ctrl-g")
+	out, _ := exec.Command("bash", "--norc", "-i", "-c", code+"\nbind -X").CombinedOutput()
+	if !strings.Contains(string(out), `"\C-g": "_wt_widget"`) {
+		t.Errorf("bash should list the binding, got:\n%s", out)
+	}
+}
+
 func TestGenerate_UnsupportedShell(t *testing.T) {
 	_, err := Generate("powershell")
 	if err == nil {
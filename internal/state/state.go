@@ -0,0 +1,259 @@
+// Package state persists wt metadata for a repository.
+//
+// State is stored as JSON under the repository's shared git directory
+// (.git/wt/state.json). Every read takes a shared advisory lock and every
+// update takes an exclusive one, and writes go through a temporary file that
+// is renamed into place, so concurrent wt invocations cannot corrupt it.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	fileName = "state.json"
+	lockName = "state.lock"
+
+	// currentVersion is the schema version written to new state files.
+	currentVersion = 1
+)
+
+// State is the persisted wt metadata for a repository.
+type State struct {
+	Version int `json:"version"`
+	// LastBase is the base of the most recent wt create --base, reused by
+	// --base -.
+	LastBase string `json:"last_base,omitempty"`
+	// TrustedHooks is the digest of the hook commands, aliases, and editor of
+	// the repository's .wt.toml that the user approved to run, with wt hooks
+	// trust or when asked; changed commands need approval again.
+	TrustedHooks string `json:"trusted_hooks,omitempty"`
+	// Worktrees holds per-worktree metadata keyed by absolute worktree path.
+	Worktrees map[string]*Worktree `json:"worktrees,omitempty"`
+}
+
+// Worktree holds metadata recorded for a single worktree.
+type Worktree struct {
+	Branch   string          `json:"branch,omitempty"`
+	Created  time.Time       `json:"created,omitzero"`
+	LastUsed time.Time       `json:"last_used,omitzero"`
+	Note     string          `json:"note,omitempty"`
+	Flags    map[string]bool `json:"flags,omitempty"`
+	// PR is the number of the pull request the worktree was created for by
+	// wt pr sync.
+	PR int `json:"pr,omitempty"`
+	// Base is the ref a new branch was created from by wt create.
+	Base string `json:"base,omitempty"`
+	// Project is the monorepo project the worktree is a sparse checkout of,
+	// set by wt create --project.
+	Project string `json:"project,omitempty"`
+	// DirName is the directory name chosen with wt create --dir-name, which
+	// wt migrate-layout keeps.
+	DirName string `json:"dir_name,omitempty"`
+	// Alias is a short name the worktree can be switched to by, set with
+	// wt alias.
+	Alias string `json:"alias,omitempty"`
+	// Tags are labels of the worktree's purpose, such as review or hotfix,
+	// set with wt tag, that wt list, wt status, and wt prune filter by.
+	Tags []string `json:"tags,omitempty"`
+	// Env holds environment variables of the worktree, set with wt env set,
+	// that wt exec, wt shell, and hooks export.
+	Env map[string]string `json:"env,omitempty"`
+	// Slot is a small number unique among the worktrees, for deriving ports
+	// and names that must not collide, such as 3000+slot. Zero means none
+	// has been assigned; the main worktree never gets one.
+	Slot int `json:"slot,omitempty"`
+}
+
+// Lookup returns the metadata recorded for the worktree at path, if any.
+func (s *State) Lookup(path string) (*Worktree, bool) {
+	wt, ok := s.Worktrees[path]
+	return wt, ok
+}
+
+// AssignSlot returns the slot of the worktree at path, first giving it the
+// lowest one no other worktree has if it has none. A slot stays with its
+// worktree, and becomes free again once the worktree is forgotten.
+func (s *State) AssignSlot(path string) int {
+	wt := s.Worktree(path)
+	if wt.Slot > 0 {
+		return wt.Slot
+	}
+	taken := make(map[int]bool, len(s.Worktrees))
+	for _, other := range s.Worktrees {
+		taken[other.Slot] = true
+	}
+	wt.Slot = 1
+	for taken[wt.Slot] {
+		wt.Slot++
+	}
+	return wt.Slot
+}
+
+// Aliased returns the path of the worktree with the given alias, if any.
+func (s *State) Aliased(alias string) (string, bool) {
+	if alias == "" {
+		return "", false
+	}
+	for path, wt := range s.Worktrees {
+		if wt.Alias == alias {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Worktree returns the metadata for the worktree at path, creating an empty
+// record if none exists yet.
+func (s *State) Worktree(path string) *Worktree {
+	if s.Worktrees == nil {
+		s.Worktrees = make(map[string]*Worktree)
+	}
+	wt, ok := s.Worktrees[path]
+	if !ok {
+		wt = &Worktree{}
+		s.Worktrees[path] = wt
+	}
+	return wt
+}
+
+// Move re-keys the metadata recorded for the worktree at from to to, after the
+// worktree has been moved.
+func (s *State) Move(from, to string) {
+	if wt, ok := s.Worktrees[from]; ok {
+		delete(s.Worktrees, from)
+		s.Worktrees[to] = wt
+	}
+}
+
+// Forget drops all metadata recorded for the worktree at path.
+func (s *State) Forget(path string) {
+	delete(s.Worktrees, path)
+}
+
+// Store reads and writes the state file in a directory.
+type Store struct {
+	dir string
+}
+
+// New returns a Store backed by the given directory, typically repo.Info.StateDir().
+// The directory is created on first write.
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Load reads the current state under a shared lock.
+// A missing state file yields an empty State.
+func (s *Store) Load() (*State, error) {
+	if _, err := os.Stat(s.dir); errors.Is(err, os.ErrNotExist) {
+		return &State{Version: currentVersion}, nil
+	}
+
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	return s.read()
+}
+
+// Update reads the state under an exclusive lock, applies fn, and atomically
+// writes the result back. If fn returns an error nothing is written.
+func (s *Store) Update(fn func(*State) error) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	st, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := fn(st); err != nil {
+		return err
+	}
+	st.Version = currentVersion
+	return s.write(st)
+}
+
+func (s *Store) lock(exclusive bool) (func(), error) {
+	return Lock(filepath.Join(s.dir, lockName), exclusive)
+}
+
+// Lock takes an advisory lock on the file at path, creating it if needed,
+// and blocks until the lock is acquired. The returned function releases it.
+func Lock(path string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening lock file: %w", err)
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+func (s *Store) read() (*State, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, fileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{Version: currentVersion}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading state: %w", err)
+	}
+
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("parsing state %s: %w", filepath.Join(s.dir, fileName), err)
+	}
+	return &st, nil
+}
+
+func (s *Store) write(st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	return WriteFileAtomic(filepath.Join(s.dir, fileName), append(data, '\n'))
+}
+
+// WriteFileAtomic writes data to a temporary file in the same directory as
+// path and renames it into place, so readers never observe a partial file.
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("syncing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replacing %s: %w", path, err)
+	}
+	return nil
+}
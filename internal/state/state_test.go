@@ -0,0 +1,173 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoad_MissingDirectory(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "wt"))
+
+	st, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(st.Worktrees) != 0 {
+		t.Errorf("expected empty state, got %v", st.Worktrees)
+	}
+}
+
+func TestUpdate_RoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "wt")
+	s := New(dir)
+
+	used := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	err := s.Update(func(st *State) error {
+		wt := st.Worktree("/tmp/repo-worktrees/feature-x")
+		wt.Branch = "feature-x"
+		wt.LastUsed = used
+		wt.Note = "waiting on review"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error: %v", err)
+	}
+
+	st, err := New(dir).Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	wt, ok := st.Lookup("/tmp/repo-worktrees/feature-x")
+	if !ok {
+		t.Fatal("worktree metadata not persisted")
+	}
+	if wt.Branch != "feature-x" || wt.Note != "waiting on review" || !wt.LastUsed.Equal(used) {
+		t.Errorf("unexpected metadata: %+v", wt)
+	}
+}
+
+func TestUpdate_ErrorDiscardsChanges(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "wt"))
+
+	err := s.Update(func(st *State) error {
+		st.Worktree("/discarded")
+		return fmt.Errorf("boom")
+	})
+	if err == nil {
+		t.Fatal("Update() should return the callback error")
+	}
+
+	st, _ := s.Load()
+	if _, ok := st.Lookup("/discarded"); ok {
+		t.Error("changes from a failed update should not be written")
+	}
+}
+
+func TestUpdate_Concurrent(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "wt"))
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// Each goroutine uses its own Store to mimic separate processes.
+			err := New(s.dir).Update(func(st *State) error {
+				st.Worktree(fmt.Sprintf("/wt-%d", i))
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Update() error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	st, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(st.Worktrees) != n {
+		t.Errorf("expected %d worktrees after concurrent updates, got %d", n, len(st.Worktrees))
+	}
+}
+
+func TestForget(t *testing.T) {
+	st := &State{}
+	st.Worktree("/a")
+	st.Forget("/a")
+	if _, ok := st.Lookup("/a"); ok {
+		t.Error("Forget() should remove the worktree record")
+	}
+}
+
+func TestMove(t *testing.T) {
+	st := &State{}
+	st.Worktree("/a").Note = "keep me"
+	st.Move("/a", "/b")
+	if _, ok := st.Lookup("/a"); ok {
+		t.Error("Move() should drop the old key")
+	}
+	if wt, ok := st.Lookup("/b"); !ok || wt.Note != "keep me" {
+		t.Errorf("Move() should keep the metadata under the new key, got %+v", wt)
+	}
+	st.Move("/missing", "/c")
+	if _, ok := st.Lookup("/c"); ok {
+		t.Error("Move() of an unknown path should not create a record")
+	}
+}
+
+func TestAliased(t *testing.T) {
+	st := &State{}
+	st.Worktree("/repo-worktrees/api-gateway").Alias = "api"
+	st.Worktree("/repo-worktrees/web")
+	if path, ok := st.Aliased("api"); !ok || path != "/repo-worktrees/api-gateway" {
+		t.Errorf("Aliased(api) = %q, %v", path, ok)
+	}
+	if _, ok := st.Aliased(""); ok {
+		t.Error("the empty alias should name no worktree")
+	}
+	if _, ok := st.Aliased("web"); ok {
+		t.Error("Aliased(web) should not match a worktree without that alias")
+	}
+}
+
+func TestAssignSlot(t *testing.T) {
+	st := &State{}
+	st.Worktree("/repo") // the main worktree, recorded without a slot
+	a, b := st.AssignSlot("/wt/a"), st.AssignSlot("/wt/b")
+	if a != 1 || b != 2 {
+		t.Fatalf("slots = %d, %d; want 1, 2", a, b)
+	}
+	if again := st.AssignSlot("/wt/a"); again != 1 {
+		t.Errorf("AssignSlot should keep a worktree's slot, got %d", again)
+	}
+
+	// A forgotten worktree's slot is reused before a new one is taken
+	st.Forget("/wt/a")
+	if c := st.AssignSlot("/wt/c"); c != 1 {
+		t.Errorf("AssignSlot = %d, want the freed slot 1", c)
+	}
+	if d := st.AssignSlot("/wt/d"); d != 3 {
+		t.Errorf("AssignSlot = %d, want 3", d)
+	}
+}
+
+func TestWriteFileAtomic_NoTempLeftovers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.json")
+
+	if err := WriteFileAtomic(path, []byte("{}")); err != nil {
+		t.Fatalf("WriteFileAtomic() error: %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "file.json" {
+		t.Errorf("expected only file.json in dir, got %v", entries)
+	}
+}
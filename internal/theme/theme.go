@@ -0,0 +1,158 @@
+// Package theme defines the named color palettes used for styled output and
+// builds the lipgloss styles for the active palette.
+package theme
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Palette holds lipgloss color values ("212", "#268bd2") for each styled
+// element. An empty value means the terminal's default color.
+type Palette struct {
+	Selected  string `toml:"selected"`
+	Dim       string `toml:"dim"`
+	Prompt    string `toml:"prompt"`
+	Highlight string `toml:"highlight"`
+	Main      string `toml:"main"`
+}
+
+// Default is the theme used when none is configured.
+const Default = "default"
+
+// NoColor is the theme that disables all color and emphasis.
+const NoColor = "nocolor"
+
+var themes = map[string]Palette{
+	Default: {
+		Selected:  "212",
+		Dim:       "241",
+		Prompt:    "205",
+		Highlight: "220",
+		Main:      "39",
+	},
+	NoColor: {},
+	"solarized": {
+		Selected:  "#268bd2",
+		Dim:       "#586e75",
+		Prompt:    "#d33682",
+		Highlight: "#b58900",
+		Main:      "#2aa198",
+	},
+}
+
+// Names returns the names of all built-in themes, sorted.
+func Names() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the built-in palette with the given name.
+func Lookup(name string) (Palette, error) {
+	p, ok := themes[name]
+	if !ok {
+		return Palette{}, fmt.Errorf("unknown theme %q; available: %v", name, Names())
+	}
+	return p, nil
+}
+
+// Merge returns p with every non-empty color in overrides applied on top.
+func (p Palette) Merge(overrides Palette) Palette {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&p.Selected, overrides.Selected)
+	set(&p.Dim, overrides.Dim)
+	set(&p.Prompt, overrides.Prompt)
+	set(&p.Highlight, overrides.Highlight)
+	set(&p.Main, overrides.Main)
+	return p
+}
+
+// Styles are the lipgloss styles derived from a palette.
+type Styles struct {
+	Selected  lipgloss.Style
+	Dim       lipgloss.Style
+	Prompt    lipgloss.Style
+	Highlight lipgloss.Style
+	Disabled  lipgloss.Style
+	Main      lipgloss.Style
+	// Tags are the styles worktree tags are shown in; see Tag.
+	Tags []lipgloss.Style
+}
+
+// tagColors are the colors of worktree tags. They are the basic ANSI colors,
+// which suit any palette since the terminal's color scheme defines them.
+var tagColors = []string{"1", "2", "3", "4", "5", "6"}
+
+// Tag returns the style of a worktree tag, picked by its name so that a tag
+// has the same color everywhere.
+func (s Styles) Tag(tag string) lipgloss.Style {
+	if len(s.Tags) == 0 {
+		return s.Dim
+	}
+	h := fnv.New32a()
+	h.Write([]byte(tag))
+	return s.Tags[h.Sum32()%uint32(len(s.Tags))]
+}
+
+var current = build(themes[Default], true)
+
+// Current returns the styles for the active palette.
+func Current() Styles {
+	return current
+}
+
+// Apply activates palette p. When color is false, all styling is disabled
+// and styled output is plain text.
+func Apply(p Palette, color bool) {
+	current = build(p, color)
+}
+
+// Enabled reports whether colored output should be used, honoring the
+// NO_COLOR convention (https://no-color.org).
+func Enabled(noColorFlag bool) bool {
+	return !noColorFlag && os.Getenv("NO_COLOR") == ""
+}
+
+func build(p Palette, color bool) Styles {
+	// All styled output goes to stderr; stdout is captured by the shell wrapper.
+	r := lipgloss.NewRenderer(os.Stderr)
+	if !color {
+		r.SetColorProfile(termenv.Ascii)
+		plain := r.NewStyle()
+		return Styles{plain, plain, plain, plain, plain, plain, nil}
+	}
+
+	fg := func(c string) lipgloss.Style {
+		s := r.NewStyle()
+		if c != "" {
+			s = s.Foreground(lipgloss.Color(c))
+		}
+		return s
+	}
+	tags := make([]lipgloss.Style, len(tagColors))
+	for i, c := range tagColors {
+		tags[i] = fg(c)
+	}
+	return Styles{
+		Selected:  fg(p.Selected).Bold(true),
+		Dim:       fg(p.Dim),
+		Prompt:    fg(p.Prompt),
+		Highlight: fg(p.Highlight).Bold(true),
+		Disabled:  fg(p.Dim).Faint(true),
+		Main:      fg(p.Main),
+		Tags:      tags,
+	}
+}
@@ -0,0 +1,71 @@
+package theme
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"default", "nocolor", "solarized"} {
+		if _, err := Lookup(name); err != nil {
+			t.Errorf("Lookup(%q) error: %v", name, err)
+		}
+	}
+	if _, err := Lookup("neon"); err == nil {
+		t.Error("Lookup(neon) should fail for unknown theme")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	p, _ := Lookup(Default)
+	merged := p.Merge(Palette{Selected: "#ff0000"})
+
+	if merged.Selected != "#ff0000" {
+		t.Errorf("Selected = %q, want override", merged.Selected)
+	}
+	if merged.Dim != p.Dim {
+		t.Errorf("Dim = %q, want unchanged %q", merged.Dim, p.Dim)
+	}
+}
+
+func TestApply_NoColorRendersPlainText(t *testing.T) {
+	t.Cleanup(func() { Apply(themes[Default], true) })
+
+	Apply(themes["solarized"], false)
+	if got := Current().Selected.Render("feature-x"); got != "feature-x" {
+		t.Errorf("Render() with color disabled = %q, want plain text", got)
+	}
+}
+
+func TestEnabled_HonorsNoColorEnv(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	if Enabled(false) {
+		t.Error("Enabled() should be false when NO_COLOR is set")
+	}
+
+	t.Setenv("NO_COLOR", "")
+	if !Enabled(false) {
+		t.Error("Enabled() should be true without NO_COLOR or flag")
+	}
+	if Enabled(true) {
+		t.Error("Enabled(true) should be false when --no-color is given")
+	}
+}
+
+func TestTag(t *testing.T) {
+	t.Cleanup(func() { Apply(themes[Default], true) })
+
+	s := build(themes[Default], true)
+	if a, b := s.Tag("review").GetForeground(), s.Tag("review").GetForeground(); a != b {
+		t.Errorf("Tag(review) colors differ between calls: %v, %v", a, b)
+	}
+	colors := map[any]bool{}
+	for _, tag := range []string{"review", "hotfix", "experiment", "spike", "blocked"} {
+		colors[s.Tag(tag).GetForeground()] = true
+	}
+	if len(colors) < 2 {
+		t.Error("different tags should get different colors")
+	}
+
+	Apply(themes[Default], false)
+	if got := Current().Tag("review").Render("review"); got != "review" {
+		t.Errorf("Tag().Render() with color disabled = %q, want plain text", got)
+	}
+}
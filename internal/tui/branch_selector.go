@@ -5,6 +5,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,8 +16,32 @@ import (
 // BranchEntry represents a branch in the branch selector.
 type BranchEntry struct {
 	Name        string
-	Source      string // "local" or "remote"
+	Source      string // "default", "recent", "local", "remote", "tag", or "ref" (free-text input)
 	HasWorktree bool
+	// Description is the branch description, shown dimmed in a second column.
+	Description string
+	// Date and Author describe the branch's last commit, shown dimmed before
+	// the description. Entries without a commit (tags, refs) leave them zero.
+	Date   time.Time
+	Author string
+}
+
+// sectionTitle names the section an entry is listed under.
+func (e BranchEntry) sectionTitle() string {
+	switch e.Source {
+	case "default":
+		return "Default"
+	case "recent":
+		return "Recent"
+	case "remote":
+		return "Remote"
+	case "tag":
+		return "Tags"
+	case "ref":
+		return "Ref"
+	default:
+		return "Local"
+	}
 }
 
 // filteredBranchEntry holds a BranchEntry along with its fuzzy match result.
@@ -28,7 +53,67 @@ type filteredBranchEntry struct {
 // SelectBranch displays an interactive fuzzy selector for branches.
 // Returns the selected branch name, or empty string if cancelled.
 func SelectBranch(entries []BranchEntry, header string) (string, error) {
+	return runBranchSelector(newBranchModel(entries, header))
+}
+
+// SelectRef is like SelectBranch but additionally offers the typed filter
+// text itself as a ref (e.g. a commit SHA), listed after the matches.
+func SelectRef(entries []BranchEntry, header string) (string, error) {
 	m := newBranchModel(entries, header)
+	m.allowCustom = true
+	return runBranchSelector(m)
+}
+
+// SelectBranchRefreshing is like SelectBranch but shows entries (e.g. from a
+// cache) immediately while refresh loads the current list in the background.
+// The list is replaced once refresh returns; until then the header shows a
+// "refreshing…" indicator.
+func SelectBranchRefreshing(entries []BranchEntry, header string, refresh RefreshBranchesFunc) (string, error) {
+	m := newBranchModel(entries, header)
+	m.refresh = refresh
+	m.refreshing = refresh != nil
+	return runBranchSelector(m)
+}
+
+// RefreshBranchesFunc loads the up-to-date entries for a branch selector.
+type RefreshBranchesFunc func() ([]BranchEntry, error)
+
+// asyncFilterThreshold is the number of candidate entries from which a branch
+// selector scores them in the background, so that typing stays responsive in
+// repositories with tens of thousands of remote branches.
+const asyncFilterThreshold = 5000
+
+// filterDebounce delays background filtering until typing pauses for this
+// long; zero filters after every keystroke. See SetFilterDebounce.
+var filterDebounce time.Duration
+
+// SetFilterDebounce sets how long the branch selectors wait for typing to
+// pause before filtering a large list.
+func SetFilterDebounce(d time.Duration) {
+	filterDebounce = d
+}
+
+// filterTickMsg starts background filtering for a query once the debounce
+// delay has passed without another keystroke.
+type filterTickMsg struct {
+	seq int
+}
+
+// filteredMsg delivers the result of background filtering.
+type filteredMsg struct {
+	seq      int
+	query    string
+	matched  []int
+	filtered []filteredBranchEntry
+}
+
+// branchesMsg delivers the result of a background refresh.
+type branchesMsg struct {
+	entries []BranchEntry
+	err     error
+}
+
+func runBranchSelector(m branchModel) (string, error) {
 	p := tea.NewProgram(m, tea.WithOutput(os.Stderr))
 	finalModel, err := p.Run()
 	if err != nil {
@@ -56,21 +141,27 @@ type branchModel struct {
 	selected  int
 	cancelled bool
 	header    string
+	view      viewport
+	// allowCustom offers the query itself as a selectable "ref" entry.
+	allowCustom bool
+	refresh     RefreshBranchesFunc
+	refreshing  bool
+	// query is the text filtered holds the matches of, and matched the
+	// indices of those matches in entries. A query extending it only needs
+	// to score those entries.
+	query   string
+	matched []int
+	// filterSeq numbers filter requests; background results for an older
+	// one are dropped. filtering is set while one is outstanding, and
+	// requested is the query last filtered or requested, so that only a
+	// changed query is filtered again.
+	filterSeq int
+	filtering bool
+	requested string
 }
 
-var (
-	disabledStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Faint(true)
-	worktreeMarker = dimStyle.Render(" [worktree]")
-)
-
 func newBranchModel(entries []BranchEntry, header string) branchModel {
-	ti := textinput.New()
-	ti.Placeholder = "Type to filter..."
-	ti.Focus()
-	ti.CharLimit = 100
-	ti.Width = 40
-	ti.PromptStyle = promptStyle
-	ti.Prompt = "  "
+	ti := newFilterInput()
 
 	filtered := make([]filteredBranchEntry, len(entries))
 	for i, e := range entries {
@@ -92,21 +183,58 @@ func newBranchModel(entries []BranchEntry, header string) branchModel {
 		textInput: ti,
 		selected:  startIdx,
 		header:    header,
+		view:      newViewport(),
 	}
 }
 
 func (m branchModel) Init() tea.Cmd {
-	return textinput.Blink
+	if m.refresh == nil {
+		return textinput.Blink
+	}
+	refresh := m.refresh
+	return tea.Batch(textinput.Blink, func() tea.Msg {
+		entries, err := refresh()
+		return branchesMsg{entries: entries, err: err}
+	})
 }
 
 func (m branchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case branchesMsg:
+		m.refreshing = false
+		if msg.err == nil {
+			m.replaceEntries(msg.entries)
+		}
+		return m, nil
+	case filterTickMsg:
+		if msg.seq != m.filterSeq {
+			return m, nil // Superseded by a later keystroke
+		}
+		return m, m.filterCmd()
+	case filteredMsg:
+		if msg.seq == m.filterSeq {
+			m.filtering = false
+			m.setFiltered(msg.query, msg.matched, msg.filtered)
+			m.settle()
+		}
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.view.resize(msg.Height)
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			m.cancelled = true
 			return m, tea.Quit
 		case tea.KeyEnter:
+			// The list still shows the matches of an older query while the
+			// current one is filtered in the background; filter it now, so
+			// that the entry chosen is one for what was typed
+			if m.filtering {
+				m.filterSeq++
+				m.filtering = false
+				m.applyFilter()
+				m.settle()
+			}
 			if len(m.filtered) > 0 && !m.filtered[m.selected].HasWorktree {
 				return m, tea.Quit
 			}
@@ -114,33 +242,38 @@ func (m branchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.moveSelection(-1)
 		case tea.KeyDown:
 			m.moveSelection(1)
+		case tea.KeyPgUp:
+			m.jumpSelection(-m.view.height)
+		case tea.KeyPgDown:
+			m.jumpSelection(m.view.height)
+		case tea.KeyTab:
+			m.jumpSection(1)
+			m.view.follow(m.selected, len(m.filtered))
+			return m, nil
+		case tea.KeyShiftTab:
+			m.jumpSection(-1)
+			m.view.follow(m.selected, len(m.filtered))
+			return m, nil
 		}
 	}
 
 	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
+	m.textInput, cmd = updateInput(m.textInput, msg)
 
-	// Filter and score entries
-	query := m.textInput.Value()
-	if query == "" {
-		m.filtered = make([]filteredBranchEntry, len(m.entries))
-		for i, e := range m.entries {
-			m.filtered[i] = filteredBranchEntry{BranchEntry: e}
-		}
-	} else {
-		m.filtered = nil
-		for _, e := range m.entries {
-			match := fuzzy.Score(e.Name, query)
-			if match.Matched {
-				m.filtered = append(m.filtered, filteredBranchEntry{BranchEntry: e, match: match})
-			}
-		}
-		sort.Slice(m.filtered, func(i, j int) bool {
-			return m.filtered[i].match.Score > m.filtered[j].match.Score
-		})
+	if m.textInput.Value() == m.requested {
+		return m, cmd
+	}
+	if filterCmd := m.requestFilter(); filterCmd != nil {
+		return m, tea.Batch(cmd, filterCmd)
 	}
+	m.settle()
+
+	return m, cmd
+}
 
-	// Clamp selection
+// settle clamps the selection to the filtered list, moves it off disabled
+// entries, and scrolls it into view.
+func (m *branchModel) settle() {
 	if m.selected >= len(m.filtered) {
 		m.selected = max(0, len(m.filtered)-1)
 	}
@@ -149,8 +282,145 @@ func (m branchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if len(m.filtered) > 0 && m.filtered[m.selected].HasWorktree {
 		m.moveSelection(1) // Try down first
 	}
+	m.view.follow(m.selected, len(m.filtered))
+}
 
-	return m, cmd
+// replaceEntries swaps in a refreshed entry list, keeping the selection on
+// the same branch when it is still listed.
+func (m *branchModel) replaceEntries(entries []BranchEntry) {
+	var current string
+	if m.selected < len(m.filtered) {
+		current = m.filtered[m.selected].Name
+	}
+	m.entries = entries
+	m.matched = nil // Indices into the old entries
+	m.filterSeq++
+	m.filtering = false
+	m.applyFilter()
+	for i, fe := range m.filtered {
+		if fe.Name == current {
+			m.selected = i
+			break
+		}
+	}
+	m.settle()
+}
+
+// applyFilter scores entries against the query and rebuilds the filtered list.
+func (m *branchModel) applyFilter() {
+	query := m.textInput.Value()
+	m.requested = query
+	matched, filtered := filterBranches(m.entries, m.candidates(query), query, m.allowCustom)
+	m.setFiltered(query, matched, filtered)
+}
+
+// requestFilter filters the entries for the current query: right away for a
+// short list, or else in the background after the debounce delay, returning
+// the command that does so.
+func (m *branchModel) requestFilter() tea.Cmd {
+	query := m.textInput.Value()
+	m.requested = query
+	m.filterSeq++
+	if query == "" || len(m.candidates(query)) < asyncFilterThreshold {
+		m.filtering = false
+		m.applyFilter()
+		return nil
+	}
+	m.filtering = true
+	if filterDebounce > 0 {
+		seq := m.filterSeq
+		return tea.Tick(filterDebounce, func(time.Time) tea.Msg { return filterTickMsg{seq: seq} })
+	}
+	return m.filterCmd()
+}
+
+// filterCmd scores the entries against the current query in the background.
+func (m branchModel) filterCmd() tea.Cmd {
+	entries, query, seq, allowCustom := m.entries, m.textInput.Value(), m.filterSeq, m.allowCustom
+	candidates := m.candidates(query)
+	return func() tea.Msg {
+		matched, filtered := filterBranches(entries, candidates, query, allowCustom)
+		return filteredMsg{seq: seq, query: query, matched: matched, filtered: filtered}
+	}
+}
+
+// candidates returns the indices of the entries that can match query: the
+// matches of the previous query if query extends it, since a pattern only
+// matches where its prefixes do, or else all entries.
+func (m branchModel) candidates(query string) []int {
+	if m.query != "" && m.matched != nil && strings.HasPrefix(query, m.query) {
+		return m.matched
+	}
+	all := make([]int, len(m.entries))
+	for i := range all {
+		all[i] = i
+	}
+	return all
+}
+
+// setFiltered records the result of filtering for query.
+func (m *branchModel) setFiltered(query string, matched []int, filtered []filteredBranchEntry) {
+	m.query, m.matched, m.filtered = query, matched, filtered
+}
+
+// filterBranches scores the candidate entries against query and returns the
+// indices of the matching ones, in entry order, and the filtered list, best
+// match first. It only reads entries, so it may run in the background.
+func filterBranches(entries []BranchEntry, candidates []int, query string, allowCustom bool) ([]int, []filteredBranchEntry) {
+	if query == "" {
+		filtered := make([]filteredBranchEntry, len(entries))
+		for i, e := range entries {
+			filtered[i] = filteredBranchEntry{BranchEntry: e}
+		}
+		return nil, filtered
+	}
+
+	names := make([]string, len(candidates))
+	for i, c := range candidates {
+		names[i] = entries[c].Name
+	}
+	matches := fuzzy.ScoreBatch(names, query)
+
+	matched := []int{}
+	var filtered []filteredBranchEntry
+	exact := false
+	for i, c := range candidates {
+		e := entries[c]
+		if match := matches[i]; match.Matched {
+			matched = append(matched, c)
+			filtered = append(filtered, filteredBranchEntry{BranchEntry: e, match: match})
+		}
+		exact = exact || e.Name == query
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].match.Score > filtered[j].match.Score
+	})
+
+	if allowCustom && !exact {
+		filtered = append(filtered, filteredBranchEntry{BranchEntry: BranchEntry{Name: query, Source: "ref"}})
+	}
+	return matched, filtered
+}
+
+// matchCount returns the number of branches the query matches, leaving out
+// the query itself offered as a ref.
+func (m branchModel) matchCount() int {
+	n := len(m.filtered)
+	if n > 0 && m.filtered[n-1].Source == "ref" {
+		n--
+	}
+	return n
+}
+
+// sectioned reports whether entries span more than one section, in which case
+// the unfiltered list is rendered with section headers.
+func (m branchModel) sectioned() bool {
+	for _, e := range m.entries {
+		if e.sectionTitle() != m.entries[0].sectionTitle() {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *branchModel) moveSelection(dir int) {
@@ -173,21 +443,108 @@ func (m *branchModel) moveSelection(dir int) {
 	}
 }
 
+// jumpSelection moves the selection by delta entries (clamped to the list),
+// then steps onward to the nearest selectable entry.
+func (m *branchModel) jumpSelection(delta int) {
+	if len(m.filtered) == 0 {
+		return
+	}
+	m.selected = max(0, min(len(m.filtered)-1, m.selected+delta))
+	if m.filtered[m.selected].HasWorktree {
+		dir := 1
+		if delta < 0 {
+			dir = -1
+		}
+		m.moveSelection(dir)
+		if m.filtered[m.selected].HasWorktree {
+			m.moveSelection(-dir)
+		}
+	}
+}
+
+// showsSections reports whether the list is currently rendered in sections:
+// only while unfiltered, since matches are ordered by score.
+func (m branchModel) showsSections() bool {
+	return m.textInput.Value() == "" && m.sectioned()
+}
+
+// jumpSection moves the selection to the first selectable entry of the next
+// (dir > 0) or previous (dir < 0) section. Sections without a selectable
+// entry are skipped.
+func (m *branchModel) jumpSection(dir int) {
+	if !m.showsSections() || len(m.filtered) == 0 {
+		return
+	}
+	var starts []int
+	current := 0
+	for i, fe := range m.filtered {
+		if i == 0 || fe.sectionTitle() != m.filtered[i-1].sectionTitle() {
+			starts = append(starts, i)
+		}
+		if i == m.selected {
+			current = len(starts) - 1
+		}
+	}
+	for k := current + dir; k >= 0 && k < len(starts); k += dir {
+		end := len(m.filtered)
+		if k+1 < len(starts) {
+			end = starts[k+1]
+		}
+		for i := starts[k]; i < end; i++ {
+			if !m.filtered[i].HasWorktree {
+				m.selected = i
+				return
+			}
+		}
+	}
+}
+
 func (m branchModel) View() string {
 	var b strings.Builder
 
 	b.WriteString("\n")
 	b.WriteString(promptStyle.Render("  " + m.header))
+	if m.refreshing {
+		b.WriteString(dimStyle.Render("  refreshing…"))
+	}
+	if m.filtering {
+		b.WriteString(dimStyle.Render("  filtering…"))
+	}
 	b.WriteString("\n\n")
-	b.WriteString(m.textInput.View())
+	b.WriteString(inputView(m.textInput, m.matchCount(), len(m.entries)))
 	b.WriteString("\n\n")
 
 	hasQuery := m.textInput.Value() != ""
+	sections := m.showsSections()
 
-	for i, fe := range m.filtered {
+	start, end := m.view.bounds(len(m.filtered))
+	nameWidth := 0
+	for _, fe := range m.filtered[start:end] {
+		nameWidth = max(nameWidth, lipgloss.Width(fe.Name))
+	}
+	commits := commitColumns(m.filtered[start:end], time.Now())
+	for i := start; i < end; i++ {
+		fe := m.filtered[i]
+		desc := ""
+		if commits[i-start] != "" || fe.Description != "" {
+			desc = strings.Repeat(" ", nameWidth-lipgloss.Width(fe.Name)) + commits[i-start] + descriptionText(fe.Description)
+		}
+		if sections && (i == start || fe.sectionTitle() != m.filtered[i-1].sectionTitle()) {
+			b.WriteString(dimStyle.Render("  ── " + fe.sectionTitle() + " ──"))
+			b.WriteString("\n")
+		}
+		if fe.Source == "ref" {
+			label := fmt.Sprintf("use %q as ref", fe.Name)
+			if i == m.selected {
+				b.WriteString(fmt.Sprintf("%s%s\n", selectedStyle.Render("> "), selectedStyle.Render(label)))
+			} else {
+				b.WriteString(fmt.Sprintf("  %s\n", dimStyle.Render(label)))
+			}
+			continue
+		}
 		if fe.HasWorktree {
 			// Disabled entry: dimmed with marker
-			b.WriteString(fmt.Sprintf("  %s%s\n", disabledStyle.Render(fe.Name), worktreeMarker))
+			b.WriteString(fmt.Sprintf("  %s%s%s\n", disabledStyle.Render(fe.Name), dimStyle.Render(" [worktree]"), desc))
 			continue
 		}
 
@@ -201,25 +558,94 @@ func (m branchModel) View() string {
 			} else {
 				nameText = selectedStyle.Render(fe.Name)
 			}
-			b.WriteString(fmt.Sprintf("%s%s\n", cursor, nameText))
+			b.WriteString(fmt.Sprintf("%s%s%s\n", cursor, nameText, desc))
 		} else {
 			if hasQuery && fe.match.Positions != nil {
 				nameText = highlightBranch(fe.Name, fe.match.Positions, lipgloss.NewStyle(), highlightStyle)
 			} else {
 				nameText = fe.Name
 			}
-			b.WriteString(fmt.Sprintf("  %s\n", nameText))
+			b.WriteString(fmt.Sprintf("  %s%s\n", nameText, desc))
 		}
 	}
 
 	if len(m.filtered) == 0 {
 		b.WriteString(dimStyle.Render("  No matches"))
 		b.WriteString("\n")
+	} else {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %d of %d", m.selected+1, len(m.filtered))))
+		b.WriteString("\n")
 	}
 
+	help := "  ↑/↓ navigate • pgup/pgdn page • ctrl+u clear • enter select • esc cancel"
+	if sections {
+		help = "  ↑/↓ navigate • pgup/pgdn page • tab/shift+tab section • ctrl+u clear • enter select • esc cancel"
+	}
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  ↑/↓ navigate • enter select • esc cancel"))
+	b.WriteString(dimStyle.Render(help))
 	b.WriteString("\n")
 
 	return b.String()
 }
+
+// maxAuthorWidth caps the author column of the branch selector.
+const maxAuthorWidth = 20
+
+// commitColumns renders the age and author of each entry's last commit as
+// dimmed, aligned columns. Entries without a commit get blank columns, and
+// when none has one, no columns are shown.
+func commitColumns(entries []filteredBranchEntry, now time.Time) []string {
+	ages := make([]string, len(entries))
+	authors := make([]string, len(entries))
+	ageWidth, authorWidth := 0, 0
+	for i, fe := range entries {
+		if fe.Date.IsZero() {
+			continue
+		}
+		ages[i] = relativeTime(fe.Date, now)
+		authors[i] = fe.Author
+		if runes := []rune(fe.Author); len(runes) > maxAuthorWidth {
+			authors[i] = string(runes[:maxAuthorWidth-1]) + "…"
+		}
+		ageWidth = max(ageWidth, lipgloss.Width(ages[i]))
+		authorWidth = max(authorWidth, lipgloss.Width(authors[i]))
+	}
+	cols := make([]string, len(entries))
+	if ageWidth == 0 {
+		return cols
+	}
+	for i := range entries {
+		age := ages[i] + strings.Repeat(" ", ageWidth-lipgloss.Width(ages[i]))
+		author := authors[i] + strings.Repeat(" ", authorWidth-lipgloss.Width(authors[i]))
+		cols[i] = "  " + dimStyle.Render(age+"  "+author)
+	}
+	return cols
+}
+
+// relativeTime renders how long before now t was, e.g. "3 days ago".
+func relativeTime(t, now time.Time) string {
+	const day = 24 * time.Hour
+	d := now.Sub(t)
+	var n int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case d < day:
+		n, unit = int(d/time.Hour), "hour"
+	case d < 14*day:
+		n, unit = int(d/day), "day"
+	case d < 60*day:
+		n, unit = int(d/(7*day)), "week"
+	case d < 365*day:
+		n, unit = int(d/(30*day)), "month"
+	default:
+		n, unit = int(d/(365*day)), "year"
+	}
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s ago", n, unit)
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// clearQueryKey empties the filter input wherever the cursor is.
+var clearQueryKey = key.NewBinding(key.WithKeys("ctrl+u"))
+
+// inputKeys are the readline-style editing keys of the filter input. They are
+// spelled out rather than taken from textinput's defaults so that both
+// selectors keep the same keys, and so that keys the selectors use, such as
+// tab and the arrows, never reach the suggestion bindings.
+var inputKeys = textinput.KeyMap{
+	CharacterForward:        key.NewBinding(key.WithKeys("right", "ctrl+f")),
+	CharacterBackward:       key.NewBinding(key.WithKeys("left", "ctrl+b")),
+	WordForward:             key.NewBinding(key.WithKeys("alt+f", "alt+right", "ctrl+right")),
+	WordBackward:            key.NewBinding(key.WithKeys("alt+b", "alt+left", "ctrl+left")),
+	DeleteWordBackward:      key.NewBinding(key.WithKeys("ctrl+w", "alt+backspace")),
+	DeleteWordForward:       key.NewBinding(key.WithKeys("alt+d", "alt+delete")),
+	DeleteAfterCursor:       key.NewBinding(key.WithKeys("ctrl+k")),
+	DeleteBeforeCursor:      key.NewBinding(key.WithDisabled()),
+	DeleteCharacterBackward: key.NewBinding(key.WithKeys("backspace", "ctrl+h")),
+	DeleteCharacterForward:  key.NewBinding(key.WithKeys("delete", "ctrl+d")),
+	LineStart:               key.NewBinding(key.WithKeys("home", "ctrl+a")),
+	LineEnd:                 key.NewBinding(key.WithKeys("end", "ctrl+e")),
+	Paste:                   key.NewBinding(key.WithKeys("ctrl+v")),
+	AcceptSuggestion:        key.NewBinding(key.WithDisabled()),
+	NextSuggestion:          key.NewBinding(key.WithDisabled()),
+	PrevSuggestion:          key.NewBinding(key.WithDisabled()),
+}
+
+// newFilterInput returns the focused text input the selectors filter by.
+func newFilterInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "Type to filter..."
+	ti.Focus()
+	ti.CharLimit = 100
+	ti.Width = 40
+	ti.PromptStyle = promptStyle
+	ti.Prompt = "  "
+	ti.KeyMap = inputKeys
+	return ti
+}
+
+// updateInput passes msg on to the filter input; ctrl+u clears the query.
+func updateInput(ti textinput.Model, msg tea.Msg) (textinput.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok && key.Matches(msg, clearQueryKey) {
+		ti.Reset()
+		return ti, nil
+	}
+	return ti.Update(msg)
+}
+
+// inputView renders the filter input with the number of matches next to it.
+func inputView(ti textinput.Model, matches, total int) string {
+	return ti.View() + dimStyle.Render(fmt.Sprintf("  %d/%d", matches, total))
+}
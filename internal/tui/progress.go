@@ -0,0 +1,285 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ErrInterrupted is returned by Progress when the user cancels with ctrl-c.
+var ErrInterrupted = errors.New("interrupted")
+
+// Step is one unit of work shown by Progress. A step without Run is shown as
+// already done, for work that happened before the view started.
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, out io.Writer) error
+}
+
+// progressLogLines is how many trailing lines of output the log area shows.
+const progressLogLines = 10
+
+// Progress runs steps in order while showing the state of each, and stops at
+// the first failure, returning its error. Output written by the steps streams
+// into a log area below them that l collapses and expands; it is left
+// expanded after a failure. ctrl-c cancels the running step's context and
+// returns ErrInterrupted once the step has stopped; cancelling ctx stops the
+// running step the same way.
+func Progress(ctx context.Context, title string, steps []Step) error {
+	m := newProgressModel(ctx, title, steps)
+	p := tea.NewProgram(m, tea.WithOutput(os.Stderr))
+	finalModel, err := p.Run()
+	if err != nil {
+		return fmt.Errorf("running progress view: %w", err)
+	}
+	return finalModel.(progressModel).err
+}
+
+type stepState int
+
+const (
+	stepPending stepState = iota
+	stepRunning
+	stepDone
+	stepFailed
+	stepSkipped
+)
+
+type progressModel struct {
+	title   string
+	steps   []Step
+	states  []stepState
+	out     *logWriter
+	log     []string
+	showLog bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    bool
+	err     error
+}
+
+// stepDoneMsg reports that the step at index finished.
+type stepDoneMsg struct {
+	index int
+	err   error
+}
+
+// logMsg carries one line of step output.
+type logMsg string
+
+func newProgressModel(parent context.Context, title string, steps []Step) progressModel {
+	ctx, cancel := context.WithCancel(parent)
+	return progressModel{
+		title:   title,
+		steps:   steps,
+		states:  make([]stepState, len(steps)),
+		out:     newLogWriter(),
+		showLog: true,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return tea.Batch(m.out.next(), m.startNext())
+}
+
+// startNext runs the first pending step, or quits when none is left. Steps
+// without Run are marked done on the way.
+func (m progressModel) startNext() tea.Cmd {
+	for i, st := range m.states {
+		if st != stepPending {
+			continue
+		}
+		if m.steps[i].Run == nil {
+			m.states[i] = stepDone
+			continue
+		}
+		m.states[i] = stepRunning
+		run, ctx, out := m.steps[i].Run, m.ctx, m.out
+		return func() tea.Msg {
+			err := run(ctx, out)
+			out.flush()
+			return stepDoneMsg{index: i, err: err}
+		}
+	}
+	return finish(nil)
+}
+
+// finish stops the view, recording err as the result.
+func finish(err error) tea.Cmd {
+	return func() tea.Msg { return finishMsg{err: err} }
+}
+
+// finishMsg ends the view with a result.
+type finishMsg struct{ err error }
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			m.cancel()
+			if !m.running() {
+				m.err = ErrInterrupted
+				m.done = true
+				return m, tea.Quit
+			}
+			m.err = ErrInterrupted
+		case "l":
+			m.showLog = !m.showLog
+		}
+	case logMsg:
+		m.appendLog(string(msg))
+		return m, m.out.next()
+	case stepDoneMsg:
+		m.drainLog()
+		if msg.err != nil {
+			m.states[msg.index] = stepFailed
+			for i := msg.index + 1; i < len(m.states); i++ {
+				m.states[i] = stepSkipped
+			}
+			if m.err == nil {
+				m.err = msg.err
+			}
+			m.showLog = true
+			return m, finish(m.err)
+		}
+		m.states[msg.index] = stepDone
+		if m.err != nil {
+			// Interrupted while this step was finishing
+			return m, finish(m.err)
+		}
+		return m, m.startNext()
+	case finishMsg:
+		m.cancel()
+		m.done = true
+		if m.err == nil {
+			m.err = msg.err
+		}
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m progressModel) running() bool {
+	for _, st := range m.states {
+		if st == stepRunning {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *progressModel) appendLog(line string) {
+	m.log = append(m.log, line)
+	if len(m.log) > progressLogLines {
+		m.log = m.log[len(m.log)-progressLogLines:]
+	}
+}
+
+// drainLog moves output already written by a finished step into the log, so
+// it is complete before the next step starts or the view ends.
+func (m *progressModel) drainLog() {
+	for {
+		select {
+		case line := <-m.out.lines:
+			m.appendLog(line)
+		default:
+			return
+		}
+	}
+}
+
+func (m progressModel) View() string {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(promptStyle.Render("  " + m.title))
+	b.WriteString("\n\n")
+
+	for i, step := range m.steps {
+		switch m.states[i] {
+		case stepDone:
+			b.WriteString(fmt.Sprintf("  %s %s\n", selectedStyle.Render("✓"), step.Name))
+		case stepRunning:
+			b.WriteString(fmt.Sprintf("  %s %s%s\n", highlightStyle.Render("…"), step.Name, dimStyle.Render("  running")))
+		case stepFailed:
+			b.WriteString(fmt.Sprintf("  %s %s\n", highlightStyle.Render("✗"), step.Name))
+		default:
+			b.WriteString(dimStyle.Render(fmt.Sprintf("  · %s", step.Name)))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.showLog && len(m.log) > 0 {
+		b.WriteString("\n")
+		for _, line := range m.log {
+			b.WriteString(dimStyle.Render("  │ " + line))
+			b.WriteString("\n")
+		}
+	}
+
+	if !m.done {
+		b.WriteString("\n")
+		help := "  l show/hide output • ctrl+c cancel"
+		if m.err != nil {
+			help = "  cancelling…"
+		}
+		b.WriteString(dimStyle.Render(help))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// logWriter splits step output into lines for the log area. Carriage
+// returns also end a line, so progress bars show up as their latest state.
+type logWriter struct {
+	mu      sync.Mutex
+	partial string
+	lines   chan string
+}
+
+func newLogWriter() *logWriter {
+	return &logWriter{lines: make(chan string, 256)}
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	text := w.partial + string(p)
+	for {
+		i := strings.IndexAny(text, "\r\n")
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimRight(text[:i], " \t"); line != "" {
+			w.lines <- line
+		}
+		text = text[i+1:]
+	}
+	w.partial = text
+	return len(p), nil
+}
+
+// flush emits a trailing line that did not end in a newline.
+func (w *logWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.partial != "" {
+		w.lines <- w.partial
+		w.partial = ""
+	}
+}
+
+// next waits for the next line of output.
+func (w *logWriter) next() tea.Cmd {
+	return func() tea.Msg { return logMsg(<-w.lines) }
+}
@@ -0,0 +1,118 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runProgress drives a progress model to completion without a terminal,
+// feeding each command's message back into Update.
+func runProgress(t *testing.T, m progressModel) progressModel {
+	t.Helper()
+	cmd := m.startNext()
+	for i := 0; cmd != nil; i++ {
+		if i > 100 {
+			t.Fatal("progress model did not finish")
+		}
+		msg := cmd()
+		if _, ok := msg.(tea.QuitMsg); ok {
+			break
+		}
+		var next tea.Model
+		next, cmd = m.Update(msg)
+		m = next.(progressModel)
+	}
+	return m
+}
+
+func TestProgress_RunsStepsInOrder(t *testing.T) {
+	var order []string
+	step := func(name string) Step {
+		return Step{Name: name, Run: func(ctx context.Context, out io.Writer) error {
+			order = append(order, name)
+			fmt.Fprintf(out, "output of %s\n", name)
+			return nil
+		}}
+	}
+	m := runProgress(t, newProgressModel(t.Context(), "Creating feat", []Step{
+		{Name: "Create worktree"},
+		step("npm ci"),
+		step("make"),
+	}))
+
+	if m.err != nil {
+		t.Fatalf("err = %v, want nil", m.err)
+	}
+	if strings.Join(order, ",") != "npm ci,make" {
+		t.Errorf("steps ran as %v", order)
+	}
+	view := m.View()
+	if strings.Count(view, "✓") != 3 {
+		t.Errorf("View() should mark all three steps done, got:\n%s", view)
+	}
+	if !strings.Contains(view, "output of make") {
+		t.Errorf("View() should show step output, got:\n%s", view)
+	}
+	if strings.Contains(view, "ctrl+c") {
+		t.Error("View() should drop the help line once done")
+	}
+}
+
+func TestProgress_StopsAtFailure(t *testing.T) {
+	ran := false
+	m := newProgressModel(t.Context(), "Creating feat", []Step{
+		{Name: "npm ci", Run: func(ctx context.Context, out io.Writer) error {
+			io.WriteString(out, "npm ERR! missing lockfile")
+			return errors.New("exit status 1")
+		}},
+		{Name: "make", Run: func(ctx context.Context, out io.Writer) error {
+			ran = true
+			return nil
+		}},
+	})
+	m.showLog = false
+	m = runProgress(t, m)
+
+	if ran {
+		t.Error("steps after a failure should not run")
+	}
+	if m.err == nil || m.err.Error() != "exit status 1" {
+		t.Errorf("err = %v, want the failing step's error", m.err)
+	}
+	view := m.View()
+	if !strings.Contains(view, "✗ npm ci") || !strings.Contains(view, "npm ERR! missing lockfile") {
+		t.Errorf("View() should mark the failure and expand its output, got:\n%s", view)
+	}
+}
+
+func TestProgress_ToggleLog(t *testing.T) {
+	m := newProgressModel(t.Context(), "Creating feat", []Step{{Name: "npm ci"}})
+	m.log = []string{"added 12 packages"}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	if strings.Contains(updated.(progressModel).View(), "added 12 packages") {
+		t.Error("l should collapse the log")
+	}
+}
+
+func TestLogWriter_SplitsLines(t *testing.T) {
+	w := newLogWriter()
+	io.WriteString(w, "one\ntw")
+	io.WriteString(w, "o\r50%\r100%")
+	w.flush()
+	close(w.lines)
+
+	var got []string
+	for line := range w.lines {
+		got = append(got, line)
+	}
+	if strings.Join(got, "|") != "one|two|50%|100%" {
+		t.Errorf("lines = %q", got)
+	}
+}
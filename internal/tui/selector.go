@@ -17,6 +17,42 @@ type Entry struct {
 	Branch string
 	Path   string
 	Rel    string
+	// Alias is the worktree's short name, shown dimmed after the branch and
+	// matched by the filter like it.
+	Alias string
+	// Description is the branch description, shown dimmed after the path.
+	Description string
+	// Tags are the worktree's tags, shown as colored chips after the branch.
+	Tags []string
+	// Current marks the worktree the user is in.
+	Current bool
+	// Dimmed renders the entry like a disabled one, for worktrees that are
+	// pointless to pick, such as the current one when switching. It can still
+	// be selected.
+	Dimmed bool
+	// Status is filled in asynchronously once loaded; nil until then.
+	Status *Status
+}
+
+// Status is the working-tree state shown next to a selector entry.
+type Status struct {
+	Dirty  bool
+	Ahead  int
+	Behind int
+	// Failed is set when the status could not be determined.
+	Failed bool
+}
+
+// StatusFunc loads the status of the worktree at path.
+type StatusFunc func(path string) (Status, error)
+
+// statusWorkers bounds how many statuses are loaded at once.
+const statusWorkers = 8
+
+// statusMsg delivers the loaded status of the worktree at path.
+type statusMsg struct {
+	path   string
+	status Status
 }
 
 // filteredEntry holds an Entry along with its fuzzy match result for rendering.
@@ -26,9 +62,13 @@ type filteredEntry struct {
 }
 
 // Select displays an interactive fuzzy selector and returns the selected worktree path.
-// Returns empty string if the user cancels.
-func Select(entries []Entry) (string, error) {
+// Returns empty string if the user cancels. The cursor starts on the entry
+// whose path is cursor, or on the first one. If load is non-nil, each entry's
+// status is loaded concurrently after the selector first renders.
+func Select(entries []Entry, cursor string, load StatusFunc) (string, error) {
 	m := newModel(entries)
+	m.preselect(cursor)
+	m.load = load
 	p := tea.NewProgram(m, tea.WithOutput(os.Stderr))
 	finalModel, err := p.Run()
 	if err != nil {
@@ -51,23 +91,12 @@ type model struct {
 	textInput textinput.Model
 	selected  int
 	cancelled bool
+	view      viewport
+	load      StatusFunc
 }
 
-var (
-	selectedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
-	dimStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
-	promptStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
-	highlightStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("220")).Bold(true)
-)
-
 func newModel(entries []Entry) model {
-	ti := textinput.New()
-	ti.Placeholder = "Type to filter..."
-	ti.Focus()
-	ti.CharLimit = 100
-	ti.Width = 40
-	ti.PromptStyle = promptStyle
-	ti.Prompt = "  "
+	ti := newFilterInput()
 
 	// Build initial filtered list with no scoring
 	filtered := make([]filteredEntry, len(entries))
@@ -80,15 +109,71 @@ func newModel(entries []Entry) model {
 		filtered:  filtered,
 		textInput: ti,
 		selected:  0,
+		view:      newViewport(),
+	}
+}
+
+// preselect moves the cursor to the entry at path, if there is one.
+func (m *model) preselect(path string) {
+	for i, fe := range m.filtered {
+		if fe.Path == path {
+			m.selected = i
+			m.view.follow(m.selected, len(m.filtered))
+			return
+		}
 	}
 }
 
 func (m model) Init() tea.Cmd {
-	return textinput.Blink
+	return tea.Batch(textinput.Blink, m.loadStatuses())
+}
+
+// loadStatuses returns commands that load every entry's status, at most
+// statusWorkers at a time.
+func (m model) loadStatuses() tea.Cmd {
+	if m.load == nil {
+		return nil
+	}
+	sem := make(chan struct{}, statusWorkers)
+	cmds := make([]tea.Cmd, len(m.entries))
+	for i, e := range m.entries {
+		path, load := e.Path, m.load
+		cmds[i] = func() tea.Msg {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			st, err := load(path)
+			if err != nil {
+				st = Status{Failed: true}
+			}
+			return statusMsg{path: path, status: st}
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// setStatus records a loaded status on the matching entries.
+func (m *model) setStatus(msg statusMsg) {
+	for i := range m.entries {
+		if m.entries[i].Path == msg.path {
+			st := msg.status
+			m.entries[i].Status = &st
+		}
+	}
+	for i := range m.filtered {
+		if m.filtered[i].Path == msg.path {
+			st := msg.status
+			m.filtered[i].Status = &st
+		}
+	}
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case statusMsg:
+		m.setStatus(msg)
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.view.resize(msg.Height)
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC, tea.KeyEsc:
@@ -106,11 +191,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.selected < len(m.filtered)-1 {
 				m.selected++
 			}
+		case tea.KeyPgUp:
+			m.selected = max(0, m.selected-m.view.height)
+		case tea.KeyPgDown:
+			m.selected = max(0, min(len(m.filtered)-1, m.selected+m.view.height))
 		}
 	}
 
 	var cmd tea.Cmd
-	m.textInput, cmd = m.textInput.Update(msg)
+	m.textInput, cmd = updateInput(m.textInput, msg)
 
 	// Filter and score entries
 	query := m.textInput.Value()
@@ -120,9 +209,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.filtered[i] = filteredEntry{Entry: e}
 		}
 	} else {
+		branches := make([]string, len(m.entries))
+		aliases := make([]string, len(m.entries))
+		for i, e := range m.entries {
+			branches[i], aliases[i] = e.Branch, e.Alias
+		}
+		branchMatches := fuzzy.ScoreBatch(branches, query)
+		aliasMatches := fuzzy.ScoreBatch(aliases, query)
+
 		m.filtered = nil
-		for _, e := range m.entries {
-			match := fuzzy.Score(e.Branch, query)
+		for i, e := range m.entries {
+			match := branchMatches[i]
+			if alias := aliasMatches[i]; alias.Matched && e.Alias != "" && (!match.Matched || alias.Score > match.Score) {
+				// Positions refer to the branch, so none are highlighted
+				match = fuzzy.Match{Score: alias.Score, Matched: true}
+			}
 			if match.Matched {
 				m.filtered = append(m.filtered, filteredEntry{Entry: e, match: match})
 			}
@@ -137,6 +238,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if m.selected >= len(m.filtered) {
 		m.selected = max(0, len(m.filtered)-1)
 	}
+	m.view.follow(m.selected, len(m.filtered))
 
 	return m, cmd
 }
@@ -147,15 +249,26 @@ func (m model) View() string {
 	b.WriteString("\n")
 	b.WriteString(promptStyle.Render("  Worktrees"))
 	b.WriteString("\n\n")
-	b.WriteString(m.textInput.View())
+	b.WriteString(inputView(m.textInput, len(m.filtered), len(m.entries)))
 	b.WriteString("\n\n")
 
 	hasQuery := m.textInput.Value() != ""
 
-	for i, fe := range m.filtered {
+	start, end := m.view.bounds(len(m.filtered))
+	for i := start; i < end; i++ {
+		fe := m.filtered[i]
 		cursor := "  "
 		var branchText string
-		pathText := dimStyle.Render(fe.Rel)
+		pathText := statusText(fe.Status) + dimStyle.Render(fe.Rel) + descriptionText(fe.Description)
+		if len(fe.Tags) > 0 {
+			pathText = TagChips(fe.Tags, true) + "  " + pathText
+		}
+		if fe.Alias != "" {
+			pathText = dimStyle.Render("("+fe.Alias+")") + "  " + pathText
+		}
+		if fe.Current {
+			pathText = dimStyle.Render("(current)") + "  " + pathText
+		}
 
 		if i == m.selected {
 			cursor = selectedStyle.Render("> ")
@@ -166,10 +279,14 @@ func (m model) View() string {
 			}
 			b.WriteString(fmt.Sprintf("%s%s  %s\n", cursor, branchText, pathText))
 		} else {
+			base := lipgloss.NewStyle()
+			if fe.Dimmed {
+				base = disabledStyle
+			}
 			if hasQuery && fe.match.Positions != nil {
-				branchText = highlightBranch(fe.Branch, fe.match.Positions, lipgloss.NewStyle(), highlightStyle)
+				branchText = highlightBranch(fe.Branch, fe.match.Positions, base, highlightStyle)
 			} else {
-				branchText = fe.Branch
+				branchText = base.Render(fe.Branch)
 			}
 			b.WriteString(fmt.Sprintf("  %s  %s\n", branchText, pathText))
 		}
@@ -178,15 +295,74 @@ func (m model) View() string {
 	if len(m.filtered) == 0 {
 		b.WriteString(dimStyle.Render("  No matches"))
 		b.WriteString("\n")
+	} else {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  %d of %d", m.selected+1, len(m.filtered))))
+		b.WriteString("\n")
 	}
 
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("  ↑/↓ navigate • enter select • esc cancel"))
+	b.WriteString(dimStyle.Render("  ↑/↓ navigate • pgup/pgdn page • ctrl+u clear • enter select • esc cancel"))
 	b.WriteString("\n")
 
 	return b.String()
 }
 
+// statusText renders a loaded status as a dirty dot and ahead/behind counts,
+// followed by a separator. It is empty until the status has loaded.
+func statusText(st *Status) string {
+	if st == nil {
+		return ""
+	}
+	if st.Failed {
+		return dimStyle.Render("?") + "  "
+	}
+	var parts []string
+	if st.Dirty {
+		parts = append(parts, highlightStyle.Render("●"))
+	}
+	if st.Ahead > 0 {
+		parts = append(parts, dimStyle.Render(fmt.Sprintf("↑%d", st.Ahead)))
+	}
+	if st.Behind > 0 {
+		parts = append(parts, dimStyle.Render(fmt.Sprintf("↓%d", st.Behind)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, " ") + "  "
+}
+
+// TagChips renders tags as chips, in the color of each tag when color is set.
+// The selectors and wt's tables show tags this way.
+func TagChips(tags []string, color bool) string {
+	chips := make([]string, len(tags))
+	for i, tag := range tags {
+		chips[i] = "[" + tag + "]"
+		if color {
+			chips[i] = tagStyle(tag).Render(chips[i])
+		}
+	}
+	return strings.Join(chips, " ")
+}
+
+// maxDescriptionWidth caps how much of a branch description a selector row
+// shows.
+const maxDescriptionWidth = 60
+
+// descriptionText renders the first line of a branch description, dimmed and
+// preceded by a separator. It is empty when there is no description.
+func descriptionText(desc string) string {
+	desc, _, _ = strings.Cut(desc, "\n")
+	desc = strings.TrimSpace(desc)
+	if desc == "" {
+		return ""
+	}
+	if runes := []rune(desc); len(runes) > maxDescriptionWidth {
+		desc = string(runes[:maxDescriptionWidth-1]) + "…"
+	}
+	return "  " + dimStyle.Render(desc)
+}
+
 // highlightBranch renders a branch name with matched positions highlighted.
 func highlightBranch(branch string, positions []int, baseStyle, hlStyle lipgloss.Style) string {
 	posSet := make(map[int]bool, len(positions))
@@ -12,8 +12,11 @@
 package tui
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/provenimpact/wt/internal/fuzzy"
@@ -158,6 +161,116 @@ func TestModelUpdate_NavigateUpDown(t *testing.T) {
 	}
 }
 
+func TestModel_PreselectsCurrentWorktree(t *testing.T) {
+	entries := []Entry{
+		{Branch: "a", Path: "/a", Rel: "a"},
+		{Branch: "b", Path: "/b", Rel: "b", Current: true},
+		{Branch: "c", Path: "/c", Rel: "c"},
+	}
+
+	m := newModel(entries)
+	m.preselect("/b")
+	if m.selected != 1 {
+		t.Errorf("selected = %d, want 1 (the current worktree)", m.selected)
+	}
+	if view := m.View(); !strings.Contains(view, "(current)") {
+		t.Errorf("View() should mark the current worktree:\n%s", view)
+	}
+
+	entries[1].Dimmed = true
+	m = newModel(entries)
+	if view := m.View(); !strings.Contains(view, "b") {
+		t.Errorf("View() should still list a dimmed entry:\n%s", view)
+	}
+	m.preselect("/gone")
+	if m.selected != 0 {
+		t.Errorf("selected = %d, want 0 for an unknown path", m.selected)
+	}
+}
+
+// Aliases are shown next to the branch and matched by the filter.
+func TestModel_FiltersByAlias(t *testing.T) {
+	entries := []Entry{
+		{Branch: "feature/api-gateway-rewrite", Path: "/tmp/wt/gw", Rel: "wt/gw", Alias: "gw"},
+		{Branch: "fix/login", Path: "/tmp/wt/login", Rel: "wt/login"},
+	}
+	m := newModel(entries)
+	if !strings.Contains(m.View(), "(gw)") {
+		t.Errorf("View() should show the alias, got:\n%s", m.View())
+	}
+
+	m.textInput.SetValue("gw")
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: nil})
+	result := updated.(model)
+	if len(result.filtered) != 1 || result.filtered[0].Alias != "gw" {
+		t.Errorf("filtering by alias = %+v, want only the aliased entry", result.filtered)
+	}
+}
+
+// Tags are shown as chips next to the branch.
+func TestModelView_Tags(t *testing.T) {
+	m := newModel([]Entry{{Branch: "fix/login", Path: "/tmp/wt/login", Rel: "wt/login", Tags: []string{"hotfix", "review"}}})
+	if view := m.View(); !strings.Contains(view, "hotfix") || !strings.Contains(view, "review") {
+		t.Errorf("View() should show the tags, got:\n%s", view)
+	}
+}
+
+// The filter input has readline-style editing keys, ctrl+u clearing the query.
+func TestModelUpdate_EditKeys(t *testing.T) {
+	m := newModel([]Entry{
+		{Branch: "feature/login", Path: "/tmp/wt/login", Rel: "wt/login"},
+		{Branch: "fix/bug", Path: "/tmp/wt/bug", Rel: "wt/bug"},
+	})
+	m.textInput.SetValue("fix bug")
+	steps := []struct {
+		key  tea.KeyMsg
+		want string
+	}{
+		{tea.KeyMsg{Type: tea.KeyCtrlW}, "fix "},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b"), Alt: true}, "fix "},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}, "xfix "},
+		{tea.KeyMsg{Type: tea.KeyEnd}, "xfix "},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}, "xfix y"},
+		{tea.KeyMsg{Type: tea.KeyHome}, "xfix y"},
+		{tea.KeyMsg{Type: tea.KeyRight}, "xfix y"},
+		{tea.KeyMsg{Type: tea.KeyCtrlU}, ""},
+	}
+	for _, step := range steps {
+		updated, _ := m.Update(step.key)
+		m = updated.(model)
+		if got := m.textInput.Value(); got != step.want {
+			t.Fatalf("after %s the query = %q, want %q", step.key, got, step.want)
+		}
+	}
+	if len(m.filtered) != 2 {
+		t.Errorf("clearing the query should list every entry, got %d", len(m.filtered))
+	}
+}
+
+// The number of matches is shown next to the input.
+func TestModelView_MatchCount(t *testing.T) {
+	m := newModel([]Entry{
+		{Branch: "feature/login", Path: "/tmp/wt/login", Rel: "wt/login"},
+		{Branch: "fix/bug", Path: "/tmp/wt/bug", Rel: "wt/bug"},
+	})
+	if view := m.View(); !strings.Contains(view, "2/2") {
+		t.Errorf("View() should show 2/2 matches, got:\n%s", view)
+	}
+	m.textInput.SetValue("login")
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: nil})
+	if view := updated.(model).View(); !strings.Contains(view, "1/2") {
+		t.Errorf("View() should show 1/2 matches, got:\n%s", view)
+	}
+
+	b := newBranchModel([]BranchEntry{{Name: "main"}, {Name: "develop"}}, "Branches")
+	b.allowCustom = true
+	b.textInput.SetValue("nope")
+	updated, _ = b.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: nil})
+	if view := updated.(branchModel).View(); !strings.Contains(view, "0/2") {
+		t.Errorf("the ref entry should not count as a match, got:\n%s", view)
+	}
+}
+
 func TestModelView_NoMatchesMessage(t *testing.T) {
 	m := newModel(nil)
 	m.filtered = nil
@@ -306,3 +419,403 @@ func TestBranchSelector_ShowsHeader(t *testing.T) {
 		t.Error("View() should display the header")
 	}
 }
+
+// --- Viewport tests ---
+
+func manyEntries(n int) []Entry {
+	entries := make([]Entry, n)
+	for i := range entries {
+		name := fmt.Sprintf("branch-%02d", i)
+		entries[i] = Entry{Branch: name, Path: "/wt/" + name, Rel: "wt/" + name}
+	}
+	return entries
+}
+
+func TestModelView_ViewportLimitsRenderedEntries(t *testing.T) {
+	m := newModel(manyEntries(50))
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 13})
+	result := updated.(model)
+
+	view := result.View()
+	if !strings.Contains(view, "branch-00") {
+		t.Error("first entry should be visible initially")
+	}
+	if strings.Contains(view, "branch-49") {
+		t.Error("entries beyond the viewport should not be rendered")
+	}
+	if !strings.Contains(view, "1 of 50") {
+		t.Error("View() should show the position counter")
+	}
+}
+
+func TestModelUpdate_PageDownScrolls(t *testing.T) {
+	m := newModel(manyEntries(50))
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 13})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	result := updated.(model)
+
+	if result.selected != 10 {
+		t.Errorf("after 2x pgdown with height 5: selected = %d, want 10", result.selected)
+	}
+	view := result.View()
+	if !strings.Contains(view, "branch-10") {
+		t.Error("selected entry should be scrolled into view")
+	}
+	if strings.Contains(view, "branch-00") {
+		t.Error("scrolled-past entries should not be rendered")
+	}
+
+	// Page up past the top clamps to the first entry
+	for i := 0; i < 5; i++ {
+		updated, _ = updated.(model).Update(tea.KeyMsg{Type: tea.KeyPgUp})
+	}
+	if updated.(model).selected != 0 {
+		t.Errorf("pgup past top: selected = %d, want 0", updated.(model).selected)
+	}
+}
+
+func TestBranchSelector_PageDownSkipsDisabled(t *testing.T) {
+	var entries []BranchEntry
+	for i := 0; i < 20; i++ {
+		entries = append(entries, BranchEntry{Name: fmt.Sprintf("b-%02d", i), Source: "local", HasWorktree: i == 5})
+	}
+
+	m := newBranchModel(entries, "Branches")
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 13})
+	updated, _ = updated.(branchModel).Update(tea.KeyMsg{Type: tea.KeyPgDown})
+	result := updated.(branchModel)
+
+	if result.selected != 6 {
+		t.Errorf("pgdown onto disabled entry: selected = %d, want 6", result.selected)
+	}
+}
+
+func TestBranchSelector_SectionHeaders(t *testing.T) {
+	entries := []BranchEntry{
+		{Name: "main", Source: "local"},
+		{Name: "v1.0.0", Source: "tag"},
+	}
+
+	view := newBranchModel(entries, "Base").View()
+	if !strings.Contains(view, "Local") || !strings.Contains(view, "Tags") {
+		t.Errorf("View() should show section headers for branches and tags, got:\n%s", view)
+	}
+
+	single := newBranchModel(entries[:1], "Base").View()
+	if strings.Contains(single, "── Local") {
+		t.Error("View() should not show a section header when all entries share one section")
+	}
+}
+
+func TestBranchSelector_DefaultBasePreselected(t *testing.T) {
+	entries := []BranchEntry{
+		{Name: "origin/main", Source: "default", Description: "up to date as of 2s ago"},
+		{Name: "main", Source: "local"},
+		{Name: "origin/dev", Source: "remote"},
+	}
+	m := newBranchModel(entries, "Base")
+	if got := m.filtered[m.selected].Name; got != "origin/main" {
+		t.Errorf("selected %q, want the default base origin/main", got)
+	}
+	view := m.View()
+	if !strings.Contains(view, "── Default") || !strings.Contains(view, "up to date as of 2s ago") {
+		t.Errorf("View() should list the default base in its own section with its fetch age:\n%s", view)
+	}
+}
+
+func TestBranchSelector_JumpSections(t *testing.T) {
+	entries := []BranchEntry{
+		{Name: "recent-1", Source: "recent"},
+		{Name: "recent-2", Source: "recent"},
+		{Name: "main", Source: "local", HasWorktree: true},
+		{Name: "dev", Source: "local"},
+		{Name: "all-taken", Source: "remote", HasWorktree: true},
+		{Name: "v1", Source: "tag"},
+	}
+	m := newBranchModel(entries, "Branches")
+	if view := m.View(); !strings.Contains(view, "── Recent") || !strings.Contains(view, "tab/shift+tab") {
+		t.Fatalf("View() should show the Recent section and the jump keys:\n%s", view)
+	}
+
+	// Tab lands on the first selectable entry of each section, skipping
+	// sections without one
+	var updated tea.Model = m
+	for _, want := range []string{"dev", "v1", "v1"} {
+		updated, _ = updated.(branchModel).Update(tea.KeyMsg{Type: tea.KeyTab})
+		if got := updated.(branchModel).filtered[updated.(branchModel).selected].Name; got != want {
+			t.Errorf("after tab: selected %q, want %q", got, want)
+		}
+	}
+	updated, _ = updated.(branchModel).Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if got := updated.(branchModel).filtered[updated.(branchModel).selected].Name; got != "dev" {
+		t.Errorf("after shift+tab: selected %q, want dev", got)
+	}
+}
+
+func TestSelectors_ShowDescriptions(t *testing.T) {
+	long := strings.Repeat("x", 80)
+	view := newBranchModel([]BranchEntry{
+		{Name: "feat", Source: "local", Description: "Rework the parser\nMore detail"},
+		{Name: "long-branch", Source: "local", Description: long},
+	}, "Branches").View()
+	if !strings.Contains(view, "feat         Rework the parser") {
+		t.Errorf("View() should show the first description line in an aligned column, got:\n%s", view)
+	}
+	if strings.Contains(view, "More detail") || strings.Contains(view, long) {
+		t.Errorf("View() should show only a truncated first line, got:\n%s", view)
+	}
+
+	view = newModel([]Entry{{Branch: "feat", Rel: "wts/feat", Description: "Rework the parser"}}).View()
+	if !strings.Contains(view, "wts/feat  Rework the parser") {
+		t.Errorf("View() should show the description after the path, got:\n%s", view)
+	}
+}
+
+func TestBranchSelector_CustomRef(t *testing.T) {
+	entries := []BranchEntry{{Name: "main", Source: "local"}}
+
+	m := newBranchModel(entries, "Base")
+	m.allowCustom = true
+	var updated tea.Model = m
+	for _, r := range "abc123" {
+		updated, _ = updated.(branchModel).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	result := updated.(branchModel)
+
+	if len(result.filtered) != 1 || result.filtered[0].Source != "ref" || result.filtered[0].Name != "abc123" {
+		t.Fatalf("filtered = %+v, want only the typed ref", result.filtered)
+	}
+	if !strings.Contains(result.View(), `use "abc123" as ref`) {
+		t.Error("View() should offer the typed text as a ref")
+	}
+
+	// An exact match needs no extra ref entry
+	m = newBranchModel(entries, "Base")
+	m.allowCustom = true
+	m.textInput.SetValue("main")
+	m.applyFilter()
+	if len(m.filtered) != 1 || m.filtered[0].Source != "local" {
+		t.Errorf("filtered = %+v, want only the exact branch match", m.filtered)
+	}
+}
+
+func TestModel_LoadsStatusAsync(t *testing.T) {
+	entries := []Entry{
+		{Branch: "feature-a", Path: "/wt/a", Rel: "wt/a"},
+		{Branch: "feature-b", Path: "/wt/b", Rel: "wt/b"},
+	}
+
+	m := newModel(entries)
+	if strings.Contains(m.View(), "●") {
+		t.Error("View() should not show status before it has loaded")
+	}
+
+	m.load = func(path string) (Status, error) {
+		if path == "/wt/b" {
+			return Status{}, fmt.Errorf("boom")
+		}
+		return Status{Dirty: true, Ahead: 2, Behind: 1}, nil
+	}
+	if m.loadStatuses() == nil {
+		t.Fatal("loadStatuses() should return commands when a loader is set")
+	}
+
+	// Deliver the messages the commands would produce
+	var updated tea.Model = m
+	for _, e := range entries {
+		st, err := m.load(e.Path)
+		if err != nil {
+			st = Status{Failed: true}
+		}
+		updated, _ = updated.(model).Update(statusMsg{path: e.Path, status: st})
+	}
+	view := updated.(model).View()
+	if !strings.Contains(view, "●") || !strings.Contains(view, "↑2") || !strings.Contains(view, "↓1") {
+		t.Errorf("View() should show dirty marker and ahead/behind, got:\n%s", view)
+	}
+	if !strings.Contains(view, "?") {
+		t.Errorf("View() should mark entries whose status failed to load, got:\n%s", view)
+	}
+}
+
+func TestBranchSelector_BackgroundRefresh(t *testing.T) {
+	cached := []BranchEntry{
+		{Name: "old", Source: "local"},
+		{Name: "feature", Source: "local"},
+	}
+	m := newBranchModel(cached, "Branches")
+	m.refresh = func() ([]BranchEntry, error) { return nil, nil }
+	m.refreshing = true
+	m.selected = 1
+
+	if !strings.Contains(m.View(), "refreshing…") {
+		t.Error("View() should show the refreshing indicator until the refresh arrives")
+	}
+
+	updated, _ := m.Update(branchesMsg{entries: []BranchEntry{
+		{Name: "feature", Source: "local"},
+		{Name: "new", Source: "remote"},
+	}})
+	result := updated.(branchModel)
+
+	if result.refreshing || strings.Contains(result.View(), "refreshing…") {
+		t.Error("refreshing indicator should clear once the refresh arrives")
+	}
+	if len(result.filtered) != 2 || result.filtered[1].Name != "new" {
+		t.Errorf("filtered = %+v, want the refreshed entries", result.filtered)
+	}
+	if result.filtered[result.selected].Name != "feature" {
+		t.Errorf("selection should stay on %q, got %q", "feature", result.filtered[result.selected].Name)
+	}
+}
+
+func TestBranchSelector_ShowsLastCommit(t *testing.T) {
+	entries := []BranchEntry{
+		{Name: "feature", Source: "local", Date: time.Now().Add(-3 * 24 * time.Hour), Author: "Ada Lovelace"},
+		{Name: "v1.0", Source: "tag"},
+	}
+	view := newBranchModel(entries, "Branches").View()
+	for _, want := range []string{"3 days ago", "Ada Lovelace", "v1.0"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() should contain %q, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	for ago, want := range map[time.Duration]string{
+		10 * time.Second: "just now",
+		time.Minute:      "1 minute ago",
+		5 * time.Hour:    "5 hours ago",
+		3 * day:          "3 days ago",
+		20 * day:         "2 weeks ago",
+		90 * day:         "3 months ago",
+		800 * day:        "2 years ago",
+	} {
+		if got := relativeTime(now.Add(-ago), now); got != want {
+			t.Errorf("relativeTime(%v ago) = %q, want %q", ago, got, want)
+		}
+	}
+}
+
+// manyBranches returns n remote branch entries.
+func manyBranches(n int) []BranchEntry {
+	entries := make([]BranchEntry, n)
+	for i := range entries {
+		entries[i] = BranchEntry{Name: fmt.Sprintf("origin/team-%d/feature-%d", i%50, i), Source: "remote"}
+	}
+	return entries
+}
+
+func TestBranchSelector_IncrementalFilter(t *testing.T) {
+	entries := []BranchEntry{
+		{Name: "feature-auth", Source: "local"},
+		{Name: "fix-login", Source: "local"},
+		{Name: "docs", Source: "local"},
+	}
+	var updated tea.Model = newBranchModel(entries, "Branches")
+	for _, r := range "fe" {
+		updated, _ = updated.(branchModel).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m := updated.(branchModel)
+	if got := m.candidates("fea"); !slices.Equal(got, []int{0}) {
+		t.Errorf("candidates for an extended query = %v, want the previous matches [0]", got)
+	}
+	if got := m.candidates("d"); len(got) != len(entries) {
+		t.Errorf("candidates for an unrelated query = %v, want all entries", got)
+	}
+
+	// Deleting a character filters all entries again
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if got := len(updated.(branchModel).filtered); got != 2 {
+		t.Errorf("after backspace to %q: %d matches, want 2", updated.(branchModel).query, got)
+	}
+}
+
+func TestBranchSelector_AsyncFilter(t *testing.T) {
+	entries := manyBranches(asyncFilterThreshold)
+	m := newBranchModel(entries, "Branches")
+	m.textInput.SetValue("team-7/")
+	cmd := m.requestFilter()
+	if cmd == nil || !m.filtering {
+		t.Fatal("a large list should be filtered in the background")
+	}
+	if len(m.filtered) != len(entries) || !strings.Contains(m.View(), "filtering…") {
+		t.Error("the previous list and a filtering indicator should show until the result arrives")
+	}
+
+	// A result for an older query is dropped
+	stale := cmd().(filteredMsg)
+	m.textInput.SetValue("team-7/feature-1")
+	next := m.requestFilter()
+	updated, _ := m.Update(stale)
+	if updated.(branchModel).query != "" {
+		t.Errorf("stale result was applied for %q", updated.(branchModel).query)
+	}
+
+	updated, _ = updated.(branchModel).Update(next())
+	result := updated.(branchModel)
+	if result.filtering || result.query != "team-7/feature-1" {
+		t.Fatalf("filtering = %v, query = %q; want the result for the latest query", result.filtering, result.query)
+	}
+	_, want := filterBranches(entries, result.candidates(""), "team-7/feature-1", false)
+	if len(result.filtered) != len(want) || result.filtered[0].Name != want[0].Name {
+		t.Errorf("background filtering found %d matches, best %q; want %d, best %q",
+			len(result.filtered), result.filtered[0].Name, len(want), want[0].Name)
+	}
+}
+
+// Enter while the query is filtered in the background picks from the matches
+// of what was typed, not the list still on screen; other messages leave the
+// outstanding request alone.
+func TestBranchSelector_EnterWhileFiltering(t *testing.T) {
+	entries := manyBranches(asyncFilterThreshold)
+	var updated tea.Model = newBranchModel(entries, "Branches")
+	for _, r := range "team-7/feature-1" {
+		updated, _ = updated.(branchModel).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m := updated.(branchModel)
+	if !m.filtering {
+		t.Fatal("a large list should be filtered in the background")
+	}
+	seq := m.filterSeq
+	updated, _ = m.Update(struct{}{})
+	if got := updated.(branchModel).filterSeq; got != seq {
+		t.Errorf("an unrelated message requested filtering again (seq %d, want %d)", got, seq)
+	}
+
+	updated, cmd := updated.(branchModel).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	result := updated.(branchModel)
+	if cmd == nil {
+		t.Fatal("Enter should quit")
+	}
+	_, want := filterBranches(entries, result.candidates(""), "team-7/feature-1", false)
+	if got := result.filtered[result.selected].Name; got != want[0].Name {
+		t.Errorf("Enter while filtering selected %q, want the best match %q", got, want[0].Name)
+	}
+}
+
+func TestBranchSelector_FilterDebounce(t *testing.T) {
+	SetFilterDebounce(time.Millisecond)
+	defer SetFilterDebounce(0)
+
+	m := newBranchModel(manyBranches(asyncFilterThreshold), "Branches")
+	m.textInput.SetValue("team-1")
+	tick := m.requestFilter()
+	m.textInput.SetValue("team-12")
+	latest := m.requestFilter()
+
+	if _, cmd := m.Update(tick()); cmd != nil {
+		t.Error("a tick superseded by another keystroke should not start filtering")
+	}
+	_, cmd := m.Update(latest())
+	if cmd == nil {
+		t.Fatal("the latest tick should start filtering")
+	}
+	if msg, ok := cmd().(filteredMsg); !ok || msg.query != "team-12" {
+		t.Errorf("filtering produced %+v, want a result for team-12", msg)
+	}
+}
@@ -0,0 +1,29 @@
+package tui
+
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/provenimpact/wt/internal/theme"
+)
+
+var (
+	selectedStyle  lipgloss.Style
+	dimStyle       lipgloss.Style
+	promptStyle    lipgloss.Style
+	highlightStyle lipgloss.Style
+	disabledStyle  lipgloss.Style
+	tagStyle       func(tag string) lipgloss.Style
+)
+
+func init() {
+	ApplyTheme(theme.Current())
+}
+
+// ApplyTheme sets the styles used by all selectors.
+func ApplyTheme(s theme.Styles) {
+	selectedStyle = s.Selected
+	dimStyle = s.Dim
+	promptStyle = s.Prompt
+	highlightStyle = s.Highlight
+	disabledStyle = s.Disabled
+	tagStyle = s.Tag
+}
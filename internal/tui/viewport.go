@@ -0,0 +1,42 @@
+package tui
+
+const (
+	// chromeLines is the number of lines the selector frame uses around the
+	// entry list: blank, header, blank, input, blank, counter, blank, help.
+	chromeLines = 8
+
+	// defaultListHeight is the number of entries shown until the terminal
+	// reports its size.
+	defaultListHeight = 10
+)
+
+// viewport tracks which slice of a long entry list is visible.
+type viewport struct {
+	height int // rows available for entries
+	offset int // index of the first visible entry
+}
+
+func newViewport() viewport {
+	return viewport{height: defaultListHeight}
+}
+
+// resize adapts the number of visible entries to the terminal height.
+func (v *viewport) resize(termHeight int) {
+	v.height = max(1, termHeight-chromeLines)
+}
+
+// follow scrolls the viewport so that the selected entry is visible.
+func (v *viewport) follow(selected, total int) {
+	if selected < v.offset {
+		v.offset = selected
+	}
+	if selected >= v.offset+v.height {
+		v.offset = selected - v.height + 1
+	}
+	v.offset = min(max(v.offset, 0), max(0, total-v.height))
+}
+
+// bounds returns the half-open range [start, end) of entries to render.
+func (v viewport) bounds(total int) (int, int) {
+	return v.offset, min(total, v.offset+v.height)
+}
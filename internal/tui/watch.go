@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// RefreshFunc produces the content shown by Watch on each refresh.
+type RefreshFunc func() (string, error)
+
+// Watch displays a full-screen view that re-runs refresh every interval
+// until the user quits with q, esc, or ctrl-c.
+func Watch(title string, interval time.Duration, refresh RefreshFunc) error {
+	m := newWatchModel(title, interval, refresh)
+	p := tea.NewProgram(m, tea.WithOutput(os.Stderr), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		return fmt.Errorf("running watch view: %w", err)
+	}
+	return nil
+}
+
+type watchModel struct {
+	title    string
+	interval time.Duration
+	refresh  RefreshFunc
+	content  string
+	err      error
+	updated  time.Time
+}
+
+// refreshMsg carries the result of one refresh.
+type refreshMsg struct {
+	content string
+	err     error
+	at      time.Time
+}
+
+// tickMsg triggers the next refresh.
+type tickMsg time.Time
+
+func newWatchModel(title string, interval time.Duration, refresh RefreshFunc) watchModel {
+	return watchModel{title: title, interval: interval, refresh: refresh}
+}
+
+func (m watchModel) Init() tea.Cmd {
+	return m.refreshCmd()
+}
+
+func (m watchModel) refreshCmd() tea.Cmd {
+	refresh := m.refresh
+	return func() tea.Msg {
+		content, err := refresh()
+		return refreshMsg{content: content, err: err, at: time.Now()}
+	}
+}
+
+func (m watchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		}
+	case refreshMsg:
+		m.content = msg.content
+		m.err = msg.err
+		m.updated = msg.at
+		return m, tea.Tick(m.interval, func(t time.Time) tea.Msg { return tickMsg(t) })
+	case tickMsg:
+		return m, m.refreshCmd()
+	}
+	return m, nil
+}
+
+func (m watchModel) View() string {
+	var b strings.Builder
+
+	b.WriteString("\n")
+	b.WriteString(promptStyle.Render("  " + m.title))
+	if !m.updated.IsZero() {
+		b.WriteString(dimStyle.Render(fmt.Sprintf("  every %s • updated %s", m.interval, m.updated.Format("15:04:05"))))
+	}
+	b.WriteString("\n\n")
+
+	switch {
+	case m.err != nil:
+		b.WriteString(fmt.Sprintf("  Error: %s\n", m.err))
+	case m.updated.IsZero():
+		b.WriteString(dimStyle.Render("  Loading..."))
+		b.WriteString("\n")
+	default:
+		for _, line := range strings.Split(strings.TrimRight(m.content, "\n"), "\n") {
+			b.WriteString("  " + line + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("  q quit"))
+	b.WriteString("\n")
+
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestWatchModel_ShowsRefreshedContent(t *testing.T) {
+	m := newWatchModel("Status", time.Second, func() (string, error) { return "", nil })
+
+	if !strings.Contains(m.View(), "Loading") {
+		t.Error("View() should show loading before the first refresh")
+	}
+
+	updated, cmd := m.Update(refreshMsg{content: "BRANCH  STATUS\nfeature  dirty\n", at: time.Now()})
+	result := updated.(watchModel)
+	if cmd == nil {
+		t.Error("refresh should schedule the next tick")
+	}
+
+	view := result.View()
+	if !strings.Contains(view, "feature  dirty") {
+		t.Errorf("View() should contain refreshed content, got: %s", view)
+	}
+	if !strings.Contains(view, "Status") {
+		t.Error("View() should show the title")
+	}
+}
+
+func TestWatchModel_ShowsError(t *testing.T) {
+	m := newWatchModel("Status", time.Second, nil)
+	updated, _ := m.Update(refreshMsg{err: errors.New("listing worktrees: boom"), at: time.Now()})
+
+	if !strings.Contains(updated.(watchModel).View(), "boom") {
+		t.Error("View() should show the refresh error")
+	}
+}
+
+func TestWatchModel_QuitKeys(t *testing.T) {
+	m := newWatchModel("Status", time.Second, nil)
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("q")},
+		{Type: tea.KeyEsc},
+		{Type: tea.KeyCtrlC},
+	} {
+		if _, cmd := m.Update(key); cmd == nil {
+			t.Errorf("key %q should quit", key.String())
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package workspace writes VS Code multi-root workspace files
+// (.code-workspace) listing a repository's worktrees.
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/provenimpact/wt/internal/state"
+)
+
+// Extension is the file extension VS Code uses for workspace files.
+const Extension = ".code-workspace"
+
+// Folder is one root folder of a workspace.
+type Folder struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path"`
+}
+
+// Write creates or updates the workspace file at path so that its folders
+// are exactly folders. Folder paths are written relative to the workspace
+// file when possible. Any other settings already in the file are preserved.
+func Write(path string, folders []Folder) error {
+	doc := map[string]json.RawMessage{}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("reading workspace: %w", err)
+	default:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("updating workspace %s: not plain JSON (comments are not supported): %w", path, err)
+		}
+	}
+
+	base := filepath.Dir(path)
+	rel := make([]Folder, len(folders))
+	for i, f := range folders {
+		rel[i] = f
+		if r, err := filepath.Rel(base, f.Path); err == nil {
+			rel[i].Path = filepath.ToSlash(r)
+		}
+	}
+	encoded, err := json.Marshal(rel)
+	if err != nil {
+		return err
+	}
+	doc["folders"] = encoded
+
+	out, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return err
+	}
+	return state.WriteFileAtomic(path, append(out, '\n'))
+}
@@ -0,0 +1,61 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite_CreatesRelativeFolders(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "repo"+Extension)
+
+	err := Write(path, []Folder{
+		{Name: "main", Path: filepath.Join(dir, "repo")},
+		{Name: "feature/x", Path: filepath.Join(dir, "repo-worktrees", "feature-x")},
+	})
+	if err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	var doc struct{ Folders []Folder }
+	data, _ := os.ReadFile(path)
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("workspace is not valid JSON: %v", err)
+	}
+	if len(doc.Folders) != 2 || doc.Folders[0].Path != "repo" || doc.Folders[1].Path != "repo-worktrees/feature-x" {
+		t.Errorf("folders = %+v, want paths relative to the workspace file", doc.Folders)
+	}
+}
+
+func TestWrite_PreservesOtherSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repo"+Extension)
+	os.WriteFile(path, []byte(`{"folders": [{"path": "old"}], "settings": {"editor.tabSize": 2}}`), 0o644)
+
+	if err := Write(path, []Folder{{Path: filepath.Join(filepath.Dir(path), "new")}}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	var doc struct {
+		Folders  []Folder
+		Settings map[string]int
+	}
+	data, _ := os.ReadFile(path)
+	json.Unmarshal(data, &doc)
+	if len(doc.Folders) != 1 || doc.Folders[0].Path != "new" {
+		t.Errorf("folders = %+v, want replaced list", doc.Folders)
+	}
+	if doc.Settings["editor.tabSize"] != 2 {
+		t.Errorf("settings = %v, want preserved", doc.Settings)
+	}
+}
+
+func TestWrite_RejectsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "repo"+Extension)
+	os.WriteFile(path, []byte("// my workspace\n{}"), 0o644)
+
+	if err := Write(path, nil); err == nil {
+		t.Error("Write() should refuse to rewrite a workspace with comments")
+	}
+}
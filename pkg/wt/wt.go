@@ -0,0 +1,280 @@
+// Package wt lets other programs, such as editor plugins and bots, manage git
+// worktrees the way the wt command does, without shelling out to it.
+//
+// Worktrees are created next to the repository in a "<repo>-worktrees"
+// directory, named after their branch, and creations and removals are
+// recorded in the same history and state that wt itself reads. Every
+// operation takes a context; cancelling it stops the git commands involved.
+package wt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/provenimpact/wt/internal/config"
+	"github.com/provenimpact/wt/internal/git"
+	"github.com/provenimpact/wt/internal/history"
+	"github.com/provenimpact/wt/internal/names"
+	"github.com/provenimpact/wt/internal/repo"
+	"github.com/provenimpact/wt/internal/state"
+)
+
+// Errors returned by this package can be tested with errors.Is against these.
+var (
+	// ErrNotARepo means Open was given a directory outside any git repository.
+	ErrNotARepo = git.ErrNotARepo
+	// ErrBranchCheckedOut means the branch already has a worktree.
+	ErrBranchCheckedOut = git.ErrBranchCheckedOut
+	// ErrDirty means a worktree has uncommitted changes and Force was not set.
+	ErrDirty = git.ErrDirty
+	// ErrMainWorktree means an operation was refused on the main worktree.
+	ErrMainWorktree = errors.New("main worktree")
+)
+
+// Repo is a git repository and its worktrees.
+type Repo struct {
+	// Root is the path of the main worktree.
+	Root string
+	// WorktreesDir is the directory new worktrees are created in.
+	WorktreesDir string
+
+	info *repo.Info
+	// names are the naming options of the [names] config.
+	names names.Options
+}
+
+// Worktree is one checked-out worktree of a repository.
+type Worktree struct {
+	Path string
+	// Branch is the checked-out branch; empty when Detached.
+	Branch   string
+	Detached bool
+	// HEAD is the commit the worktree is on.
+	HEAD string
+	// Main is set for the repository's main worktree.
+	Main bool
+	// Prunable is set when the worktree's directory no longer exists.
+	Prunable bool
+}
+
+// Status is a worktree together with its working tree and upstream state.
+type Status struct {
+	Worktree
+	// Dirty is set when the worktree has uncommitted changes.
+	Dirty bool
+	// Upstream is the short name of the branch's upstream, e.g.
+	// "origin/main"; empty when none is configured.
+	Upstream string
+	Ahead    int
+	Behind   int
+}
+
+// CreateOptions control Create.
+type CreateOptions struct {
+	// Base is the ref a new branch starts from. When set, a new branch is
+	// always created; otherwise an existing local or remote branch is checked
+	// out, and only a branch that exists nowhere is created from HEAD.
+	Base string
+}
+
+// RemoveOptions control Remove.
+type RemoveOptions struct {
+	// Force removes the worktree even if it has uncommitted changes, which
+	// are lost.
+	Force bool
+}
+
+// Open returns the repository containing dir, which may be the main worktree
+// or any linked worktree. Its configuration, that of the user and the
+// repository's .wt.toml, is read as the wt command reads it.
+func Open(ctx context.Context, dir string) (*Repo, error) {
+	info, err := repo.ResolveDir(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.Load(info.MainWorktree)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := cfg.Names.Options()
+	if err != nil {
+		return nil, err
+	}
+	return &Repo{Root: info.MainWorktree, WorktreesDir: info.WorktreesDir, info: info, names: opts}, nil
+}
+
+// Worktrees returns all worktrees of the repository, the main one first.
+func (r *Repo) Worktrees(ctx context.Context) ([]Worktree, error) {
+	worktrees, err := git.ListWorktreesDir(ctx, r.Root)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Worktree, 0, len(worktrees))
+	for _, wt := range worktrees {
+		if wt.Bare {
+			continue
+		}
+		w := Worktree{
+			Path:     wt.Path,
+			Branch:   wt.Branch,
+			HEAD:     wt.HEAD,
+			Main:     wt.Path == r.Root,
+			Prunable: wt.Prunable != "",
+		}
+		if wt.Branch == "(detached)" {
+			w.Branch, w.Detached = "", true
+		}
+		result = append(result, w)
+	}
+	return result, nil
+}
+
+// PathFor returns where Create puts the worktree for branch: its name in
+// WorktreesDir, sanitized as the [names] config says.
+func (r *Repo) PathFor(branch string) string {
+	return filepath.Join(r.WorktreesDir, names.Sanitize(branch, r.names))
+}
+
+// Create adds a worktree for branch at PathFor(branch) and returns it. See
+// CreateOptions for how the branch is chosen. A branch that already has a
+// worktree is refused with ErrBranchCheckedOut.
+func (r *Repo) Create(ctx context.Context, branch string, opts CreateOptions) (Worktree, error) {
+	existing, err := r.Worktrees(ctx)
+	if err != nil {
+		return Worktree{}, err
+	}
+	for _, wt := range existing {
+		if wt.Branch == branch {
+			return Worktree{}, fmt.Errorf("%w: %q is checked out at %s", ErrBranchCheckedOut, branch, wt.Path)
+		}
+	}
+
+	path := r.PathFor(branch)
+	if err := r.info.EnsureWorktreesDir(); err != nil {
+		return Worktree{}, fmt.Errorf("creating worktrees directory: %w", err)
+	}
+
+	createBranch := opts.Base != ""
+	var upstream string
+	if !createBranch && !git.LocalBranchExistsDir(ctx, r.Root, branch) {
+		if upstream, err = git.RemoteTrackingRefDir(ctx, r.Root, branch); err != nil {
+			return Worktree{}, err
+		}
+		createBranch = upstream == ""
+	}
+
+	if upstream != "" {
+		err = git.AddTrackingWorktreeDir(ctx, r.Root, path, branch, upstream)
+	} else {
+		err = git.AddWorktreeDir(ctx, r.Root, path, branch, createBranch, opts.Base)
+	}
+	r.record(history.Create, branch, path, err)
+	if err != nil {
+		return Worktree{}, err
+	}
+	state.New(r.info.StateDir()).Update(func(st *state.State) error {
+		wt := st.Worktree(path)
+		wt.Branch = branch
+		wt.Created = time.Now()
+		wt.LastUsed = wt.Created
+		return nil
+	})
+
+	worktrees, err := r.Worktrees(ctx)
+	if err != nil {
+		return Worktree{}, err
+	}
+	for _, wt := range worktrees {
+		if wt.Path == path {
+			return wt, nil
+		}
+	}
+	return Worktree{Path: path, Branch: branch}, nil
+}
+
+// Remove deletes the worktree at path, which when relative is taken from
+// Root rather than the process's directory. The branch itself is kept. A
+// worktree with uncommitted changes is refused with ErrDirty unless
+// opts.Force is set, and the main worktree is always refused with
+// ErrMainWorktree.
+func (r *Repo) Remove(ctx context.Context, path string, opts RemoveOptions) error {
+	abs := filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		abs = filepath.Join(r.Root, path)
+	}
+	worktrees, err := r.Worktrees(ctx)
+	if err != nil {
+		return err
+	}
+	var target *Worktree
+	for i := range worktrees {
+		if worktrees[i].Path == abs {
+			target = &worktrees[i]
+			break
+		}
+	}
+	switch {
+	case target == nil:
+		return fmt.Errorf("no worktree at %s", path)
+	case target.Main:
+		return fmt.Errorf("%w: refusing to remove %s", ErrMainWorktree, path)
+	}
+
+	err = git.RemoveWorktreeDir(ctx, r.Root, target.Path, opts.Force)
+	r.record(history.Remove, target.Branch, target.Path, err)
+	if err != nil {
+		return err
+	}
+	state.New(r.info.StateDir()).Update(func(st *state.State) error {
+		st.Forget(target.Path)
+		return nil
+	})
+	r.info.CleanEmptyParents(target.Path)
+	return nil
+}
+
+// Status returns every worktree with its dirty state and how far its branch
+// is ahead of and behind its upstream. Prunable worktrees are reported
+// without any state.
+func (r *Repo) Status(ctx context.Context) ([]Status, error) {
+	worktrees, err := r.Worktrees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tracking, err := git.BranchTrackingDir(ctx, r.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(worktrees))
+	for _, wt := range worktrees {
+		s := Status{Worktree: wt}
+		if !wt.Prunable {
+			if s.Dirty, err = git.IsDirty(ctx, wt.Path, true); err != nil {
+				return nil, err
+			}
+			t := tracking[wt.Branch]
+			s.Upstream, s.Ahead, s.Behind = t.Upstream, t.Ahead, t.Behind
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// record adds an operation to the history that wt history shows. Like the
+// wt command, it never fails the operation over it.
+func (r *Repo) record(op, branch, path string, opErr error) {
+	outcome := history.OK
+	if opErr != nil {
+		outcome = opErr.Error()
+	}
+	history.New(r.info.StateDir()).Append(history.Event{
+		Op:      op,
+		Branch:  branch,
+		Path:    path,
+		Outcome: outcome,
+	})
+}
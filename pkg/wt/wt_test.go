@@ -0,0 +1,116 @@
+package wt
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTestRepo creates a repository named "myrepo" in a temporary directory.
+// The current directory is left alone: the package must not depend on it.
+func setupTestRepo(t *testing.T) string {
+	t.Helper()
+	parent, _ := filepath.EvalSymlinks(t.TempDir())
+	dir := filepath.Join(parent, "myrepo")
+	os.MkdirAll(dir, 0o755)
+	runGit(t, dir, "init", "-b", "main")
+	runGit(t, dir, "commit", "--allow-empty", "-m", "initial")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test",
+		"GIT_AUTHOR_EMAIL=test@test.com",
+		"GIT_COMMITTER_NAME=test",
+		"GIT_COMMITTER_EMAIL=test@test.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// PathFor names worktrees as the [names] config of the repository says.
+func TestPathFor_Names(t *testing.T) {
+	t.Setenv("WT_CONFIG_DIR", t.TempDir())
+	dir := setupTestRepo(t)
+	os.WriteFile(filepath.Join(dir, ".wt.toml"), []byte("[names]\nnested = true\n"), 0o644)
+
+	r, err := Open(t.Context(), dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if got, want := r.PathFor("feature/login"), filepath.Join(r.WorktreesDir, "feature", "login"); got != want {
+		t.Errorf("PathFor() = %q, want %q", got, want)
+	}
+}
+
+func TestOpen_NotARepo(t *testing.T) {
+	if _, err := Open(t.Context(), t.TempDir()); !errors.Is(err, ErrNotARepo) {
+		t.Errorf("Open() error = %v, want ErrNotARepo", err)
+	}
+}
+
+func TestCreateStatusRemove(t *testing.T) {
+	t.Setenv("WT_CONFIG_DIR", t.TempDir())
+	dir := setupTestRepo(t)
+	ctx := t.Context()
+
+	r, err := Open(ctx, dir)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if want := filepath.Join(filepath.Dir(dir), "myrepo-worktrees"); r.WorktreesDir != want {
+		t.Errorf("WorktreesDir = %q, want %q", r.WorktreesDir, want)
+	}
+
+	wt, err := r.Create(ctx, "feature/login", CreateOptions{})
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	if want := filepath.Join(r.WorktreesDir, "feature-login"); wt.Path != want || wt.Branch != "feature/login" || wt.HEAD == "" {
+		t.Errorf("Create() = %+v, want feature/login at %s", wt, want)
+	}
+	if _, err := r.Create(ctx, "feature/login", CreateOptions{}); !errors.Is(err, ErrBranchCheckedOut) {
+		t.Errorf("second Create() error = %v, want ErrBranchCheckedOut", err)
+	}
+
+	// Opening from the linked worktree finds the same repository
+	if linked, err := Open(ctx, wt.Path); err != nil || linked.Root != dir {
+		t.Errorf("Open(linked) = %+v, %v; want root %s", linked, err, dir)
+	}
+
+	os.WriteFile(filepath.Join(wt.Path, "new.txt"), []byte("x"), 0o644)
+	statuses, err := r.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if len(statuses) != 2 || !statuses[0].Main || statuses[0].Dirty || !statuses[1].Dirty {
+		t.Errorf("Status() = %+v, want a clean main worktree and a dirty linked one", statuses)
+	}
+
+	if err := r.Remove(ctx, dir, RemoveOptions{}); !errors.Is(err, ErrMainWorktree) {
+		t.Errorf("Remove(main) error = %v, want ErrMainWorktree", err)
+	}
+	if err := r.Remove(ctx, wt.Path, RemoveOptions{}); !errors.Is(err, ErrDirty) {
+		t.Errorf("Remove(dirty) error = %v, want ErrDirty", err)
+	}
+	// A relative path is taken from the repository, not the test's directory
+	rel, _ := filepath.Rel(dir, wt.Path)
+	if err := r.Remove(ctx, rel, RemoveOptions{Force: true}); err != nil {
+		t.Fatalf("Remove(force) error: %v", err)
+	}
+	if _, err := os.Stat(wt.Path); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be gone", wt.Path)
+	}
+	worktrees, err := r.Worktrees(ctx)
+	if err != nil || len(worktrees) != 1 {
+		t.Errorf("Worktrees() = %+v, %v; want only the main worktree", worktrees, err)
+	}
+}